@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+	"truck-management/config"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/services"
+	"truck-management/internal/utils"
+)
+
+// newAdminService connects to the database and blob store for the operator
+// commands.
+func newAdminService(cfg *config.Config) *services.AdminService {
+	db := config.InitDatabase(cfg)
+	return services.NewAdminService(
+		repositories.NewAdminRepository(db),
+		repositories.NewCompanyRepository(db),
+		repositories.NewUserRepository(db),
+		repositories.NewUnitOfWork(db),
+		config.InitStorage(cfg.Storage),
+	)
+}
+
+// runSeed runs the seed command. --demo is the only data set so far.
+func runSeed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	demo := flags.Bool("demo", false, "create the demo tenant")
+	cfg, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if !*demo {
+		return errors.New("usage: truck-management seed --demo")
+	}
+
+	seed, err := newAdminService(cfg).SeedDemo()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created %s (company %d) with %d branches, %d trucks and %d cargos\n",
+		seed.CompanyName, seed.CompanyID, seed.Branches, seed.Trucks, seed.Cargos)
+	fmt.Printf("admin:    %s\n", seed.AdminEmail)
+	for _, email := range seed.DriverEmails {
+		fmt.Printf("driver:   %s\n", email)
+	}
+	fmt.Printf("password: %s (all users)\n", seed.Password)
+	return nil
+}
+
+// runCreateAdmin runs the create-admin command, which bootstraps the first
+// admin of a tenant.
+func runCreateAdmin(args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	companyID := flags.Uint("company", 0, "company ID")
+	email := flags.String("email", "", "email address, used to log in")
+	firstName := flags.String("first-name", "Admin", "first name")
+	lastName := flags.String("last-name", "User", "last name")
+	password := flags.String("password", "", "password; generated when empty")
+	cfg, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if *companyID == 0 || *email == "" {
+		return errors.New("usage: truck-management create-admin --company ID --email EMAIL [--first-name NAME] [--last-name NAME] [--password PASSWORD]")
+	}
+
+	user, generated, err := newAdminService(cfg).CreateAdmin(models.CreateAdminRequest{
+		CompanyID: *companyID,
+		Email:     *email,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Password:  *password,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin %s (user %d) of company %d\n", user.Email, user.ID, *companyID)
+	if *password == "" {
+		fmt.Printf("password: %s\n", generated)
+	}
+	return nil
+}
+
+// runRotateJWTSecret runs the rotate-jwt-secret command. The secret lives in
+// the environment or config file, so the command prints the settings to
+// deploy rather than changing anything itself.
+func runRotateJWTSecret(args []string) error {
+	cfg, err := parseFlags(flag.NewFlagSet("rotate-jwt-secret", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	secret, err := utils.NewJWTSecret()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Set on every instance and restart:")
+	fmt.Println()
+	fmt.Printf("JWT_SECRET=%s\n", secret)
+	if !cfg.JWT.IsDevelopmentDefault() {
+		fmt.Printf("JWT_PREVIOUS_SECRET=%s\n", cfg.JWT.Secret)
+		fmt.Println()
+		fmt.Printf("Tokens signed with the previous secret stay valid until they expire (%s);\n", cfg.JWT.TTL)
+		fmt.Println("remove JWT_PREVIOUS_SECRET after that.")
+	} else {
+		fmt.Println()
+		fmt.Println("JWT_SECRET is not set here, so the development secret was in use; it is not kept")
+		fmt.Println("as the previous secret and every user has to log in again.")
+	}
+	if cfg.URLSigningSecret == "" {
+		fmt.Println()
+		fmt.Println("URL_SIGNING_SECRET is not set, so signed download links issued before the")
+		fmt.Println("rotation stop working.")
+	}
+	return nil
+}
+
+// runReindex runs the reindex command.
+func runReindex(args []string) error {
+	cfg, err := parseFlags(flag.NewFlagSet("reindex", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	err = newAdminService(cfg).Reindex(func(table string, took time.Duration) {
+		fmt.Printf("reindexed %s in %s\n", table, took.Round(time.Millisecond))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("statistics refreshed")
+	return nil
+}
+
+// runPurge runs the purge command. Without --yes it only shows what would be
+// deleted.
+func runPurge(args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	tenant := flags.Uint("tenant", 0, "company ID")
+	confirm := flags.Bool("yes", false, "delete; without it the command only reports")
+	cfg, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if *tenant == 0 {
+		return errors.New("usage: truck-management purge --tenant ID [--yes]")
+	}
+
+	purge, err := newAdminService(cfg).PurgeTenant(*tenant, *confirm)
+	if err != nil {
+		return err
+	}
+
+	tables := make([]string, 0, len(purge.Rows))
+	for table := range purge.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	fmt.Printf("company %d, %s:\n", purge.CompanyID, purge.CompanyName)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, table := range tables {
+		fmt.Fprintf(w, "  %s\t%d rows\n", table, purge.Rows[table])
+	}
+	fmt.Fprintf(w, "  files\t%d\n", purge.Files)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !purge.Purged {
+		fmt.Println("nothing deleted; run again with --yes to purge")
+		return nil
+	}
+	fmt.Println("purged")
+	if purge.FileErrors > 0 {
+		return fmt.Errorf("%d blob prefixes could not be cleared, see the log; %d files were deleted", purge.FileErrors, purge.Files)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"truck-management/config"
+)
+
+// command is a subcommand of the server binary.
+type command struct {
+	name string
+	args string
+	help string
+	run  func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "", "start the HTTP server (the default)", runServe},
+	{"migrate", "up | down [steps] | status", "apply, revert or list database migrations", runMigrate},
+	{"seed", "--demo", "create a demo tenant with branches, drivers, trucks and cargo", runSeed},
+	{"create-admin", "--company ID --email EMAIL [--first-name NAME] [--last-name NAME] [--password PASSWORD]", "create an admin user of a company", runCreateAdmin},
+	{"rotate-jwt-secret", "", "generate a new JWT secret and print the settings to roll it out", runRotateJWTSecret},
+	{"reindex", "", "rebuild the indexes of every table and refresh statistics", runReindex},
+	{"purge", "--tenant ID [--yes]", "delete a company with all its data and files for good", runPurge},
+}
+
+// runCommand runs the subcommand named by the first argument, serve when
+// there is none.
+func runCommand(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		return runServe(args)
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage()
+		return nil
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	printUsage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: truck-management <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n      %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+}
+
+// parseFlags parses a command's flags, with the configuration flags added,
+// and loads the configuration.
+func parseFlags(flags *flag.FlagSet, args []string) (*config.Config, error) {
+	configFlags := config.AddFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return config.Load(configFlags)
+}
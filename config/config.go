@@ -0,0 +1,315 @@
+// Package config loads the server's settings from command line flags, the
+// environment and a config file, in that order of precedence, and validates
+// them before anything is started.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+	"truck-management/internal/notify"
+	"truck-management/internal/storage"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// developmentJWTSecret signs tokens when JWT_SECRET is not set. It is public,
+// so production refuses to start with it.
+const developmentJWTSecret = "development-only-jwt-secret-do-not-use-in-production"
+
+// minSecretLength is the shortest signing secret production accepts.
+const minSecretLength = 32
+
+type Config struct {
+	// Env is development or production, from APP_ENV. Production refuses
+	// insecure defaults.
+	Env      string
+	Server   ServerConfig
+	LogLevel string
+	Database DatabaseConfig
+	JWT      JWTConfig
+	// URLSigningSecret signs download links, the JWT secret when empty.
+	URLSigningSecret string
+	CORS             CORSConfig
+	Storage          StorageConfig
+	Notify           NotifyConfig
+	Jobs             JobsConfig
+	RateLimit        RateLimitConfig
+	Features         FeatureConfig
+	// OperatorUserIDs are the users who run the platform. Only they reach
+	// the API that spans every tenant, such as the scheduled jobs.
+	OperatorUserIDs []uint
+	// TrustedProxies are the addresses and CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For header names the client. With none,
+	// the client is the peer address, so the header cannot fake it.
+	TrustedProxies []string
+}
+
+type JWTConfig struct {
+	Secret string
+	// PreviousSecret is the secret before the last rotation. Tokens it
+	// signed are accepted until they expire.
+	PreviousSecret string
+	TTL            time.Duration
+}
+
+// IsDevelopmentDefault reports whether tokens are signed with the built-in
+// development secret.
+func (c JWTConfig) IsDevelopmentDefault() bool {
+	return c.Secret == developmentJWTSecret
+}
+
+type CORSConfig struct {
+	// AllowedOrigins are the browser origins allowed to call the API, "*"
+	// for any.
+	AllowedOrigins []string
+}
+
+// FeatureConfig switches optional parts of the server on and off.
+type FeatureConfig struct {
+	Swagger        bool
+	PublicTracking bool
+}
+
+// Flags are the command line flags that override settings.
+type Flags struct {
+	file     *string
+	env      *string
+	port     *int
+	logLevel *string
+}
+
+// AddFlags adds the configuration flags to a command's flag set; pass the
+// result to Load once the flags are parsed.
+func AddFlags(flags *flag.FlagSet) *Flags {
+	return &Flags{
+		file:     flags.String("config", "", "read settings from this KEY=VALUE file (default .env, if it exists)"),
+		env:      flags.String("env", "", "development or production, overrides APP_ENV"),
+		port:     flags.Int("port", 0, "HTTP port, overrides PORT"),
+		logLevel: flags.String("log-level", "", "debug, info, warn, error or silent, overrides LOG_LEVEL"),
+	}
+}
+
+// Load reads and validates the settings. It returns every invalid setting
+// at once.
+func Load(flags *Flags) (*Config, error) {
+	l := &loader{flags: map[string]string{}}
+	if flags != nil {
+		l.flags["CONFIG_FILE"] = *flags.file
+		l.flags["APP_ENV"] = *flags.env
+		l.flags["LOG_LEVEL"] = *flags.logLevel
+		if *flags.port != 0 {
+			l.flags["PORT"] = fmt.Sprint(*flags.port)
+		}
+	}
+
+	if path, ok := l.lookup("CONFIG_FILE"); ok {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		l.file = file
+	} else if file, err := readFile(".env"); err == nil {
+		l.file = file
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	env := l.string("APP_ENV", EnvDevelopment)
+	production := env == EnvProduction
+
+	// Development logs every query and lets any origin call the API;
+	// production has to name its origins and gives load balancers time to
+	// stop routing to an instance that is shutting down.
+	logLevel, origins, shutdownDelay := "debug", []string{"*"}, time.Duration(0)
+	if production {
+		logLevel, origins, shutdownDelay = "info", nil, 5*time.Second
+	}
+
+	cfg := &Config{
+		Env: env,
+		Server: ServerConfig{
+			Port:              l.int("PORT", 8080),
+			ReadHeaderTimeout: l.duration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+			ReadTimeout:       l.duration("HTTP_READ_TIMEOUT", 5*time.Minute),
+			WriteTimeout:      l.duration("HTTP_WRITE_TIMEOUT", 5*time.Minute),
+			IdleTimeout:       l.duration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+			ShutdownDelay:     l.duration("SHUTDOWN_DELAY", shutdownDelay),
+			ShutdownTimeout:   l.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
+		LogLevel: l.string("LOG_LEVEL", logLevel),
+		Database: DatabaseConfig{
+			URL:                l.string("DATABASE_URL", developmentDatabaseURL),
+			MaxOpenConns:       l.int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:       l.int("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetime:    l.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			ConnMaxIdleTime:    l.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			ConnectTimeout:     l.duration("DB_CONNECT_TIMEOUT", 10*time.Second),
+			SlowQueryThreshold: l.duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		},
+		JWT: JWTConfig{
+			Secret:         l.string("JWT_SECRET", developmentJWTSecret),
+			PreviousSecret: l.string("JWT_PREVIOUS_SECRET", ""),
+			TTL:            l.duration("JWT_TTL", 24*time.Hour),
+		},
+		URLSigningSecret: l.string("URL_SIGNING_SECRET", ""),
+		CORS: CORSConfig{
+			AllowedOrigins: l.list("CORS_ALLOWED_ORIGINS", origins),
+		},
+		TrustedProxies: l.list("TRUSTED_PROXIES", nil),
+		Storage: StorageConfig{
+			Backend:   l.string("STORAGE_BACKEND", "local"),
+			LocalPath: l.string("STORAGE_LOCAL_PATH", "./data/blobs"),
+			S3: storage.S3Config{
+				Endpoint:        l.string("S3_ENDPOINT", ""),
+				Region:          l.string("S3_REGION", ""),
+				Bucket:          l.string("S3_BUCKET", ""),
+				AccessKeyID:     l.string("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: l.string("S3_SECRET_ACCESS_KEY", ""),
+				UsePathStyle:    l.bool("S3_USE_PATH_STYLE", false),
+			},
+			MaxAttachmentMB:   int64(l.int("ATTACHMENT_MAX_FILE_MB", 25)),
+			AttachmentQuotaMB: int64(l.int("ATTACHMENT_TENANT_QUOTA_MB", 1024)),
+		},
+		Notify: NotifyConfig{
+			EmailBackend: l.string("NOTIFY_EMAIL_BACKEND", "log"),
+			SMTP: notify.SMTPConfig{
+				Host:     l.string("SMTP_HOST", ""),
+				Port:     l.int("SMTP_PORT", 587),
+				Username: l.string("SMTP_USERNAME", ""),
+				Password: l.string("SMTP_PASSWORD", ""),
+				From:     l.string("SMTP_FROM", ""),
+			},
+			SMSBackend: l.string("NOTIFY_SMS_BACKEND", "log"),
+		},
+		Jobs: JobsConfig{
+			Workers:          l.int("JOB_WORKERS", 4),
+			SchedulerEnabled: l.bool("SCHEDULER_ENABLED", true),
+		},
+		RateLimit: RateLimitConfig{
+			PublicPerMinute: l.int("PUBLIC_RATE_LIMIT_PER_MINUTE", 60),
+		},
+		Features: FeatureConfig{
+			Swagger:        l.bool("SWAGGER_ENABLED", !production),
+			PublicTracking: l.bool("PUBLIC_TRACKING_ENABLED", true),
+		},
+		OperatorUserIDs: l.ids("OPERATOR_USER_IDS"),
+	}
+
+	cfg.validate(l)
+	if production {
+		cfg.validateProduction(l)
+	}
+	if len(l.errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(l.errs...))
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate(l *loader) {
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		l.errorf("APP_ENV: %q is not development or production", c.Env)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		l.errorf("PORT: %d is not a TCP port", c.Server.Port)
+	}
+	if c.Server.ShutdownDelay < 0 {
+		l.errorf("SHUTDOWN_DELAY: must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		l.errorf("SHUTDOWN_TIMEOUT: must be positive")
+	}
+	if _, ok := logLevels[c.LogLevel]; !ok {
+		l.errorf("LOG_LEVEL: %q is not debug, info, warn, error or silent", c.LogLevel)
+	}
+
+	if c.Database.MaxOpenConns < 1 {
+		l.errorf("DB_MAX_OPEN_CONNS: must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		l.errorf("DB_MAX_IDLE_CONNS: must be between 0 and DB_MAX_OPEN_CONNS (%d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		l.errorf("DB_CONNECT_TIMEOUT: must be positive")
+	}
+
+	if c.JWT.TTL <= 0 {
+		l.errorf("JWT_TTL: must be positive")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !validOrigin(origin) {
+			l.errorf("CORS_ALLOWED_ORIGINS: %q is not * or an origin such as https://app.example.com", origin)
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if !validProxy(proxy) {
+			l.errorf("TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+
+	switch c.Storage.Backend {
+	case "local":
+	case "s3":
+		if c.Storage.S3.Bucket == "" {
+			l.errorf("S3_BUCKET: required with STORAGE_BACKEND=s3")
+		}
+	default:
+		l.errorf("STORAGE_BACKEND: %q is not local or s3", c.Storage.Backend)
+	}
+	if c.Storage.MaxAttachmentMB < 1 {
+		l.errorf("ATTACHMENT_MAX_FILE_MB: must be at least 1")
+	}
+	if c.Storage.AttachmentQuotaMB < 1 {
+		l.errorf("ATTACHMENT_TENANT_QUOTA_MB: must be at least 1")
+	}
+
+	switch c.Notify.EmailBackend {
+	case "log", "none":
+	case "smtp":
+		if c.Notify.SMTP.Host == "" || c.Notify.SMTP.From == "" {
+			l.errorf("SMTP_HOST, SMTP_FROM: required with NOTIFY_EMAIL_BACKEND=smtp")
+		}
+	default:
+		l.errorf("NOTIFY_EMAIL_BACKEND: %q is not smtp, log or none", c.Notify.EmailBackend)
+	}
+	if c.Notify.SMSBackend != "log" && c.Notify.SMSBackend != "none" {
+		l.errorf("NOTIFY_SMS_BACKEND: %q is not log or none", c.Notify.SMSBackend)
+	}
+
+	if c.Jobs.Workers < 1 {
+		l.errorf("JOB_WORKERS: must be at least 1")
+	}
+	if c.RateLimit.PublicPerMinute < 1 {
+		l.errorf("PUBLIC_RATE_LIMIT_PER_MINUTE: must be at least 1")
+	}
+}
+
+// validateProduction rejects the defaults that are only fit for a developer
+// machine.
+func (c *Config) validateProduction(l *loader) {
+	if !l.isSet("DATABASE_URL") {
+		l.errorf("DATABASE_URL: required in production")
+	}
+	if !l.isSet("JWT_SECRET") {
+		l.errorf("JWT_SECRET: required in production")
+	} else if c.JWT.IsDevelopmentDefault() || len(c.JWT.Secret) < minSecretLength {
+		l.errorf("JWT_SECRET: must be a random string of at least %d characters in production", minSecretLength)
+	}
+	if c.JWT.PreviousSecret != "" && len(c.JWT.PreviousSecret) < minSecretLength {
+		l.errorf("JWT_PREVIOUS_SECRET: must be at least %d characters in production", minSecretLength)
+	}
+	if c.URLSigningSecret != "" && len(c.URLSigningSecret) < minSecretLength {
+		l.errorf("URL_SIGNING_SECRET: must be at least %d characters in production", minSecretLength)
+	}
+	if slices.Contains(c.CORS.AllowedOrigins, "*") {
+		l.errorf("CORS_ALLOWED_ORIGINS: * is not allowed in production, list the origins of the web apps")
+	}
+}
@@ -0,0 +1,9 @@
+package config
+
+type JobsConfig struct {
+	// Workers is how many background jobs this instance runs at once.
+	Workers int
+	// SchedulerEnabled is whether this instance takes part in running the
+	// recurring system jobs. Only one enabled instance runs them at a time.
+	SchedulerEnabled bool
+}
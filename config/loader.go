@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loader reads settings by key from the command line flags, the environment
+// and the config file, in that order of precedence. Empty values count as
+// unset. Malformed values are collected rather than returned one by one, so
+// a bad deployment reports all its mistakes at once.
+type loader struct {
+	flags map[string]string
+	file  map[string]string
+	errs  []error
+}
+
+func (l *loader) lookup(key string) (string, bool) {
+	if v := l.flags[key]; v != "" {
+		return v, true
+	}
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v, true
+	}
+	if v := l.file[key]; v != "" {
+		return v, true
+	}
+	return "", false
+}
+
+func (l *loader) isSet(key string) bool {
+	_, ok := l.lookup(key)
+	return ok
+}
+
+func (l *loader) errorf(format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+func (l *loader) string(key, def string) string {
+	if v, ok := l.lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+func (l *loader) int(key string, def int) int {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.errorf("%s: %q is not a whole number", key, v)
+		return def
+	}
+	return n
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errorf("%s: %q is not true or false", key, v)
+		return def
+	}
+	return b
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errorf("%s: %q is not a duration such as 30s or 5m", key, v)
+		return def
+	}
+	return d
+}
+
+// list reads a comma separated list.
+func (l *loader) list(key string, def []string) []string {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ids reads a comma separated list of record IDs.
+func (l *loader) ids(key string) []uint {
+	var ids []uint
+	for _, item := range l.list(key, nil) {
+		id, err := strconv.ParseUint(item, 10, 32)
+		if err != nil || id == 0 {
+			l.errorf("%s: %q is not an ID", key, item)
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+// readFile reads a config file of KEY=VALUE lines, the format of a .env
+// file: blank lines and lines starting with # are skipped, values may be
+// quoted and unquoted values may end in a # comment.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		} else if strings.HasPrefix(value, "#") {
+			value = ""
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// validProxy reports whether proxy is an IP address or a CIDR range.
+func validProxy(proxy string) bool {
+	if _, _, err := net.ParseCIDR(proxy); err == nil {
+		return true
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+// validOrigin reports whether origin is a CORS origin, scheme://host[:port].
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"log"
+	"truck-management/internal/notify"
+)
+
+type NotifyConfig struct {
+	// EmailBackend is smtp, log or none.
+	EmailBackend string
+	SMTP         notify.SMTPConfig
+	// SMSBackend is log or none.
+	SMSBackend string
+}
+
+// InitNotifiers returns the email and SMS senders for consignee
+// notifications. Both log messages unless a provider is configured.
+func InitNotifiers(cfg NotifyConfig) (email notify.Sender, sms notify.Sender) {
+	switch cfg.EmailBackend {
+	case "smtp":
+		sender, err := notify.NewSMTPSender(cfg.SMTP)
+		if err != nil {
+			log.Fatal("Failed to initialize SMTP:", err)
+		}
+		email = sender
+	case "log":
+		email = notify.NewLogSender("email")
+	case "none":
+		email = notify.NopSender{}
+	default:
+		log.Fatal("Unknown NOTIFY_EMAIL_BACKEND: ", cfg.EmailBackend)
+	}
+
+	switch cfg.SMSBackend {
+	case "log":
+		sms = notify.NewLogSender("sms")
+	case "none":
+		sms = notify.NopSender{}
+	default:
+		log.Fatal("Unknown NOTIFY_SMS_BACKEND: ", cfg.SMSBackend)
+	}
+
+	return email, sms
+}
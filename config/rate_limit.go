@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+type RateLimitConfig struct {
+	PublicPerMinute int
+}
+
+// Public returns how many requests a client IP may make to the
+// unauthenticated endpoints per window.
+func (c RateLimitConfig) Public() (int, time.Duration) {
+	return c.PublicPerMinute, time.Minute
+}
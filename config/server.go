@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type ServerConfig struct {
+	Port              int
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownDelay is how long the server keeps serving with its readiness
+	// check failing before it shuts down, for load balancers to notice.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds draining requests, closing WebSocket clients
+	// and stopping background workers.
+	ShutdownTimeout time.Duration
+}
+
+// Addr is the address the HTTP server listens on.
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"log"
+	"truck-management/internal/storage"
+)
+
+type StorageConfig struct {
+	// Backend is local or s3.
+	Backend   string
+	LocalPath string
+	S3        storage.S3Config
+	// MaxAttachmentMB is the size limit of a single attachment and
+	// AttachmentQuotaMB the attachments a company may store in total.
+	MaxAttachmentMB   int64
+	AttachmentQuotaMB int64
+}
+
+func InitStorage(cfg StorageConfig) storage.BlobStore {
+	switch cfg.Backend {
+	case "s3":
+		store, err := storage.NewS3Store(cfg.S3)
+		if err != nil {
+			log.Fatal("Failed to initialize S3 storage:", err)
+		}
+		return store
+
+	case "local":
+		store, err := storage.NewLocalStore(cfg.LocalPath)
+		if err != nil {
+			log.Fatal("Failed to initialize blob storage:", err)
+		}
+		return store
+
+	default:
+		log.Fatal("Unknown STORAGE_BACKEND: ", cfg.Backend)
+		return nil
+	}
+}
+
+// AttachmentLimits returns the per-file size limit and the per-company quota
+// for attachments, in bytes.
+func (c StorageConfig) AttachmentLimits() (int64, int64) {
+	return c.MaxAttachmentMB << 20, c.AttachmentQuotaMB << 20
+}
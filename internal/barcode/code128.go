@@ -0,0 +1,99 @@
+// Package barcode encodes Code 128 and QR symbols as module matrices that
+// renderers can draw at any scale.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// code128Patterns holds the bar and space widths of each Code 128 symbol,
+// starting with a bar. 103-105 are the start codes A, B and C, 106 is stop.
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128 encodes printable ASCII data and returns its modules, true for a
+// bar. Runs of digits are packed in code set C; everything else uses code set
+// B. Quiet zones are left to the caller.
+func Code128(data string) ([]bool, error) {
+	if data == "" {
+		return nil, errors.New("barcode data is empty")
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < 32 || data[i] > 126 {
+			return nil, errors.New("barcode data must be printable ASCII")
+		}
+	}
+
+	var codes []int
+	inC := digitRun(data, 0) >= 4
+	if inC {
+		codes = append(codes, code128StartC)
+	} else {
+		codes = append(codes, code128StartB)
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(data, i)
+		if !inC && run >= 6 {
+			codes = append(codes, code128CodeC)
+			inC = true
+		}
+		if inC {
+			if run >= 2 {
+				codes = append(codes, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+			codes = append(codes, code128CodeB)
+			inC = false
+		}
+		codes = append(codes, int(data[i])-32)
+		i++
+	}
+
+	checksum := codes[0]
+	for i := 1; i < len(codes); i++ {
+		checksum += i * codes[i]
+	}
+	codes = append(codes, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, code := range codes {
+		bar := true
+		for _, width := range code128Patterns[code] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, bar)
+			}
+			bar = !bar
+		}
+	}
+	return modules, nil
+}
+
+func digitRun(data string, from int) int {
+	n := 0
+	for from+n < len(data) && strings.IndexByte("0123456789", data[from+n]) >= 0 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package barcode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		data  string
+		codes []int
+	}{
+		// Start B, the data, checksum (104 + 48*1 + 42*2 + ... + 35*7) % 103 = 55, stop
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		// Start C and digit pairs
+		{"123456", []int{105, 12, 34, 56, 44, 106}},
+		// An odd digit left over switches to code set B
+		{"12345", []int{105, 12, 34, 100, 21, 54, 106}},
+		// A long enough digit run switches to code set C
+		{"TRK000042260314", []int{104, 52, 50, 43, 99, 0, 0, 42, 26, 3, 14, 8, 106}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			modules, err := Code128(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := 11*len(tt.codes) + 2; len(modules) != want {
+				t.Fatalf("got %d modules, want %d", len(modules), want)
+			}
+			if got := decodeCode128(t, modules); !slices.Equal(got, tt.codes) {
+				t.Errorf("symbols = %v, want %v", got, tt.codes)
+			}
+		})
+	}
+}
+
+func TestCode128RejectsInvalidData(t *testing.T) {
+	for _, data := range []string{"", "TRK\n1", "café"} {
+		if _, err := Code128(data); err == nil {
+			t.Errorf("Code128(%q) succeeded", data)
+		}
+	}
+}
+
+// decodeCode128 reads the modules back into symbol values, six runs per
+// symbol and seven for the stop pattern.
+func decodeCode128(t *testing.T, modules []bool) []int {
+	t.Helper()
+
+	var widths []byte
+	for i := 0; i < len(modules); {
+		n := 1
+		for i+n < len(modules) && modules[i+n] == modules[i] {
+			n++
+		}
+		widths = append(widths, byte('0'+n))
+		i += n
+	}
+
+	var codes []int
+	for len(widths) > 0 {
+		n := min(6, len(widths))
+		if len(widths) == 7 {
+			n = 7
+		}
+		code := slices.Index(code128Patterns[:], string(widths[:n]))
+		if code < 0 {
+			t.Fatalf("no symbol has the widths %s", widths[:n])
+		}
+		codes = append(codes, code)
+		widths = widths[n:]
+	}
+	return codes
+}
@@ -0,0 +1,203 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// qrFormatM lists the format information of level M for masks 0-7 as given
+// in the standard, BCH code and XOR mask applied.
+var qrFormatM = [8]int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+
+func TestQRErrorCorrection(t *testing.T) {
+	// "HELLO WORLD" at 1-M, the worked example of the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ec := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qrAddErrorCorrection(data, 1)
+	if want := append(append([]byte{}, data...), ec...); !bytes.Equal(got, want) {
+		t.Errorf("codewords = %v, want %v", got, want)
+	}
+}
+
+func TestQR(t *testing.T) {
+	tests := []struct {
+		data    string
+		version int
+	}{
+		{"TRK1", 1},
+		{"TRK000042260314", 2},
+		{"https://track.example.com/t/TRK000042260314?token=" + strings.Repeat("a", 60), 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("version %d", tt.version), func(t *testing.T) {
+			q, err := QR([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.version*4 + 17; q.Size != want {
+				t.Fatalf("size = %d, want %d", q.Size, want)
+			}
+
+			format, second := qrReadFormat(q)
+			if format != second {
+				t.Fatalf("format copies differ: %#x and %#x", format, second)
+			}
+			mask := -1
+			for m, bits := range qrFormatM {
+				if bits == format {
+					mask = m
+				}
+			}
+			if mask < 0 {
+				t.Fatalf("format %#x is not a level M format", format)
+			}
+			if !q.Module(8, q.Size-8) {
+				t.Error("dark module is light")
+			}
+			if tt.version >= 7 {
+				// Version 7 information from the standard's table
+				if got := qrReadVersion(q); got != 0x07C94 {
+					t.Errorf("version information = %#x, want 0x07c94", got)
+				}
+			}
+
+			if got := qrReadData(q, tt.version, mask); got != tt.data {
+				t.Errorf("decoded %q, want %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestQRRejectsLongData(t *testing.T) {
+	if _, err := QR(bytes.Repeat([]byte("a"), 181)); err == nil {
+		t.Error("QR accepted data beyond version 9")
+	}
+}
+
+// qrReadFormat reads both copies of the format information, least significant
+// bit first.
+func qrReadFormat(q *QRCode) (int, int) {
+	var first, second int
+	for i := 0; i < 15; i++ {
+		var x, y int
+		switch {
+		case i < 6:
+			x, y = 8, i
+		case i < 8:
+			x, y = 8, i+1
+		case i == 8:
+			x, y = 7, 8
+		default:
+			x, y = 14-i, 8
+		}
+		if q.Module(x, y) {
+			first |= 1 << i
+		}
+
+		if i < 8 {
+			x, y = q.Size-1-i, 8
+		} else {
+			x, y = 8, q.Size-15+i
+		}
+		if q.Module(x, y) {
+			second |= 1 << i
+		}
+	}
+	return first, second
+}
+
+// qrReadVersion reads the version information block below the top right
+// finder.
+func qrReadVersion(q *QRCode) int {
+	bits := 0
+	for i := 0; i < 18; i++ {
+		if q.Module(q.Size-11+i%3, i/3) {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+// qrReadData unmasks the symbol, reads its codewords in placement order,
+// undoes the block interleaving and parses the byte mode segment.
+func qrReadData(q *QRCode, version, mask int) string {
+	var raw []byte
+	var current byte
+	n := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if q.isFunction[y][x] {
+					continue
+				}
+				dark := q.Module(x, y) != qrMaskBit(mask, x, y)
+				current <<= 1
+				if dark {
+					current |= 1
+				}
+				if n++; n%8 == 0 {
+					raw = append(raw, current)
+				}
+			}
+		}
+	}
+
+	numBlocks := qrNumBlocks[version]
+	total := qrRawCodewords(version)
+	numShort := numBlocks - total%numBlocks
+	shortLen := total/numBlocks - qrECCodewordsPerBlock[version]
+
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortLen; i++ {
+		for b := range blocks {
+			if i < shortLen || b >= numShort {
+				blocks[b] = append(blocks[b], raw[k])
+				k++
+			}
+		}
+	}
+	data := bytes.Join(blocks, nil)
+
+	if data[0]>>4 != 0x4 {
+		return ""
+	}
+	length := int(data[0]&0x0F)<<4 | int(data[1]>>4)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = data[i+1]<<4 | data[i+2]>>4
+	}
+	return string(out)
+}
+
+func qrMaskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+// UploadAttachment godoc
+// @Summary Upload an attachment
+// @Description Attach a document or photo to a cargo, truck, request, visit or task
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param owner_type formData string true "Owner type (cargo, truck, request, visit, task)"
+// @Param owner_id formData int true "Owner ID"
+// @Param category formData string false "Category, e.g. bill_of_lading, insurance"
+// @Param file formData file true "File"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Security BearerAuth
+// @Router /attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.UploadAttachmentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	attachment, err := h.attachmentService.UploadAttachment(companyID, userID, req, file)
+	if errors.Is(err, services.ErrAttachmentQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachments godoc
+// @Summary List attachments
+// @Description List the attachments of a cargo, truck, request, visit or task
+// @Tags attachments
+// @Produce json
+// @Param owner_type query string true "Owner type"
+// @Param owner_id query int true "Owner ID"
+// @Param category query string false "Filter by category"
+// @Success 200 {array} models.Attachment
+// @Security BearerAuth
+// @Router /attachments [get]
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.AttachmentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attachments, err := h.attachmentService.GetAttachments(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// GetAttachmentUsage godoc
+// @Summary Get attachment storage usage
+// @Description Get the company's attachment storage usage and quota
+// @Tags attachments
+// @Produce json
+// @Success 200 {object} models.AttachmentUsage
+// @Security BearerAuth
+// @Router /attachments/usage [get]
+func (h *AttachmentHandler) GetAttachmentUsage(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	usage, err := h.attachmentService.GetUsage(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetAttachment godoc
+// @Summary Get an attachment
+// @Description Get attachment metadata by ID
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Success 200 {object} models.Attachment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /attachments/{id} [get]
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	attachment, err := h.attachmentService.GetAttachment(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Download the attachment's content
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /attachments/{id}/download [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	attachment, r, err := h.attachmentService.OpenAttachment(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, r, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+	})
+}
+
+// GetAttachmentURL godoc
+// @Summary Get a signed download URL
+// @Description Get a time-limited URL that downloads the attachment without authentication
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Param expires_in query int false "Validity in seconds" default(900)
+// @Success 200 {object} models.AttachmentURLResponse
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /attachments/{id}/url [get]
+func (h *AttachmentHandler) GetAttachmentURL(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	expiresIn, _ := strconv.Atoi(c.Query("expires_in"))
+
+	url, err := h.attachmentService.GetDownloadURL(uint(id), companyID, time.Duration(expiresIn)*time.Second, apiBaseURL(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Remove an attachment and its stored file
+// @Tags attachments
+// @Param id path int true "Attachment ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.attachmentService.DeleteAttachment(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DownloadSignedFile godoc
+// @Summary Download a file by signed URL
+// @Description Download an attachment using a signed URL obtained from /attachments/{id}/url
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Expiry (unix seconds)"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string
+// @Router /files/{id} [get]
+func (h *AttachmentHandler) DownloadSignedFile(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	attachment, r, err := h.attachmentService.OpenSignedAttachment(uint(id), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, r, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+	})
+}
+
+func apiBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + "/api/v1"
+}
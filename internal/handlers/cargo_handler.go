@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -67,7 +68,9 @@ func (h *CargoHandler) CreateCargo(c *gin.Context) {
 // @Param type query string false "Filter by cargo type"
 // @Param priority query string false "Filter by priority"
 // @Param truck_id query int false "Filter by truck ID"
+// @Param customer_id query int false "Filter by customer ID"
 // @Param assigned query bool false "Filter assigned/unassigned cargo"
+// @Param awaiting_approval query bool false "Filter portal bookings awaiting approval"
 // @Param search query string false "Search in title, tracking number, or description"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
@@ -122,23 +125,41 @@ func (h *CargoHandler) GetCargo(c *gin.Context) {
 
 // GetCargoByTracking godoc
 // @Summary Get cargo by tracking number
-// @Description Retrieve cargo information using tracking number
+// @Description Public shipment status. Addresses and the proof of delivery summary are only shown with the cargo's tracking token or destination postcode.
 // @Tags cargo
 // @Produce json
 // @Param tracking_number path string true "Tracking Number"
-// @Success 200 {object} models.Cargo
+// @Param token query string false "Tracking token shared by the shipper"
+// @Param postcode query string false "Destination postcode"
+// @Success 200 {object} models.PublicTracking
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /cargo/track/{tracking_number} [get]
 func (h *CargoHandler) GetCargoByTracking(c *gin.Context) {
+	h.getPublicTracking(c, false)
+}
+
+func (h *CargoHandler) getPublicTracking(c *gin.Context, detailed bool) {
 	trackingNumber := c.Param("tracking_number")
-	
-	cargo, err := h.cargoService.GetCargoByTracking(trackingNumber)
+
+	var verification models.TrackingVerification
+	if err := c.ShouldBindQuery(&verification); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tracking, err := h.cargoService.GetPublicTracking(trackingNumber, verification, detailed)
+	if errors.Is(err, services.ErrInvalidTrackingNumber) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tracking number, please check it for typos"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, cargo)
+	c.JSON(http.StatusOK, tracking)
 }
 
 // UpdateCargo godoc
@@ -232,6 +253,10 @@ func (h *CargoHandler) AssignCargoToTruck(c *gin.Context) {
 	}
 
 	cargo, err := h.cargoService.AssignCargoToTruck(uint(id), companyID, req.TruckID, userID)
+	if errors.Is(err, services.ErrBookingNotApproved) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -275,6 +300,72 @@ func (h *CargoHandler) UnassignCargoFromTruck(c *gin.Context) {
 	c.JSON(http.StatusOK, cargo)
 }
 
+// ApproveCargoBooking godoc
+// @Summary Approve a portal booking
+// @Description Approve a cargo booked by a customer in the portal so it can be dispatched
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.Cargo
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/approve [post]
+func (h *CargoHandler) ApproveCargoBooking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	cargo, err := h.cargoService.ApproveBooking(uint(id), companyID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_booking_approved",
+		Data: cargo,
+	})
+
+	c.JSON(http.StatusOK, cargo)
+}
+
+// RejectCargoBooking godoc
+// @Summary Reject a portal booking
+// @Description Cancel a cargo booked by a customer in the portal that has not been approved
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param request body models.RejectBookingRequest true "Rejection reason"
+// @Success 200 {object} models.Cargo
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/reject [post]
+func (h *CargoHandler) RejectCargoBooking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.RejectBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cargo, err := h.cargoService.RejectBooking(uint(id), companyID, userID, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_booking_rejected",
+		Data: cargo,
+	})
+
+	c.JSON(http.StatusOK, cargo)
+}
+
 // GetCargosByTruck godoc
 // @Summary Get cargo assigned to truck
 // @Description Get all cargo shipments assigned to a specific truck
@@ -425,23 +516,42 @@ func (h *CargoHandler) UpdateCargoLocation(c *gin.Context) {
 
 // GetCargoTracking godoc
 // @Summary Get detailed cargo tracking info
-// @Description Get comprehensive tracking information including progress and ETA
+// @Description Public tracking with progress, ETA and shipment milestones. Event locations, the journey and the truck position are only shown with the cargo's tracking token or destination postcode.
 // @Tags cargo
 // @Produce json
 // @Param tracking_number path string true "Tracking Number"
-// @Success 200 {object} models.CargoTrackingResponse
+// @Param token query string false "Tracking token shared by the shipper"
+// @Param postcode query string false "Destination postcode"
+// @Success 200 {object} models.PublicTracking
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /cargo/track/{tracking_number}/details [get]
 func (h *CargoHandler) GetCargoTracking(c *gin.Context) {
-	trackingNumber := c.Param("tracking_number")
-	
-	tracking, err := h.cargoService.GetCargoTracking(trackingNumber)
+	h.getPublicTracking(c, true)
+}
+
+// RotateCargoTrackingToken godoc
+// @Summary Rotate the tracking token
+// @Description Issue a new public tracking token for a cargo; links shared with the old token stop showing details
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.Cargo
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/tracking-token [post]
+func (h *CargoHandler) RotateCargoTrackingToken(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	cargo, err := h.cargoService.RotateTrackingToken(uint(id), companyID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tracking)
+	c.JSON(http.StatusOK, cargo)
 }
 
 // GetCargoRoute godoc
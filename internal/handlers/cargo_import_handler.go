@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CargoImportHandler struct {
+	importService *services.CargoImportService
+}
+
+func NewCargoImportHandler(importService *services.CargoImportService) *CargoImportHandler {
+	return &CargoImportHandler{importService: importService}
+}
+
+// ImportCargo godoc
+// @Summary Bulk import cargo
+// @Description Import cargo from a CSV or XLSX file. Every row is validated like POST /cargo and valid rows are created in one transaction. Files with more than 200 rows are processed in the background and return 202 with the job to poll.
+// @Tags cargo
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file with a header row"
+// @Param mapping formData string false "JSON object mapping column headers to cargo fields, e.g. {\"Consignee\":\"destination_contact\"}"
+// @Param dry_run formData bool false "Validate and preview without creating cargo"
+// @Param sheet formData string false "XLSX worksheet name, defaults to the first sheet"
+// @Success 200 {object} models.CargoImportJob
+// @Success 202 {object} models.CargoImportJob
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/import [post]
+func (h *CargoImportHandler) ImportCargo(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.CargoImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	job, async, err := h.importService.StartImport(companyID, userID, req, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if async {
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetCargoImports godoc
+// @Summary List cargo imports
+// @Description List the company's cargo import jobs, newest first
+// @Tags cargo
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /cargo/imports [get]
+func (h *CargoImportHandler) GetCargoImports(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.CargoImportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.importService.GetImportJobs(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imports": jobs,
+		"total":   total,
+		"page":    filter.Page,
+		"limit":   filter.Limit,
+	})
+}
+
+// GetCargoImport godoc
+// @Summary Get a cargo import
+// @Description Get the progress and per-row errors of a cargo import job
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Import job ID"
+// @Success 200 {object} models.CargoImportJob
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/imports/{id} [get]
+func (h *CargoImportHandler) GetCargoImport(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	job, err := h.importService.GetImportJob(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+	"truck-management/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CargoPieceHandler struct {
+	pieceService *services.CargoPieceService
+	wsHub        *websocket.Hub
+}
+
+func NewCargoPieceHandler(pieceService *services.CargoPieceService, wsHub *websocket.Hub) *CargoPieceHandler {
+	return &CargoPieceHandler{
+		pieceService: pieceService,
+		wsHub:        wsHub,
+	}
+}
+
+// GetCargoPieces godoc
+// @Summary Get cargo pieces
+// @Description Get the pieces of a cargo shipment in piece order
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {array} models.CargoPiece
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pieces [get]
+func (h *CargoPieceHandler) GetCargoPieces(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	pieces, err := h.pieceService.GetPieces(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pieces)
+}
+
+// CreateCargoPieces godoc
+// @Summary Add cargo pieces
+// @Description Add pieces to a cargo shipment. Each piece gets a barcode derived from the tracking number and the cargo's weight and volume become the sum of its pieces.
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param request body models.CreateCargoPiecesRequest true "Pieces"
+// @Success 201 {array} models.CargoPiece
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pieces [post]
+func (h *CargoPieceHandler) CreateCargoPieces(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CreateCargoPiecesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pieces, err := h.pieceService.AddPieces(uint(id), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pieces)
+}
+
+// SplitCargo godoc
+// @Summary Split cargo into pieces
+// @Description Split a cargo without pieces into a number of equal pieces
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param request body models.SplitCargoRequest true "Piece count"
+// @Success 201 {array} models.CargoPiece
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/split [post]
+func (h *CargoPieceHandler) SplitCargo(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.SplitCargoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pieces, err := h.pieceService.SplitCargo(uint(id), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pieces)
+}
+
+// DeleteCargoPiece godoc
+// @Summary Remove a cargo piece
+// @Description Remove a pending piece from a cargo shipment
+// @Tags cargo
+// @Param id path int true "Cargo ID"
+// @Param piece_id path int true "Piece ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pieces/{piece_id} [delete]
+func (h *CargoPieceHandler) DeleteCargoPiece(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	pieceID, _ := strconv.ParseUint(c.Param("piece_id"), 10, 32)
+
+	err := h.pieceService.DeletePiece(uint(id), uint(pieceID), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ScanPiece godoc
+// @Summary Scan a piece or handling unit
+// @Description Record the pickup or delivery of a piece, or of every piece on a handling unit (Driver only). The cargo's status follows its pieces, including partial delivery.
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param request body models.ScanPieceRequest true "Scan data"
+// @Success 200 {object} models.ScanPieceResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /pieces/scan [post]
+func (h *CargoPieceHandler) ScanPiece(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.ScanPieceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.pieceService.ScanPiece(companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast piece scans and the resulting cargo updates via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_piece_scan",
+		Data: result.Pieces,
+	})
+	for _, cargo := range result.Cargos {
+		h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+			Type: "cargo_updated",
+			Data: cargo,
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetHandlingUnits godoc
+// @Summary Get handling units
+// @Description Get the company's pallets, cages, boxes and containers
+// @Tags handling-units
+// @Produce json
+// @Param status query string false "Filter by status (open, closed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /handling-units [get]
+func (h *CargoPieceHandler) GetHandlingUnits(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.HandlingUnitFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	units, total, err := h.pieceService.GetHandlingUnits(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"handling_units": units,
+		"total":          total,
+		"page":           filter.Page,
+		"limit":          filter.Limit,
+	})
+}
+
+// CreateHandlingUnit godoc
+// @Summary Create a handling unit
+// @Description Open a new pallet, cage, box or container to consolidate pieces on
+// @Tags handling-units
+// @Accept json
+// @Produce json
+// @Param request body models.CreateHandlingUnitRequest true "Handling unit data"
+// @Success 201 {object} models.HandlingUnit
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /handling-units [post]
+func (h *CargoPieceHandler) CreateHandlingUnit(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.CreateHandlingUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.pieceService.CreateHandlingUnit(companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, unit)
+}
+
+// GetHandlingUnit godoc
+// @Summary Get a handling unit
+// @Description Get a handling unit with its pieces
+// @Tags handling-units
+// @Produce json
+// @Param id path int true "Handling unit ID"
+// @Success 200 {object} models.HandlingUnit
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /handling-units/{id} [get]
+func (h *CargoPieceHandler) GetHandlingUnit(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	unit, err := h.pieceService.GetHandlingUnit(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Handling unit not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// AddHandlingUnitPieces godoc
+// @Summary Add pieces to a handling unit
+// @Description Consolidate pieces, possibly of several cargos, onto an open handling unit by barcode
+// @Tags handling-units
+// @Accept json
+// @Produce json
+// @Param id path int true "Handling unit ID"
+// @Param request body models.AddHandlingUnitPiecesRequest true "Piece barcodes"
+// @Success 200 {object} models.HandlingUnit
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /handling-units/{id}/pieces [post]
+func (h *CargoPieceHandler) AddHandlingUnitPieces(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.AddHandlingUnitPiecesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.pieceService.AddToHandlingUnit(uint(id), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// RemoveHandlingUnitPiece godoc
+// @Summary Remove a piece from a handling unit
+// @Description Take a piece off an open handling unit
+// @Tags handling-units
+// @Produce json
+// @Param id path int true "Handling unit ID"
+// @Param piece_id path int true "Piece ID"
+// @Success 200 {object} models.HandlingUnit
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /handling-units/{id}/pieces/{piece_id} [delete]
+func (h *CargoPieceHandler) RemoveHandlingUnitPiece(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	pieceID, _ := strconv.ParseUint(c.Param("piece_id"), 10, 32)
+
+	unit, err := h.pieceService.RemoveFromHandlingUnit(uint(id), uint(pieceID), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// CloseHandlingUnit godoc
+// @Summary Close a handling unit
+// @Description Close a handling unit once it is wrapped; its contents can no longer change
+// @Tags handling-units
+// @Produce json
+// @Param id path int true "Handling unit ID"
+// @Success 200 {object} models.HandlingUnit
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /handling-units/{id}/close [post]
+func (h *CargoPieceHandler) CloseHandlingUnit(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	unit, err := h.pieceService.CloseHandlingUnit(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
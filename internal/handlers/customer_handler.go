@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CustomerHandler struct {
+	customerService *services.CustomerService
+}
+
+func NewCustomerHandler(customerService *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{customerService: customerService}
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Add a customer to the address book, optionally with contacts and saved addresses
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCustomerRequest true "Customer data"
+// @Success 201 {object} models.Customer
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.CreateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := h.customerService.CreateCustomer(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, customer)
+}
+
+// GetCustomers godoc
+// @Summary List customers
+// @Description List the company's customers by name
+// @Tags customers
+// @Produce json
+// @Param search query string false "Search in name, email or phone"
+// @Param is_active query bool false "Filter active/inactive customers"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /customers [get]
+func (h *CustomerHandler) GetCustomers(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.CustomerFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customers, total, err := h.customerService.GetCustomers(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customers": customers,
+		"total":     total,
+		"page":      filter.Page,
+		"limit":     filter.Limit,
+	})
+}
+
+// SearchAddresses godoc
+// @Summary Autocomplete addresses
+// @Description Search the address book by label, address or customer name for address autocomplete
+// @Tags customers
+// @Produce json
+// @Param q query string true "Text typed so far"
+// @Param customer_id query int false "Only addresses of this customer"
+// @Param limit query int false "Maximum suggestions" default(10)
+// @Success 200 {array} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/addresses [get]
+func (h *CustomerHandler) SearchAddresses(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var query models.AddressSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addresses, err := h.customerService.SearchAddresses(companyID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// GetCustomer godoc
+// @Summary Get a customer
+// @Description Get a customer with its contacts and saved addresses
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} models.Customer
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	customer, err := h.customerService.GetCustomer(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Update a customer's details, delivery window or active flag
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param request body models.UpdateCustomerRequest true "Customer data"
+// @Success 200 {object} models.Customer
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := h.customerService.UpdateCustomer(uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Remove a customer from the address book (Admin only). Cargo, visits and invoices keep the details copied from it.
+// @Tags customers
+// @Param id path int true "Customer ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.customerService.DeleteCustomer(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddContact godoc
+// @Summary Add a customer contact
+// @Description Add a contact person to a customer; a primary contact replaces the previous one
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param request body models.CreateCustomerContactRequest true "Contact data"
+// @Success 201 {object} models.CustomerContact
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/contacts [post]
+func (h *CustomerHandler) AddContact(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CreateCustomerContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.customerService.AddContact(uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+// UpdateContact godoc
+// @Summary Update a customer contact
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param contact_id path int true "Contact ID"
+// @Param request body models.UpdateCustomerContactRequest true "Contact data"
+// @Success 200 {object} models.CustomerContact
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/contacts/{contact_id} [put]
+func (h *CustomerHandler) UpdateContact(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	contactID, _ := strconv.ParseUint(c.Param("contact_id"), 10, 32)
+
+	var req models.UpdateCustomerContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.customerService.UpdateContact(uint(contactID), uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// DeleteContact godoc
+// @Summary Delete a customer contact
+// @Tags customers
+// @Param id path int true "Customer ID"
+// @Param contact_id path int true "Contact ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/contacts/{contact_id} [delete]
+func (h *CustomerHandler) DeleteContact(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	contactID, _ := strconv.ParseUint(c.Param("contact_id"), 10, 32)
+
+	err := h.customerService.DeleteContact(uint(contactID), uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddAddress godoc
+// @Summary Save a customer address
+// @Description Save a pickup or delivery address with coordinates, contact, delivery window and instructions. The first address, or one marked is_default, becomes the default.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param request body models.CreateCustomerAddressRequest true "Address data"
+// @Success 201 {object} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/addresses [post]
+func (h *CustomerHandler) AddAddress(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CreateCustomerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.customerService.AddAddress(uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress godoc
+// @Summary Update a saved address
+// @Description Update a saved address. Cargo, visits and stops booked from it keep their copy.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param address_id path int true "Address ID"
+// @Param request body models.UpdateCustomerAddressRequest true "Address data"
+// @Success 200 {object} models.CustomerAddress
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/addresses/{address_id} [put]
+func (h *CustomerHandler) UpdateAddress(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	addressID, _ := strconv.ParseUint(c.Param("address_id"), 10, 32)
+
+	var req models.UpdateCustomerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.customerService.UpdateAddress(uint(addressID), uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress godoc
+// @Summary Delete a saved address
+// @Tags customers
+// @Param id path int true "Customer ID"
+// @Param address_id path int true "Address ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /customers/{id}/addresses/{address_id} [delete]
+func (h *CustomerHandler) DeleteAddress(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	addressID, _ := strconv.ParseUint(c.Param("address_id"), 10, 32)
+
+	err := h.customerService.DeleteAddress(uint(addressID), uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportContentTypes = map[models.ExportFormat]string{
+	models.ExportFormatCSV:    "text/csv; charset=utf-8",
+	models.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	models.ExportFormatNDJSON: "application/x-ndjson",
+}
+
+type ExportHandler struct {
+	exportService *services.ExportService
+	// writeTimeout bounds each write of an export rather than the whole
+	// response, which may take longer than the server's write timeout.
+	writeTimeout time.Duration
+}
+
+func NewExportHandler(exportService *services.ExportService, writeTimeout time.Duration) *ExportHandler {
+	return &ExportHandler{exportService: exportService, writeTimeout: writeTimeout}
+}
+
+// ExportCargo godoc
+// @Summary Export cargo
+// @Description Stream all cargo matching the filters as CSV, XLSX or NDJSON
+// @Tags cargo
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "Export format (csv, xlsx, ndjson)" default(csv)
+// @Param columns query string false "Comma separated columns, e.g. tracking_number,title,weight"
+// @Param status query string false "Filter by status"
+// @Param type query string false "Filter by cargo type"
+// @Param priority query string false "Filter by priority"
+// @Param truck_id query int false "Filter by truck ID"
+// @Param customer_id query int false "Filter by customer ID"
+// @Param assigned query bool false "Filter assigned/unassigned cargo"
+// @Param search query string false "Search in title, tracking number, or description"
+// @Param created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/export [get]
+func (h *ExportHandler) ExportCargo(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.CargoFilter
+	req, ok := h.bindExport(c, &filter, models.Cargo{})
+	if !ok {
+		return
+	}
+
+	h.stream(c, "cargo", req, func(w io.Writer) error {
+		return h.exportService.ExportCargos(w, companyID, filter, req)
+	})
+}
+
+// ExportTrucks godoc
+// @Summary Export trucks
+// @Description Stream all trucks matching the filters as CSV, XLSX or NDJSON
+// @Tags trucks
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "Export format (csv, xlsx, ndjson)" default(csv)
+// @Param columns query string false "Comma separated columns, e.g. license_plate,model,status"
+// @Param status query string false "Filter by status"
+// @Param branch_id query int false "Filter by branch ID"
+// @Param driver_id query int false "Filter by driver ID"
+// @Param model query string false "Filter by model"
+// @Param online query bool false "Only online trucks"
+// @Param approved query bool false "Filter by approval"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /trucks/export [get]
+func (h *ExportHandler) ExportTrucks(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.TruckFilter
+	req, ok := h.bindExport(c, &filter, models.Truck{})
+	if !ok {
+		return
+	}
+
+	h.stream(c, "trucks", req, func(w io.Writer) error {
+		return h.exportService.ExportTrucks(w, companyID, filter, req)
+	})
+}
+
+// ExportRoutes godoc
+// @Summary Export routes
+// @Description Stream all routes matching the filters as CSV, XLSX or NDJSON
+// @Tags routes
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "Export format (csv, xlsx, ndjson)" default(csv)
+// @Param columns query string false "Comma separated columns, e.g. name,status,start_time"
+// @Param status query string false "Filter by status"
+// @Param truck_id query int false "Filter by truck ID"
+// @Param driver_id query int false "Filter by driver ID"
+// @Param branch_id query int false "Filter by branch ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /routes/export [get]
+func (h *ExportHandler) ExportRoutes(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.RouteFilter
+	req, ok := h.bindExport(c, &filter, models.Route{})
+	if !ok {
+		return
+	}
+
+	h.stream(c, "routes", req, func(w io.Writer) error {
+		return h.exportService.ExportRoutes(w, companyID, filter, req)
+	})
+}
+
+// ExportVisits godoc
+// @Summary Export visits
+// @Description Stream all visits matching the filters as CSV, XLSX or NDJSON
+// @Tags visits
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "Export format (csv, xlsx, ndjson)" default(csv)
+// @Param columns query string false "Comma separated columns, e.g. customer_name,address,status"
+// @Param status query string false "Filter by status"
+// @Param truck_id query int false "Filter by truck ID"
+// @Param driver_id query int false "Filter by driver ID"
+// @Param customer_id query int false "Filter by customer ID"
+// @Param search query string false "Search in customer name or address"
+// @Param created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param created_to query string false "Created on or before (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /visits/export [get]
+func (h *ExportHandler) ExportVisits(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.VisitFilter
+	req, ok := h.bindExport(c, &filter, models.Visit{})
+	if !ok {
+		return
+	}
+
+	h.stream(c, "visits", req, func(w io.Writer) error {
+		return h.exportService.ExportVisits(w, companyID, filter, req)
+	})
+}
+
+// ExportInvoices godoc
+// @Summary Export invoices
+// @Description Stream one row per invoice line for the accounting system as CSV, XLSX or NDJSON. Drafts are only included when filtering by status=draft; credit note amounts are negative. (Moderator only)
+// @Tags invoices
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "Export format (csv, xlsx, ndjson)" default(csv)
+// @Param columns query string false "Comma separated columns, e.g. number,customer_name,net_amount"
+// @Param status query string false "Filter by status (draft, issued, paid, void)"
+// @Param type query string false "Filter by type (invoice, credit_note)"
+// @Param customer_name query string false "Filter by customer"
+// @Param issued_from query string false "Issued on or after (YYYY-MM-DD)"
+// @Param issued_to query string false "Issued on or before (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/export [get]
+func (h *ExportHandler) ExportInvoices(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.InvoiceFilter
+	req, ok := h.bindExport(c, &filter, models.InvoiceExportRow{})
+	if !ok {
+		return
+	}
+
+	h.stream(c, "invoices", req, func(w io.Writer) error {
+		return h.exportService.ExportInvoices(w, companyID, filter, req)
+	})
+}
+
+// bindExport binds the list filter and export options and validates the
+// requested columns, writing a 400 response when anything is invalid.
+func (h *ExportHandler) bindExport(c *gin.Context, filter interface{}, model interface{}) (models.ExportRequest, bool) {
+	var req models.ExportRequest
+	if err := c.ShouldBindQuery(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	if err := h.exportService.ValidateColumns(model, req.Columns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func (h *ExportHandler) stream(c *gin.Context, name string, req models.ExportRequest, export func(w io.Writer) error) {
+	fileName := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), req.Format)
+	c.Header("Content-Type", exportContentTypes[req.Format])
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent once rows start flowing, so a failure part way
+	// through can only be logged; the client sees a truncated file.
+	w := &deadlineWriter{w: c.Writer, rc: http.NewResponseController(c.Writer), timeout: h.writeTimeout}
+	if err := export(w); err != nil {
+		log.Printf("Export of %s failed: %v", name, err)
+		c.Abort()
+	}
+}
+
+// deadlineWriter moves the connection's write deadline forward before every
+// write, so a large export runs as long as the client keeps reading and is
+// only cut off once a single write stalls for the timeout.
+type deadlineWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	if d.timeout > 0 {
+		err := d.rc.SetWriteDeadline(time.Now().Add(d.timeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+	}
+	return d.w.Write(p)
+}
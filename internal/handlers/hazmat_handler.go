@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HazmatHandler struct {
+	hazmatService *services.HazmatService
+}
+
+func NewHazmatHandler(hazmatService *services.HazmatService) *HazmatHandler {
+	return &HazmatHandler{hazmatService: hazmatService}
+}
+
+// GetRouteDangerousGoodsManifest godoc
+// @Summary Download a route's dangerous goods manifest
+// @Description Render the dangerous goods manifest of a route as PDF: vehicle placarding, driver certification and every dangerous goods shipment on the route with its UN number, hazard class, packing group, quantity and emergency phone. Drivers only see their own routes.
+// @Tags routes
+// @Produce application/pdf
+// @Param id path int true "Route ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /routes/{id}/dangerous-goods-manifest [get]
+func (h *HazmatHandler) GetRouteDangerousGoodsManifest(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	role := c.MustGet("role").(models.UserRole)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var buf bytes.Buffer
+	err := h.hazmatService.RouteManifest(&buf, uint(id), companyID, role, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", fmt.Sprintf("dangerous-goods-route-%d.pdf", id)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type InvoiceHandler struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService}
+}
+
+// GenerateInvoices godoc
+// @Summary Generate invoices for a period
+// @Description Create one draft invoice per customer and currency for the delivered, priced and not yet invoiced cargo of the period. Delivered cargo without a price is listed in unpriced.
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param request body models.GenerateInvoicesRequest true "Billing period"
+// @Success 201 {object} models.GenerateInvoicesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/generate [post]
+func (h *InvoiceHandler) GenerateInvoices(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.GenerateInvoicesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.invoiceService.GenerateInvoices(companyID, userID, req)
+	if errors.Is(err, repositories.ErrCargoAlreadyInvoiced) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetInvoices godoc
+// @Summary List invoices
+// @Description List the company's invoices and credit notes, newest first
+// @Tags invoices
+// @Produce json
+// @Param status query string false "Status (draft, issued, paid, void)"
+// @Param type query string false "Type (invoice, credit_note)"
+// @Param customer_name query string false "Customer"
+// @Param issued_from query string false "Issued on or after (YYYY-MM-DD)"
+// @Param issued_to query string false "Issued on or before (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /invoices [get]
+func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.InvoiceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.invoiceService.GetInvoices(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices": invoices,
+		"total":    total,
+		"page":     filter.Page,
+		"limit":    filter.Limit,
+	})
+}
+
+// GetInvoice godoc
+// @Summary Get an invoice
+// @Description Get an invoice or credit note with its lines
+// @Tags invoices
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id} [get]
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	invoice, err := h.invoiceService.GetInvoice(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// GetInvoicePDF godoc
+// @Summary Download an invoice
+// @Description Render an invoice or credit note as PDF
+// @Tags invoices
+// @Produce application/pdf
+// @Param id path int true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id}/pdf [get]
+func (h *InvoiceHandler) GetInvoicePDF(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var buf bytes.Buffer
+	err := h.invoiceService.RenderInvoice(&buf, uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", fmt.Sprintf("invoice-%d.pdf", id)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// IssueInvoice godoc
+// @Summary Issue an invoice
+// @Description Assign the next number to a draft invoice or credit note and start its payment terms
+// @Tags invoices
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id}/issue [post]
+func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	invoice, err := h.invoiceService.IssueInvoice(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// MarkInvoicePaid godoc
+// @Summary Mark an invoice paid
+// @Description Record payment of an issued invoice, or the refund of an issued credit note
+// @Tags invoices
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id}/pay [post]
+func (h *InvoiceHandler) MarkInvoicePaid(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	invoice, err := h.invoiceService.MarkInvoicePaid(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// VoidInvoice godoc
+// @Summary Void an invoice
+// @Description Cancel a draft or issued invoice; its cargo can be invoiced again
+// @Tags invoices
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id}/void [post]
+func (h *InvoiceHandler) VoidInvoice(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	invoice, err := h.invoiceService.VoidInvoice(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// CreateCreditNote godoc
+// @Summary Raise a credit note
+// @Description Draft a credit note against an issued or paid invoice for cancelled or damaged shipments. Each line credits one cargo of the invoice, in full unless an amount is given.
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param request body models.CreateCreditNoteRequest true "Credit note"
+// @Success 201 {object} models.Invoice
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/{id}/credit-notes [post]
+func (h *InvoiceHandler) CreateCreditNote(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.invoiceService.CreateCreditNote(uint(id), companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// GetInvoiceSettings godoc
+// @Summary Get invoice settings
+// @Description Get the company's tax rule, payment terms and number sequences (Admin only)
+// @Tags invoices
+// @Produce json
+// @Success 200 {object} models.InvoiceSettings
+// @Security BearerAuth
+// @Router /invoices/settings [get]
+func (h *InvoiceHandler) GetInvoiceSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	settings, err := h.invoiceService.GetSettings(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateInvoiceSettings godoc
+// @Summary Update invoice settings
+// @Description Configure tax name and rate, payment terms and number prefixes for new documents (Admin only)
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateInvoiceSettingsRequest true "Invoice settings"
+// @Success 200 {object} models.InvoiceSettings
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /invoices/settings [put]
+func (h *InvoiceHandler) UpdateInvoiceSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.UpdateInvoiceSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.invoiceService.UpdateSettings(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
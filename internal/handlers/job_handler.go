@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type JobHandler struct {
+	jobService *services.JobService
+}
+
+func NewJobHandler(jobService *services.JobService) *JobHandler {
+	return &JobHandler{jobService: jobService}
+}
+
+// GetJobs godoc
+// @Summary List background jobs
+// @Description List the company's background jobs, newest first. Filter by status=dead for the dead-letter view (Admin only)
+// @Tags jobs
+// @Produce json
+// @Param status query string false "Status (queued, running, succeeded, dead)"
+// @Param type query string false "Job type"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /jobs [get]
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.JobFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs, total, err := h.jobService.GetJobs(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"total": total,
+		"page":  filter.Page,
+		"limit": filter.Limit,
+	})
+}
+
+// GetJob godoc
+// @Summary Get a background job
+// @Description Get a job with its payload, attempts and last error (Admin only)
+// @Tags jobs
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	job, err := h.jobService.GetJob(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob godoc
+// @Summary Retry a dead job
+// @Description Queue a job that ran out of attempts again with a fresh set of attempts (Admin only)
+// @Tags jobs
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 202 {object} models.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /jobs/{id}/retry [post]
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	job, err := h.jobService.RetryJob(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
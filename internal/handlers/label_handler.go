@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/labels"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelHandler(labelService *services.LabelService) *LabelHandler {
+	return &LabelHandler{labelService: labelService}
+}
+
+// GetCargoLabel godoc
+// @Summary Print a cargo label
+// @Description Render the shipping label of a cargo with Code 128 and QR codes of its tracking number, as PDF or ZPL for thermal printers
+// @Tags cargo
+// @Produce application/pdf,application/zpl
+// @Param id path int true "Cargo ID"
+// @Param format query string false "Label format (pdf, zpl)" default(pdf)
+// @Param size query string false "Label size (a6, 4x6)" default(4x6)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/label [get]
+func (h *LabelHandler) GetCargoLabel(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.LabelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	err := h.labelService.CargoLabel(&buf, uint(id), companyID, req)
+	h.send(c, fmt.Sprintf("cargo-%d", id), req, &buf, err, "Cargo not found")
+}
+
+// GetCargoPieceLabels godoc
+// @Summary Print cargo piece labels
+// @Description Render one label per piece of a cargo, each with the piece's own barcode
+// @Tags cargo
+// @Produce application/pdf,application/zpl
+// @Param id path int true "Cargo ID"
+// @Param format query string false "Label format (pdf, zpl)" default(pdf)
+// @Param size query string false "Label size (a6, 4x6)" default(4x6)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pieces/labels [get]
+func (h *LabelHandler) GetCargoPieceLabels(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.LabelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	err := h.labelService.PieceLabels(&buf, uint(id), companyID, req)
+	h.send(c, fmt.Sprintf("cargo-%d-pieces", id), req, &buf, err, "Cargo not found")
+}
+
+// GetRouteLabels godoc
+// @Summary Print labels for a route
+// @Description Render the labels of all cargo on a route in one batch: cargo with a leg on the route and open cargo assigned to its truck. Split cargo gets one label per piece.
+// @Tags routes
+// @Produce application/pdf,application/zpl
+// @Param id path int true "Route ID"
+// @Param format query string false "Label format (pdf, zpl)" default(pdf)
+// @Param size query string false "Label size (a6, 4x6)" default(4x6)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /routes/{id}/labels [get]
+func (h *LabelHandler) GetRouteLabels(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	role := c.MustGet("role").(models.UserRole)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.LabelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	err := h.labelService.RouteLabels(&buf, uint(id), companyID, role, userID, req)
+	h.send(c, fmt.Sprintf("route-%d", id), req, &buf, err, "Route not found")
+}
+
+// send writes the rendered labels inline so browsers open the PDF directly;
+// labels are rendered in memory first so errors still get a JSON response.
+func (h *LabelHandler) send(c *gin.Context, name string, req models.LabelRequest, buf *bytes.Buffer, err error, notFound string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileName := fmt.Sprintf("labels-%s.%s", name, req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", fileName))
+	c.Data(http.StatusOK, labels.ContentType(labels.Format(req.Format)), buf.Bytes())
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LocationRetentionHandler struct {
+	locationRetentionService *services.LocationRetentionService
+}
+
+func NewLocationRetentionHandler(locationRetentionService *services.LocationRetentionService) *LocationRetentionHandler {
+	return &LocationRetentionHandler{locationRetentionService: locationRetentionService}
+}
+
+// GetLocationRetentionPolicy godoc
+// @Summary Get the location retention policy
+// @Description Get how long truck locations are kept raw, how they are downsampled and when they are archived (Admin only)
+// @Tags location-retention
+// @Produce json
+// @Success 200 {object} models.LocationRetentionPolicy
+// @Security BearerAuth
+// @Router /location-retention/policy [get]
+func (h *LocationRetentionHandler) GetLocationRetentionPolicy(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	policy, err := h.locationRetentionService.GetPolicy(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateLocationRetentionPolicy godoc
+// @Summary Update the location retention policy
+// @Description Set the days locations are kept raw, the downsampling mode (minute, trip, none) and the days after which they are archived, 0 to never archive (Admin only)
+// @Tags location-retention
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateLocationRetentionPolicyRequest true "Retention policy"
+// @Success 200 {object} models.LocationRetentionPolicy
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /location-retention/policy [put]
+func (h *LocationRetentionHandler) UpdateLocationRetentionPolicy(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.UpdateLocationRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.locationRetentionService.UpdatePolicy(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetLocationArchives godoc
+// @Summary List location archives
+// @Description List the archived days of truck locations, newest first (Admin only)
+// @Tags location-retention
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /location-retention/archives [get]
+func (h *LocationRetentionHandler) GetLocationArchives(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.LocationArchiveFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	archives, total, err := h.locationRetentionService.GetArchives(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"archives": archives,
+		"total":    total,
+		"page":     filter.Page,
+		"limit":    filter.Limit,
+	})
+}
+
+// DownloadLocationArchive godoc
+// @Summary Download a location archive
+// @Description Download a day of truck locations as gzip-compressed NDJSON (Admin only)
+// @Tags location-retention
+// @Produce octet-stream
+// @Param id path int true "Archive ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /location-retention/archives/{id}/download [get]
+func (h *LocationRetentionHandler) DownloadLocationArchive(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	archive, r, err := h.locationRetentionService.OpenArchive(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location archive not found"})
+		return
+	}
+	defer r.Close()
+
+	fileName := fmt.Sprintf("locations-%s.%s", archive.Day.Format("2006-01-02"), archive.Format)
+	c.DataFromReader(http.StatusOK, archive.SizeBytes, "application/gzip", r, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
+	})
+}
+
+// GetLocationStorage godoc
+// @Summary Get location storage per tenant
+// @Description Get the truck locations every company keeps in the database and in archives, largest first (Platform operators only)
+// @Tags location-retention
+// @Produce json
+// @Success 200 {array} models.LocationStorage
+// @Security BearerAuth
+// @Router /location-retention/storage [get]
+func (h *LocationRetentionHandler) GetLocationStorage(c *gin.Context) {
+	storage, err := h.locationRetentionService.GetStorage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, storage)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetNotificationSettings godoc
+// @Summary Get notification settings
+// @Description Get which consignee notification channels are enabled and the default locale (Admin only)
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} models.NotificationSettings
+// @Security BearerAuth
+// @Router /notifications/settings [get]
+func (h *NotificationHandler) GetNotificationSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	settings, err := h.notificationService.GetSettings(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateNotificationSettings godoc
+// @Summary Update notification settings
+// @Description Enable or disable email and SMS notifications and set the default locale (Admin only)
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateNotificationSettingsRequest true "Notification settings"
+// @Success 200 {object} models.NotificationSettings
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /notifications/settings [put]
+func (h *NotificationHandler) UpdateNotificationSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetNotificationTemplates godoc
+// @Summary List notification templates
+// @Description List the company's notification templates; triggers without one use the built-in English wording (Admin only)
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} models.NotificationTemplate
+// @Security BearerAuth
+// @Router /notifications/templates [get]
+func (h *NotificationHandler) GetNotificationTemplates(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	templates, err := h.notificationService.GetTemplates(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// SaveNotificationTemplate godoc
+// @Summary Save a notification template
+// @Description Create or replace the template of a trigger, channel and locale. Subject and body are Go templates over tracking number, carrier, recipient, addresses and ETA (Admin only)
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body models.SaveNotificationTemplateRequest true "Template"
+// @Success 200 {object} models.NotificationTemplate
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /notifications/templates [put]
+func (h *NotificationHandler) SaveNotificationTemplate(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.SaveNotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.notificationService.SaveTemplate(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteNotificationTemplate godoc
+// @Summary Delete a notification template
+// @Description Remove a template; its trigger falls back to another locale or the built-in wording (Admin only)
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /notifications/templates/{id} [delete]
+func (h *NotificationHandler) DeleteNotificationTemplate(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.notificationService.DeleteTemplate(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification template deleted successfully"})
+}
+
+// GetNotificationOptOuts godoc
+// @Summary List notification opt-outs
+// @Description List the email addresses and phone numbers that receive no notifications
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} models.NotificationOptOut
+// @Security BearerAuth
+// @Router /notifications/opt-outs [get]
+func (h *NotificationHandler) GetNotificationOptOuts(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	optOuts, err := h.notificationService.GetOptOuts(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, optOuts)
+}
+
+// CreateNotificationOptOut godoc
+// @Summary Opt a recipient out
+// @Description Stop all notifications of a channel to an email address or phone number
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body models.CreateNotificationOptOutRequest true "Recipient"
+// @Success 201 {object} models.NotificationOptOut
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /notifications/opt-outs [post]
+func (h *NotificationHandler) CreateNotificationOptOut(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.CreateNotificationOptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	optOut, err := h.notificationService.CreateOptOut(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, optOut)
+}
+
+// DeleteNotificationOptOut godoc
+// @Summary Remove an opt-out
+// @Description Resume notifications to a recipient who had opted out
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Opt-out ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /notifications/opt-outs/{id} [delete]
+func (h *NotificationHandler) DeleteNotificationOptOut(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.notificationService.DeleteOptOut(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification opt-out not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification opt-out removed successfully"})
+}
+
+// GetNotificationLog godoc
+// @Summary Get the notification delivery log
+// @Description List the notifications sent, failed or suppressed by an opt-out, newest first
+// @Tags notifications
+// @Produce json
+// @Param cargo_id query int false "Cargo ID"
+// @Param channel query string false "Channel (email, sms)"
+// @Param status query string false "Status (sent, failed, opted_out)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /notifications/log [get]
+func (h *NotificationHandler) GetNotificationLog(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.NotificationLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logs, total, err := h.notificationService.GetLogs(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": logs,
+		"total":         total,
+		"page":          filter.Page,
+		"limit":         filter.Limit,
+	})
+}
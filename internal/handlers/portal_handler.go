@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PortalHandler struct {
+	portalService *services.PortalService
+}
+
+func NewPortalHandler(portalService *services.PortalService) *PortalHandler {
+	return &PortalHandler{portalService: portalService}
+}
+
+// QuoteBooking godoc
+// @Summary Quote a booking
+// @Description Price a prospective shipment for the signed-in customer before booking it
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCargoRequest true "Prospective shipment"
+// @Success 200 {object} models.FreightQuote
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/quote [post]
+func (h *PortalHandler) QuoteBooking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+
+	var req models.CreateCargoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.portalService.Quote(companyID, customerID, req)
+	if errors.Is(err, services.ErrNoFreightRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// CreateBooking godoc
+// @Summary Book a shipment
+// @Description Book a cargo for the signed-in customer. The booking is priced from the rate table and stays pending until a moderator approves it.
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCargoRequest true "Shipment"
+// @Success 201 {object} models.PortalShipment
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings [post]
+func (h *PortalHandler) CreateBooking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.CreateCargoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shipment, err := h.portalService.CreateBooking(companyID, customerID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// GetShipments godoc
+// @Summary List my shipments
+// @Description List the signed-in customer's shipments, newest first
+// @Tags portal
+// @Produce json
+// @Param status query string false "Filter by status"
+// @Param search query string false "Search in title, tracking number and description"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /portal/bookings [get]
+func (h *PortalHandler) GetShipments(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+
+	var filter models.PortalShipmentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shipments, total, err := h.portalService.GetShipments(companyID, customerID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shipments": shipments,
+		"total":     total,
+		"page":      filter.Page,
+		"limit":     filter.Limit,
+	})
+}
+
+// GetShipment godoc
+// @Summary Get one of my shipments
+// @Tags portal
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.PortalShipment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id} [get]
+func (h *PortalHandler) GetShipment(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	shipment, err := h.portalService.GetShipment(uint(id), companyID, customerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// GetShipmentTracking godoc
+// @Summary Track one of my shipments
+// @Description Get the progress, recent events, journey and truck position of one of the signed-in customer's shipments
+// @Tags portal
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.PortalTracking
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id}/tracking [get]
+func (h *PortalHandler) GetShipmentTracking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	tracking, err := h.portalService.GetTracking(uint(id), companyID, customerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// CancelBooking godoc
+// @Summary Cancel a booking
+// @Description Cancel one of the signed-in customer's shipments. Only shipments that have not been picked up can be cancelled.
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param request body models.CancelBookingRequest false "Cancellation reason"
+// @Success 200 {object} models.PortalShipment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id}/cancel [post]
+func (h *PortalHandler) CancelBooking(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CancelBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	shipment, err := h.portalService.CancelBooking(uint(id), companyID, customerID, userID, req.Reason)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// GetShipmentPOD godoc
+// @Summary Get the proof of delivery of my shipment
+// @Tags portal
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.ProofOfDelivery
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id}/pod [get]
+func (h *PortalHandler) GetShipmentPOD(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	pod, err := h.portalService.GetProofOfDelivery(uint(id), companyID, customerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proof of delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pod)
+}
+
+// GetShipmentPODSignature godoc
+// @Summary Download the POD signature of my shipment
+// @Tags portal
+// @Produce image/png,image/jpeg,image/webp
+// @Param id path int true "Cargo ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id}/pod/signature [get]
+func (h *PortalHandler) GetShipmentPODSignature(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	r, contentType, err := h.portalService.OpenSignature(uint(id), companyID, customerID)
+	serveBlob(c, r, contentType, err)
+}
+
+// GetShipmentPODPhoto godoc
+// @Summary Download a POD photo of my shipment
+// @Tags portal
+// @Produce image/png,image/jpeg,image/webp
+// @Param id path int true "Cargo ID"
+// @Param photo_id path int true "Photo ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/bookings/{id}/pod/photos/{photo_id} [get]
+func (h *PortalHandler) GetShipmentPODPhoto(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	photoID, _ := strconv.ParseUint(c.Param("photo_id"), 10, 32)
+
+	r, contentType, err := h.portalService.OpenPhoto(uint(id), companyID, customerID, uint(photoID))
+	serveBlob(c, r, contentType, err)
+}
+
+// GetInvoices godoc
+// @Summary List my invoices
+// @Description List the signed-in customer's issued invoices and credit notes, newest first
+// @Tags portal
+// @Produce json
+// @Param status query string false "Status (issued, paid, void)"
+// @Param type query string false "Type (invoice, credit_note)"
+// @Param issued_from query string false "Issued on or after (YYYY-MM-DD)"
+// @Param issued_to query string false "Issued on or before (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /portal/invoices [get]
+func (h *PortalHandler) GetInvoices(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+
+	var filter models.InvoiceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invoices, total, err := h.portalService.GetInvoices(companyID, customerID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices": invoices,
+		"total":    total,
+		"page":     filter.Page,
+		"limit":    filter.Limit,
+	})
+}
+
+// GetInvoicePDF godoc
+// @Summary Download one of my invoices
+// @Tags portal
+// @Produce application/pdf
+// @Param id path int true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /portal/invoices/{id}/pdf [get]
+func (h *PortalHandler) GetInvoicePDF(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var buf bytes.Buffer
+	err := h.portalService.RenderInvoice(&buf, uint(id), companyID, customerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", fmt.Sprintf("invoice-%d.pdf", id)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// GetAddresses godoc
+// @Summary List my saved addresses
+// @Description List the signed-in customer's saved addresses to book from
+// @Tags portal
+// @Produce json
+// @Success 200 {array} models.CustomerAddress
+// @Security BearerAuth
+// @Router /portal/addresses [get]
+func (h *PortalHandler) GetAddresses(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	customerID := c.MustGet("customer_id").(uint)
+
+	addresses, err := h.portalService.GetAddresses(companyID, customerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
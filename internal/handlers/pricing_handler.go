@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	pricingService *services.PricingService
+}
+
+func NewPricingHandler(pricingService *services.PricingService) *PricingHandler {
+	return &PricingHandler{pricingService: pricingService}
+}
+
+// QuoteFreight godoc
+// @Summary Quote a shipment
+// @Description Price a prospective cargo with the company's rate table before it is booked: linehaul from the best matching rate, surcharges, minimum charge and fuel surcharge
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCargoRequest true "Prospective cargo"
+// @Success 200 {object} models.FreightQuote
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Security BearerAuth
+// @Router /pricing/quote [post]
+func (h *PricingHandler) QuoteFreight(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.CreateCargoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.pricingService.Quote(companyID, req)
+	if errors.Is(err, services.ErrNoFreightRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// GetPricingSettings godoc
+// @Summary Get pricing settings
+// @Description Get the company's currency, surcharges, fuel surcharge and minimum charge (Admin only)
+// @Tags pricing
+// @Produce json
+// @Success 200 {object} models.PricingSettings
+// @Security BearerAuth
+// @Router /pricing/settings [get]
+func (h *PricingHandler) GetPricingSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	settings, err := h.pricingService.GetSettings(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdatePricingSettings godoc
+// @Summary Update pricing settings
+// @Description Configure currency, volumetric factor, surcharges, fuel surcharge and minimum charge (Admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param request body models.UpdatePricingSettingsRequest true "Pricing settings"
+// @Success 200 {object} models.PricingSettings
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /pricing/settings [put]
+func (h *PricingHandler) UpdatePricingSettings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.UpdatePricingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.pricingService.UpdateSettings(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetFreightRates godoc
+// @Summary List freight rates
+// @Description Get the company's rate table (Moderator only)
+// @Tags pricing
+// @Produce json
+// @Success 200 {array} models.FreightRate
+// @Security BearerAuth
+// @Router /pricing/rates [get]
+func (h *PricingHandler) GetFreightRates(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	rates, err := h.pricingService.GetRates(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// CreateFreightRate godoc
+// @Summary Create a freight rate
+// @Description Add a rate for a lane, distance band and weight break (Admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param request body models.CreateFreightRateRequest true "Rate"
+// @Success 201 {object} models.FreightRate
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /pricing/rates [post]
+func (h *PricingHandler) CreateFreightRate(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.CreateFreightRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.pricingService.CreateRate(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// UpdateFreightRate godoc
+// @Summary Update a freight rate
+// @Description Change or deactivate a rate; cargo already booked keeps its price (Admin only)
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param id path int true "Rate ID"
+// @Param request body models.UpdateFreightRateRequest true "Rate changes"
+// @Success 200 {object} models.FreightRate
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /pricing/rates/{id} [put]
+func (h *PricingHandler) UpdateFreightRate(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.UpdateFreightRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.pricingService.UpdateRate(uint(id), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteFreightRate godoc
+// @Summary Delete a freight rate
+// @Description Remove a rate from the rate table (Admin only)
+// @Tags pricing
+// @Produce json
+// @Param id path int true "Rate ID"
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /pricing/rates/{id} [delete]
+func (h *PricingHandler) DeleteFreightRate(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.pricingService.DeleteRate(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Freight rate deleted successfully"})
+}
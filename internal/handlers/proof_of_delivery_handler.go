@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+	"truck-management/internal/storage"
+	"truck-management/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProofOfDeliveryHandler struct {
+	podService *services.ProofOfDeliveryService
+	wsHub      *websocket.Hub
+}
+
+func NewProofOfDeliveryHandler(podService *services.ProofOfDeliveryService, wsHub *websocket.Hub) *ProofOfDeliveryHandler {
+	return &ProofOfDeliveryHandler{
+		podService: podService,
+		wsHub:      wsHub,
+	}
+}
+
+// CaptureProofOfDelivery godoc
+// @Summary Capture proof of delivery
+// @Description Upload the recipient's signature, delivery photos and recipient name, and mark the cargo delivered (Driver only)
+// @Tags cargo
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param recipient_name formData string true "Recipient name"
+// @Param latitude formData number true "Delivery latitude"
+// @Param longitude formData number true "Delivery longitude"
+// @Param captured_at formData string false "Capture time (RFC3339), defaults to now"
+// @Param location formData string false "Delivery location description"
+// @Param notes formData string false "Delivery notes"
+// @Param signature formData file true "Signature image"
+// @Param photos formData file true "Delivery photos (repeatable)"
+// @Success 201 {object} models.ProofOfDelivery
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pod [post]
+func (h *ProofOfDeliveryHandler) CaptureProofOfDelivery(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CaptureProofOfDeliveryRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signature, _ := c.FormFile("signature")
+	pod, err := h.podService.CaptureProofOfDelivery(uint(id), companyID, userID, req, signature, form.File["photos"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast delivery via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_delivered",
+		Data: pod,
+	})
+
+	c.JSON(http.StatusCreated, pod)
+}
+
+// GetProofOfDelivery godoc
+// @Summary Get proof of delivery
+// @Description Get the proof of delivery captured for a cargo
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.ProofOfDelivery
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pod [get]
+func (h *ProofOfDeliveryHandler) GetProofOfDelivery(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	pod, err := h.podService.GetProofOfDelivery(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proof of delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pod)
+}
+
+// GetProofOfDeliverySignature godoc
+// @Summary Download POD signature
+// @Description Download the signature image of a proof of delivery
+// @Tags cargo
+// @Produce image/png,image/jpeg,image/webp
+// @Param id path int true "Cargo ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pod/signature [get]
+func (h *ProofOfDeliveryHandler) GetProofOfDeliverySignature(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	r, contentType, err := h.podService.OpenSignature(uint(id), companyID)
+	serveBlob(c, r, contentType, err)
+}
+
+// GetProofOfDeliveryPhoto godoc
+// @Summary Download POD photo
+// @Description Download one of the photos of a proof of delivery
+// @Tags cargo
+// @Produce image/png,image/jpeg,image/webp
+// @Param id path int true "Cargo ID"
+// @Param photo_id path int true "Photo ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pod/photos/{photo_id} [get]
+func (h *ProofOfDeliveryHandler) GetProofOfDeliveryPhoto(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	photoID, _ := strconv.ParseUint(c.Param("photo_id"), 10, 32)
+
+	r, contentType, err := h.podService.OpenPhoto(uint(id), companyID, uint(photoID))
+	serveBlob(c, r, contentType, err)
+}
+
+// GetProofOfDeliveryTemperatureLog godoc
+// @Summary Download POD temperature log
+// @Description Download the temperature log report stored with the proof of delivery of a cold-chain cargo
+// @Tags cargo
+// @Produce application/pdf
+// @Param id path int true "Cargo ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/pod/temperature-log [get]
+func (h *ProofOfDeliveryHandler) GetProofOfDeliveryTemperatureLog(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	r, contentType, err := h.podService.OpenTemperatureLog(uint(id), companyID)
+	serveBlob(c, r, contentType, err)
+}
+
+func serveBlob(c *gin.Context, r io.ReadCloser, contentType string, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, -1, contentType, r, nil)
+}
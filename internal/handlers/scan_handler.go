@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+	"truck-management/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScanHandler struct {
+	scanService *services.ScanService
+	wsHub       *websocket.Hub
+}
+
+func NewScanHandler(scanService *services.ScanService, wsHub *websocket.Hub) *ScanHandler {
+	return &ScanHandler{
+		scanService: scanService,
+		wsHub:       wsHub,
+	}
+}
+
+// ScanCargo godoc
+// @Summary Scan a cargo barcode
+// @Description Scan a tracking number, piece barcode or handling unit barcode at the driver's position (Driver only). The cargo must be on the driver's truck; the next transition (pickup, handover, arrival or delivery) is applied and mismatches are rejected with the reason.
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param request body models.ScanRequest true "Scanned code and position"
+// @Success 200 {object} models.ScanResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/scan [post]
+func (h *ScanHandler) ScanCargo(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.scanService.Scan(companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast the scan and the resulting cargo updates via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_scan",
+		Data: gin.H{
+			"action": result.Action,
+			"code":   req.Code,
+		},
+	})
+	for _, cargo := range result.Cargos {
+		h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+			Type: "cargo_updated",
+			Data: cargo,
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
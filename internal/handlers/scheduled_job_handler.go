@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScheduledJobHandler struct {
+	scheduledJobService *services.ScheduledJobService
+}
+
+func NewScheduledJobHandler(scheduledJobService *services.ScheduledJobService) *ScheduledJobHandler {
+	return &ScheduledJobHandler{scheduledJobService: scheduledJobService}
+}
+
+// GetScheduledJobs godoc
+// @Summary List scheduled jobs
+// @Description List the recurring system jobs with their schedule, next run and last outcome (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Success 200 {array} models.ScheduledJob
+// @Security BearerAuth
+// @Router /scheduled-jobs [get]
+func (h *ScheduledJobHandler) GetScheduledJobs(c *gin.Context) {
+	jobs, err := h.scheduledJobService.GetJobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetScheduledJob godoc
+// @Summary Get a scheduled job
+// @Description Get a recurring system job by name (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} models.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /scheduled-jobs/{name} [get]
+func (h *ScheduledJobHandler) GetScheduledJob(c *gin.Context) {
+	job, err := h.scheduledJobService.GetJob(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetScheduledJobRuns godoc
+// @Summary Get the history of a scheduled job
+// @Description List the runs of a job with their trigger, outcome, duration and error, newest first (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Param status query string false "Status (running, succeeded, failed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /scheduled-jobs/{name}/runs [get]
+func (h *ScheduledJobHandler) GetScheduledJobRuns(c *gin.Context) {
+	var filter models.ScheduledJobRunFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs, total, err := h.scheduledJobService.GetRuns(c.Param("name"), filter)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"total": total,
+		"page":  filter.Page,
+		"limit": filter.Limit,
+	})
+}
+
+// TriggerScheduledJob godoc
+// @Summary Run a scheduled job now
+// @Description Ask the scheduler to run the job at its next check, within seconds, even if it is paused (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} models.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /scheduled-jobs/{name}/trigger [post]
+func (h *ScheduledJobHandler) TriggerScheduledJob(c *gin.Context) {
+	job, err := h.scheduledJobService.TriggerJob(c.Param("name"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// PauseScheduledJob godoc
+// @Summary Pause a scheduled job
+// @Description Stop running the job on its schedule until it is resumed (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} models.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /scheduled-jobs/{name}/pause [post]
+func (h *ScheduledJobHandler) PauseScheduledJob(c *gin.Context) {
+	job, err := h.scheduledJobService.PauseJob(c.Param("name"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeScheduledJob godoc
+// @Summary Resume a scheduled job
+// @Description Run a paused job on its schedule again, from its next scheduled time (Platform operators only)
+// @Tags scheduled-jobs
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} models.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /scheduled-jobs/{name}/resume [post]
+func (h *ScheduledJobHandler) ResumeScheduledJob(c *gin.Context) {
+	job, err := h.scheduledJobService.ResumeJob(c.Param("name"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+	"truck-management/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShipmentLegHandler struct {
+	legService *services.ShipmentLegService
+	wsHub      *websocket.Hub
+}
+
+func NewShipmentLegHandler(legService *services.ShipmentLegService, wsHub *websocket.Hub) *ShipmentLegHandler {
+	return &ShipmentLegHandler{
+		legService: legService,
+		wsHub:      wsHub,
+	}
+}
+
+// GetShipmentLegs godoc
+// @Summary Get shipment legs
+// @Description Get the legs of a multi-leg cargo shipment in journey order
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {array} models.ShipmentLeg
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs [get]
+func (h *ShipmentLegHandler) GetShipmentLegs(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	legs, err := h.legService.GetLegs(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, legs)
+}
+
+// CreateShipmentLeg godoc
+// @Summary Add a shipment leg
+// @Description Append a leg to the cargo's journey, e.g. depot to hub. Addresses default to the branches' addresses.
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param request body models.CreateShipmentLegRequest true "Leg data"
+// @Success 201 {object} models.ShipmentLeg
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs [post]
+func (h *ShipmentLegHandler) CreateShipmentLeg(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.CreateShipmentLegRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leg, err := h.legService.CreateLeg(uint(id), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, leg)
+}
+
+// UpdateShipmentLeg godoc
+// @Summary Update a shipment leg
+// @Description Change the truck, route, endpoints or schedule of a pending leg
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param leg_id path int true "Leg ID"
+// @Param request body models.UpdateShipmentLegRequest true "Leg data"
+// @Success 200 {object} models.ShipmentLeg
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs/{leg_id} [put]
+func (h *ShipmentLegHandler) UpdateShipmentLeg(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	legID, _ := strconv.ParseUint(c.Param("leg_id"), 10, 32)
+
+	var req models.UpdateShipmentLegRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	leg, err := h.legService.UpdateLeg(uint(id), uint(legID), companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leg)
+}
+
+// DeleteShipmentLeg godoc
+// @Summary Remove a shipment leg
+// @Description Remove a pending leg from the cargo's journey
+// @Tags cargo
+// @Param id path int true "Cargo ID"
+// @Param leg_id path int true "Leg ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs/{leg_id} [delete]
+func (h *ShipmentLegHandler) DeleteShipmentLeg(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	legID, _ := strconv.ParseUint(c.Param("leg_id"), 10, 32)
+
+	err := h.legService.DeleteLeg(uint(id), uint(legID), companyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DepartShipmentLeg godoc
+// @Summary Depart on a shipment leg
+// @Description Start a leg once the previous leg is complete (Driver only)
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param leg_id path int true "Leg ID"
+// @Param request body models.ShipmentLegLocationRequest false "Departure location"
+// @Success 200 {object} models.ShipmentLeg
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs/{leg_id}/depart [post]
+func (h *ShipmentLegHandler) DepartShipmentLeg(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	legID, _ := strconv.ParseUint(c.Param("leg_id"), 10, 32)
+
+	var req models.ShipmentLegLocationRequest
+	c.ShouldBindJSON(&req)
+
+	leg, err := h.legService.DepartLeg(uint(id), uint(legID), companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast leg update via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "shipment_leg_update",
+		Data: leg,
+	})
+
+	c.JSON(http.StatusOK, leg)
+}
+
+// ArriveShipmentLeg godoc
+// @Summary Arrive at the end of a shipment leg
+// @Description Complete an intermediate leg at its destination, e.g. a hub (Driver only). The final leg is completed by the delivery.
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param leg_id path int true "Leg ID"
+// @Param request body models.ShipmentLegLocationRequest false "Arrival location"
+// @Success 200 {object} models.ShipmentLeg
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs/{leg_id}/arrive [post]
+func (h *ShipmentLegHandler) ArriveShipmentLeg(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	legID, _ := strconv.ParseUint(c.Param("leg_id"), 10, 32)
+
+	var req models.ShipmentLegLocationRequest
+	c.ShouldBindJSON(&req)
+
+	leg, err := h.legService.ArriveLeg(uint(id), uint(legID), companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast leg update via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "shipment_leg_update",
+		Data: leg,
+	})
+
+	c.JSON(http.StatusOK, leg)
+}
+
+// CreateHandover godoc
+// @Summary Take custody of a cargo
+// @Description Record the driver of a leg taking over the cargo from the previous leg's driver (Driver only)
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Param leg_id path int true "Leg ID being taken over"
+// @Param request body models.CreateHandoverRequest false "Handover details"
+// @Success 201 {object} models.CargoHandover
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/legs/{leg_id}/handover [post]
+func (h *ShipmentLegHandler) CreateHandover(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	legID, _ := strconv.ParseUint(c.Param("leg_id"), 10, 32)
+
+	var req models.CreateHandoverRequest
+	c.ShouldBindJSON(&req)
+
+	handover, err := h.legService.Handover(uint(id), uint(legID), companyID, userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast handover via WebSocket
+	h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+		Type: "cargo_handover",
+		Data: handover,
+	})
+
+	c.JSON(http.StatusCreated, handover)
+}
+
+// GetHandovers godoc
+// @Summary Get custody handovers
+// @Description Get the chain of custody of a multi-leg cargo
+// @Tags cargo
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {array} models.CargoHandover
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/handovers [get]
+func (h *ShipmentLegHandler) GetHandovers(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	handovers, err := h.legService.GetHandovers(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, handovers)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+	"truck-management/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TemperatureHandler struct {
+	temperatureService *services.TemperatureService
+	wsHub              *websocket.Hub
+}
+
+func NewTemperatureHandler(temperatureService *services.TemperatureService, wsHub *websocket.Hub) *TemperatureHandler {
+	return &TemperatureHandler{
+		temperatureService: temperatureService,
+		wsHub:              wsHub,
+	}
+}
+
+// RecordTemperatureReadings godoc
+// @Summary Record reefer temperature readings
+// @Description Record a batch of temperature readings from a truck's reefer unit (truck_id) or from a sensor travelling with one cargo (cargo_id). Readings are checked against the range of the picked-up cargo they apply to; excursions longer than the cargo's threshold are returned and broadcast as alerts. Drivers may only report for their own truck.
+// @Tags temperature
+// @Accept json
+// @Produce json
+// @Param request body models.TemperatureTelemetryRequest true "Readings"
+// @Success 201 {object} models.TemperatureTelemetryResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /telemetry/temperature [post]
+func (h *TemperatureHandler) RecordTemperatureReadings(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	role := c.MustGet("role").(models.UserRole)
+
+	var req models.TemperatureTelemetryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.temperatureService.RecordReadings(companyID, userID, role, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Broadcast excursion alerts via WebSocket
+	for _, alert := range result.Alerts {
+		h.wsHub.BroadcastToCompany(companyID, websocket.Message{
+			Type: "temperature_alert",
+			Data: alert,
+		})
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetCargoTemperature godoc
+// @Summary Get cargo temperature history
+// @Description Get the temperature range of a cargo, the readings that applied to it while it was carried and its excursions
+// @Tags temperature
+// @Produce json
+// @Param id path int true "Cargo ID"
+// @Success 200 {object} models.CargoTemperatureHistory
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/temperature [get]
+func (h *TemperatureHandler) GetCargoTemperature(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	history, err := h.temperatureService.GetCargoTemperature(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetCargoTemperatureLog godoc
+// @Summary Download cargo temperature log
+// @Description Render the temperature log report of a cold-chain cargo as PDF: summary, chart against the required range, excursions and every reading
+// @Tags temperature
+// @Produce application/pdf
+// @Param id path int true "Cargo ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/{id}/temperature/log [get]
+func (h *TemperatureHandler) GetCargoTemperatureLog(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var buf bytes.Buffer
+	err := h.temperatureService.RenderLog(&buf, uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cargo not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", fmt.Sprintf("temperature-log-cargo-%d.pdf", id)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// GetTemperatureExcursions godoc
+// @Summary List temperature excursions
+// @Description List the company's temperature excursions, newest first (Moderator only)
+// @Tags temperature
+// @Produce json
+// @Param status query string false "Status (open, closed)"
+// @Param cargo_id query int false "Cargo ID"
+// @Param truck_id query int false "Truck ID"
+// @Param alerted query bool false "Only alerted (true) or not yet alerted (false) excursions"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /temperature/excursions [get]
+func (h *TemperatureHandler) GetTemperatureExcursions(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.TemperatureExcursionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	excursions, total, err := h.temperatureService.GetExcursions(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"excursions": excursions,
+		"total":      total,
+		"page":       filter.Page,
+		"limit":      filter.Limit,
+	})
+}
+
+// AcknowledgeTemperatureExcursion godoc
+// @Summary Acknowledge a temperature excursion
+// @Description Record that the excursion alert has been seen (Moderator only)
+// @Tags temperature
+// @Produce json
+// @Param id path int true "Excursion ID"
+// @Success 200 {object} models.TemperatureExcursion
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /temperature/excursions/{id}/acknowledge [post]
+func (h *TemperatureHandler) AcknowledgeTemperatureExcursion(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	userID := c.MustGet("user_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	excursion, err := h.temperatureService.AcknowledgeExcursion(uint(id), companyID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, excursion)
+}
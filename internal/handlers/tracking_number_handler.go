@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrackingNumberHandler struct {
+	trackingNumberService *services.TrackingNumberService
+}
+
+func NewTrackingNumberHandler(trackingNumberService *services.TrackingNumberService) *TrackingNumberHandler {
+	return &TrackingNumberHandler{trackingNumberService: trackingNumberService}
+}
+
+// GetTrackingNumberFormat godoc
+// @Summary Get tracking number format
+// @Description Get the company's tracking number format (Admin only)
+// @Tags cargo
+// @Produce json
+// @Success 200 {object} models.TrackingNumberFormat
+// @Security BearerAuth
+// @Router /cargo/tracking-format [get]
+func (h *TrackingNumberHandler) GetTrackingNumberFormat(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	format, err := h.trackingNumberService.GetFormat(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, format)
+}
+
+// UpdateTrackingNumberFormat godoc
+// @Summary Update tracking number format
+// @Description Configure prefix, date component, sequence length and check digit for new tracking numbers (Admin only)
+// @Tags cargo
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateTrackingNumberFormatRequest true "Format settings"
+// @Success 200 {object} models.TrackingNumberFormat
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /cargo/tracking-format [put]
+func (h *TrackingNumberHandler) UpdateTrackingNumberFormat(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.UpdateTrackingNumberFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, err := h.trackingNumberService.UpdateFormat(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, format)
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"truck-management/internal/models"
+	"truck-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// GetWebhookEventTypes godoc
+// @Summary List webhook event types
+// @Description List the event types webhook endpoints can subscribe to (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} string
+// @Security BearerAuth
+// @Router /webhooks/event-types [get]
+func (h *WebhookHandler) GetWebhookEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, models.WebhookEventTypes)
+}
+
+// CreateWebhookEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Subscribe a URL to event types. The response carries the signing secret, which is not shown again (Admin only)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWebhookEndpointRequest true "Endpoint"
+// @Success 201 {object} models.WebhookEndpoint
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/endpoints [post]
+func (h *WebhookHandler) CreateWebhookEndpoint(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var req models.CreateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(companyID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// GetWebhookEndpoints godoc
+// @Summary List webhook endpoints
+// @Description List the company's webhook endpoints (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookEndpoint
+// @Security BearerAuth
+// @Router /webhooks/endpoints [get]
+func (h *WebhookHandler) GetWebhookEndpoints(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	endpoints, err := h.webhookService.GetEndpoints(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// GetWebhookEndpoint godoc
+// @Summary Get a webhook endpoint
+// @Description Get a webhook endpoint by ID (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Endpoint ID"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/endpoints/{id} [get]
+func (h *WebhookHandler) GetWebhookEndpoint(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	endpoint, err := h.webhookService.GetEndpoint(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// UpdateWebhookEndpoint godoc
+// @Summary Update a webhook endpoint
+// @Description Change the URL, description or subscriptions of an endpoint, or disable it (Admin only)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Endpoint ID"
+// @Param request body models.UpdateWebhookEndpointRequest true "Endpoint changes"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/endpoints/{id} [put]
+func (h *WebhookHandler) UpdateWebhookEndpoint(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	var req models.UpdateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(uint(id), companyID, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Description Remove an endpoint and its delivery log (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Endpoint ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/endpoints/{id} [delete]
+func (h *WebhookHandler) DeleteWebhookEndpoint(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.webhookService.DeleteEndpoint(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// RotateWebhookSecret godoc
+// @Summary Rotate a webhook signing secret
+// @Description Replace the endpoint's signing secret; the response carries the new secret, which is not shown again (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Endpoint ID"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/endpoints/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	endpoint, err := h.webhookService.RotateSecret(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get the webhook delivery log
+// @Description List deliveries with their attempts and last response, newest first (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param endpoint_id query int false "Endpoint ID"
+// @Param status query string false "Status (pending, succeeded, failed)"
+// @Param event_type query string false "Event type"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /webhooks/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+
+	var filter models.WebhookDeliveryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(companyID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       filter.Page,
+		"limit":      filter.Limit,
+	})
+}
+
+// GetWebhookDelivery godoc
+// @Summary Get a webhook delivery
+// @Description Get a delivery with the event it carries (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/deliveries/{id} [get]
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	delivery, err := h.webhookService.GetDelivery(uint(id), companyID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Send the delivery's event to its endpoint again with the same body and event ID (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /webhooks/deliveries/{id}/replay [post]
+func (h *WebhookHandler) ReplayWebhookDelivery(c *gin.Context) {
+	companyID := c.MustGet("company_id").(uint)
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	delivery, err := h.webhookService.ReplayDelivery(uint(id), companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,251 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+const (
+	pollInterval     = time.Second
+	relayBatchSize   = 100
+	maintainInterval = time.Minute
+	staleAfter       = 15 * time.Minute   // a running job without a heartbeat for this long is assumed lost
+	heartbeat        = time.Minute        // running jobs refresh their lock this often
+	retention        = 7 * 24 * time.Hour // succeeded jobs and relayed events are kept this long
+	firstRetry       = 30 * time.Second
+	maxRetryDelay    = 6 * time.Hour
+)
+
+// Handler does the work of one job. An error schedules a retry until the
+// job runs out of attempts.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Runner works off the job queue and relays the outbox. Every replica can run
+// one: jobs and events are claimed with SKIP LOCKED, so each is handled once.
+type Runner struct {
+	jobRepo     *repositories.JobRepository
+	outboxRepo  *repositories.OutboxRepository
+	worker      string
+	concurrency int
+	handlers    map[string]Handler
+	subscribers map[string][]string
+}
+
+func NewRunner(jobRepo *repositories.JobRepository, outboxRepo *repositories.OutboxRepository, concurrency int) *Runner {
+	host, _ := os.Hostname()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Runner{
+		jobRepo:     jobRepo,
+		outboxRepo:  outboxRepo,
+		worker:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		concurrency: concurrency,
+		handlers:    make(map[string]Handler),
+		subscribers: make(map[string][]string),
+	}
+}
+
+// Handle registers the handler of a job type.
+func (r *Runner) Handle(jobType string, handler Handler) {
+	r.handlers[jobType] = handler
+}
+
+// Subscribe queues a job of each of the job types for every domain event of
+// the event type. The job's payload is the event.
+func (r *Runner) Subscribe(eventType string, jobTypes ...string) {
+	r.subscribers[eventType] = append(r.subscribers[eventType], jobTypes...)
+}
+
+// Run works until ctx is cancelled, then waits for the jobs in progress to
+// finish.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(2 + r.concurrency)
+	go func() {
+		defer wg.Done()
+		r.every(ctx, pollInterval, r.relay)
+	}()
+	go func() {
+		defer wg.Done()
+		r.every(ctx, maintainInterval, r.maintain)
+	}()
+	for i := 0; i < r.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (r *Runner) every(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// relay turns unpublished domain events into the jobs of their subscribers.
+func (r *Runner) relay() {
+	for {
+		n, err := r.outboxRepo.Relay(relayBatchSize, r.route)
+		if err != nil {
+			log.Printf("outbox relay: %v", err)
+			return
+		}
+		if n < relayBatchSize {
+			return
+		}
+	}
+}
+
+func (r *Runner) route(event models.DomainEvent) ([]models.Job, error) {
+	var jobs []models.Job
+	for _, jobType := range r.subscribers[event.Type] {
+		companyID := event.CompanyID
+		job, err := models.NewJob(&companyID, jobType, event)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
+// maintain requeues jobs of workers that died and prunes old rows.
+func (r *Runner) maintain() {
+	if n, err := r.jobRepo.RequeueStale(time.Now().Add(-staleAfter)); err != nil {
+		log.Printf("requeue stale jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("requeued %d stale jobs", n)
+	}
+
+	before := time.Now().Add(-retention)
+	if _, err := r.jobRepo.DeleteSucceededBefore(before); err != nil {
+		log.Printf("prune jobs: %v", err)
+	}
+	if _, err := r.outboxRepo.DeletePublishedBefore(before); err != nil {
+		log.Printf("prune outbox: %v", err)
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	types := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		types = append(types, jobType)
+	}
+
+	for ctx.Err() == nil {
+		jobs, err := r.jobRepo.Claim(r.worker, types, 1)
+		if err != nil {
+			log.Printf("claim jobs: %v", err)
+		}
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		r.run(ctx, &jobs[0])
+	}
+}
+
+// run calls the job's handler and records the outcome. The lock is kept
+// fresh while the handler runs; if it is lost anyway, e.g. because the
+// database was unreachable for longer than staleAfter and another worker took
+// the job, the handler is cancelled and its outcome dropped.
+func (r *Runner) run(ctx context.Context, job *models.Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	var lost atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.keepLocked(jobCtx, job, &lost, cancel)
+	}()
+
+	err := r.call(jobCtx, job)
+	cancel()
+	<-done
+
+	if lost.Load() {
+		log.Printf("job %d (%s) was requeued while running, dropping its outcome", job.ID, job.Type)
+		return
+	}
+
+	switch {
+	case err == nil:
+		err = r.jobRepo.Complete(job)
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("job %d (%s) failed for good: %v", job.ID, job.Type, err)
+		err = r.jobRepo.Bury(job, err)
+	default:
+		err = r.jobRepo.Retry(job, err, time.Now().Add(RetryDelay(job.Attempts)))
+	}
+	if err != nil {
+		log.Printf("job %d (%s): %v", job.ID, job.Type, err)
+	}
+}
+
+// keepLocked refreshes the job's lock every heartbeat until ctx is done.
+func (r *Runner) keepLocked(ctx context.Context, job *models.Job, lost *atomic.Bool, cancel context.CancelFunc) {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := r.jobRepo.Heartbeat(job, r.worker)
+			if err != nil {
+				log.Printf("job %d (%s) heartbeat: %v", job.ID, job.Type, err)
+				continue
+			}
+			if !held {
+				lost.Store(true)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (r *Runner) call(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return r.handlers[job.Type](ctx, job)
+}
+
+// RetryDelay is the wait after a job's failed attempt: 30 seconds, doubling
+// with each attempt up to 6 hours.
+func RetryDelay(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,63 @@
+// Package labels renders shipping labels as PDF pages or ZPL for thermal
+// printers.
+package labels
+
+import (
+	"errors"
+	"io"
+)
+
+type Format string
+type Size string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatZPL Format = "zpl"
+)
+
+const (
+	SizeA6        Size = "a6"
+	SizeFourBySix Size = "4x6"
+)
+
+// Label is everything printed on one label. Barcode goes into the Code 128
+// symbol and QRData into the QR code; for a piece the barcode is the piece's
+// own code while the QR code carries the shipment's tracking number.
+type Label struct {
+	Company            string
+	TrackingNumber     string
+	Barcode            string
+	QRData             string
+	Title              string
+	Piece              string // e.g. "2/5", empty for a whole shipment
+	OriginAddress      string
+	DestinationAddress string
+	DestinationContact string
+	DestinationPhone   string
+	Weight             float64 // in kg
+	Priority           string
+	Fragile            bool
+	Hazardous          bool
+}
+
+// Render writes one label per page (PDF) or per ^XA...^XZ block (ZPL).
+func Render(w io.Writer, format Format, size Size, labels []Label) error {
+	if len(labels) == 0 {
+		return errors.New("no labels to print")
+	}
+
+	switch format {
+	case FormatZPL:
+		return renderZPL(w, size, labels)
+	case FormatPDF:
+		return renderPDF(w, size, labels)
+	}
+	return errors.New("unsupported label format " + string(format))
+}
+
+func ContentType(format Format) string {
+	if format == FormatZPL {
+		return "application/zpl"
+	}
+	return "application/pdf"
+}
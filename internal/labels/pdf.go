@@ -0,0 +1,193 @@
+package labels
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"truck-management/internal/barcode"
+	"truck-management/internal/pdf"
+)
+
+const margin = 12.0
+
+func pageSize(size Size) (float64, float64) {
+	if size == SizeA6 {
+		return 105 * pdf.MM, 148 * pdf.MM
+	}
+	return 4 * pdf.Inch, 6 * pdf.Inch
+}
+
+func renderPDF(w io.Writer, size Size, labels []Label) error {
+	width, height := pageSize(size)
+	doc := pdf.New(width, height)
+	for _, label := range labels {
+		doc.AddPage()
+		if err := drawLabel(doc, label); err != nil {
+			return err
+		}
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// drawLabel lays the label out top to bottom: header with priority, from and
+// to addresses, weight and piece, the Code 128 barcode, then the QR code and
+// handling icons along the bottom.
+func drawLabel(doc *pdf.Document, label Label) error {
+	left, right := margin, doc.Width-margin
+	contentWidth := right - left
+	y := doc.Height - margin
+
+	// Header
+	doc.Text(left, y-11, pdf.HelveticaBold, 11, truncate(label.Company, pdf.HelveticaBold, 11, contentWidth-90), false)
+	if label.Priority != "" {
+		priority := strings.ToUpper(label.Priority)
+		boxWidth := pdf.TextWidth(priority, pdf.HelveticaBold, 9) + 10
+		if label.Priority == "high" || label.Priority == "urgent" {
+			doc.Rect(right-boxWidth, y-14, boxWidth, 16)
+			doc.Text(right-boxWidth+5, y-9, pdf.HelveticaBold, 9, priority, true)
+		} else {
+			doc.StrokeRect(right-boxWidth, y-14, boxWidth, 16, 1)
+			doc.Text(right-boxWidth+5, y-9, pdf.HelveticaBold, 9, priority, false)
+		}
+	}
+	y -= 20
+	doc.Line(left, y, right, y, 1.5)
+
+	// Addresses
+	y -= 10
+	doc.Text(left, y, pdf.HelveticaBold, 7, "FROM", false)
+	for _, line := range pdf.Wrap(label.OriginAddress, pdf.Helvetica, 8, contentWidth, 2) {
+		y -= 10
+		doc.Text(left, y, pdf.Helvetica, 8, line, false)
+	}
+	y -= 14
+	doc.Text(left, y, pdf.HelveticaBold, 7, "TO", false)
+	for _, line := range pdf.Wrap(label.DestinationAddress, pdf.HelveticaBold, 12, contentWidth, 3) {
+		y -= 14
+		doc.Text(left, y, pdf.HelveticaBold, 12, line, false)
+	}
+	contact := strings.TrimSpace(label.DestinationContact + "  " + label.DestinationPhone)
+	if contact != "" {
+		y -= 12
+		doc.Text(left, y, pdf.Helvetica, 9, truncate(contact, pdf.Helvetica, 9, contentWidth), false)
+	}
+	y -= 8
+	doc.Line(left, y, right, y, 1.5)
+
+	// Weight, piece and title
+	y -= 14
+	details := fmt.Sprintf("WEIGHT %s kg", formatWeight(label.Weight))
+	if label.Piece != "" {
+		details += "   PIECE " + label.Piece
+	}
+	doc.Text(left, y, pdf.HelveticaBold, 10, details, false)
+	if label.Title != "" {
+		y -= 11
+		doc.Text(left, y, pdf.Helvetica, 8, truncate(label.Title, pdf.Helvetica, 8, contentWidth), false)
+	}
+
+	// Code 128
+	modules, err := barcode.Code128(label.Barcode)
+	if err != nil {
+		return err
+	}
+	barHeight := 64.0
+	y -= 8 + barHeight
+	drawBars(doc, modules, left+10, y, contentWidth-20, barHeight)
+	y -= 11
+	text := label.Barcode
+	doc.Text(left+(contentWidth-pdf.TextWidth(text, pdf.Helvetica, 9))/2, y, pdf.Helvetica, 9, text, false)
+
+	// QR code, tracking number and handling icons share the bottom band
+	qr, err := barcode.QR([]byte(label.QRData))
+	if err != nil {
+		return err
+	}
+	qrSize := min(y-8-margin, 96)
+	drawQR(doc, qr, left, margin, qrSize)
+
+	textLeft := left + qrSize + 8
+	doc.Text(textLeft, margin+qrSize-16, pdf.Helvetica, 7, "TRACKING NUMBER", false)
+	doc.Text(textLeft, margin+qrSize-29, pdf.HelveticaBold, 11, label.TrackingNumber, false)
+
+	iconSize := 36.0
+	x := right - iconSize
+	if label.Hazardous {
+		drawHazardousIcon(doc, x, margin+8, iconSize)
+		x -= iconSize + 8
+	}
+	if label.Fragile {
+		drawFragileIcon(doc, x, margin+8, iconSize)
+	}
+	return nil
+}
+
+// drawBars draws Code 128 modules, merging adjacent bars into one rectangle.
+func drawBars(doc *pdf.Document, modules []bool, x, y, width, height float64) {
+	moduleWidth := width / float64(len(modules))
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		doc.Rect(x+float64(start)*moduleWidth, y, float64(i-start)*moduleWidth, height)
+	}
+}
+
+// drawQR draws the QR code in a square of the given size, including a quiet
+// zone of four modules on each side.
+func drawQR(doc *pdf.Document, qr *barcode.QRCode, x, y, size float64) {
+	moduleSize := size / float64(qr.Size+8)
+	origin := x + 4*moduleSize
+	top := y + size - 4*moduleSize
+	for row := 0; row < qr.Size; row++ {
+		for col := 0; col < qr.Size; {
+			if !qr.Module(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < qr.Size && qr.Module(col, row) {
+				col++
+			}
+			doc.Rect(origin+float64(start)*moduleSize, top-float64(row+1)*moduleSize, float64(col-start)*moduleSize, moduleSize)
+		}
+	}
+}
+
+// drawFragileIcon draws a wine glass in a box, captioned FRAGILE.
+func drawFragileIcon(doc *pdf.Document, x, y, size float64) {
+	doc.StrokeRect(x, y, size, size, 1)
+	doc.Polygon(1.5, x+0.28*size, y+0.85*size, x+0.72*size, y+0.85*size, x+0.5*size, y+0.45*size)
+	doc.Line(x+0.5*size, y+0.45*size, x+0.5*size, y+0.2*size, 1.5)
+	doc.Line(x+0.33*size, y+0.2*size, x+0.67*size, y+0.2*size, 1.5)
+	caption := "FRAGILE"
+	doc.Text(x+(size-pdf.TextWidth(caption, pdf.HelveticaBold, 6))/2, y-7, pdf.HelveticaBold, 6, caption, false)
+}
+
+// drawHazardousIcon draws a hazard diamond with an exclamation mark,
+// captioned HAZARDOUS.
+func drawHazardousIcon(doc *pdf.Document, x, y, size float64) {
+	doc.Polygon(1.5, x+size/2, y+size, x+size, y+size/2, x+size/2, y, x, y+size/2)
+	doc.Text(x+size/2-3, y+size/2-7, pdf.HelveticaBold, 18, "!", false)
+	caption := "HAZARDOUS"
+	doc.Text(x+(size-pdf.TextWidth(caption, pdf.HelveticaBold, 6))/2, y-7, pdf.HelveticaBold, 6, caption, false)
+}
+
+func truncate(text string, font pdf.Font, size, width float64) string {
+	lines := pdf.Wrap(text, font, size, width, 1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+func formatWeight(weight float64) string {
+	s := fmt.Sprintf("%.2f", weight)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
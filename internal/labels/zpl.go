@@ -0,0 +1,138 @@
+package labels
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"truck-management/internal/barcode"
+)
+
+// ZPL coordinates are in dots of a 203 dpi (8 dots/mm) printer. The printer
+// draws the barcodes itself from the field data.
+func zplPageSize(size Size) (int, int) {
+	if size == SizeA6 {
+		return 840, 1184
+	}
+	return 812, 1218
+}
+
+func renderZPL(w io.Writer, size Size, labels []Label) error {
+	width, height := zplPageSize(size)
+	out := bufio.NewWriter(w)
+	for _, label := range labels {
+		writeZPLLabel(out, label, width, height)
+	}
+	return out.Flush()
+}
+
+func writeZPLLabel(out *bufio.Writer, label Label, width, height int) {
+	const left = 30
+	contentWidth := width - 2*left
+
+	out.WriteString("^XA\n^CI28\n")
+	fmt.Fprintf(out, "^PW%d\n^LL%d\n", width, height)
+
+	// Header
+	fmt.Fprintf(out, "^FO%d,30^A0N,34,34^FB%d,1,0,L%s^FS\n", left, contentWidth-200, zplField(label.Company))
+	if label.Priority != "" {
+		priority := strings.ToUpper(label.Priority)
+		if label.Priority == "high" || label.Priority == "urgent" {
+			fmt.Fprintf(out, "^FO%d,24^GB180,46,46^FS\n", width-left-180)
+			fmt.Fprintf(out, "^FO%d,34^A0N,30,30^FR^FB180,1,0,C%s^FS\n", width-left-180, zplField(priority))
+		} else {
+			fmt.Fprintf(out, "^FO%d,24^GB180,46,3^FS\n", width-left-180)
+			fmt.Fprintf(out, "^FO%d,34^A0N,30,30^FB180,1,0,C%s^FS\n", width-left-180, zplField(priority))
+		}
+	}
+	fmt.Fprintf(out, "^FO%d,84^GB%d,4,4^FS\n", left, contentWidth)
+
+	// Addresses
+	fmt.Fprintf(out, "^FO%d,104^A0N,22,22%s^FS\n", left, zplField("FROM"))
+	fmt.Fprintf(out, "^FO%d,132^A0N,26,26^FB%d,2,4,L%s^FS\n", left, contentWidth, zplField(label.OriginAddress))
+	fmt.Fprintf(out, "^FO%d,204^A0N,22,22%s^FS\n", left, zplField("TO"))
+	fmt.Fprintf(out, "^FO%d,232^A0N,40,40^FB%d,3,6,L%s^FS\n", left, contentWidth, zplField(label.DestinationAddress))
+	contact := strings.TrimSpace(label.DestinationContact + "  " + label.DestinationPhone)
+	if contact != "" {
+		fmt.Fprintf(out, "^FO%d,372^A0N,28,28^FB%d,1,0,L%s^FS\n", left, contentWidth, zplField(contact))
+	}
+	fmt.Fprintf(out, "^FO%d,412^GB%d,4,4^FS\n", left, contentWidth)
+
+	// Weight, piece and title
+	details := fmt.Sprintf("WEIGHT %s kg", formatWeight(label.Weight))
+	if label.Piece != "" {
+		details += "   PIECE " + label.Piece
+	}
+	fmt.Fprintf(out, "^FO%d,432^A0N,34,34%s^FS\n", left, zplField(details))
+	if label.Title != "" {
+		fmt.Fprintf(out, "^FO%d,474^A0N,26,26^FB%d,1,0,L%s^FS\n", left, contentWidth, zplField(label.Title))
+	}
+
+	// Code 128 with the interpretation line below it, as wide as fits
+	moduleWidth := 3
+	if modules, err := barcode.Code128(label.Barcode); err == nil {
+		moduleWidth = max(1, min(3, (contentWidth-40)/len(modules)))
+	}
+	fmt.Fprintf(out, "^FO%d,524^BY%d,3,200^BCN,200,Y,N,N%s^FS\n", left+20, moduleWidth, zplField(label.Barcode))
+
+	// QR code, tracking number and handling icons along the bottom
+	qrTop := height - 300
+	fmt.Fprintf(out, "^FO%d,%d^BQN,2,7%s^FS\n", left, qrTop, zplField("MA,"+label.QRData))
+	fmt.Fprintf(out, "^FO%d,%d^A0N,22,22%s^FS\n", left+290, qrTop+40, zplField("TRACKING NUMBER"))
+	fmt.Fprintf(out, "^FO%d,%d^A0N,36,36%s^FS\n", left+290, qrTop+70, zplField(label.TrackingNumber))
+
+	iconSize := 110
+	x := width - left - iconSize
+	iconTop := height - 30 - iconSize - 30
+	if label.Hazardous {
+		writeZPLHazardousIcon(out, x, iconTop, iconSize)
+		x -= iconSize + 30
+	}
+	if label.Fragile {
+		writeZPLFragileIcon(out, x, iconTop, iconSize)
+	}
+
+	out.WriteString("^XZ\n")
+}
+
+// writeZPLFragileIcon draws a boxed wine glass captioned FRAGILE.
+func writeZPLFragileIcon(out *bufio.Writer, x, y, size int) {
+	fmt.Fprintf(out, "^FO%d,%d^GB%d,%d,3^FS\n", x, y, size, size)
+	bowl := size * 44 / 100
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,L^FS\n", x+size*28/100, y+size*15/100, bowl/2, size*40/100)
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,R^FS\n", x+size/2, y+size*15/100, bowl/2, size*40/100)
+	fmt.Fprintf(out, "^FO%d,%d^GB%d,4,4^FS\n", x+size*28/100, y+size*15/100, bowl)
+	fmt.Fprintf(out, "^FO%d,%d^GB4,%d,4^FS\n", x+size/2-2, y+size*55/100, size*25/100)
+	fmt.Fprintf(out, "^FO%d,%d^GB%d,4,4^FS\n", x+size*33/100, y+size*80/100, size*34/100)
+	fmt.Fprintf(out, "^FO%d,%d^A0N,20,20^FB%d,1,0,C%s^FS\n", x, y+size+6, size, zplField("FRAGILE"))
+}
+
+// writeZPLHazardousIcon draws a hazard diamond with an exclamation mark,
+// captioned HAZARDOUS.
+func writeZPLHazardousIcon(out *bufio.Writer, x, y, size int) {
+	half := size / 2
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,R^FS\n", x, y, half, half)
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,L^FS\n", x+half, y, half, half)
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,L^FS\n", x, y+half, half, half)
+	fmt.Fprintf(out, "^FO%d,%d^GD%d,%d,4,B,R^FS\n", x+half, y+half, half, half)
+	fmt.Fprintf(out, "^FO%d,%d^A0N,60,50^FB%d,1,0,C%s^FS\n", x, y+half-28, size, zplField("!"))
+	fmt.Fprintf(out, "^FO%d,%d^A0N,20,20^FB%d,1,0,C%s^FS\n", x, y+size+6, size, zplField("HAZARDOUS"))
+}
+
+// zplField returns the ^FD command for text, hex-escaping the characters ZPL
+// treats as commands so user input cannot inject any.
+func zplField(text string) string {
+	var b strings.Builder
+	b.WriteString("^FH_^FD")
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '^', '~', '_', '\\':
+			fmt.Fprintf(&b, "_%02X", c)
+		case '\n', '\r':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 	"truck-management/internal/models"
 	"truck-management/internal/utils"
@@ -35,6 +36,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("company_id", claims.CompanyID)
 		c.Set("role", claims.Role)
+		if claims.CustomerID != nil {
+			c.Set("customer_id", *claims.CustomerID)
+		}
 		c.Next()
 	}
 }
@@ -89,6 +93,61 @@ func AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OperatorMiddleware admits the platform operators, the users in
+// operatorIDs, to the API that spans every tenant. A company's admin is not
+// one by virtue of the role.
+func OperatorMiddleware(operatorIDs []uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists || !slices.Contains(operatorIDs, userID.(uint)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Platform operator access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// StaffMiddleware keeps customer users out of the company's internal API;
+// they only have access to the booking portal.
+func StaffMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+			c.Abort()
+			return
+		}
+
+		if role.(models.UserRole) == models.RoleCustomer {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Staff access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CustomerMiddleware admits customer users linked to a customer account.
+func CustomerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Role not found"})
+			c.Abort()
+			return
+		}
+
+		_, hasCustomer := c.Get("customer_id")
+		if role.(models.UserRole) != models.RoleCustomer || !hasCustomer {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Customer access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func DriverMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
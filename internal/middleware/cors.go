@@ -1,13 +1,28 @@
 package middleware
 
 import (
+	"slices"
+
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware lets the browser origins in allowedOrigins call the API; "*"
+// allows any origin, without credentials. Requests from other origins get no
+// CORS headers, so browsers block them.
+func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		origin := c.GetHeader("Origin")
+		c.Writer.Header().Add("Vary", "Origin")
+
+		switch {
+		case anyOrigin:
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(allowedOrigins, origin):
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
@@ -18,4 +33,4 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
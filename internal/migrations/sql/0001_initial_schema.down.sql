@@ -0,0 +1,25 @@
+ -- Drops the whole schema of 0001_initial_schema, data included.
+ 
+ DROP VIEW IF EXISTS cargo_tracking_summary;
+ DROP VIEW IF EXISTS truck_utilization_stats;
+ DROP VIEW IF EXISTS company_dashboard_stats;
+ 
+ DROP TABLE IF EXISTS
+     location_archives, location_retention_policies,
+     scheduled_job_runs, scheduled_jobs,
+     jobs, domain_events,
+     webhook_deliveries, webhook_events, webhook_endpoints,
+     notification_logs, notification_opt_outs, notification_templates, notification_settings,
+     invoice_lines, invoices, invoice_settings, freight_rates, pricing_settings,
+     temperature_excursions, temperature_readings,
+     cargo_pieces, handling_units, cargo_handovers, shipment_legs,
+     cargo_import_row_errors, cargo_import_jobs,
+     attachments, proof_of_delivery_photos, proof_of_deliveries, tracking_number_formats,
+     cargo_events, cargo, requests, tasks, visits, route_stops, routes,
+     customer_addresses, customer_contacts, customers,
+     truck_locations, trucks, branches, users, companies
+     CASCADE;
+ 
+ DROP FUNCTION IF EXISTS cleanup_old_truck_locations();
+ DROP FUNCTION IF EXISTS calculate_distance(DECIMAL, DECIMAL, DECIMAL, DECIMAL);
+ DROP FUNCTION IF EXISTS update_updated_at_column();
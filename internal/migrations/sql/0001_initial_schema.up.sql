@@ -0,0 +1,1342 @@
+ -- Initial schema: the tables, indexes, functions, triggers and views of the
+ -- hand-maintained db.txt this migration replaces. Every statement is
+ -- idempotent, and the tables db.txt created get the columns added since, so
+ -- a database created from db.txt adopts it with "migrate up".
+ 
+ CREATE TABLE IF NOT EXISTS companies (
+    id BIGSERIAL PRIMARY KEY,
+     name VARCHAR(255) NOT NULL,
+     address TEXT,
+     phone VARCHAR(50),
+     email VARCHAR(255),
+     license VARCHAR(100) UNIQUE NOT NULL,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 2. USERS TABLE (Authentication & Authorization)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+     email VARCHAR(255) UNIQUE NOT NULL,
+     password_hash VARCHAR(255) NOT NULL,
+     first_name VARCHAR(100) NOT NULL,
+     last_name VARCHAR(100) NOT NULL,
+     role VARCHAR(20) DEFAULT 'driver' CHECK (role IN ('admin', 'assignee', 'driver', 'customer')),
+    company_id BIGINT REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT,
+    truck_id BIGINT,
+    customer_id BIGINT,
+     hazmat_certification_number VARCHAR(50),
+     hazmat_certified_until TIMESTAMPTZ,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 3. BRANCHES TABLE (Company branches)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS branches (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     name VARCHAR(255) NOT NULL,
+     address TEXT,
+     phone VARCHAR(50),
+     email VARCHAR(255),
+    manager_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 4. TRUCKS TABLE (Fleet management)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS trucks (  
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+     license_plate VARCHAR(20) UNIQUE NOT NULL,
+     model VARCHAR(100) NOT NULL,
+     year INTEGER,
+     color VARCHAR(50),
+     status VARCHAR(20) DEFAULT 'offline' CHECK (status IN ('online', 'offline', 'in_use', 'maintenance')),
+    driver_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     is_approved BOOLEAN DEFAULT false,
+     hazmat_placarded BOOLEAN DEFAULT false,
+    approved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     approved_at TIMESTAMPTZ,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 5. TRUCK LOCATIONS TABLE (Real-time GPS tracking)
+ -- =====================================================
+ -- Partitioned by month of timestamp so that retention can drop whole
+ -- months. An unpartitioned table from an older install is converted below.
+ DO $$
+ BEGIN
+     IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'truck_locations' AND relkind = 'r') THEN
+         ALTER TABLE truck_locations RENAME TO truck_locations_legacy;
+         ALTER INDEX IF EXISTS truck_locations_pkey RENAME TO truck_locations_legacy_pkey;
+         ALTER SEQUENCE IF EXISTS truck_locations_id_seq RENAME TO truck_locations_legacy_id_seq;
+     END IF;
+ END $$;
+ 
+ CREATE TABLE IF NOT EXISTS truck_locations (
+    id BIGSERIAL,
+    truck_id BIGINT NOT NULL REFERENCES trucks(id) ON DELETE CASCADE,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     latitude DECIMAL(10, 8) NOT NULL,
+     longitude DECIMAL(11, 8) NOT NULL,
+     speed DECIMAL(5, 2) DEFAULT 0,
+     heading DECIMAL(5, 2) DEFAULT 0,
+     timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     PRIMARY KEY (id, timestamp)
+ ) PARTITION BY RANGE (timestamp);
+ 
+ -- Catches points outside the monthly partitions, which the retention job
+ -- creates three months ahead
+ CREATE TABLE IF NOT EXISTS truck_locations_default PARTITION OF truck_locations DEFAULT;
+ 
+ -- Monthly partitions (truck_locations_pYYYYMM, UTC months) from the oldest
+ -- legacy point to two months ahead, then the legacy rows
+ DO $$
+ DECLARE
+     month TIMESTAMP;
+ BEGIN
+     FOR month IN
+         SELECT generate_series(
+             date_trunc('month', COALESCE(
+                 (CASE WHEN to_regclass('truck_locations_legacy') IS NOT NULL
+                     THEN (SELECT MIN(timestamp) FROM truck_locations_legacy) END),
+                 NOW()) AT TIME ZONE 'UTC'),
+             date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '2 months',
+             INTERVAL '1 month')
+     LOOP
+         EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF truck_locations FOR VALUES FROM (%L) TO (%L)',
+             'truck_locations_p' || to_char(month, 'YYYYMM'),
+             to_char(month, 'YYYY-MM-DD') || ' 00:00:00+00',
+             to_char(month + INTERVAL '1 month', 'YYYY-MM-DD') || ' 00:00:00+00');
+     END LOOP;
+ 
+     IF to_regclass('truck_locations_legacy') IS NOT NULL THEN
+         INSERT INTO truck_locations (id, truck_id, company_id, latitude, longitude, speed, heading, timestamp, created_at)
+         SELECT l.id, l.truck_id, t.company_id, l.latitude, l.longitude, l.speed, l.heading,
+             COALESCE(l.timestamp, l.created_at, NOW()), l.created_at
+         FROM truck_locations_legacy l
+         JOIN trucks t ON t.id = l.truck_id;
+         PERFORM setval(pg_get_serial_sequence('truck_locations', 'id'), COALESCE((SELECT MAX(id) FROM truck_locations), 0) + 1, false);
+         DROP TABLE truck_locations_legacy CASCADE;
+     END IF;
+ END $$;
+ 
+ -- =====================================================
+ -- 6. ROUTES TABLE (Route planning)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS routes (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL,
+    driver_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     name VARCHAR(255) NOT NULL,
+     description TEXT,
+     status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'active', 'completed', 'cancelled')),
+     start_time TIMESTAMPTZ,
+     end_time TIMESTAMPTZ,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    approved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     approved_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 7. ROUTE STOPS TABLE (Route stop details)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS route_stops (
+    id BIGSERIAL PRIMARY KEY,
+    route_id BIGINT NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+     stop_order INTEGER NOT NULL,
+    customer_id BIGINT,
+    customer_address_id BIGINT,
+     address TEXT NOT NULL,
+     latitude DECIMAL(10, 8),
+     longitude DECIMAL(11, 8),
+     contact_name VARCHAR(255),
+     contact_phone VARCHAR(50),
+     instructions TEXT,
+     delivery_window VARCHAR(11),
+     estimated_arrival TIMESTAMPTZ,
+     actual_arrival TIMESTAMPTZ,
+     is_completed BOOLEAN DEFAULT false,
+     completed_at TIMESTAMPTZ,
+     notes TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 8. VISITS TABLE (Customer visits)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS visits (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    truck_id BIGINT NOT NULL REFERENCES trucks(id) ON DELETE CASCADE,
+    driver_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    customer_id BIGINT,
+     customer_name VARCHAR(255) NOT NULL,
+    customer_address_id BIGINT,
+     address TEXT NOT NULL,
+     latitude DECIMAL(10, 8),
+     longitude DECIMAL(11, 8),
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'in_progress', 'completed', 'cancelled')),
+     start_time TIMESTAMPTZ,
+     end_time TIMESTAMPTZ,
+     notes TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 9. TASKS TABLE (Tasks during visits)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS tasks (
+    id BIGSERIAL PRIMARY KEY,
+    visit_id BIGINT NOT NULL REFERENCES visits(id) ON DELETE CASCADE,
+     title VARCHAR(255) NOT NULL,
+     description TEXT,
+     is_completed BOOLEAN DEFAULT false,
+     completed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 10. REQUESTS TABLE (Request approval system)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS requests (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL,
+     type VARCHAR(30) NOT NULL CHECK (type IN ('truck_assignment', 'maintenance', 'leave', 'other')),
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'terminated')),
+     title VARCHAR(255) NOT NULL,
+     description TEXT,
+    accepted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     accepted_at TIMESTAMPTZ,
+   terminated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     terminated_at TIMESTAMPTZ,
+     termination_reason TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 11. CARGO TABLE (Cargo management)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS cargo (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL,
+     tracking_number VARCHAR(50) UNIQUE NOT NULL,
+     tracking_token VARCHAR(32),
+    customer_id BIGINT,
+     title VARCHAR(255) NOT NULL,
+     description TEXT,
+     type VARCHAR(20) DEFAULT 'general' CHECK (type IN ('general', 'fragile', 'hazardous', 'perishable', 'liquid', 'oversized')),
+     priority VARCHAR(10) DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'assigned', 'in_transit', 'partially_delivered', 'delivered', 'cancelled')),
+     weight DECIMAL(10, 2),
+     volume DECIMAL(10, 2),
+     value DECIMAL(12, 2),
+     currency VARCHAR(3) DEFAULT 'USD',
+     
+     -- Booked freight price
+     price DECIMAL(12, 2),
+     price_currency VARCHAR(3),
+    freight_rate_id BIGINT,
+    invoice_id BIGINT,
+     
+     -- Origin details
+    origin_address_id BIGINT,
+     origin_address TEXT NOT NULL,
+     origin_latitude DECIMAL(10, 8),
+     origin_longitude DECIMAL(11, 8),
+     origin_contact VARCHAR(255),
+     origin_phone VARCHAR(50),
+     
+     -- Destination details
+    destination_address_id BIGINT,
+     destination_address TEXT NOT NULL,
+     destination_postcode VARCHAR(20),
+     destination_latitude DECIMAL(10, 8),
+     destination_longitude DECIMAL(11, 8),
+     destination_contact VARCHAR(255),
+     destination_phone VARCHAR(50),
+     destination_email VARCHAR(255),
+     recipient_locale VARCHAR(10),
+     
+     -- Timing
+     pickup_time TIMESTAMPTZ,
+     delivery_time TIMESTAMPTZ,
+     estimated_delivery TIMESTAMPTZ,
+     actual_pickup TIMESTAMPTZ,
+     actual_delivery TIMESTAMPTZ,
+     
+     -- Assignment details
+    assigned_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     assigned_at TIMESTAMPTZ,
+     
+     -- Customer portal booking
+    booked_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    booking_approved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     booking_approved_at TIMESTAMPTZ,
+     
+     -- Special instructions
+     instructions TEXT,
+     special_handling BOOLEAN DEFAULT false,
+     
+     -- Cold chain
+     min_temperature DECIMAL(5, 2),
+     max_temperature DECIMAL(5, 2),
+     excursion_threshold_minutes INTEGER DEFAULT 15,
+     
+     -- Dangerous goods; empty strings for other cargo
+     un_number VARCHAR(6),
+     proper_shipping_name VARCHAR(255),
+     hazard_class VARCHAR(5),
+     packing_group VARCHAR(3) CHECK (packing_group IN ('', 'I', 'II', 'III')),
+     hazmat_quantity DECIMAL(10, 2),
+     hazmat_quantity_unit VARCHAR(2) CHECK (hazmat_quantity_unit IN ('', 'kg', 'L')),
+     emergency_phone VARCHAR(20),
+     
+     -- Real-time tracking
+     current_latitude DECIMAL(10, 8),
+     current_longitude DECIMAL(11, 8),
+     current_location TEXT,
+     last_updated TIMESTAMPTZ,
+     
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 12. CARGO EVENTS TABLE (Cargo tracking events)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS cargo_events (
+    id BIGSERIAL PRIMARY KEY,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+     event_type VARCHAR(30) NOT NULL,
+     description TEXT,
+     location TEXT,
+     latitude DECIMAL(10, 8),
+     longitude DECIMAL(11, 8),
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     timestamp TIMESTAMPTZ DEFAULT NOW(),
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 13. TRACKING NUMBER FORMATS TABLE (Per-company tracking number sequences)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS tracking_number_formats (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT UNIQUE NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     prefix VARCHAR(8) DEFAULT 'TRK',
+     date_format VARCHAR(10) DEFAULT 'none' CHECK (date_format IN ('none', 'yymm', 'yymmdd', 'yyyymmdd')),
+     sequence_length INTEGER DEFAULT 6,
+     check_digit VARCHAR(10) DEFAULT 'none' CHECK (check_digit IN ('none', 'luhn', 'mod11')),
+     next_value BIGINT NOT NULL DEFAULT 1,
+     require_verification BOOLEAN DEFAULT false,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 14. PROOF OF DELIVERY TABLES (Signatures and delivery photos)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS proof_of_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT UNIQUE NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+    cargo_event_id BIGINT REFERENCES cargo_events(id) ON DELETE SET NULL,
+     recipient_name VARCHAR(255) NOT NULL,
+     signature_key TEXT NOT NULL,
+     signature_content_type VARCHAR(100),
+     latitude DECIMAL(10, 8) NOT NULL,
+     longitude DECIMAL(11, 8) NOT NULL,
+     captured_at TIMESTAMPTZ NOT NULL,
+    captured_by BIGINT NOT NULL REFERENCES users(id),
+     notes TEXT,
+     temperature_log_key TEXT,
+     has_temperature_log BOOLEAN DEFAULT false,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS proof_of_delivery_photos (
+    id BIGSERIAL PRIMARY KEY,
+    proof_of_delivery_id BIGINT NOT NULL REFERENCES proof_of_deliveries(id) ON DELETE CASCADE,
+     storage_key TEXT NOT NULL,
+     file_name VARCHAR(255),
+     content_type VARCHAR(100),
+     size BIGINT,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 15. ATTACHMENTS TABLE (Documents linked to cargo, trucks, requests, visits and tasks)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS attachments (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     owner_type VARCHAR(20) NOT NULL CHECK (owner_type IN ('cargo', 'truck', 'request', 'visit', 'task')),
+     owner_id BIGINT NOT NULL,
+     category VARCHAR(50),
+     file_name VARCHAR(255) NOT NULL,
+     content_type VARCHAR(100) NOT NULL,
+     size BIGINT NOT NULL,
+     checksum VARCHAR(64),
+     storage_key TEXT NOT NULL,
+    uploaded_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 16. CARGO IMPORT TABLES (Bulk cargo import jobs and row errors)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS cargo_import_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+     file_name VARCHAR(255) NOT NULL,
+     dry_run BOOLEAN DEFAULT false,
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+     total_rows INTEGER DEFAULT 0,
+     processed_rows INTEGER DEFAULT 0,
+     valid_rows INTEGER DEFAULT 0,
+     created_rows INTEGER DEFAULT 0,
+     error TEXT,
+     started_at TIMESTAMPTZ,
+     completed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS cargo_import_row_errors (
+    id BIGSERIAL PRIMARY KEY,
+    job_id BIGINT NOT NULL REFERENCES cargo_import_jobs(id) ON DELETE CASCADE,
+     row INTEGER NOT NULL,
+     field VARCHAR(50),
+     message TEXT NOT NULL
+ );
+ 
+ -- =====================================================
+ -- 17. SHIPMENT LEGS TABLES (Multi-leg journeys and custody handovers)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS shipment_legs (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+     sequence INTEGER NOT NULL,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL,
+    route_id BIGINT REFERENCES routes(id) ON DELETE SET NULL,
+    origin_branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+     origin_address TEXT,
+    destination_branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+     destination_address TEXT,
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'in_transit', 'completed', 'cancelled')),
+     planned_departure TIMESTAMPTZ,
+     planned_arrival TIMESTAMPTZ,
+     departed_at TIMESTAMPTZ,
+     arrived_at TIMESTAMPTZ,
+     notes TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ,
+     UNIQUE (cargo_id, sequence)
+ );
+ 
+ CREATE TABLE IF NOT EXISTS cargo_handovers (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+    from_leg_id BIGINT REFERENCES shipment_legs(id) ON DELETE SET NULL,
+    to_leg_id BIGINT NOT NULL REFERENCES shipment_legs(id) ON DELETE CASCADE,
+    from_driver_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    to_driver_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+     location TEXT,
+     latitude DECIMAL(10, 8),
+     longitude DECIMAL(11, 8),
+     notes TEXT,
+     handed_over_at TIMESTAMPTZ NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 18. CARGO PIECES TABLES (Pieces and consolidated handling units)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS handling_units (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     barcode VARCHAR(50) UNIQUE,
+     type VARCHAR(20) DEFAULT 'pallet' CHECK (type IN ('pallet', 'cage', 'box', 'container')),
+     label VARCHAR(255),
+     status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+     tare_weight DECIMAL(10, 2) DEFAULT 0,
+     weight DECIMAL(10, 2) DEFAULT 0,
+     volume DECIMAL(10, 2) DEFAULT 0,
+     piece_count INTEGER DEFAULT 0,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+     closed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ CREATE TABLE IF NOT EXISTS cargo_pieces (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+     piece_number INTEGER NOT NULL,
+     barcode VARCHAR(80) NOT NULL UNIQUE,
+     description TEXT,
+     weight DECIMAL(10, 3) DEFAULT 0,
+     volume DECIMAL(10, 3) DEFAULT 0,
+     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'picked_up', 'delivered')),
+    handling_unit_id BIGINT REFERENCES handling_units(id) ON DELETE SET NULL,
+     picked_up_at TIMESTAMPTZ,
+     delivered_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 19. TEMPERATURE TABLES (Reefer telemetry and cold-chain excursions)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS temperature_readings (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE CASCADE,
+    cargo_id BIGINT REFERENCES cargo(id) ON DELETE CASCADE,
+     sensor_id VARCHAR(100),
+     temperature DECIMAL(5, 2) NOT NULL,
+     humidity DECIMAL(5, 2),
+     recorded_at TIMESTAMPTZ NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS temperature_excursions (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+    truck_id BIGINT REFERENCES trucks(id) ON DELETE SET NULL,
+     direction VARCHAR(10) NOT NULL CHECK (direction IN ('above', 'below')),
+     status VARCHAR(10) DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+     min_temperature DECIMAL(5, 2),
+     max_temperature DECIMAL(5, 2),
+     peak_temperature DECIMAL(5, 2),
+     started_at TIMESTAMPTZ NOT NULL,
+     last_reading_at TIMESTAMPTZ NOT NULL,
+     ended_at TIMESTAMPTZ,
+     alerted_at TIMESTAMPTZ,
+    acknowledged_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     acknowledged_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 20. PRICING TABLES (Rate table and surcharges per company)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS pricing_settings (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT UNIQUE NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     currency VARCHAR(3) DEFAULT 'USD',
+     volumetric_factor DECIMAL(8, 2) DEFAULT 250,
+     urgent_surcharge_percent DECIMAL(5, 2) DEFAULT 0,
+     hazardous_surcharge_percent DECIMAL(5, 2) DEFAULT 0,
+     perishable_surcharge_percent DECIMAL(5, 2) DEFAULT 0,
+     special_handling_fee DECIMAL(12, 2) DEFAULT 0,
+     fuel_surcharge_percent DECIMAL(5, 2) DEFAULT 0,
+     minimum_charge DECIMAL(12, 2) DEFAULT 0,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS freight_rates (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     name VARCHAR(255) NOT NULL,
+     origin_match VARCHAR(255),
+     destination_match VARCHAR(255),
+     min_distance_km DECIMAL(10, 2) DEFAULT 0,
+     max_distance_km DECIMAL(10, 2),
+     min_weight DECIMAL(10, 2) DEFAULT 0,
+     base_charge DECIMAL(12, 2) DEFAULT 0,
+     rate_per_km DECIMAL(12, 4) DEFAULT 0,
+     rate_per_kg DECIMAL(12, 4) DEFAULT 0,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 21. INVOICE TABLES (Invoices, credit notes and numbering per company)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS invoice_settings (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT UNIQUE NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     tax_name VARCHAR(50) DEFAULT 'VAT',
+     tax_rate_percent DECIMAL(5, 2) DEFAULT 0,
+     payment_terms_days INTEGER DEFAULT 30,
+     invoice_prefix VARCHAR(8) DEFAULT 'INV',
+     credit_note_prefix VARCHAR(8) DEFAULT 'CN',
+     next_invoice_number BIGINT NOT NULL DEFAULT 1,
+     next_credit_note_number BIGINT NOT NULL DEFAULT 1,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS invoices (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     type VARCHAR(20) DEFAULT 'invoice' CHECK (type IN ('invoice', 'credit_note')),
+     number VARCHAR(30),
+     status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'issued', 'paid', 'void')),
+    customer_id BIGINT,
+     customer_name VARCHAR(255),
+     customer_address TEXT,
+     period_start TIMESTAMPTZ,
+     period_end TIMESTAMPTZ,
+     currency VARCHAR(3),
+     subtotal DECIMAL(12, 2) DEFAULT 0,
+     tax_name VARCHAR(50),
+     tax_rate_percent DECIMAL(5, 2) DEFAULT 0,
+     tax_amount DECIMAL(12, 2) DEFAULT 0,
+     total DECIMAL(12, 2) DEFAULT 0,
+    credited_invoice_id BIGINT REFERENCES invoices(id) ON DELETE RESTRICT,
+     reason TEXT,
+     notes TEXT,
+     issued_at TIMESTAMPTZ,
+     due_date TIMESTAMPTZ,
+     paid_at TIMESTAMPTZ,
+     voided_at TIMESTAMPTZ,
+    created_by BIGINT REFERENCES users(id),
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     UNIQUE(company_id, number)
+ );
+ 
+ CREATE TABLE IF NOT EXISTS invoice_lines (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    cargo_id BIGINT REFERENCES cargo(id) ON DELETE SET NULL,
+     description TEXT,
+     delivered_at TIMESTAMPTZ,
+     amount DECIMAL(12, 2) NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 22. CUSTOMER TABLES (Customer accounts and address book)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS customers (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     name VARCHAR(255) NOT NULL,
+     email VARCHAR(255),
+     phone VARCHAR(50),
+     billing_address TEXT,
+     notes TEXT,
+     is_active BOOLEAN DEFAULT true,
+     delivery_window_start VARCHAR(5),
+     delivery_window_end VARCHAR(5),
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ CREATE TABLE IF NOT EXISTS customer_contacts (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+     name VARCHAR(255) NOT NULL,
+     role VARCHAR(100),
+     email VARCHAR(255),
+     phone VARCHAR(50),
+     is_primary BOOLEAN DEFAULT false,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS customer_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    customer_id BIGINT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+     label VARCHAR(255),
+     address TEXT NOT NULL,
+     latitude DECIMAL(10, 8),
+     longitude DECIMAL(11, 8),
+     contact_name VARCHAR(255),
+     contact_phone VARCHAR(50),
+     delivery_window_start VARCHAR(5),
+     delivery_window_end VARCHAR(5),
+     instructions TEXT,
+     is_default BOOLEAN DEFAULT false,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     deleted_at TIMESTAMPTZ
+ );
+ 
+ -- =====================================================
+ -- 23. NOTIFICATION TABLES (Consignee email and SMS notifications)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS notification_settings (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL UNIQUE REFERENCES companies(id) ON DELETE CASCADE,
+     email_enabled BOOLEAN DEFAULT true,
+     sms_enabled BOOLEAN DEFAULT true,
+     default_locale VARCHAR(10) DEFAULT 'en',
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS notification_templates (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     trigger VARCHAR(50) NOT NULL CHECK (trigger IN ('cargo_assigned', 'cargo_picked_up', 'cargo_delayed', 'cargo_delivered')),
+     channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'sms')),
+     locale VARCHAR(10) NOT NULL,
+     subject TEXT,
+     body TEXT NOT NULL,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW(),
+     UNIQUE (company_id, trigger, channel, locale)
+ );
+ 
+ CREATE TABLE IF NOT EXISTS notification_opt_outs (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'sms')),
+     recipient VARCHAR(255) NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     UNIQUE (company_id, channel, recipient)
+ );
+ 
+ CREATE TABLE IF NOT EXISTS notification_logs (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    cargo_id BIGINT NOT NULL REFERENCES cargo(id) ON DELETE CASCADE,
+    cargo_event_id BIGINT REFERENCES cargo_events(id) ON DELETE SET NULL,
+     trigger VARCHAR(50),
+     channel VARCHAR(10),
+     recipient VARCHAR(255),
+     locale VARCHAR(10),
+     subject TEXT,
+     body TEXT,
+     status VARCHAR(20) CHECK (status IN ('sent', 'failed', 'opted_out')),
+     error TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 24. WEBHOOK TABLES (Integrator endpoints and event outbox)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     url TEXT NOT NULL,
+     description TEXT,
+     event_types JSONB NOT NULL,
+     secret VARCHAR(100) NOT NULL,
+     is_active BOOLEAN DEFAULT true,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     event_id VARCHAR(50) NOT NULL UNIQUE,
+     type VARCHAR(50) NOT NULL,
+     payload JSONB NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES webhook_events(id) ON DELETE CASCADE,
+    replay_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+     status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+     attempts INTEGER DEFAULT 0,
+     next_attempt_at TIMESTAMPTZ,
+     last_attempt_at TIMESTAMPTZ,
+     response_status INTEGER,
+     response_body TEXT,
+     error TEXT,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 25. OUTBOX AND JOB TABLES (Domain events and background jobs)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS domain_events (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     type VARCHAR(50) NOT NULL,
+     aggregate_type VARCHAR(50) NOT NULL,
+     aggregate_id BIGINT NOT NULL,
+     payload JSONB NOT NULL,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     published_at TIMESTAMPTZ
+ );
+ 
+ CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT REFERENCES companies(id) ON DELETE CASCADE,
+     type VARCHAR(50) NOT NULL,
+     payload JSONB NOT NULL,
+     status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'dead')),
+     attempts INTEGER DEFAULT 0,
+     max_attempts INTEGER DEFAULT 8,
+     run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     locked_at TIMESTAMPTZ,
+     locked_by VARCHAR(100),
+     last_error TEXT,
+     finished_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ -- =====================================================
+ -- 26. SCHEDULED JOB TABLES (Recurring system jobs and their history)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    id BIGSERIAL PRIMARY KEY,
+     name VARCHAR(100) NOT NULL UNIQUE,
+     schedule VARCHAR(100) NOT NULL,
+     description TEXT,
+     is_paused BOOLEAN DEFAULT false,
+     next_run_at TIMESTAMPTZ,
+     run_requested_at TIMESTAMPTZ,
+     last_run_at TIMESTAMPTZ,
+     last_status VARCHAR(20),
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+    id BIGSERIAL PRIMARY KEY,
+     job_name VARCHAR(100) NOT NULL,
+     trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+     status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+     instance VARCHAR(100),
+     started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     finished_at TIMESTAMPTZ,
+     duration_ms BIGINT DEFAULT 0,
+     error TEXT
+ );
+ 
+ -- =====================================================
+ -- 27. LOCATION RETENTION TABLES (Retention policies and location archives)
+ -- =====================================================
+ CREATE TABLE IF NOT EXISTS location_retention_policies (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL UNIQUE REFERENCES companies(id) ON DELETE CASCADE,
+     raw_days INTEGER NOT NULL DEFAULT 30 CHECK (raw_days >= 1),
+     downsample_mode VARCHAR(20) NOT NULL DEFAULT 'minute' CHECK (downsample_mode IN ('minute', 'trip', 'none')),
+     archive_after_days INTEGER NOT NULL DEFAULT 365 CHECK (archive_after_days >= 0),
+     downsampled_through TIMESTAMPTZ,
+     last_applied_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     updated_at TIMESTAMPTZ DEFAULT NOW()
+ );
+ 
+ CREATE TABLE IF NOT EXISTS location_archives (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+     day DATE NOT NULL,
+     storage_key VARCHAR(500) NOT NULL,
+     format VARCHAR(20) NOT NULL,
+     points BIGINT DEFAULT 0,
+     size_bytes BIGINT DEFAULT 0,
+     created_at TIMESTAMPTZ DEFAULT NOW(),
+     UNIQUE (company_id, day)
+ );
+ 
+ -- =====================================================
+ -- ADOPT DATABASES CREATED FROM db.txt
+ -- =====================================================
+ 
+ -- CREATE TABLE IF NOT EXISTS leaves the tables db.txt created as they were,
+ -- so add the columns and widen the checks the schema has gained since
+ ALTER TABLE users
+     ADD COLUMN IF NOT EXISTS customer_id BIGINT,
+     ADD COLUMN IF NOT EXISTS hazmat_certification_number VARCHAR(50),
+     ADD COLUMN IF NOT EXISTS hazmat_certified_until TIMESTAMPTZ;
+ -- Not validated: roles from before are mapped by 0002_reconcile_users
+ ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ ALTER TABLE users ADD CONSTRAINT users_role_check
+     CHECK (role IN ('admin', 'assignee', 'driver', 'customer')) NOT VALID;
+ 
+ ALTER TABLE trucks
+     ADD COLUMN IF NOT EXISTS hazmat_placarded BOOLEAN DEFAULT false;
+ 
+ ALTER TABLE route_stops
+     ADD COLUMN IF NOT EXISTS customer_id BIGINT,
+     ADD COLUMN IF NOT EXISTS customer_address_id BIGINT,
+     ADD COLUMN IF NOT EXISTS delivery_window VARCHAR(11);
+ 
+ ALTER TABLE visits
+     ADD COLUMN IF NOT EXISTS customer_id BIGINT,
+     ADD COLUMN IF NOT EXISTS customer_address_id BIGINT;
+ 
+ ALTER TABLE cargo
+     ADD COLUMN IF NOT EXISTS tracking_token VARCHAR(32),
+     ADD COLUMN IF NOT EXISTS customer_id BIGINT,
+     ADD COLUMN IF NOT EXISTS price DECIMAL(12, 2),
+     ADD COLUMN IF NOT EXISTS price_currency VARCHAR(3),
+     ADD COLUMN IF NOT EXISTS freight_rate_id BIGINT,
+     ADD COLUMN IF NOT EXISTS invoice_id BIGINT,
+     ADD COLUMN IF NOT EXISTS origin_address_id BIGINT,
+     ADD COLUMN IF NOT EXISTS destination_address_id BIGINT,
+     ADD COLUMN IF NOT EXISTS destination_postcode VARCHAR(20),
+     ADD COLUMN IF NOT EXISTS destination_email VARCHAR(255),
+     ADD COLUMN IF NOT EXISTS recipient_locale VARCHAR(10),
+     ADD COLUMN IF NOT EXISTS booked_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     ADD COLUMN IF NOT EXISTS booking_approved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+     ADD COLUMN IF NOT EXISTS booking_approved_at TIMESTAMPTZ,
+     ADD COLUMN IF NOT EXISTS min_temperature DECIMAL(5, 2),
+     ADD COLUMN IF NOT EXISTS max_temperature DECIMAL(5, 2),
+     ADD COLUMN IF NOT EXISTS excursion_threshold_minutes INTEGER DEFAULT 15,
+     ADD COLUMN IF NOT EXISTS un_number VARCHAR(6),
+     ADD COLUMN IF NOT EXISTS proper_shipping_name VARCHAR(255),
+     ADD COLUMN IF NOT EXISTS hazard_class VARCHAR(5),
+     ADD COLUMN IF NOT EXISTS packing_group VARCHAR(3) CHECK (packing_group IN ('', 'I', 'II', 'III')),
+     ADD COLUMN IF NOT EXISTS hazmat_quantity DECIMAL(10, 2),
+     ADD COLUMN IF NOT EXISTS hazmat_quantity_unit VARCHAR(2) CHECK (hazmat_quantity_unit IN ('', 'kg', 'L')),
+     ADD COLUMN IF NOT EXISTS emergency_phone VARCHAR(20);
+ ALTER TABLE cargo DROP CONSTRAINT IF EXISTS cargo_status_check;
+ ALTER TABLE cargo ADD CONSTRAINT cargo_status_check
+     CHECK (status IN ('pending', 'assigned', 'in_transit', 'partially_delivered', 'delivered', 'cancelled'));
+ 
+ -- =====================================================
+ -- ADD FOREIGN KEY CONSTRAINTS
+ -- =====================================================
+ 
+ -- Add foreign key constraints that reference tables created later
+ DO $$
+ BEGIN
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_branch_id') THEN
+         ALTER TABLE users ADD CONSTRAINT fk_users_branch_id
+           FOREIGN KEY (branch_id) REFERENCES branches(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_truck_id') THEN
+         ALTER TABLE users ADD CONSTRAINT fk_users_truck_id
+           FOREIGN KEY (truck_id) REFERENCES trucks(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_cargo_freight_rate_id') THEN
+         ALTER TABLE cargo ADD CONSTRAINT fk_cargo_freight_rate_id
+           FOREIGN KEY (freight_rate_id) REFERENCES freight_rates(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_cargo_invoice_id') THEN
+         ALTER TABLE cargo ADD CONSTRAINT fk_cargo_invoice_id
+           FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_customer_id') THEN
+         ALTER TABLE users ADD CONSTRAINT fk_users_customer_id
+           FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_cargo_customer_id') THEN
+         ALTER TABLE cargo ADD CONSTRAINT fk_cargo_customer_id
+           FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_cargo_origin_address_id') THEN
+         ALTER TABLE cargo ADD CONSTRAINT fk_cargo_origin_address_id
+           FOREIGN KEY (origin_address_id) REFERENCES customer_addresses(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_cargo_destination_address_id') THEN
+         ALTER TABLE cargo ADD CONSTRAINT fk_cargo_destination_address_id
+           FOREIGN KEY (destination_address_id) REFERENCES customer_addresses(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_visits_customer_id') THEN
+         ALTER TABLE visits ADD CONSTRAINT fk_visits_customer_id
+           FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_visits_customer_address_id') THEN
+         ALTER TABLE visits ADD CONSTRAINT fk_visits_customer_address_id
+           FOREIGN KEY (customer_address_id) REFERENCES customer_addresses(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_route_stops_customer_id') THEN
+         ALTER TABLE route_stops ADD CONSTRAINT fk_route_stops_customer_id
+           FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_route_stops_customer_address_id') THEN
+         ALTER TABLE route_stops ADD CONSTRAINT fk_route_stops_customer_address_id
+           FOREIGN KEY (customer_address_id) REFERENCES customer_addresses(id) ON DELETE SET NULL;
+     END IF;
+     IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_invoices_customer_id') THEN
+         ALTER TABLE invoices ADD CONSTRAINT fk_invoices_customer_id
+           FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE SET NULL;
+     END IF;
+ END $$;
+ 
+ -- =====================================================
+ -- INDEXES FOR PERFORMANCE
+ -- =====================================================
+ 
+ -- Companies indexes
+ CREATE INDEX IF NOT EXISTS idx_companies_license ON companies(license);
+ CREATE INDEX IF NOT EXISTS idx_companies_active ON companies(is_active);
+ 
+ -- Users indexes
+ CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+ CREATE INDEX IF NOT EXISTS idx_users_company_id ON users(company_id);
+ CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+ CREATE INDEX IF NOT EXISTS idx_users_branch_id ON users(branch_id);
+ CREATE INDEX IF NOT EXISTS idx_users_truck_id ON users(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_users_active ON users(is_active);
+ 
+ -- Branches indexes
+ CREATE INDEX IF NOT EXISTS idx_branches_company_id ON branches(company_id);
+ CREATE INDEX IF NOT EXISTS idx_branches_manager_id ON branches(manager_id);
+ CREATE INDEX IF NOT EXISTS idx_branches_active ON branches(is_active);
+ 
+ -- Trucks indexes
+ CREATE INDEX IF NOT EXISTS idx_trucks_company_id ON trucks(company_id);
+ CREATE INDEX IF NOT EXISTS idx_trucks_branch_id ON trucks(branch_id);
+ CREATE INDEX IF NOT EXISTS idx_trucks_driver_id ON trucks(driver_id);
+ CREATE INDEX IF NOT EXISTS idx_trucks_license_plate ON trucks(license_plate);
+ CREATE INDEX IF NOT EXISTS idx_trucks_status ON trucks(status);
+ CREATE INDEX IF NOT EXISTS idx_trucks_approved ON trucks(is_approved);
+ CREATE INDEX IF NOT EXISTS idx_trucks_active ON trucks(is_active);
+ 
+ -- Truck locations indexes
+ CREATE INDEX IF NOT EXISTS idx_truck_locations_truck_id ON truck_locations(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_truck_locations_timestamp ON truck_locations(timestamp DESC);
+ CREATE INDEX IF NOT EXISTS idx_truck_locations_coordinates ON truck_locations(latitude, longitude);
+ CREATE INDEX IF NOT EXISTS idx_truck_locations_company_id ON truck_locations(company_id, timestamp);
+ 
+ -- Routes indexes
+ CREATE INDEX IF NOT EXISTS idx_routes_company_id ON routes(company_id);
+ CREATE INDEX IF NOT EXISTS idx_routes_truck_id ON routes(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_routes_driver_id ON routes(driver_id);
+ CREATE INDEX IF NOT EXISTS idx_routes_status ON routes(status);
+ CREATE INDEX IF NOT EXISTS idx_routes_created_by ON routes(created_by);
+ 
+ -- Route stops indexes
+ CREATE INDEX IF NOT EXISTS idx_route_stops_route_id ON route_stops(route_id);
+ CREATE INDEX IF NOT EXISTS idx_route_stops_order ON route_stops(route_id, stop_order);
+ CREATE INDEX IF NOT EXISTS idx_route_stops_completed ON route_stops(is_completed);
+ 
+ -- Visits indexes
+ CREATE INDEX IF NOT EXISTS idx_visits_company_id ON visits(company_id);
+ CREATE INDEX IF NOT EXISTS idx_visits_truck_id ON visits(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_visits_driver_id ON visits(driver_id);
+ CREATE INDEX IF NOT EXISTS idx_visits_status ON visits(status);
+ CREATE INDEX IF NOT EXISTS idx_visits_date ON visits(created_at DESC);
+ 
+ -- Tasks indexes
+ CREATE INDEX IF NOT EXISTS idx_tasks_visit_id ON tasks(visit_id);
+ CREATE INDEX IF NOT EXISTS idx_tasks_completed ON tasks(is_completed);
+ 
+ -- Requests indexes
+ CREATE INDEX IF NOT EXISTS idx_requests_company_id ON requests(company_id);
+ CREATE INDEX IF NOT EXISTS idx_requests_user_id ON requests(user_id);
+ CREATE INDEX IF NOT EXISTS idx_requests_status ON requests(status);
+ CREATE INDEX IF NOT EXISTS idx_requests_type ON requests(type);
+ 
+ -- Cargo indexes
+ CREATE INDEX IF NOT EXISTS idx_cargo_company_id ON cargo(company_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_truck_id ON cargo(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_tracking_number ON cargo(tracking_number);
+ CREATE INDEX IF NOT EXISTS idx_cargo_status ON cargo(status);
+ CREATE INDEX IF NOT EXISTS idx_cargo_type ON cargo(type);
+ CREATE INDEX IF NOT EXISTS idx_cargo_priority ON cargo(priority);
+ CREATE INDEX IF NOT EXISTS idx_cargo_assigned_by ON cargo(assigned_by);
+ CREATE INDEX IF NOT EXISTS idx_cargo_current_location ON cargo(current_latitude, current_longitude);
+ 
+ -- Full-text search index for cargo
+ CREATE INDEX IF NOT EXISTS idx_cargo_search ON cargo USING gin(to_tsvector('english', title || ' ' || COALESCE(description, '') || ' ' || tracking_number));
+ 
+ -- Cargo events indexes
+ CREATE INDEX IF NOT EXISTS idx_cargo_events_cargo_id ON cargo_events(cargo_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_events_timestamp ON cargo_events(timestamp DESC);
+ CREATE INDEX IF NOT EXISTS idx_cargo_events_type ON cargo_events(event_type);
+ CREATE INDEX IF NOT EXISTS idx_cargo_events_user_id ON cargo_events(user_id);
+ 
+ -- Tracking number format indexes
+ CREATE INDEX IF NOT EXISTS idx_tracking_number_formats_prefix ON tracking_number_formats(prefix);
+ 
+ -- Proof of delivery indexes
+ CREATE INDEX IF NOT EXISTS idx_proof_of_deliveries_company_id ON proof_of_deliveries(company_id);
+ CREATE INDEX IF NOT EXISTS idx_proof_of_delivery_photos_pod_id ON proof_of_delivery_photos(proof_of_delivery_id);
+ 
+ -- Attachments indexes
+ CREATE INDEX IF NOT EXISTS idx_attachments_company_id ON attachments(company_id);
+ CREATE INDEX IF NOT EXISTS idx_attachments_owner ON attachments(owner_type, owner_id);
+ 
+ -- Cargo import indexes
+ CREATE INDEX IF NOT EXISTS idx_cargo_import_jobs_company_id ON cargo_import_jobs(company_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_import_row_errors_job_id ON cargo_import_row_errors(job_id);
+ 
+ -- Shipment leg indexes
+ CREATE INDEX IF NOT EXISTS idx_shipment_legs_company_id ON shipment_legs(company_id);
+ CREATE INDEX IF NOT EXISTS idx_shipment_legs_truck_id ON shipment_legs(truck_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_handovers_cargo_id ON cargo_handovers(cargo_id);
+ 
+ -- Cargo piece indexes
+ CREATE INDEX IF NOT EXISTS idx_cargo_pieces_cargo_id ON cargo_pieces(cargo_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_pieces_handling_unit_id ON cargo_pieces(handling_unit_id);
+ CREATE INDEX IF NOT EXISTS idx_handling_units_company_id ON handling_units(company_id);
+ 
+ -- Temperature indexes
+ CREATE INDEX IF NOT EXISTS idx_temperature_readings_truck_time ON temperature_readings(truck_id, recorded_at);
+ CREATE INDEX IF NOT EXISTS idx_temperature_readings_cargo_time ON temperature_readings(cargo_id, recorded_at);
+ CREATE INDEX IF NOT EXISTS idx_temperature_excursions_company_id ON temperature_excursions(company_id, started_at DESC);
+ CREATE INDEX IF NOT EXISTS idx_temperature_excursions_cargo_id ON temperature_excursions(cargo_id, status);
+ 
+ -- Pricing indexes
+ CREATE INDEX IF NOT EXISTS idx_freight_rates_company_id ON freight_rates(company_id, is_active);
+ 
+ -- Invoice indexes
+ CREATE INDEX IF NOT EXISTS idx_invoices_company_id ON invoices(company_id, status, issued_at);
+ CREATE INDEX IF NOT EXISTS idx_invoices_credited_invoice_id ON invoices(credited_invoice_id);
+ CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice_id ON invoice_lines(invoice_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_invoice_id ON cargo(invoice_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_billable ON cargo(company_id, status, actual_delivery) WHERE invoice_id IS NULL;
+ CREATE INDEX IF NOT EXISTS idx_invoices_customer_id ON invoices(customer_id);
+ 
+ -- Customer indexes
+ CREATE INDEX IF NOT EXISTS idx_customers_company_name ON customers(company_id, name);
+ CREATE INDEX IF NOT EXISTS idx_customer_contacts_customer_id ON customer_contacts(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer_id ON customer_addresses(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_customer_addresses_company_id ON customer_addresses(company_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_customer_id ON cargo(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_visits_customer_id ON visits(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_route_stops_customer_id ON route_stops(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_users_customer_id ON users(customer_id);
+ CREATE INDEX IF NOT EXISTS idx_cargo_awaiting_approval ON cargo(company_id, created_at) WHERE booked_by IS NOT NULL AND booking_approved_at IS NULL;
+ 
+ -- Notification indexes
+ CREATE INDEX IF NOT EXISTS idx_notification_logs_company_id ON notification_logs(company_id, created_at);
+ CREATE INDEX IF NOT EXISTS idx_notification_logs_cargo_id ON notification_logs(cargo_id, trigger, channel);
+ 
+ -- Webhook indexes
+ CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_company_id ON webhook_endpoints(company_id, is_active);
+ CREATE INDEX IF NOT EXISTS idx_webhook_events_company_id ON webhook_events(company_id, created_at);
+ CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+ CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_company_id ON webhook_deliveries(company_id, created_at);
+ CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id);
+ 
+ -- Outbox and job indexes
+ CREATE INDEX IF NOT EXISTS idx_domain_events_unpublished ON domain_events(id) WHERE published_at IS NULL;
+ CREATE INDEX IF NOT EXISTS idx_domain_events_published_at ON domain_events(published_at);
+ CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_at) WHERE status = 'queued';
+ CREATE INDEX IF NOT EXISTS idx_jobs_locked_at ON jobs(locked_at) WHERE status = 'running';
+ CREATE INDEX IF NOT EXISTS idx_jobs_company_id ON jobs(company_id, status, created_at);
+ 
+ -- Scheduled job indexes
+ CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_job_name ON scheduled_job_runs(job_name, started_at);
+ CREATE INDEX IF NOT EXISTS idx_scheduled_job_runs_running ON scheduled_job_runs(status) WHERE status = 'running';
+ 
+ -- Location retention indexes
+ CREATE INDEX IF NOT EXISTS idx_location_archives_day ON location_archives(company_id, day DESC);
+ 
+ -- =====================================================
+ -- ROW LEVEL SECURITY (RLS) POLICIES
+ -- =====================================================
+ 
+ -- Enable RLS on all tables
+ ALTER TABLE companies ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE branches ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE trucks ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE truck_locations ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE routes ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE route_stops ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE visits ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE tasks ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE requests ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE cargo ENABLE ROW LEVEL SECURITY;
+ ALTER TABLE cargo_events ENABLE ROW LEVEL SECURITY;
+ 
+ -- Companies policies (Admin only), where the Supabase authenticated role exists
+ DO $$
+ BEGIN
+     IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'authenticated')
+         AND NOT EXISTS (SELECT 1 FROM pg_policies WHERE tablename = 'companies' AND policyname = 'Companies are viewable by authenticated users') THEN
+         CREATE POLICY "Companies are viewable by authenticated users" ON companies
+             FOR SELECT TO authenticated USING (true);
+     END IF;
+ END $$;
+ 
+
+ 
+ 
+ -- =====================================================
+ -- FUNCTIONS AND TRIGGERS
+ -- =====================================================
+ 
+ -- Function to update updated_at timestamp
+ CREATE OR REPLACE FUNCTION update_updated_at_column()
+ RETURNS TRIGGER AS $$
+ BEGIN
+     NEW.updated_at = NOW();
+     RETURN NEW;
+ END;
+ $$ language 'plpgsql';
+ 
+ -- Apply updated_at triggers to all tables
+ CREATE OR REPLACE TRIGGER update_companies_updated_at BEFORE UPDATE ON companies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_users_updated_at BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_branches_updated_at BEFORE UPDATE ON branches FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_trucks_updated_at BEFORE UPDATE ON trucks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_routes_updated_at BEFORE UPDATE ON routes FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_route_stops_updated_at BEFORE UPDATE ON route_stops FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_visits_updated_at BEFORE UPDATE ON visits FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_tasks_updated_at BEFORE UPDATE ON tasks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_requests_updated_at BEFORE UPDATE ON requests FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ CREATE OR REPLACE TRIGGER update_cargo_updated_at BEFORE UPDATE ON cargo FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+ 
+ -- Function to calculate distance between two GPS coordinates (Haversine formula)
+ CREATE OR REPLACE FUNCTION calculate_distance(lat1 DECIMAL, lon1 DECIMAL, lat2 DECIMAL, lon2 DECIMAL)
+ RETURNS DECIMAL AS $$
+ DECLARE
+     R DECIMAL := 6371; -- Earth's radius in kilometers
+     dLat DECIMAL;
+     dLon DECIMAL;
+     a DECIMAL;
+     c DECIMAL;
+ BEGIN
+     dLat := RADIANS(lat2 - lat1);
+     dLon := RADIANS(lon2 - lon1);
+     a := SIN(dLat/2) * SIN(dLat/2) + COS(RADIANS(lat1)) * COS(RADIANS(lat2)) * SIN(dLon/2) * SIN(dLon/2);
+     c := 2 * ATAN2(SQRT(a), SQRT(1-a));
+     RETURN R * c;
+ END;
+ $$ LANGUAGE plpgsql;
+ 
+ -- Function to cleanup old truck locations (keep last 1000 per truck)
+ CREATE OR REPLACE FUNCTION cleanup_old_truck_locations()
+ RETURNS void AS $$
+ BEGIN
+     DELETE FROM truck_locations 
+     WHERE id NOT IN (
+         SELECT id FROM (
+             SELECT id, ROW_NUMBER() OVER (PARTITION BY truck_id ORDER BY timestamp DESC) as rn
+             FROM truck_locations
+         ) t WHERE t.rn <= 1000
+     );
+ END;
+ $$ LANGUAGE plpgsql;
+ 
+ -- =====================================================
+ -- VIEWS FOR REPORTING AND DASHBOARDS
+ -- =====================================================
+ 
+ -- Company dashboard statistics
+ CREATE OR REPLACE VIEW company_dashboard_stats AS
+ SELECT 
+     c.id as company_id,
+     c.name as company_name,
+     COUNT(DISTINCT u.id) as total_users,
+     COUNT(DISTINCT CASE WHEN u.role = 'driver' THEN u.id END) as total_drivers,
+     COUNT(DISTINCT t.id) as total_trucks,
+     COUNT(DISTINCT CASE WHEN t.status = 'online' THEN t.id END) as online_trucks,
+     COUNT(DISTINCT CASE WHEN t.status = 'in_use' THEN t.id END) as trucks_in_use,
+     COUNT(DISTINCT car.id) as total_cargo,
+     COUNT(DISTINCT CASE WHEN car.status = 'in_transit' THEN car.id END) as cargo_in_transit,
+     COUNT(DISTINCT CASE WHEN car.status = 'delivered' THEN car.id END) as cargo_delivered,
+     COUNT(DISTINCT r.id) as total_routes,
+     COUNT(DISTINCT CASE WHEN r.status = 'active' THEN r.id END) as active_routes,
+     COUNT(DISTINCT v.id) as total_visits,
+     COUNT(DISTINCT CASE WHEN v.status = 'completed' THEN v.id END) as completed_visits
+ FROM companies c
+ LEFT JOIN users u ON c.id = u.company_id AND u.deleted_at IS NULL
+ LEFT JOIN trucks t ON c.id = t.company_id AND t.deleted_at IS NULL
+ LEFT JOIN cargo car ON c.id = car.company_id AND car.deleted_at IS NULL
+ LEFT JOIN routes r ON c.id = r.company_id AND r.deleted_at IS NULL
+ LEFT JOIN visits v ON c.id = v.company_id AND v.deleted_at IS NULL
+ WHERE c.deleted_at IS NULL
+ GROUP BY c.id, c.name;
+ 
+ -- Truck utilization statistics
+ CREATE OR REPLACE VIEW truck_utilization_stats AS
+ SELECT 
+     t.id as truck_id,
+     t.license_plate,
+     t.model,
+     t.status,
+     u.first_name || ' ' || u.last_name as driver_name,
+     COUNT(DISTINCT car.id) as assigned_cargo_count,
+     COUNT(DISTINCT r.id) as assigned_routes_count,
+     COUNT(DISTINCT v.id) as total_visits,
+     COUNT(DISTINCT CASE WHEN v.status = 'completed' THEN v.id END) as completed_visits,
+     COALESCE(AVG(EXTRACT(EPOCH FROM (v.end_time - v.start_time))/3600), 0) as avg_visit_duration_hours,
+     MAX(tl.timestamp) as last_location_update
+ FROM trucks t
+ LEFT JOIN users u ON t.driver_id = u.id
+ LEFT JOIN cargo car ON t.id = car.truck_id AND car.deleted_at IS NULL
+ LEFT JOIN routes r ON t.id = r.truck_id AND r.deleted_at IS NULL
+ LEFT JOIN visits v ON t.id = v.truck_id AND v.deleted_at IS NULL
+ LEFT JOIN truck_locations tl ON t.id = tl.truck_id
+ WHERE t.deleted_at IS NULL
+ GROUP BY t.id, t.license_plate, t.model, t.status, u.first_name, u.last_name;
+ 
+ -- Cargo tracking summary
+ CREATE OR REPLACE VIEW cargo_tracking_summary AS
+ SELECT 
+     c.id as cargo_id,
+     c.tracking_number,
+     c.title,
+     c.status,
+     c.type,
+     c.priority,
+     c.origin_address,
+     c.destination_address,
+     c.estimated_delivery,
+     c.actual_delivery,
+     t.license_plate as assigned_truck,
+     u.first_name || ' ' || u.last_name as driver_name,
+     c.current_latitude,
+     c.current_longitude,
+     c.last_updated,
+     COUNT(ce.id) as total_events,
+     MAX(ce.timestamp) as last_event_time
+ FROM cargo c
+ LEFT JOIN trucks t ON c.truck_id = t.id
+ LEFT JOIN users u ON t.driver_id = u.id
+ LEFT JOIN cargo_events ce ON c.id = ce.cargo_id
+ WHERE c.deleted_at IS NULL
+ GROUP BY c.id, c.tracking_number, c.title, c.status, c.type, c.priority, 
+          c.origin_address, c.destination_address, c.estimated_delivery, 
+          c.actual_delivery, t.license_plate, u.first_name, u.last_name,
+          c.current_latitude, c.current_longitude, c.last_updated;
@@ -0,0 +1,5 @@
+ -- Restores the role check without the draft role. It is not validated, so
+ -- draft users stay; the password_hash column keeps its name.
+ ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ ALTER TABLE users ADD CONSTRAINT users_role_check
+     CHECK (role IN ('admin', 'assignee', 'driver', 'customer')) NOT VALID;
@@ -0,0 +1,17 @@
+ -- Brings users in line with the User model: databases created by GORM
+ -- AutoMigrate named the password hash column "password", and self-registered
+ -- users get the draft role, which the role check did not allow.
+ DO $$
+ BEGIN
+     IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'password')
+         AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'password_hash') THEN
+         ALTER TABLE users RENAME COLUMN password TO password_hash;
+     END IF;
+ END $$;
+ 
+ UPDATE users SET role = 'draft' WHERE role IN ('visitor', 'user');
+ 
+ ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ ALTER TABLE users ADD CONSTRAINT users_role_check
+     CHECK (role IN ('admin', 'assignee', 'driver', 'draft', 'customer'));
+ ALTER TABLE users ALTER COLUMN role SET DEFAULT 'driver';
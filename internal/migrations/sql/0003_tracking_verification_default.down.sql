@@ -0,0 +1 @@
+ ALTER TABLE tracking_number_formats ALTER COLUMN require_verification SET DEFAULT false;
@@ -0,0 +1,3 @@
+ -- New tracking number formats require verification for public tracking, so
+ -- tracking numbers cannot be enumerated. Existing formats keep their setting.
+ ALTER TABLE tracking_number_formats ALTER COLUMN require_verification SET DEFAULT true;
@@ -0,0 +1,32 @@
+package models
+
+// DemoSeed describes the demo tenant created by "seed --demo".
+type DemoSeed struct {
+	CompanyID    uint
+	CompanyName  string
+	AdminEmail   string
+	DriverEmails []string
+	Password     string // of the admin and the drivers
+	Branches     int
+	Trucks       int
+	Cargos       int
+}
+
+type CreateAdminRequest struct {
+	CompanyID uint
+	Email     string
+	FirstName string
+	LastName  string
+	Password  string // generated when empty
+}
+
+// TenantPurge is what purging a company removes: its rows per table and the
+// files it keeps in the blob store.
+type TenantPurge struct {
+	CompanyID   uint
+	CompanyName string
+	Rows        map[string]int64
+	Files       int // files found, or deleted once purged
+	FileErrors  int // blob prefixes that could not be cleared
+	Purged      bool
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentOwnerType string
+
+const (
+	AttachmentOwnerCargo   AttachmentOwnerType = "cargo"
+	AttachmentOwnerTruck   AttachmentOwnerType = "truck"
+	AttachmentOwnerRequest AttachmentOwnerType = "request"
+	AttachmentOwnerVisit   AttachmentOwnerType = "visit"
+	AttachmentOwnerTask    AttachmentOwnerType = "task"
+)
+
+type Attachment struct {
+	ID             uint                `json:"id" gorm:"primaryKey"`
+	CompanyID      uint                `json:"company_id" gorm:"not null"`
+	OwnerType      AttachmentOwnerType `json:"owner_type" gorm:"not null"`
+	OwnerID        uint                `json:"owner_id" gorm:"not null"`
+	Category       string              `json:"category"` // e.g. bill_of_lading, invoice, registration, insurance, quote, photo
+	FileName       string              `json:"file_name" gorm:"not null"`
+	ContentType    string              `json:"content_type" gorm:"not null"`
+	Size           int64               `json:"size" gorm:"not null"`
+	Checksum       string              `json:"checksum"` // hex SHA-256
+	StorageKey     string              `json:"-" gorm:"not null"`
+	UploadedBy     *uint               `json:"uploaded_by"`
+	UploadedByUser *User               `json:"uploaded_by_user,omitempty" gorm:"foreignKey:UploadedBy"`
+	CreatedAt      time.Time           `json:"created_at"`
+	DeletedAt      gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
+type UploadAttachmentRequest struct {
+	OwnerType AttachmentOwnerType `form:"owner_type" binding:"required,oneof=cargo truck request visit task"`
+	OwnerID   uint                `form:"owner_id" binding:"required"`
+	Category  string              `form:"category"`
+}
+
+type AttachmentFilter struct {
+	OwnerType AttachmentOwnerType `form:"owner_type" binding:"required,oneof=cargo truck request visit task"`
+	OwnerID   uint                `form:"owner_id" binding:"required"`
+	Category  string              `form:"category"`
+}
+
+type AttachmentURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AttachmentUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+	Count      int64 `json:"count"`
+}
@@ -11,11 +11,12 @@ type CargoType string
 type CargoPriority string
 
 const (
-	CargoStatusPending    CargoStatus = "pending"
-	CargoStatusAssigned   CargoStatus = "assigned"
-	CargoStatusInTransit  CargoStatus = "in_transit"
-	CargoStatusDelivered  CargoStatus = "delivered"
-	CargoStatusCancelled  CargoStatus = "cancelled"
+	CargoStatusPending            CargoStatus = "pending"
+	CargoStatusAssigned           CargoStatus = "assigned"
+	CargoStatusInTransit          CargoStatus = "in_transit"
+	CargoStatusPartiallyDelivered CargoStatus = "partially_delivered"
+	CargoStatusDelivered          CargoStatus = "delivered"
+	CargoStatusCancelled          CargoStatus = "cancelled"
 )
 
 const (
@@ -34,6 +35,26 @@ const (
 	CargoPriorityUrgent   CargoPriority = "urgent"
 )
 
+// cargoTransitions is the cargo status state machine: the statuses each
+// status may move on to. Delivered and cancelled cargo is final.
+var cargoTransitions = map[CargoStatus][]CargoStatus{
+	CargoStatusPending:            {CargoStatusAssigned, CargoStatusCancelled},
+	CargoStatusAssigned:           {CargoStatusPending, CargoStatusInTransit, CargoStatusDelivered, CargoStatusCancelled},
+	CargoStatusInTransit:          {CargoStatusPartiallyDelivered, CargoStatusDelivered, CargoStatusCancelled},
+	CargoStatusPartiallyDelivered: {CargoStatusDelivered, CargoStatusCancelled},
+}
+
+// CanTransitionTo reports whether the state machine allows moving from s to
+// next.
+func (s CargoStatus) CanTransitionTo(next CargoStatus) bool {
+	for _, allowed := range cargoTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Cargo struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
 	CompanyID       uint           `json:"company_id" gorm:"not null"`
@@ -41,6 +62,9 @@ type Cargo struct {
 	TruckID         *uint          `json:"truck_id"`
 	Truck           *Truck         `json:"truck,omitempty"`
 	TrackingNumber  string         `json:"tracking_number" gorm:"uniqueIndex;not null"`
+	TrackingToken   string         `json:"tracking_token"` // shared with the recipient to unlock public tracking details
+	CustomerID      *uint          `json:"customer_id" gorm:"index"` // the shipper booking and paying for the cargo
+	Customer        *Customer      `json:"customer,omitempty"`
 	Title           string         `json:"title" gorm:"not null"`
 	Description     string         `json:"description"`
 	Type            CargoType      `json:"type" gorm:"default:'general'"`
@@ -51,7 +75,14 @@ type Cargo struct {
 	Value           float64        `json:"value"`  // monetary value
 	Currency        string         `json:"currency" gorm:"default:'USD'"`
 	
+	// Freight price booked with the cargo
+	Price           *float64       `json:"price"`
+	PriceCurrency   string         `json:"price_currency"`
+	FreightRateID   *uint          `json:"freight_rate_id"`
+	InvoiceID       *uint          `json:"invoice_id"` // set once the cargo is billed
+	
 	// Origin details
+	OriginAddressID *uint          `json:"origin_address_id"` // saved address it was booked from
 	OriginAddress   string         `json:"origin_address" gorm:"not null"`
 	OriginLatitude  *float64       `json:"origin_latitude"`
 	OriginLongitude *float64       `json:"origin_longitude"`
@@ -59,11 +90,15 @@ type Cargo struct {
 	OriginPhone     string         `json:"origin_phone"`
 	
 	// Destination details
+	DestinationAddressID *uint     `json:"destination_address_id"`
 	DestinationAddress   string    `json:"destination_address" gorm:"not null"`
+	DestinationPostcode  string    `json:"destination_postcode"` // lets the recipient verify on public tracking
 	DestinationLatitude  *float64  `json:"destination_latitude"`
 	DestinationLongitude *float64  `json:"destination_longitude"`
 	DestinationContact   string    `json:"destination_contact"`
 	DestinationPhone     string    `json:"destination_phone"`
+	DestinationEmail     string    `json:"destination_email"`
+	RecipientLocale      string    `json:"recipient_locale"` // language of consignee notifications; company default when empty
 	
 	// Timing
 	PickupTime      *time.Time     `json:"pickup_time"`
@@ -74,15 +109,36 @@ type Cargo struct {
 	
 	// Assignment details
 	AssignedBy      *uint          `json:"assigned_by"`
-	AssignedByUser  *User          `json:"assigned_by_user,omitempty"`
+	AssignedByUser  *User          `json:"assigned_by_user,omitempty" gorm:"foreignKey:AssignedBy"`
 	AssignedAt      *time.Time     `json:"assigned_at"`
 	
+	// Customer portal booking; dispatch waits for a moderator to approve it
+	BookedBy          *uint        `json:"booked_by"`
+	BookingApprovedBy *uint        `json:"booking_approved_by"`
+	BookingApprovedAt *time.Time   `json:"booking_approved_at"`
+	
 	// Special instructions
 	Instructions    string         `json:"instructions"`
 	SpecialHandling bool           `json:"special_handling" gorm:"default:false"`
 	
+	// Cold chain, in °C; required for perishable cargo
+	MinTemperature            *float64 `json:"min_temperature"`
+	MaxTemperature            *float64 `json:"max_temperature"`
+	ExcursionThresholdMinutes int      `json:"excursion_threshold_minutes" gorm:"default:15"`
+	
+	// Dangerous goods; required for hazardous cargo
+	UNNumber           string       `json:"un_number"`
+	ProperShippingName string       `json:"proper_shipping_name"`
+	HazardClass        HazardClass  `json:"hazard_class"`
+	PackingGroup       PackingGroup `json:"packing_group"`
+	HazmatQuantity     float64      `json:"hazmat_quantity"`
+	HazmatQuantityUnit string       `json:"hazmat_quantity_unit"` // kg or L
+	EmergencyPhone     string       `json:"emergency_phone"`
+	
 	// Tracking
 	CargoEvents     []CargoEvent   `json:"cargo_events,omitempty"`
+	ShipmentLegs    []ShipmentLeg  `json:"shipment_legs,omitempty"`
+	Pieces          []CargoPiece   `json:"pieces,omitempty"`
 	
 	// Real-time tracking
 	CurrentLatitude  *float64      `json:"current_latitude"`
@@ -95,6 +151,17 @@ type Cargo struct {
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// TableName keeps the singular table name the schema has always used.
+func (Cargo) TableName() string {
+	return "cargo"
+}
+
+// AwaitingApproval reports whether the cargo was booked in the customer
+// portal and no moderator has approved it yet.
+func (c *Cargo) AwaitingApproval() bool {
+	return c.BookedBy != nil && c.BookingApprovedAt == nil
+}
+
 type CargoEvent struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	CargoID     uint           `json:"cargo_id" gorm:"not null"`
@@ -114,24 +181,32 @@ type CargoEvent struct {
 type CreateCargoRequest struct {
 	Title           string        `json:"title" binding:"required"`
 	Description     string        `json:"description"`
-	Type            CargoType     `json:"type"`
-	Priority        CargoPriority `json:"priority"`
+	Type            CargoType     `json:"type" binding:"omitempty,oneof=general fragile hazardous perishable liquid oversized"`
+	Priority        CargoPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
 	Weight          float64       `json:"weight" binding:"required"`
 	Volume          float64       `json:"volume"`
 	Value           float64       `json:"value"`
 	Currency        string        `json:"currency"`
+	Price           *float64      `json:"price" binding:"omitempty,min=0"` // overrides the quoted price
+	CustomerID      *uint         `json:"customer_id"` // defaults to the customer of the origin address
 	
-	OriginAddress   string        `json:"origin_address" binding:"required"`
+	// Saved addresses fill in whatever location and contact fields are left out
+	OriginAddressID *uint         `json:"origin_address_id"`
+	OriginAddress   string        `json:"origin_address" binding:"required_without=OriginAddressID"`
 	OriginLatitude  *float64      `json:"origin_latitude"`
 	OriginLongitude *float64      `json:"origin_longitude"`
 	OriginContact   string        `json:"origin_contact"`
 	OriginPhone     string        `json:"origin_phone"`
 	
-	DestinationAddress   string   `json:"destination_address" binding:"required"`
+	DestinationAddressID *uint    `json:"destination_address_id"`
+	DestinationAddress   string   `json:"destination_address" binding:"required_without=DestinationAddressID"`
+	DestinationPostcode  string   `json:"destination_postcode"`
 	DestinationLatitude  *float64 `json:"destination_latitude"`
 	DestinationLongitude *float64 `json:"destination_longitude"`
 	DestinationContact   string   `json:"destination_contact"`
 	DestinationPhone     string   `json:"destination_phone"`
+	DestinationEmail     string   `json:"destination_email" binding:"omitempty,email"`
+	RecipientLocale      string   `json:"recipient_locale" binding:"omitempty,min=2,max=10"`
 	
 	PickupTime        *time.Time `json:"pickup_time"`
 	DeliveryTime      *time.Time `json:"delivery_time"`
@@ -139,6 +214,18 @@ type CreateCargoRequest struct {
 	
 	Instructions      string     `json:"instructions"`
 	SpecialHandling   bool       `json:"special_handling"`
+	
+	MinTemperature            *float64 `json:"min_temperature"`
+	MaxTemperature            *float64 `json:"max_temperature"`
+	ExcursionThresholdMinutes *int     `json:"excursion_threshold_minutes" binding:"omitempty,min=1"`
+	
+	UNNumber           string       `json:"un_number"`
+	ProperShippingName string       `json:"proper_shipping_name"`
+	HazardClass        HazardClass  `json:"hazard_class"`
+	PackingGroup       PackingGroup `json:"packing_group" binding:"omitempty,oneof=I II III"`
+	HazmatQuantity     float64      `json:"hazmat_quantity"`
+	HazmatQuantityUnit string       `json:"hazmat_quantity_unit" binding:"omitempty,oneof=kg L"`
+	EmergencyPhone     string       `json:"emergency_phone"`
 }
 
 type UpdateCargoRequest struct {
@@ -151,18 +238,26 @@ type UpdateCargoRequest struct {
 	Volume          float64       `json:"volume"`
 	Value           float64       `json:"value"`
 	Currency        string        `json:"currency"`
+	Price           *float64      `json:"price" binding:"omitempty,min=0"` // overrides the quoted price
+	CustomerID      *uint         `json:"customer_id"`
 	
+	// A new saved address replaces the location and contact fields not given
+	OriginAddressID *uint         `json:"origin_address_id"`
 	OriginAddress   string        `json:"origin_address"`
 	OriginLatitude  *float64      `json:"origin_latitude"`
 	OriginLongitude *float64      `json:"origin_longitude"`
 	OriginContact   string        `json:"origin_contact"`
 	OriginPhone     string        `json:"origin_phone"`
 	
+	DestinationAddressID *uint    `json:"destination_address_id"`
 	DestinationAddress   string   `json:"destination_address"`
+	DestinationPostcode  string   `json:"destination_postcode"`
 	DestinationLatitude  *float64 `json:"destination_latitude"`
 	DestinationLongitude *float64 `json:"destination_longitude"`
 	DestinationContact   string   `json:"destination_contact"`
 	DestinationPhone     string   `json:"destination_phone"`
+	DestinationEmail     string   `json:"destination_email" binding:"omitempty,email"`
+	RecipientLocale      string   `json:"recipient_locale" binding:"omitempty,min=2,max=10"`
 	
 	PickupTime        *time.Time `json:"pickup_time"`
 	DeliveryTime      *time.Time `json:"delivery_time"`
@@ -170,19 +265,39 @@ type UpdateCargoRequest struct {
 	
 	Instructions      string     `json:"instructions"`
 	SpecialHandling   bool       `json:"special_handling"`
+	
+	MinTemperature            *float64 `json:"min_temperature"`
+	MaxTemperature            *float64 `json:"max_temperature"`
+	ExcursionThresholdMinutes *int     `json:"excursion_threshold_minutes" binding:"omitempty,min=1"`
+	
+	UNNumber           string       `json:"un_number"`
+	ProperShippingName string       `json:"proper_shipping_name"`
+	HazardClass        HazardClass  `json:"hazard_class"`
+	PackingGroup       PackingGroup `json:"packing_group" binding:"omitempty,oneof=I II III"`
+	HazmatQuantity     float64      `json:"hazmat_quantity"`
+	HazmatQuantityUnit string       `json:"hazmat_quantity_unit" binding:"omitempty,oneof=kg L"`
+	EmergencyPhone     string       `json:"emergency_phone"`
 }
 
 type AssignCargoRequest struct {
 	TruckID uint `json:"truck_id" binding:"required"`
 }
 
+type RejectBookingRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
 type CargoFilter struct {
 	Status    CargoStatus   `form:"status"`
 	Type      CargoType     `form:"type"`
 	Priority  CargoPriority `form:"priority"`
 	TruckID   *uint         `form:"truck_id"`
+	CustomerID *uint        `form:"customer_id"`
 	Assigned  *bool         `form:"assigned"`
+	AwaitingApproval *bool  `form:"awaiting_approval"` // portal bookings not yet approved
 	Search    string        `form:"search"`
+	CreatedFrom *time.Time  `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo   *time.Time  `form:"created_to" time_format:"2006-01-02"`
 	Page      int           `form:"page,default=1"`
 	Limit     int           `form:"limit,default=10"`
 }
@@ -211,4 +326,6 @@ type CargoTrackingResponse struct {
 	LastUpdate     *time.Time        `json:"last_update"`
 	RecentEvents   []CargoEvent      `json:"recent_events"`
 	TruckLocation  *TruckLocation    `json:"truck_location,omitempty"`
+	ProofOfDelivery *PODSummary      `json:"proof_of_delivery,omitempty"`
+	Journey        []JourneyLeg     `json:"journey,omitempty"`
 }
\ No newline at end of file
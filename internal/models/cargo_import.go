@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+type CargoImportStatus string
+
+const (
+	CargoImportStatusPending    CargoImportStatus = "pending"
+	CargoImportStatusProcessing CargoImportStatus = "processing"
+	CargoImportStatusCompleted  CargoImportStatus = "completed"
+	CargoImportStatusFailed     CargoImportStatus = "failed"
+)
+
+type CargoImportJob struct {
+	ID            uint                  `json:"id" gorm:"primaryKey"`
+	CompanyID     uint                  `json:"company_id" gorm:"not null;index"`
+	CreatedBy     uint                  `json:"created_by" gorm:"not null"`
+	CreatedByUser *User                 `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy"`
+	FileName      string                `json:"file_name" gorm:"not null"`
+	DryRun        bool                  `json:"dry_run" gorm:"default:false"`
+	Status        CargoImportStatus     `json:"status" gorm:"default:'pending'"`
+	TotalRows     int                   `json:"total_rows"`
+	ProcessedRows int                   `json:"processed_rows"`
+	ValidRows     int                   `json:"valid_rows"`
+	CreatedRows   int                   `json:"created_rows"`
+	Error         string                `json:"error,omitempty"` // set when the job as a whole failed
+	Errors        []CargoImportRowError `json:"errors,omitempty" gorm:"foreignKey:JobID"`
+	StartedAt     *time.Time            `json:"started_at"`
+	CompletedAt   *time.Time            `json:"completed_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+
+	// Preview holds the parsed valid rows of a dry run; it is not stored.
+	Preview []CreateCargoRequest `json:"preview,omitempty" gorm:"-"`
+}
+
+type CargoImportRowError struct {
+	ID      uint   `json:"-" gorm:"primaryKey"`
+	JobID   uint   `json:"-" gorm:"not null;index"`
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type CargoImportRequest struct {
+	// Mapping is a JSON object from spreadsheet column header to cargo field,
+	// e.g. {"Consignee": "destination_contact"}. Unmapped headers are matched
+	// against the field names directly.
+	Mapping string `form:"mapping"`
+	DryRun  bool   `form:"dry_run"`
+	Sheet   string `form:"sheet"`
+}
+
+type CargoImportFilter struct {
+	Page  int `form:"page,default=1"`
+	Limit int `form:"limit,default=10"`
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CargoPieceStatus string
+type HandlingUnitType string
+type HandlingUnitStatus string
+
+const (
+	CargoPieceStatusPending   CargoPieceStatus = "pending"
+	CargoPieceStatusPickedUp  CargoPieceStatus = "picked_up"
+	CargoPieceStatusDelivered CargoPieceStatus = "delivered"
+)
+
+const (
+	HandlingUnitTypePallet    HandlingUnitType = "pallet"
+	HandlingUnitTypeCage      HandlingUnitType = "cage"
+	HandlingUnitTypeBox       HandlingUnitType = "box"
+	HandlingUnitTypeContainer HandlingUnitType = "container"
+)
+
+const (
+	HandlingUnitStatusOpen   HandlingUnitStatus = "open"
+	HandlingUnitStatusClosed HandlingUnitStatus = "closed"
+)
+
+// CargoPiece is one physical piece of a cargo shipment. When a cargo has
+// pieces its weight and volume are the sum of theirs and its status follows
+// theirs.
+type CargoPiece struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	CompanyID      uint             `json:"company_id" gorm:"not null"`
+	CargoID        uint             `json:"cargo_id" gorm:"not null;index"`
+	PieceNumber    int              `json:"piece_number" gorm:"not null"`
+	Barcode        string           `json:"barcode" gorm:"uniqueIndex;not null"`
+	Description    string           `json:"description"`
+	Weight         float64          `json:"weight"` // in kg
+	Volume         float64          `json:"volume"` // in cubic meters
+	Status         CargoPieceStatus `json:"status" gorm:"default:'pending'"`
+	HandlingUnitID *uint            `json:"handling_unit_id"`
+	PickedUpAt     *time.Time       `json:"picked_up_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// HandlingUnit consolidates pieces, possibly of several cargos, onto one
+// pallet, cage or container with its own barcode.
+type HandlingUnit struct {
+	ID         uint               `json:"id" gorm:"primaryKey"`
+	CompanyID  uint               `json:"company_id" gorm:"not null"`
+	Barcode    string             `json:"barcode" gorm:"uniqueIndex"`
+	Type       HandlingUnitType   `json:"type" gorm:"default:'pallet'"`
+	Label      string             `json:"label"`
+	Status     HandlingUnitStatus `json:"status" gorm:"default:'open'"`
+	TareWeight float64            `json:"tare_weight"` // empty unit, in kg
+	Weight     float64            `json:"weight"`      // tare plus pieces, in kg
+	Volume     float64            `json:"volume"`      // sum of pieces, in cubic meters
+	PieceCount int                `json:"piece_count"`
+	Pieces     []CargoPiece       `json:"pieces,omitempty"`
+	CreatedBy  uint               `json:"created_by" gorm:"not null"`
+	ClosedAt   *time.Time         `json:"closed_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt     `json:"-" gorm:"index"`
+}
+
+type CargoPieceInput struct {
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight" binding:"gte=0"`
+	Volume      float64 `json:"volume" binding:"gte=0"`
+}
+
+type CreateCargoPiecesRequest struct {
+	Pieces []CargoPieceInput `json:"pieces" binding:"required,min=1,max=500,dive"`
+}
+
+type SplitCargoRequest struct {
+	Count int `json:"count" binding:"required,min=2,max=500"`
+}
+
+type ScanPieceRequest struct {
+	Barcode   string   `json:"barcode" binding:"required"`
+	Event     string   `json:"event" binding:"required,oneof=pickup delivery"`
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+type ScanPieceResponse struct {
+	Pieces []CargoPiece `json:"pieces"`
+	Cargos []Cargo      `json:"cargos"`
+}
+
+type CreateHandlingUnitRequest struct {
+	Type       HandlingUnitType `json:"type" binding:"omitempty,oneof=pallet cage box container"`
+	Label      string           `json:"label"`
+	TareWeight float64          `json:"tare_weight" binding:"gte=0"`
+}
+
+type AddHandlingUnitPiecesRequest struct {
+	Barcodes []string `json:"barcodes" binding:"required,min=1,max=500"`
+}
+
+type HandlingUnitFilter struct {
+	Status HandlingUnitStatus `form:"status"`
+	Page   int                `form:"page,default=1"`
+	Limit  int                `form:"limit,default=10"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer is an account in a company's address book: the shipper a cargo is
+// booked and billed for, or the consignee of a delivery address.
+type Customer struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	CompanyID           uint              `json:"company_id" gorm:"not null;index"`
+	Company             Company           `json:"company,omitempty"`
+	Name                string            `json:"name" gorm:"not null"`
+	Email               string            `json:"email"`
+	Phone               string            `json:"phone"`
+	BillingAddress      string            `json:"billing_address"`
+	Notes               string            `json:"notes"`
+	IsActive            bool              `json:"is_active" gorm:"default:true"`
+	DeliveryWindowStart string            `json:"delivery_window_start"` // preferred hours as HH:MM unless an address sets its own
+	DeliveryWindowEnd   string            `json:"delivery_window_end"`
+	Contacts            []CustomerContact `json:"contacts,omitempty"`
+	Addresses           []CustomerAddress `json:"addresses,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+type CustomerContact struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CustomerID uint      `json:"customer_id" gorm:"not null;index"`
+	Name       string    `json:"name" gorm:"not null"`
+	Role       string    `json:"role"`
+	Email      string    `json:"email"`
+	Phone      string    `json:"phone"`
+	IsPrimary  bool      `json:"is_primary" gorm:"default:false"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CustomerAddress is a saved pickup or delivery location of a customer.
+type CustomerAddress struct {
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	CompanyID           uint           `json:"company_id" gorm:"not null;index"`
+	CustomerID          uint           `json:"customer_id" gorm:"not null;index"`
+	Customer            *Customer      `json:"customer,omitempty"`
+	Label               string         `json:"label"` // e.g. "Main warehouse"
+	Address             string         `json:"address" gorm:"not null"`
+	Latitude            *float64       `json:"latitude"`
+	Longitude           *float64       `json:"longitude"`
+	ContactName         string         `json:"contact_name"`
+	ContactPhone        string         `json:"contact_phone"`
+	DeliveryWindowStart string         `json:"delivery_window_start"`
+	DeliveryWindowEnd   string         `json:"delivery_window_end"`
+	Instructions        string         `json:"instructions"`
+	IsDefault           bool           `json:"is_default" gorm:"default:false"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// DeliveryWindow returns the address's delivery hours, falling back to the
+// customer's, as "HH:MM-HH:MM", or "" when neither has one.
+func (a *CustomerAddress) DeliveryWindow(customer *Customer) string {
+	start, end := a.DeliveryWindowStart, a.DeliveryWindowEnd
+	if start == "" && customer != nil {
+		start, end = customer.DeliveryWindowStart, customer.DeliveryWindowEnd
+	}
+	if start == "" {
+		return ""
+	}
+	return start + "-" + end
+}
+
+type CustomerFilter struct {
+	Search   string `form:"search"`
+	IsActive *bool  `form:"is_active"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	Limit    int    `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// AddressSearchQuery drives address autocomplete over the address book.
+type AddressSearchQuery struct {
+	Query      string `form:"q" binding:"required"`
+	CustomerID *uint  `form:"customer_id"`
+	Limit      int    `form:"limit,default=10" binding:"min=1,max=25"`
+}
+
+type CreateCustomerRequest struct {
+	Name                string                         `json:"name" binding:"required"`
+	Email               string                         `json:"email" binding:"omitempty,email"`
+	Phone               string                         `json:"phone"`
+	BillingAddress      string                         `json:"billing_address"`
+	Notes               string                         `json:"notes"`
+	DeliveryWindowStart string                         `json:"delivery_window_start"`
+	DeliveryWindowEnd   string                         `json:"delivery_window_end"`
+	Contacts            []CreateCustomerContactRequest `json:"contacts" binding:"dive"`
+	Addresses           []CreateCustomerAddressRequest `json:"addresses" binding:"dive"`
+}
+
+type UpdateCustomerRequest struct {
+	Name                string  `json:"name"`
+	Email               string  `json:"email" binding:"omitempty,email"`
+	Phone               string  `json:"phone"`
+	BillingAddress      string  `json:"billing_address"`
+	Notes               *string `json:"notes"`
+	DeliveryWindowStart *string `json:"delivery_window_start"` // "" clears the window
+	DeliveryWindowEnd   *string `json:"delivery_window_end"`
+	IsActive            *bool   `json:"is_active"`
+}
+
+type CreateCustomerContactRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Role      string `json:"role"`
+	Email     string `json:"email" binding:"omitempty,email"`
+	Phone     string `json:"phone"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type UpdateCustomerContactRequest struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Email     string `json:"email" binding:"omitempty,email"`
+	Phone     string `json:"phone"`
+	IsPrimary *bool  `json:"is_primary"`
+}
+
+type CreateCustomerAddressRequest struct {
+	Label               string   `json:"label"`
+	Address             string   `json:"address" binding:"required"`
+	Latitude            *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude           *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+	ContactName         string   `json:"contact_name"`
+	ContactPhone        string   `json:"contact_phone"`
+	DeliveryWindowStart string   `json:"delivery_window_start"`
+	DeliveryWindowEnd   string   `json:"delivery_window_end"`
+	Instructions        string   `json:"instructions"`
+	IsDefault           bool     `json:"is_default"`
+}
+
+type UpdateCustomerAddressRequest struct {
+	Label               string   `json:"label"`
+	Address             string   `json:"address"`
+	Latitude            *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude           *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+	ContactName         string   `json:"contact_name"`
+	ContactPhone        string   `json:"contact_phone"`
+	DeliveryWindowStart *string  `json:"delivery_window_start"`
+	DeliveryWindowEnd   *string  `json:"delivery_window_end"`
+	Instructions        *string  `json:"instructions"`
+	IsDefault           *bool    `json:"is_default"`
+}
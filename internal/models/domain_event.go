@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types recorded in the outbox.
+const (
+	DomainEventCargoCreated       = "cargo.created"
+	DomainEventCargoEventRecorded = "cargo.event_recorded"
+	DomainEventRouteApproved      = "route.approved"
+	DomainEventTruckApproved      = "truck.approved"
+	DomainEventTruckLocation      = "truck.location"
+	DomainEventRequestCreated     = "request.created"
+	DomainEventRequestAccepted    = "request.accepted"
+	DomainEventRequestTerminated  = "request.terminated"
+)
+
+// DomainEvent is a row of the transactional outbox. It is written in the same
+// transaction as the change it describes and relayed afterwards to the jobs
+// of its subscribers, so a committed change is never left unannounced.
+type DomainEvent struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	CompanyID     uint            `json:"company_id" gorm:"not null"`
+	Type          string          `json:"type" gorm:"not null"`
+	AggregateType string          `json:"aggregate_type" gorm:"not null"` // e.g. cargo, route
+	AggregateID   uint            `json:"aggregate_id" gorm:"not null"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at" gorm:"index"` // when its jobs were queued
+}
+
+// Decode unmarshals the event's payload into v.
+func (e *DomainEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// NewDomainEvent returns an event about one aggregate with payload marshalled
+// to JSON.
+func NewDomainEvent(companyID uint, eventType string, aggregateType string, aggregateID uint, payload interface{}) (*DomainEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &DomainEvent{
+		CompanyID:     companyID,
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+	}, nil
+}
+
+// CargoEventRecorded is the payload of cargo.event_recorded.
+type CargoEventRecorded struct {
+	CargoID      uint   `json:"cargo_id"`
+	CargoEventID uint   `json:"cargo_event_id"`
+	EventType    string `json:"event_type"`
+}
+
+// CargoCreated is the payload of cargo.created.
+type CargoCreated struct {
+	CargoID uint `json:"cargo_id"`
+}
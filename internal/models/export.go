@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatXLSX   ExportFormat = "xlsx"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+type ExportRequest struct {
+	Format ExportFormat `form:"format,default=csv" binding:"oneof=csv xlsx ndjson"`
+	// Columns is a comma separated list of field names; all columns are
+	// exported when it is empty.
+	Columns string `form:"columns"`
+}
+
+type VisitFilter struct {
+	Status      VisitStatus `form:"status"`
+	TruckID     *uint       `form:"truck_id"`
+	DriverID    *uint       `form:"driver_id"`
+	CustomerID  *uint       `form:"customer_id"`
+	Search      string      `form:"search"`
+	CreatedFrom *time.Time  `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo   *time.Time  `form:"created_to" time_format:"2006-01-02"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// HazardClass is a UN dangerous goods class or division, e.g. "3" for
+// flammable liquids or "2.1" for flammable gases.
+type HazardClass string
+type PackingGroup string
+
+const (
+	HazardClassExplosives               HazardClass = "1"
+	HazardClassFlammableGas             HazardClass = "2.1"
+	HazardClassNonFlammableGas          HazardClass = "2.2"
+	HazardClassToxicGas                 HazardClass = "2.3"
+	HazardClassFlammableLiquid          HazardClass = "3"
+	HazardClassFlammableSolid           HazardClass = "4.1"
+	HazardClassSpontaneouslyCombustible HazardClass = "4.2"
+	HazardClassDangerousWhenWet         HazardClass = "4.3"
+	HazardClassOxidizer                 HazardClass = "5.1"
+	HazardClassOrganicPeroxide          HazardClass = "5.2"
+	HazardClassToxic                    HazardClass = "6.1"
+	HazardClassInfectious               HazardClass = "6.2"
+	HazardClassRadioactive              HazardClass = "7"
+	HazardClassCorrosive                HazardClass = "8"
+	HazardClassMiscellaneous            HazardClass = "9"
+)
+
+const (
+	PackingGroupI   PackingGroup = "I"
+	PackingGroupII  PackingGroup = "II"
+	PackingGroupIII PackingGroup = "III"
+)
+
+// hazardSegregation lists, for each class, the classes it must not be loaded
+// with on the same vehicle. It is a simplified form of the 49 CFR 177.848
+// segregation table: pairs that may travel together only when separated are
+// treated as incompatible. Explosives divisions all segregate as class 1.
+var hazardSegregation = map[HazardClass][]HazardClass{
+	HazardClassExplosives: {
+		HazardClassFlammableGas, HazardClassNonFlammableGas, HazardClassToxicGas, HazardClassFlammableLiquid,
+		HazardClassFlammableSolid, HazardClassSpontaneouslyCombustible, HazardClassDangerousWhenWet, HazardClassOxidizer,
+		HazardClassOrganicPeroxide, HazardClassToxic, HazardClassInfectious, HazardClassRadioactive, HazardClassCorrosive,
+	},
+	HazardClassFlammableGas: {HazardClassToxicGas},
+	HazardClassToxicGas: {
+		HazardClassFlammableLiquid, HazardClassFlammableSolid, HazardClassSpontaneouslyCombustible, HazardClassDangerousWhenWet,
+		HazardClassOxidizer, HazardClassOrganicPeroxide, HazardClassCorrosive,
+	},
+	HazardClassFlammableLiquid:          {HazardClassOxidizer, HazardClassOrganicPeroxide},
+	HazardClassFlammableSolid:           {HazardClassOrganicPeroxide},
+	HazardClassSpontaneouslyCombustible: {HazardClassOxidizer, HazardClassCorrosive},
+	HazardClassDangerousWhenWet:         {HazardClassOxidizer, HazardClassCorrosive},
+	HazardClassOxidizer:                 {HazardClassCorrosive},
+	HazardClassInfectious:               {HazardClassToxic},
+}
+
+// Valid reports whether c is a known class or division.
+func (c HazardClass) Valid() bool {
+	switch c {
+	case "1.1", "1.2", "1.3", "1.4", "1.5", "1.6":
+		return true
+	}
+	switch c {
+	case HazardClassExplosives, HazardClassFlammableGas, HazardClassNonFlammableGas, HazardClassToxicGas,
+		HazardClassFlammableLiquid, HazardClassFlammableSolid, HazardClassSpontaneouslyCombustible, HazardClassDangerousWhenWet,
+		HazardClassOxidizer, HazardClassOrganicPeroxide, HazardClassToxic, HazardClassInfectious,
+		HazardClassRadioactive, HazardClassCorrosive, HazardClassMiscellaneous:
+		return true
+	}
+	return false
+}
+
+// segregationClass maps explosives divisions such as "1.4" to class 1.
+func (c HazardClass) segregationClass() HazardClass {
+	if strings.HasPrefix(string(c), "1.") {
+		return HazardClassExplosives
+	}
+	return c
+}
+
+// SegregatedFrom reports whether c and other must not share a vehicle.
+func (c HazardClass) SegregatedFrom(other HazardClass) bool {
+	a, b := c.segregationClass(), other.segregationClass()
+	for _, class := range hazardSegregation[a] {
+		if class == b {
+			return true
+		}
+	}
+	for _, class := range hazardSegregation[b] {
+		if class == a {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesPackingGroup reports whether goods of class c are assigned a packing
+// group; explosives, gases and radioactive material are not.
+func (c HazardClass) UsesPackingGroup() bool {
+	switch c.segregationClass() {
+	case HazardClassExplosives, HazardClassFlammableGas, HazardClassNonFlammableGas, HazardClassToxicGas, HazardClassRadioactive:
+		return false
+	}
+	return true
+}
+
+// IsDangerousGoods reports whether the cargo is subject to hazmat rules.
+func (c *Cargo) IsDangerousGoods() bool {
+	return c.Type == CargoTypeHazardous || c.HazardClass != ""
+}
+
+// HazmatCertifiedAt reports whether the user holds a hazmat certification
+// valid at t.
+func (u *User) HazmatCertifiedAt(t time.Time) bool {
+	return u.HazmatCertificationNumber != "" && u.HazmatCertifiedUntil != nil && !u.HazmatCertifiedUntil.Before(t)
+}
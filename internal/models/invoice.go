@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+)
+
+type InvoiceStatus string
+type InvoiceType string
+
+const (
+	InvoiceStatusDraft  InvoiceStatus = "draft"
+	InvoiceStatusIssued InvoiceStatus = "issued"
+	InvoiceStatusPaid   InvoiceStatus = "paid"
+	InvoiceStatusVoid   InvoiceStatus = "void"
+)
+
+const (
+	InvoiceTypeInvoice    InvoiceType = "invoice"
+	InvoiceTypeCreditNote InvoiceType = "credit_note"
+)
+
+// InvoiceSettings holds a company's tax rule, payment terms and the number
+// sequences of its invoices and credit notes. Numbers are composed as
+// <prefix>-<zero-padded sequence> and assigned when a document is issued, so
+// drafts that are voided leave no gaps.
+type InvoiceSettings struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	CompanyID            uint      `json:"company_id" gorm:"uniqueIndex;not null"`
+	TaxName              string    `json:"tax_name" gorm:"default:'VAT'"`
+	TaxRatePercent       float64   `json:"tax_rate_percent"`
+	PaymentTermsDays     int       `json:"payment_terms_days" gorm:"default:30"`
+	InvoicePrefix        string    `json:"invoice_prefix" gorm:"default:'INV'"`
+	CreditNotePrefix     string    `json:"credit_note_prefix" gorm:"default:'CN'"`
+	NextInvoiceNumber    int64     `json:"next_invoice_number" gorm:"not null;default:1"`
+	NextCreditNoteNumber int64     `json:"next_credit_note_number" gorm:"not null;default:1"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// Invoice bills a customer for the cargo delivered in a period. A credit note
+// is an Invoice of type credit_note referring to the invoice it credits; its
+// amounts are positive and subtract from what the customer owes.
+type Invoice struct {
+	ID                uint          `json:"id" gorm:"primaryKey"`
+	CompanyID         uint          `json:"company_id" gorm:"not null;index"`
+	Company           Company       `json:"company,omitempty"`
+	Type              InvoiceType   `json:"type" gorm:"default:'invoice'"`
+	Number            *string       `json:"number"` // assigned on issue
+	Status            InvoiceStatus `json:"status" gorm:"default:'draft'"`
+	CustomerID        *uint         `json:"customer_id" gorm:"index"`
+	CustomerName      string        `json:"customer_name"`
+	CustomerAddress   string        `json:"customer_address"` // billing address when issued
+	PeriodStart       time.Time     `json:"period_start"`
+	PeriodEnd         time.Time     `json:"period_end"`
+	Currency          string        `json:"currency"`
+	Subtotal          float64       `json:"subtotal"`
+	TaxName           string        `json:"tax_name"`
+	TaxRatePercent    float64       `json:"tax_rate_percent"`
+	TaxAmount         float64       `json:"tax_amount"`
+	Total             float64       `json:"total"`
+	CreditedInvoiceID *uint         `json:"credited_invoice_id"`
+	CreditedInvoice   *Invoice      `json:"credited_invoice,omitempty" gorm:"foreignKey:CreditedInvoiceID"`
+	Reason            string        `json:"reason"` // why a credit note was raised
+	Notes             string        `json:"notes"`
+	Lines             []InvoiceLine `json:"lines,omitempty"`
+	IssuedAt          *time.Time    `json:"issued_at"`
+	DueDate           *time.Time    `json:"due_date"`
+	PaidAt            *time.Time    `json:"paid_at"`
+	VoidedAt          *time.Time    `json:"voided_at"`
+	CreatedBy         uint          `json:"created_by"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+type InvoiceLine struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	InvoiceID   uint       `json:"invoice_id" gorm:"not null;index"`
+	CargoID     *uint      `json:"cargo_id" gorm:"index"`
+	Cargo       *Cargo     `json:"cargo,omitempty"`
+	Description string     `json:"description"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	Amount      float64    `json:"amount"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// InvoiceExportRow is one invoice line as exported for accounting. Amounts of
+// credit notes are negative.
+type InvoiceExportRow struct {
+	Number         string     `json:"number"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	CustomerName   string     `json:"customer_name"`
+	IssuedAt       *time.Time `json:"issued_at"`
+	DueDate        *time.Time `json:"due_date"`
+	CreditedNumber string     `json:"credited_number"`
+	TrackingNumber string     `json:"tracking_number"`
+	Description    string     `json:"description"`
+	Currency       string     `json:"currency"`
+	NetAmount      float64    `json:"net_amount"`
+	TaxRatePercent float64    `json:"tax_rate_percent"`
+	TaxAmount      float64    `json:"tax_amount"`
+	GrossAmount    float64    `json:"gross_amount"`
+}
+
+type InvoiceFilter struct {
+	Status        InvoiceStatus `form:"status"`
+	Type          InvoiceType   `form:"type"`
+	CustomerID    *uint         `form:"customer_id"`
+	CustomerName  string        `form:"customer_name"`
+	ExcludeDrafts bool          `form:"-"` // set for the customer portal
+	IssuedFrom    *time.Time    `form:"issued_from" time_format:"2006-01-02"`
+	IssuedTo      *time.Time    `form:"issued_to" time_format:"2006-01-02"`
+	Page          int           `form:"page,default=1" binding:"min=1"`
+	Limit         int           `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// GenerateInvoicesRequest bills the delivered, priced and not yet invoiced
+// cargo of a period, one draft invoice per customer and currency. Dates are
+// inclusive.
+type GenerateInvoicesRequest struct {
+	PeriodStart  time.Time `json:"period_start" binding:"required"`
+	PeriodEnd    time.Time `json:"period_end" binding:"required"`
+	CustomerID   *uint     `json:"customer_id"`   // only bill this customer
+	CustomerName string    `json:"customer_name"` // only bill cargo without a customer account shipped by this contact
+}
+
+type GenerateInvoicesResponse struct {
+	Invoices []Invoice `json:"invoices"`
+	// Unpriced lists delivered cargo left out because it has no price.
+	Unpriced []string `json:"unpriced"`
+}
+
+type CreditNoteLineRequest struct {
+	CargoID uint     `json:"cargo_id" binding:"required"`
+	Amount  *float64 `json:"amount" binding:"omitempty,gt=0"` // defaults to the rest of the invoiced amount
+}
+
+type CreateCreditNoteRequest struct {
+	Reason string                  `json:"reason" binding:"required"`
+	Lines  []CreditNoteLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type UpdateInvoiceSettingsRequest struct {
+	TaxName          string   `json:"tax_name"`
+	TaxRatePercent   *float64 `json:"tax_rate_percent" binding:"omitempty,min=0,max=100"`
+	PaymentTermsDays *int     `json:"payment_terms_days" binding:"omitempty,min=0"`
+	InvoicePrefix    *string  `json:"invoice_prefix" binding:"omitempty,max=8"`
+	CreditNotePrefix *string  `json:"credit_note_prefix" binding:"omitempty,max=8"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued" // waiting for its first or next attempt
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusDead      JobStatus = "dead" // out of attempts; shown in the dead-letter view
+)
+
+// Job types handled by the background runner.
+const (
+	JobTypeWebhookPublish   = "webhook.publish"          // turns a domain event into webhook deliveries
+	JobTypeWebhookDeliver   = "webhook.deliver"          // sends one webhook delivery
+	JobTypeNotifyCargoEvent = "notification.cargo_event" // tells the consignee about a cargo event
+	JobTypeCargoImport      = "cargo_import.process"     // processes a large cargo import
+)
+
+// Job is a unit of background work in the Postgres-backed queue. Workers
+// claim due jobs with SKIP LOCKED, so any number of replicas can share the
+// queue without running a job twice.
+type Job struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	CompanyID   *uint           `json:"company_id" gorm:"index"`
+	Type        string          `json:"type" gorm:"not null;index"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status      JobStatus       `json:"status" gorm:"not null;default:'queued'"`
+	Attempts    int             `json:"attempts" gorm:"default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"default:8"`
+	RunAt       time.Time       `json:"run_at" gorm:"not null"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	LockedBy    string          `json:"locked_by"`
+	LastError   string          `json:"last_error,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Decode unmarshals the job's payload into v.
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// NewJob returns a job of the type due now, with payload marshalled to JSON.
+func NewJob(companyID *uint, jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Job{
+		CompanyID: companyID,
+		Type:      jobType,
+		Payload:   data,
+		Status:    JobStatusQueued,
+		RunAt:     time.Now(),
+	}, nil
+}
+
+type JobFilter struct {
+	Status JobStatus `form:"status"`
+	Type   string    `form:"type"`
+	Page   int       `form:"page,default=1" binding:"min=1"`
+	Limit  int       `form:"limit,default=20" binding:"min=1,max=100"`
+}
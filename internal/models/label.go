@@ -0,0 +1,19 @@
+package models
+
+type LabelFormat string
+type LabelSize string
+
+const (
+	LabelFormatPDF LabelFormat = "pdf"
+	LabelFormatZPL LabelFormat = "zpl"
+)
+
+const (
+	LabelSizeA6        LabelSize = "a6"
+	LabelSizeFourBySix LabelSize = "4x6"
+)
+
+type LabelRequest struct {
+	Format LabelFormat `form:"format,default=pdf" binding:"oneof=pdf zpl"`
+	Size   LabelSize   `form:"size,default=4x6" binding:"oneof=a6 4x6"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type LocationDownsampleMode string
+
+const (
+	LocationDownsampleMinute LocationDownsampleMode = "minute" // first point of every minute per truck
+	LocationDownsampleTrip   LocationDownsampleMode = "trip"   // first and last point of every trip
+	LocationDownsampleNone   LocationDownsampleMode = "none"
+)
+
+// LocationRetentionPolicy decides how long a company's truck locations are
+// kept at which resolution: raw for RawDays, then downsampled, and from
+// ArchiveAfterDays on only in compressed archive files in the blob store.
+type LocationRetentionPolicy struct {
+	ID                 uint                   `json:"id" gorm:"primaryKey"`
+	CompanyID          uint                   `json:"company_id" gorm:"uniqueIndex;not null"`
+	RawDays            int                    `json:"raw_days" gorm:"default:30"`
+	DownsampleMode     LocationDownsampleMode `json:"downsample_mode" gorm:"default:'minute'"`
+	ArchiveAfterDays   int                    `json:"archive_after_days" gorm:"default:365"` // 0 keeps points in the database
+	DownsampledThrough *time.Time             `json:"downsampled_through"`                   // points before this have been downsampled
+	LastAppliedAt      *time.Time             `json:"last_applied_at"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+// LocationArchive is one day of a company's truck locations moved to the blob
+// store as gzip-compressed NDJSON, one LocationArchiveRecord per line.
+type LocationArchive struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CompanyID  uint      `json:"company_id" gorm:"not null;uniqueIndex:idx_location_archives_company_day"`
+	Day        time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_location_archives_company_day"`
+	StorageKey string    `json:"-" gorm:"not null"`
+	Format     string    `json:"format" gorm:"not null"`
+	Points     int64     `json:"points"`
+	SizeBytes  int64     `json:"size_bytes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LocationArchiveRecord is a line of a location archive.
+type LocationArchiveRecord struct {
+	TruckID   uint      `json:"truck_id"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Speed     float64   `json:"speed"`
+	Heading   float64   `json:"heading"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LocationStorage is how much location data a company keeps, in the database
+// and archived.
+type LocationStorage struct {
+	CompanyID      uint       `json:"company_id"`
+	CompanyName    string     `json:"company_name"`
+	Points         int64      `json:"points"`
+	Bytes          int64      `json:"bytes"` // approximate size of the rows
+	OldestPoint    *time.Time `json:"oldest_point"`
+	ArchiveFiles   int64      `json:"archive_files"`
+	ArchivedPoints int64      `json:"archived_points"`
+	ArchivedBytes  int64      `json:"archived_bytes"`
+}
+
+type UpdateLocationRetentionPolicyRequest struct {
+	RawDays          *int                   `json:"raw_days" binding:"omitempty,min=1"`
+	DownsampleMode   LocationDownsampleMode `json:"downsample_mode" binding:"omitempty,oneof=minute trip none"`
+	ArchiveAfterDays *int                   `json:"archive_after_days" binding:"omitempty,min=0"`
+}
+
+type LocationArchiveFilter struct {
+	Page  int `form:"page,default=1" binding:"min=1"`
+	Limit int `form:"limit,default=20" binding:"min=1,max=100"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+)
+
+type NotificationChannel string
+type NotificationTrigger string
+type NotificationStatus string
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSMS   NotificationChannel = "sms"
+)
+
+// Shipment milestones the consignee is told about, raised by cargo events.
+const (
+	NotificationTriggerAssigned  NotificationTrigger = "cargo_assigned"
+	NotificationTriggerPickedUp  NotificationTrigger = "cargo_picked_up"
+	NotificationTriggerDelayed   NotificationTrigger = "cargo_delayed"
+	NotificationTriggerDelivered NotificationTrigger = "cargo_delivered"
+)
+
+const (
+	NotificationStatusSent     NotificationStatus = "sent"
+	NotificationStatusFailed   NotificationStatus = "failed"
+	NotificationStatusOptedOut NotificationStatus = "opted_out"
+)
+
+// NotificationSettings switches a company's consignee notifications per
+// channel and sets the locale used when a cargo has none.
+type NotificationSettings struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CompanyID     uint      `json:"company_id" gorm:"uniqueIndex;not null"`
+	EmailEnabled  bool      `json:"email_enabled" gorm:"default:true"`
+	SMSEnabled    bool      `json:"sms_enabled" gorm:"default:true"`
+	DefaultLocale string    `json:"default_locale" gorm:"default:'en'"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NotificationTemplate is a company's wording of one milestone message in one
+// locale. Subject and body are Go text/template sources rendered with
+// NotificationData; SMS templates have no subject.
+type NotificationTemplate struct {
+	ID        uint                `json:"id" gorm:"primaryKey"`
+	CompanyID uint                `json:"company_id" gorm:"not null;uniqueIndex:idx_notification_template"`
+	Trigger   NotificationTrigger `json:"trigger" gorm:"not null;uniqueIndex:idx_notification_template"`
+	Channel   NotificationChannel `json:"channel" gorm:"not null;uniqueIndex:idx_notification_template"`
+	Locale    string              `json:"locale" gorm:"not null;uniqueIndex:idx_notification_template"`
+	Subject   string              `json:"subject"`
+	Body      string              `json:"body" gorm:"not null"`
+	IsActive  bool                `json:"is_active" gorm:"default:true"` // inactive templates suppress the message
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// NotificationOptOut stops all messages of a channel to one email address or
+// phone number.
+type NotificationOptOut struct {
+	ID        uint                `json:"id" gorm:"primaryKey"`
+	CompanyID uint                `json:"company_id" gorm:"not null;uniqueIndex:idx_notification_opt_out"`
+	Channel   NotificationChannel `json:"channel" gorm:"not null;uniqueIndex:idx_notification_opt_out"`
+	Recipient string              `json:"recipient" gorm:"not null;uniqueIndex:idx_notification_opt_out"` // lower-cased email or phone digits
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// NotificationLog records every message attempted, with its rendered content
+// and outcome.
+type NotificationLog struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	CompanyID    uint                `json:"company_id" gorm:"not null;index"`
+	CargoID      uint                `json:"cargo_id" gorm:"not null;index"`
+	CargoEventID *uint               `json:"cargo_event_id"`
+	Trigger      NotificationTrigger `json:"trigger"`
+	Channel      NotificationChannel `json:"channel"`
+	Recipient    string              `json:"recipient"`
+	Locale       string              `json:"locale"`
+	Subject      string              `json:"subject"`
+	Body         string              `json:"body"`
+	Status       NotificationStatus  `json:"status"`
+	Error        string              `json:"error,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// NotificationData is what notification templates can refer to, e.g.
+// {{.TrackingNumber}} or {{.EstimatedDelivery}}.
+type NotificationData struct {
+	Carrier           string
+	TrackingNumber    string
+	TrackingToken     string
+	Title             string
+	Status            string
+	RecipientName     string
+	Origin            string
+	Destination       string
+	EstimatedDelivery string // "2006-01-02 15:04", or "" when unknown
+	Pieces            int
+	Event             string // description of the cargo event
+}
+
+type UpdateNotificationSettingsRequest struct {
+	EmailEnabled  *bool  `json:"email_enabled"`
+	SMSEnabled    *bool  `json:"sms_enabled"`
+	DefaultLocale string `json:"default_locale" binding:"omitempty,min=2,max=10"`
+}
+
+// SaveNotificationTemplateRequest creates or replaces the template of a
+// trigger, channel and locale.
+type SaveNotificationTemplateRequest struct {
+	Trigger  NotificationTrigger `json:"trigger" binding:"required,oneof=cargo_assigned cargo_picked_up cargo_delayed cargo_delivered"`
+	Channel  NotificationChannel `json:"channel" binding:"required,oneof=email sms"`
+	Locale   string              `json:"locale" binding:"required,min=2,max=10"`
+	Subject  string              `json:"subject"`
+	Body     string              `json:"body" binding:"required"`
+	IsActive *bool               `json:"is_active"`
+}
+
+type CreateNotificationOptOutRequest struct {
+	Channel   NotificationChannel `json:"channel" binding:"required,oneof=email sms"`
+	Recipient string              `json:"recipient" binding:"required"`
+}
+
+type NotificationLogFilter struct {
+	CargoID *uint               `form:"cargo_id"`
+	Channel NotificationChannel `form:"channel"`
+	Status  NotificationStatus  `form:"status"`
+	Page    int                 `form:"page,default=1" binding:"min=1"`
+	Limit   int                 `form:"limit,default=20" binding:"min=1,max=100"`
+}
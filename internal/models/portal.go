@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// PortalShipment is what a customer sees of a cargo in the booking portal:
+// the shipment itself without the carrier's assignment details.
+type PortalShipment struct {
+	ID                 uint          `json:"id"`
+	TrackingNumber     string        `json:"tracking_number"`
+	TrackingToken      string        `json:"tracking_token"` // to share public tracking details with the recipient
+	Title              string        `json:"title"`
+	Description        string        `json:"description"`
+	Type               CargoType     `json:"type"`
+	Priority           CargoPriority `json:"priority"`
+	Status             CargoStatus   `json:"status"`
+	AwaitingApproval   bool          `json:"awaiting_approval"`
+	Weight             float64       `json:"weight"`
+	Volume             float64       `json:"volume"`
+	Value              float64       `json:"value"`
+	Currency           string        `json:"currency"`
+	Price              *float64      `json:"price"`
+	PriceCurrency      string        `json:"price_currency"`
+	OriginAddress      string        `json:"origin_address"`
+	OriginContact      string        `json:"origin_contact"`
+	DestinationAddress string        `json:"destination_address"`
+	DestinationContact string        `json:"destination_contact"`
+	PickupTime         *time.Time    `json:"pickup_time"`
+	DeliveryTime       *time.Time    `json:"delivery_time"`
+	EstimatedDelivery  *time.Time    `json:"estimated_delivery"`
+	ActualPickup       *time.Time    `json:"actual_pickup"`
+	ActualDelivery     *time.Time    `json:"actual_delivery"`
+	CurrentLocation    string        `json:"current_location"`
+	LastUpdated        *time.Time    `json:"last_updated"`
+	Instructions       string        `json:"instructions"`
+	CreatedAt          time.Time     `json:"created_at"`
+}
+
+// NewPortalShipment returns the portal view of a cargo.
+func NewPortalShipment(cargo *Cargo) PortalShipment {
+	return PortalShipment{
+		ID:                 cargo.ID,
+		TrackingNumber:     cargo.TrackingNumber,
+		TrackingToken:      cargo.TrackingToken,
+		Title:              cargo.Title,
+		Description:        cargo.Description,
+		Type:               cargo.Type,
+		Priority:           cargo.Priority,
+		Status:             cargo.Status,
+		AwaitingApproval:   cargo.AwaitingApproval(),
+		Weight:             cargo.Weight,
+		Volume:             cargo.Volume,
+		Value:              cargo.Value,
+		Currency:           cargo.Currency,
+		Price:              cargo.Price,
+		PriceCurrency:      cargo.PriceCurrency,
+		OriginAddress:      cargo.OriginAddress,
+		OriginContact:      cargo.OriginContact,
+		DestinationAddress: cargo.DestinationAddress,
+		DestinationContact: cargo.DestinationContact,
+		PickupTime:         cargo.PickupTime,
+		DeliveryTime:       cargo.DeliveryTime,
+		EstimatedDelivery:  cargo.EstimatedDelivery,
+		ActualPickup:       cargo.ActualPickup,
+		ActualDelivery:     cargo.ActualDelivery,
+		CurrentLocation:    cargo.CurrentLocation,
+		LastUpdated:        cargo.LastUpdated,
+		Instructions:       cargo.Instructions,
+		CreatedAt:          cargo.CreatedAt,
+	}
+}
+
+// PortalTracking is the tracking view of a customer's own shipment.
+type PortalTracking struct {
+	Shipment        PortalShipment `json:"shipment"`
+	Progress        float64        `json:"progress"` // 0-100%
+	EstimatedETA    *time.Time     `json:"estimated_eta"`
+	RecentEvents    []CargoEvent   `json:"recent_events"`
+	TruckLocation   *TruckLocation `json:"truck_location,omitempty"`
+	ProofOfDelivery *PODSummary    `json:"proof_of_delivery,omitempty"`
+	Journey         []JourneyLeg   `json:"journey,omitempty"`
+}
+
+type PortalShipmentFilter struct {
+	Status CargoStatus `form:"status"`
+	Search string      `form:"search"`
+	Page   int         `form:"page,default=1" binding:"min=1"`
+	Limit  int         `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+type CancelBookingRequest struct {
+	Reason string `json:"reason"`
+}
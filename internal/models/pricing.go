@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PricingSettings holds a company's company-wide pricing rules: the currency
+// prices are quoted in, the surcharges added on top of the rated linehaul and
+// the minimum charge per shipment.
+type PricingSettings struct {
+	ID                         uint      `json:"id" gorm:"primaryKey"`
+	CompanyID                  uint      `json:"company_id" gorm:"uniqueIndex;not null"`
+	Currency                   string    `json:"currency" gorm:"default:'USD'"`
+	VolumetricFactor           float64   `json:"volumetric_factor" gorm:"default:250"` // kg charged per m³
+	UrgentSurchargePercent     float64   `json:"urgent_surcharge_percent"`
+	HazardousSurchargePercent  float64   `json:"hazardous_surcharge_percent"`
+	PerishableSurchargePercent float64   `json:"perishable_surcharge_percent"`
+	SpecialHandlingFee         float64   `json:"special_handling_fee"`
+	FuelSurchargePercent       float64   `json:"fuel_surcharge_percent"`
+	MinimumCharge              float64   `json:"minimum_charge"`
+	CreatedAt                  time.Time `json:"created_at"`
+	UpdatedAt                  time.Time `json:"updated_at"`
+}
+
+// FreightRate is one row of a company's rate table. A rate applies to a
+// shipment when its lane (if any) matches the addresses, the distance falls in
+// its band and the chargeable weight reaches its weight break. The linehaul is
+// BaseCharge + RatePerKm × distance + RatePerKg × chargeable weight.
+type FreightRate struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CompanyID        uint           `json:"company_id" gorm:"not null;index"`
+	Name             string         `json:"name" gorm:"not null"`
+	OriginMatch      string         `json:"origin_match"`      // lane: text the origin address must contain
+	DestinationMatch string         `json:"destination_match"` // lane: text the destination address must contain
+	MinDistanceKm    float64        `json:"min_distance_km"`
+	MaxDistanceKm    *float64       `json:"max_distance_km"` // nil for no upper bound
+	MinWeight        float64        `json:"min_weight"`      // weight break in kg
+	BaseCharge       float64        `json:"base_charge"`
+	RatePerKm        float64        `json:"rate_per_km"`
+	RatePerKg        float64        `json:"rate_per_kg"`
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// IsLane reports whether the rate is restricted to an origin or destination.
+func (r *FreightRate) IsLane() bool {
+	return r.OriginMatch != "" || r.DestinationMatch != ""
+}
+
+// FreightQuote is the priced breakdown of a shipment.
+type FreightQuote struct {
+	Currency         string          `json:"currency"`
+	DistanceKm       *float64        `json:"distance_km"`
+	ChargeableWeight float64         `json:"chargeable_weight"`
+	RateID           uint            `json:"rate_id"`
+	RateName         string          `json:"rate_name"`
+	Linehaul         float64         `json:"linehaul"`
+	Surcharges       []QuoteLineItem `json:"surcharges"`
+	FuelSurcharge    float64         `json:"fuel_surcharge"`
+	MinimumApplied   bool            `json:"minimum_applied"`
+	Total            float64         `json:"total"`
+}
+
+type QuoteLineItem struct {
+	Code        string  `json:"code"` // urgent, hazardous, perishable, special_handling
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+type UpdatePricingSettingsRequest struct {
+	Currency                   string   `json:"currency" binding:"omitempty,len=3"`
+	VolumetricFactor           *float64 `json:"volumetric_factor" binding:"omitempty,gt=0"`
+	UrgentSurchargePercent     *float64 `json:"urgent_surcharge_percent" binding:"omitempty,min=0"`
+	HazardousSurchargePercent  *float64 `json:"hazardous_surcharge_percent" binding:"omitempty,min=0"`
+	PerishableSurchargePercent *float64 `json:"perishable_surcharge_percent" binding:"omitempty,min=0"`
+	SpecialHandlingFee         *float64 `json:"special_handling_fee" binding:"omitempty,min=0"`
+	FuelSurchargePercent       *float64 `json:"fuel_surcharge_percent" binding:"omitempty,min=0"`
+	MinimumCharge              *float64 `json:"minimum_charge" binding:"omitempty,min=0"`
+}
+
+type CreateFreightRateRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	OriginMatch      string   `json:"origin_match"`
+	DestinationMatch string   `json:"destination_match"`
+	MinDistanceKm    float64  `json:"min_distance_km" binding:"min=0"`
+	MaxDistanceKm    *float64 `json:"max_distance_km" binding:"omitempty,gt=0"`
+	MinWeight        float64  `json:"min_weight" binding:"min=0"`
+	BaseCharge       float64  `json:"base_charge" binding:"min=0"`
+	RatePerKm        float64  `json:"rate_per_km" binding:"min=0"`
+	RatePerKg        float64  `json:"rate_per_kg" binding:"min=0"`
+}
+
+type UpdateFreightRateRequest struct {
+	Name             string   `json:"name"`
+	OriginMatch      *string  `json:"origin_match"`
+	DestinationMatch *string  `json:"destination_match"`
+	MinDistanceKm    *float64 `json:"min_distance_km" binding:"omitempty,min=0"`
+	MaxDistanceKm    *float64 `json:"max_distance_km" binding:"omitempty,min=0"` // 0 removes the upper bound
+	MinWeight        *float64 `json:"min_weight" binding:"omitempty,min=0"`
+	BaseCharge       *float64 `json:"base_charge" binding:"omitempty,min=0"`
+	RatePerKm        *float64 `json:"rate_per_km" binding:"omitempty,min=0"`
+	RatePerKg        *float64 `json:"rate_per_kg" binding:"omitempty,min=0"`
+	IsActive         *bool    `json:"is_active"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+type ProofOfDelivery struct {
+	ID                   uint                   `json:"id" gorm:"primaryKey"`
+	CompanyID            uint                   `json:"company_id" gorm:"not null"`
+	CargoID              uint                   `json:"cargo_id" gorm:"uniqueIndex;not null"`
+	CargoEventID         *uint                  `json:"cargo_event_id"`
+	CargoEvent           *CargoEvent            `json:"cargo_event,omitempty"`
+	RecipientName        string                 `json:"recipient_name" gorm:"not null"`
+	SignatureKey         string                 `json:"-" gorm:"not null"`
+	SignatureContentType string                 `json:"signature_content_type"`
+	Latitude             float64                `json:"latitude" gorm:"not null"`
+	Longitude            float64                `json:"longitude" gorm:"not null"`
+	CapturedAt           time.Time              `json:"captured_at" gorm:"not null"`
+	CapturedBy           uint                   `json:"captured_by" gorm:"not null"`
+	CapturedByUser       *User                  `json:"captured_by_user,omitempty" gorm:"foreignKey:CapturedBy"`
+	Notes                string                 `json:"notes"`
+	TemperatureLogKey    string                 `json:"-"`
+	HasTemperatureLog    bool                   `json:"has_temperature_log" gorm:"default:false"`
+	Photos               []ProofOfDeliveryPhoto `json:"photos,omitempty"`
+	CreatedAt            time.Time              `json:"created_at"`
+}
+
+type ProofOfDeliveryPhoto struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	ProofOfDeliveryID uint      `json:"proof_of_delivery_id" gorm:"not null"`
+	StorageKey        string    `json:"-" gorm:"not null"`
+	FileName          string    `json:"file_name"`
+	ContentType       string    `json:"content_type"`
+	Size              int64     `json:"size"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type CaptureProofOfDeliveryRequest struct {
+	RecipientName string     `form:"recipient_name" binding:"required"`
+	Latitude      *float64   `form:"latitude" binding:"required,min=-90,max=90"`
+	Longitude     *float64   `form:"longitude" binding:"required,min=-180,max=180"`
+	CapturedAt    *time.Time `form:"captured_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	Location      string     `form:"location"`
+	Notes         string     `form:"notes"`
+}
+
+// PODSummary is the redacted view of a proof of delivery exposed on public
+// tracking: no images, coordinates or full recipient name.
+type PODSummary struct {
+	RecipientInitials string    `json:"recipient_initials"`
+	DeliveredAt       time.Time `json:"delivered_at"`
+	HasSignature      bool      `json:"has_signature"`
+	PhotoCount        int       `json:"photo_count"`
+}
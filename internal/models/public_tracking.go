@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// TrackingVerification is what a public tracking caller may present to prove
+// they are the shipper or the recipient: the cargo's tracking token, or the
+// postcode it is delivered to.
+type TrackingVerification struct {
+	Token    string `form:"token"`
+	Postcode string `form:"postcode"`
+}
+
+// PublicTracking is the unauthenticated view of a cargo. Contact details,
+// value, the carrier's staff and its trucks are never included; addresses,
+// event locations, the journey, the truck position and the proof of delivery
+// summary only once the caller is verified.
+type PublicTracking struct {
+	TrackingNumber     string                `json:"tracking_number"`
+	Carrier            string                `json:"carrier"`
+	Status             CargoStatus           `json:"status"`
+	Progress           float64               `json:"progress"` // 0-100%
+	EstimatedETA       *time.Time            `json:"estimated_eta"`
+	LastUpdate         *time.Time            `json:"last_update"`
+	Pieces             int                   `json:"pieces"`
+	Verified           bool                  `json:"verified"`
+	OriginAddress      string                `json:"origin_address,omitempty"`
+	DestinationAddress string                `json:"destination_address,omitempty"`
+	Events             []PublicTrackingEvent `json:"events,omitempty"`
+	Journey            []JourneyLeg          `json:"journey,omitempty"`
+	CurrentLocation    *PublicLocation       `json:"current_location,omitempty"`
+	ProofOfDelivery    *PODSummary           `json:"proof_of_delivery,omitempty"`
+}
+
+// PublicTrackingEvent is a shipment milestone with a fixed public
+// description, so notes written by staff and drivers stay internal.
+type PublicTrackingEvent struct {
+	EventType   string    `json:"event_type"`
+	Description string    `json:"description"`
+	Location    string    `json:"location,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type PublicLocation struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -35,10 +35,10 @@ type Request struct {
 	Title            string         `json:"title" gorm:"not null"`
 	Description      string         `json:"description"`
 	AcceptedBy       *uint          `json:"accepted_by"`
-	AcceptedByUser   *User          `json:"accepted_by_user,omitempty"`
+	AcceptedByUser   *User          `json:"accepted_by_user,omitempty" gorm:"foreignKey:AcceptedBy"`
 	AcceptedAt       *time.Time     `json:"accepted_at"`
 	TerminatedBy     *uint          `json:"terminated_by"`
-	TerminatedByUser *User          `json:"terminated_by_user,omitempty"`
+	TerminatedByUser *User          `json:"terminated_by_user,omitempty" gorm:"foreignKey:TerminatedBy"`
 	TerminatedAt     *time.Time     `json:"terminated_at"`
 	TerminationReason string        `json:"termination_reason"`
 	CreatedAt        time.Time      `json:"created_at"`
@@ -42,24 +42,28 @@ type Route struct {
 }
 
 type RouteStop struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	RouteID          uint           `json:"route_id" gorm:"not null"`
-	Route            Route          `json:"route,omitempty"`
-	StopOrder        int            `json:"stop_order" gorm:"not null"`
-	Address          string         `json:"address" gorm:"not null"`
-	Latitude         *float64       `json:"latitude"`
-	Longitude        *float64       `json:"longitude"`
-	ContactName      string         `json:"contact_name"`
-	ContactPhone     string         `json:"contact_phone"`
-	Instructions     string         `json:"instructions"`
-	EstimatedArrival *time.Time     `json:"estimated_arrival"`
-	ActualArrival    *time.Time     `json:"actual_arrival"`
-	IsCompleted      bool           `json:"is_completed" gorm:"default:false"`
-	CompletedAt      *time.Time     `json:"completed_at"`
-	Notes            string         `json:"notes"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	RouteID           uint           `json:"route_id" gorm:"not null"`
+	Route             Route          `json:"route,omitempty"`
+	StopOrder         int            `json:"stop_order" gorm:"not null"`
+	CustomerID        *uint          `json:"customer_id" gorm:"index"`
+	Customer          *Customer      `json:"customer,omitempty"`
+	CustomerAddressID *uint          `json:"customer_address_id"`
+	Address           string         `json:"address" gorm:"not null"`
+	Latitude          *float64       `json:"latitude"`
+	Longitude         *float64       `json:"longitude"`
+	ContactName       string         `json:"contact_name"`
+	ContactPhone      string         `json:"contact_phone"`
+	Instructions      string         `json:"instructions"`
+	DeliveryWindow    string         `json:"delivery_window"` // HH:MM-HH:MM from the address book
+	EstimatedArrival  *time.Time     `json:"estimated_arrival"`
+	ActualArrival     *time.Time     `json:"actual_arrival"`
+	IsCompleted       bool           `json:"is_completed" gorm:"default:false"`
+	CompletedAt       *time.Time     `json:"completed_at"`
+	Notes             string         `json:"notes"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type CreateRouteRequest struct {
@@ -82,14 +86,17 @@ type UpdateRouteRequest struct {
 }
 
 type CreateRouteStopRequest struct {
-	StopOrder        int        `json:"stop_order" binding:"required"`
-	Address          string     `json:"address" binding:"required"`
-	Latitude         *float64   `json:"latitude"`
-	Longitude        *float64   `json:"longitude"`
-	ContactName      string     `json:"contact_name"`
-	ContactPhone     string     `json:"contact_phone"`
-	Instructions     string     `json:"instructions"`
-	EstimatedArrival *time.Time `json:"estimated_arrival"`
+	StopOrder  int   `json:"stop_order" binding:"required"`
+	CustomerID *uint `json:"customer_id"`
+	// A saved address fills in the location, contact and instructions left out
+	CustomerAddressID *uint      `json:"customer_address_id"`
+	Address           string     `json:"address" binding:"required_without=CustomerAddressID"`
+	Latitude          *float64   `json:"latitude"`
+	Longitude         *float64   `json:"longitude"`
+	ContactName       string     `json:"contact_name"`
+	ContactPhone      string     `json:"contact_phone"`
+	Instructions      string     `json:"instructions"`
+	EstimatedArrival  *time.Time `json:"estimated_arrival"`
 }
 
 type RouteFilter struct {
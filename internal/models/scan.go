@@ -0,0 +1,30 @@
+package models
+
+type ScanAction string
+
+const (
+	ScanActionPickup   ScanAction = "pickup"
+	ScanActionHandover ScanAction = "handover"
+	ScanActionArrival  ScanAction = "arrival"
+	ScanActionDelivery ScanAction = "delivery"
+)
+
+// ScanRequest is a driver scanning a tracking number, piece barcode or
+// handling unit barcode at their current position.
+type ScanRequest struct {
+	Code      string  `json:"code" binding:"required"`
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+	Location  string  `json:"location"`
+	Notes     string  `json:"notes"`
+}
+
+// ScanResponse reports the transition a scan applied and the cargo after it.
+// Piece and handling unit scans also list the pieces that changed.
+type ScanResponse struct {
+	Action ScanAction   `json:"action"`
+	Cargos []Cargo      `json:"cargos"`
+	Pieces []CargoPiece `json:"pieces,omitempty"`
+	Leg    *ShipmentLeg `json:"leg,omitempty"`
+	Event  *CargoEvent  `json:"event,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type ScheduledJobRunStatus string
+
+const (
+	ScheduledJobRunRunning   ScheduledJobRunStatus = "running"
+	ScheduledJobRunSucceeded ScheduledJobRunStatus = "succeeded"
+	ScheduledJobRunFailed    ScheduledJobRunStatus = "failed"
+)
+
+const (
+	ScheduledJobTriggerSchedule = "schedule"
+	ScheduledJobTriggerManual   = "manual"
+)
+
+// ScheduledJob is a recurring system job. The code registers its name and
+// cron schedule; the row keeps its state across restarts and replicas.
+type ScheduledJob struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	Name           string                `json:"name" gorm:"uniqueIndex;not null"`
+	Schedule       string                `json:"schedule" gorm:"not null"` // cron expression
+	Description    string                `json:"description"`
+	IsPaused       bool                  `json:"is_paused" gorm:"default:false"`
+	NextRunAt      *time.Time            `json:"next_run_at"`
+	RunRequestedAt *time.Time            `json:"run_requested_at"` // set by a manual trigger until the leader runs it
+	LastRunAt      *time.Time            `json:"last_run_at"`
+	LastStatus     ScheduledJobRunStatus `json:"last_status"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// ScheduledJobRun is one execution of a scheduled job in its history.
+type ScheduledJobRun struct {
+	ID         uint                  `json:"id" gorm:"primaryKey"`
+	JobName    string                `json:"job_name" gorm:"not null;index"`
+	Trigger    string                `json:"trigger" gorm:"not null"` // schedule or manual
+	Status     ScheduledJobRunStatus `json:"status" gorm:"not null"`
+	Instance   string                `json:"instance"` // replica that ran it
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt *time.Time            `json:"finished_at"`
+	DurationMs int64                 `json:"duration_ms"`
+	Error      string                `json:"error,omitempty"`
+}
+
+type ScheduledJobRunFilter struct {
+	Status ScheduledJobRunStatus `form:"status"`
+	Page   int                   `form:"page,default=1" binding:"min=1"`
+	Limit  int                   `form:"limit,default=20" binding:"min=1,max=100"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShipmentLegStatus string
+
+const (
+	ShipmentLegStatusPending   ShipmentLegStatus = "pending"
+	ShipmentLegStatusInTransit ShipmentLegStatus = "in_transit"
+	ShipmentLegStatusCompleted ShipmentLegStatus = "completed"
+	ShipmentLegStatusCancelled ShipmentLegStatus = "cancelled"
+)
+
+// ShipmentLeg is one segment of a cargo's journey, e.g. depot to hub or hub to
+// the last-mile truck. A cargo without legs is carried by its own TruckID.
+type ShipmentLeg struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	CompanyID           uint              `json:"company_id" gorm:"not null"`
+	CargoID             uint              `json:"cargo_id" gorm:"not null;uniqueIndex:idx_shipment_legs_cargo_sequence"`
+	Sequence            int               `json:"sequence" gorm:"not null;uniqueIndex:idx_shipment_legs_cargo_sequence"`
+	TruckID             *uint             `json:"truck_id"`
+	Truck               *Truck            `json:"truck,omitempty"`
+	RouteID             *uint             `json:"route_id"`
+	Route               *Route            `json:"route,omitempty"`
+	OriginBranchID      *uint             `json:"origin_branch_id"`
+	OriginBranch        *Branch           `json:"origin_branch,omitempty" gorm:"foreignKey:OriginBranchID"`
+	OriginAddress       string            `json:"origin_address"`
+	DestinationBranchID *uint             `json:"destination_branch_id"`
+	DestinationBranch   *Branch           `json:"destination_branch,omitempty" gorm:"foreignKey:DestinationBranchID"`
+	DestinationAddress  string            `json:"destination_address"`
+	Status              ShipmentLegStatus `json:"status" gorm:"default:'pending'"`
+	PlannedDeparture    *time.Time        `json:"planned_departure"`
+	PlannedArrival      *time.Time        `json:"planned_arrival"`
+	DepartedAt          *time.Time        `json:"departed_at"`
+	ArrivedAt           *time.Time        `json:"arrived_at"`
+	Notes               string            `json:"notes"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// CargoHandover records custody of a cargo passing from the driver of one leg
+// to the driver of the next, typically at a cross-dock branch.
+type CargoHandover struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CompanyID    uint      `json:"company_id" gorm:"not null"`
+	CargoID      uint      `json:"cargo_id" gorm:"not null;index"`
+	FromLegID    *uint     `json:"from_leg_id"`
+	ToLegID      uint      `json:"to_leg_id" gorm:"not null"`
+	FromDriverID *uint     `json:"from_driver_id"`
+	FromDriver   *User     `json:"from_driver,omitempty" gorm:"foreignKey:FromDriverID"`
+	ToDriverID   uint      `json:"to_driver_id" gorm:"not null"`
+	ToDriver     *User     `json:"to_driver,omitempty" gorm:"foreignKey:ToDriverID"`
+	BranchID     *uint     `json:"branch_id"`
+	Branch       *Branch   `json:"branch,omitempty"`
+	Location     string    `json:"location"`
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
+	Notes        string    `json:"notes"`
+	HandedOverAt time.Time `json:"handed_over_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateShipmentLegRequest struct {
+	TruckID             *uint      `json:"truck_id"`
+	RouteID             *uint      `json:"route_id"`
+	OriginBranchID      *uint      `json:"origin_branch_id"`
+	OriginAddress       string     `json:"origin_address"`
+	DestinationBranchID *uint      `json:"destination_branch_id"`
+	DestinationAddress  string     `json:"destination_address"`
+	PlannedDeparture    *time.Time `json:"planned_departure"`
+	PlannedArrival      *time.Time `json:"planned_arrival"`
+	Notes               string     `json:"notes"`
+}
+
+type UpdateShipmentLegRequest struct {
+	TruckID             *uint      `json:"truck_id"`
+	RouteID             *uint      `json:"route_id"`
+	OriginBranchID      *uint      `json:"origin_branch_id"`
+	OriginAddress       string     `json:"origin_address"`
+	DestinationBranchID *uint      `json:"destination_branch_id"`
+	DestinationAddress  string     `json:"destination_address"`
+	PlannedDeparture    *time.Time `json:"planned_departure"`
+	PlannedArrival      *time.Time `json:"planned_arrival"`
+	Notes               string     `json:"notes"`
+}
+
+type ShipmentLegLocationRequest struct {
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+type CreateHandoverRequest struct {
+	Location  string   `json:"location"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Notes     string   `json:"notes"`
+}
+
+// JourneyLeg is the public view of a leg shown on the tracking page; it leaves
+// out trucks, drivers and internal notes.
+type JourneyLeg struct {
+	Sequence       int               `json:"sequence"`
+	Origin         string            `json:"origin"`
+	Destination    string            `json:"destination"`
+	Status         ShipmentLegStatus `json:"status"`
+	PlannedArrival *time.Time        `json:"planned_arrival,omitempty"`
+	DepartedAt     *time.Time        `json:"departed_at,omitempty"`
+	ArrivedAt      *time.Time        `json:"arrived_at,omitempty"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+)
+
+type TemperatureExcursionDirection string
+type TemperatureExcursionStatus string
+
+const (
+	TemperatureExcursionAbove TemperatureExcursionDirection = "above"
+	TemperatureExcursionBelow TemperatureExcursionDirection = "below"
+)
+
+const (
+	TemperatureExcursionOpen   TemperatureExcursionStatus = "open"
+	TemperatureExcursionClosed TemperatureExcursionStatus = "closed"
+)
+
+// DefaultExcursionThresholdMinutes is how long cargo may stay out of its
+// temperature range before an alert is raised, unless the cargo sets its own.
+const DefaultExcursionThresholdMinutes = 15
+
+// TemperatureReading is one reefer sensor sample. A reading with a TruckID and
+// no CargoID covers every monitored cargo carried by the truck at the time;
+// one with a CargoID is from a sensor travelling with that cargo.
+type TemperatureReading struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CompanyID   uint      `json:"company_id" gorm:"not null"`
+	TruckID     *uint     `json:"truck_id"`
+	CargoID     *uint     `json:"cargo_id"`
+	SensorID    string    `json:"sensor_id"`
+	Temperature float64   `json:"temperature" gorm:"not null"` // in °C
+	Humidity    *float64  `json:"humidity"`                    // relative, in %
+	RecordedAt  time.Time `json:"recorded_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TemperatureExcursion is a period during which cargo was outside its required
+// temperature range. It is alerted once it lasts longer than the cargo's
+// threshold and closed by the first reading back in range.
+type TemperatureExcursion struct {
+	ID               uint                          `json:"id" gorm:"primaryKey"`
+	CompanyID        uint                          `json:"company_id" gorm:"not null"`
+	CargoID          uint                          `json:"cargo_id" gorm:"not null"`
+	Cargo            *Cargo                        `json:"cargo,omitempty"`
+	TruckID          *uint                         `json:"truck_id"`
+	Direction        TemperatureExcursionDirection `json:"direction" gorm:"not null"`
+	Status           TemperatureExcursionStatus    `json:"status" gorm:"default:'open'"`
+	MinTemperature   float64                       `json:"min_temperature"` // allowed range at the time
+	MaxTemperature   float64                       `json:"max_temperature"`
+	PeakTemperature  float64                       `json:"peak_temperature"`
+	StartedAt        time.Time                     `json:"started_at" gorm:"not null"`
+	LastReadingAt    time.Time                     `json:"last_reading_at" gorm:"not null"`
+	EndedAt          *time.Time                    `json:"ended_at"`
+	AlertedAt        *time.Time                    `json:"alerted_at"`
+	AcknowledgedBy   *uint                         `json:"acknowledged_by"`
+	AcknowledgedUser *User                         `json:"acknowledged_user,omitempty" gorm:"foreignKey:AcknowledgedBy"`
+	AcknowledgedAt   *time.Time                    `json:"acknowledged_at"`
+	CreatedAt        time.Time                     `json:"created_at"`
+	UpdatedAt        time.Time                     `json:"updated_at"`
+}
+
+// Duration is how long the excursion lasted, or has lasted up to its latest
+// reading while still open.
+func (e *TemperatureExcursion) Duration() time.Duration {
+	if e.EndedAt != nil {
+		return e.EndedAt.Sub(e.StartedAt)
+	}
+	return e.LastReadingAt.Sub(e.StartedAt)
+}
+
+type TemperatureReadingInput struct {
+	SensorID    string     `json:"sensor_id"`
+	Temperature *float64   `json:"temperature" binding:"required"`
+	Humidity    *float64   `json:"humidity"`
+	RecordedAt  *time.Time `json:"recorded_at"`
+}
+
+// TemperatureTelemetryRequest is a batch of readings from the reefer unit of a
+// truck or from a sensor travelling with one cargo; exactly one of TruckID and
+// CargoID is set.
+type TemperatureTelemetryRequest struct {
+	TruckID  *uint                     `json:"truck_id"`
+	CargoID  *uint                     `json:"cargo_id"`
+	Readings []TemperatureReadingInput `json:"readings" binding:"required,min=1,max=1000,dive"`
+}
+
+type TemperatureTelemetryResponse struct {
+	Accepted int                    `json:"accepted"`
+	Alerts   []TemperatureExcursion `json:"alerts"`
+}
+
+type TemperatureExcursionFilter struct {
+	Status  TemperatureExcursionStatus `form:"status"`
+	CargoID *uint                      `form:"cargo_id"`
+	TruckID *uint                      `form:"truck_id"`
+	Alerted *bool                      `form:"alerted"`
+	Page    int                        `form:"page,default=1"`
+	Limit   int                        `form:"limit,default=10"`
+}
+
+// CargoTemperatureHistory is everything recorded about a cargo's temperature:
+// the readings that applied to it while it was carried and its excursions.
+type CargoTemperatureHistory struct {
+	CargoID                   uint                   `json:"cargo_id"`
+	MinTemperature            *float64               `json:"min_temperature"`
+	MaxTemperature            *float64               `json:"max_temperature"`
+	ExcursionThresholdMinutes int                    `json:"excursion_threshold_minutes"`
+	Readings                  []TemperatureReading   `json:"readings"`
+	Excursions                []TemperatureExcursion `json:"excursions"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+type TrackingCheckDigit string
+type TrackingDateFormat string
+
+const (
+	TrackingCheckDigitNone  TrackingCheckDigit = "none"
+	TrackingCheckDigitLuhn  TrackingCheckDigit = "luhn"
+	TrackingCheckDigitMod11 TrackingCheckDigit = "mod11"
+)
+
+const (
+	TrackingDateFormatNone     TrackingDateFormat = "none"
+	TrackingDateFormatYYMM     TrackingDateFormat = "yymm"
+	TrackingDateFormatYYMMDD   TrackingDateFormat = "yymmdd"
+	TrackingDateFormatYYYYMMDD TrackingDateFormat = "yyyymmdd"
+)
+
+// TrackingNumberFormat holds a company's tracking number layout together with
+// its sequence counter and public tracking policy. Numbers are composed as
+// <prefix><company id, zero-padded to 6 digits><date><zero-padded sequence><check digit>.
+type TrackingNumberFormat struct {
+	ID                  uint               `json:"id" gorm:"primaryKey"`
+	CompanyID           uint               `json:"company_id" gorm:"uniqueIndex;not null"`
+	Prefix              string             `json:"prefix" gorm:"default:'TRK'"`
+	DateFormat          TrackingDateFormat `json:"date_format" gorm:"default:'none'"`
+	SequenceLength      int                `json:"sequence_length" gorm:"default:6"`
+	CheckDigit          TrackingCheckDigit `json:"check_digit" gorm:"default:'none'"`
+	NextValue           int64              `json:"next_value" gorm:"not null;default:1"`
+	RequireVerification bool               `json:"require_verification" gorm:"default:true"` // public tracking only with the tracking token or destination postcode
+	CreatedAt           time.Time          `json:"created_at"`
+	UpdatedAt           time.Time          `json:"updated_at"`
+}
+
+type UpdateTrackingNumberFormatRequest struct {
+	Prefix              *string            `json:"prefix" binding:"omitempty,max=8"`
+	DateFormat          TrackingDateFormat `json:"date_format" binding:"omitempty,oneof=none yymm yymmdd yyyymmdd"`
+	SequenceLength      int                `json:"sequence_length" binding:"omitempty,min=4,max=12"`
+	CheckDigit          TrackingCheckDigit `json:"check_digit" binding:"omitempty,oneof=none luhn mod11"`
+	RequireVerification *bool              `json:"require_verification"`
+}
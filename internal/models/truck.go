@@ -29,6 +29,7 @@ type Truck struct {
 	DriverID     *uint           `json:"driver_id"`
 	Driver       *User           `json:"driver,omitempty"`
 	IsApproved   bool            `json:"is_approved" gorm:"default:false"`
+	HazmatPlacarded bool         `json:"hazmat_placarded" gorm:"default:false"` // fitted with placards to carry dangerous goods
 	ApprovedBy   *uint           `json:"approved_by"`
 	ApprovedByUser *User         `json:"approved_by_user,omitempty"`
 	ApprovedAt   *time.Time      `json:"approved_at"`
@@ -46,6 +47,7 @@ type TruckLocation struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	TruckID   uint      `json:"truck_id" gorm:"not null"`
 	Truck     Truck     `json:"truck,omitempty"`
+	CompanyID uint      `json:"company_id" gorm:"not null"` // copied from the truck for retention and storage per company
 	Latitude  float64   `json:"latitude" gorm:"not null"`
 	Longitude float64   `json:"longitude" gorm:"not null"`
 	Speed     float64   `json:"speed"`
@@ -61,6 +63,7 @@ type CreateTruckRequest struct {
 	Year         int    `json:"year" binding:"required"`
 	Color        string `json:"color"`
 	DriverID     *uint  `json:"driver_id"`
+	HazmatPlacarded bool `json:"hazmat_placarded"`
 }
 
 type UpdateTruckRequest struct {
@@ -72,6 +75,7 @@ type UpdateTruckRequest struct {
 	Status       TruckStatus `json:"status"`
 	DriverID     *uint       `json:"driver_id"`
 	IsApproved   bool        `json:"is_approved"`
+	HazmatPlacarded *bool    `json:"hazmat_placarded"`
 }
 
 type LocationUpdateRequest struct {
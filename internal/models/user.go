@@ -13,25 +13,30 @@ const (
 	RoleAssignee UserRole = "assignee"
 	RoleDriver   UserRole = "driver"
 	RoleDraft    UserRole = "draft"
+	RoleCustomer UserRole = "customer" // shipper using the booking portal
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	FirstName string         `json:"first_name" gorm:"not null"`
-	LastName  string         `json:"last_name" gorm:"not null"`
-	Role      UserRole       `json:"role" gorm:"default:'user'"`
-	CompanyID *uint          `json:"company_id"`
-	Company   *Company       `json:"company,omitempty"`
-	BranchID  *uint          `json:"branch_id"`
-	Branch    *Branch        `json:"branch,omitempty"`
-	TruckID   *uint          `json:"truck_id"` // For drivers assigned to specific truck
-	Truck     *Truck         `json:"truck,omitempty"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                        uint           `json:"id" gorm:"primaryKey"`
+	Email                     string         `json:"email" gorm:"uniqueIndex;not null"`
+	Password                  string         `json:"-" gorm:"column:password_hash;not null"`
+	FirstName                 string         `json:"first_name" gorm:"not null"`
+	LastName                  string         `json:"last_name" gorm:"not null"`
+	Role                      UserRole       `json:"role" gorm:"default:'driver'"`
+	CompanyID                 *uint          `json:"company_id"`
+	Company                   *Company       `json:"company,omitempty"`
+	BranchID                  *uint          `json:"branch_id"`
+	Branch                    *Branch        `json:"branch,omitempty"`
+	TruckID                   *uint          `json:"truck_id"` // For drivers assigned to specific truck
+	Truck                     *Truck         `json:"truck,omitempty"`
+	CustomerID                *uint          `json:"customer_id"` // For customer users, the account they book for
+	Customer                  *Customer      `json:"customer,omitempty"`
+	HazmatCertificationNumber string         `json:"hazmat_certification_number"`
+	HazmatCertifiedUntil      *time.Time     `json:"hazmat_certified_until"`
+	IsActive                  bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt                 time.Time      `json:"created_at"`
+	UpdatedAt                 time.Time      `json:"updated_at"`
+	DeletedAt                 gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type RegisterRequest struct {
@@ -44,22 +49,28 @@ type RegisterRequest struct {
 }
 
 type CreateUserRequest struct {
-	Email     string   `json:"email" binding:"required,email"`
-	Password  string   `json:"password" binding:"required,min=6"`
-	FirstName string   `json:"first_name" binding:"required"`
-	LastName  string   `json:"last_name" binding:"required"`
-	Role      UserRole `json:"role" binding:"required"`
-	BranchID  *uint    `json:"branch_id"`
-	TruckID   *uint    `json:"truck_id"`
+	Email                     string     `json:"email" binding:"required,email"`
+	Password                  string     `json:"password" binding:"required,min=6"`
+	FirstName                 string     `json:"first_name" binding:"required"`
+	LastName                  string     `json:"last_name" binding:"required"`
+	Role                      UserRole   `json:"role" binding:"required"`
+	BranchID                  *uint      `json:"branch_id"`
+	TruckID                   *uint      `json:"truck_id"`
+	CustomerID                *uint      `json:"customer_id"`
+	HazmatCertificationNumber string     `json:"hazmat_certification_number"`
+	HazmatCertifiedUntil      *time.Time `json:"hazmat_certified_until"`
 }
 
 type UpdateUserRequest struct {
-	FirstName string   `json:"first_name"`
-	LastName  string   `json:"last_name"`
-	Role      UserRole `json:"role"`
-	BranchID  *uint    `json:"branch_id"`
-	TruckID   *uint    `json:"truck_id"`
-	IsActive  bool     `json:"is_active"`
+	FirstName                 string     `json:"first_name"`
+	LastName                  string     `json:"last_name"`
+	Role                      UserRole   `json:"role"`
+	BranchID                  *uint      `json:"branch_id"`
+	TruckID                   *uint      `json:"truck_id"`
+	CustomerID                *uint      `json:"customer_id"`
+	IsActive                  bool       `json:"is_active"`
+	HazmatCertificationNumber string     `json:"hazmat_certification_number"`
+	HazmatCertifiedUntil      *time.Time `json:"hazmat_certified_until"`
 }
 
 type LoginRequest struct {
@@ -23,7 +23,10 @@ type Visit struct {
 	Truck       Truck          `json:"truck,omitempty"`
 	DriverID    uint           `json:"driver_id" gorm:"not null"`
 	Driver      User           `json:"driver,omitempty"`
+	CustomerID  *uint          `json:"customer_id" gorm:"index"`
+	Customer    *Customer      `json:"customer,omitempty"`
 	CustomerName string        `json:"customer_name" gorm:"not null"`
+	CustomerAddressID *uint    `json:"customer_address_id"`
 	Address     string         `json:"address" gorm:"not null"`
 	Latitude    *float64       `json:"latitude"`
 	Longitude   *float64       `json:"longitude"`
@@ -53,8 +56,11 @@ type Task struct {
 type CreateVisitRequest struct {
 	TruckID      uint     `json:"truck_id" binding:"required"`
 	DriverID     uint     `json:"driver_id" binding:"required"`
-	CustomerName string   `json:"customer_name" binding:"required"`
-	Address      string   `json:"address" binding:"required"`
+	CustomerID   *uint    `json:"customer_id"`
+	CustomerName string   `json:"customer_name" binding:"required_without_all=CustomerID CustomerAddressID"`
+	// A saved address fills in the address and coordinates, and the customer
+	CustomerAddressID *uint  `json:"customer_address_id"`
+	Address      string   `json:"address" binding:"required_without=CustomerAddressID"`
 	Latitude     *float64 `json:"latitude"`
 	Longitude    *float64 `json:"longitude"`
 	Notes        string   `json:"notes"`
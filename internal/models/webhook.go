@@ -0,0 +1,182 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookEventType string
+type WebhookDeliveryStatus string
+
+const (
+	WebhookEventCargoCreated       WebhookEventType = "cargo.created"
+	WebhookEventCargoStatusChanged WebhookEventType = "cargo.status_changed"
+	WebhookEventCargoEvent         WebhookEventType = "cargo.event"
+	WebhookEventRouteApproved      WebhookEventType = "route.approved"
+	WebhookEventTruckApproved      WebhookEventType = "truck.approved"
+	WebhookEventTruckLocation      WebhookEventType = "truck.location"
+	WebhookEventRequestCreated     WebhookEventType = "request.created"
+	WebhookEventRequestAccepted    WebhookEventType = "request.accepted"
+	WebhookEventRequestTerminated  WebhookEventType = "request.terminated"
+)
+
+// WebhookEventTypes lists the event types endpoints can subscribe to.
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventCargoCreated,
+	WebhookEventCargoStatusChanged,
+	WebhookEventCargoEvent,
+	WebhookEventRouteApproved,
+	WebhookEventTruckApproved,
+	WebhookEventTruckLocation,
+	WebhookEventRequestCreated,
+	WebhookEventRequestAccepted,
+	WebhookEventRequestTerminated,
+}
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending" // waiting for its first or next attempt
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed" // gave up after the last retry
+)
+
+// WebhookEndpoint is an integrator's URL subscribed to some event types.
+// The secret signs every delivery and is only shown when it is created or
+// rotated.
+type WebhookEndpoint struct {
+	ID          uint               `json:"id" gorm:"primaryKey"`
+	CompanyID   uint               `json:"company_id" gorm:"not null;index"`
+	URL         string             `json:"url" gorm:"not null"`
+	Description string             `json:"description"`
+	EventTypes  []WebhookEventType `json:"event_types" gorm:"serializer:json;type:jsonb;not null"`
+	Secret      string             `json:"secret,omitempty" gorm:"not null"`
+	IsActive    bool               `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of the type.
+func (e *WebhookEndpoint) Subscribes(eventType WebhookEventType) bool {
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is a row of the outbox: a domain event with the exact body
+// sent to every subscribed endpoint, kept so deliveries can be retried and
+// replayed.
+type WebhookEvent struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	CompanyID uint             `json:"company_id" gorm:"not null;index"`
+	EventID   string           `json:"event_id" gorm:"uniqueIndex;not null"` // sent as X-Webhook-Id for receivers to deduplicate
+	Type      WebhookEventType `json:"type" gorm:"not null"`
+	Payload   json.RawMessage  `json:"payload" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// WebhookDelivery is one event on its way to one endpoint, with the outcome
+// of its latest attempt.
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	CompanyID      uint                  `json:"company_id" gorm:"not null;index"`
+	EndpointID     uint                  `json:"endpoint_id" gorm:"not null;index"`
+	Endpoint       *WebhookEndpoint      `json:"endpoint,omitempty"`
+	EventID        uint                  `json:"event_id" gorm:"not null;index"`
+	Event          *WebhookEvent         `json:"event,omitempty"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"not null;default:'pending'"`
+	Attempts       int                   `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at"`
+	ResponseStatus *int                  `json:"response_status"`
+	ResponseBody   string                `json:"response_body"` // first 256 bytes of a 2xx answer
+	Error          string                `json:"error,omitempty"`
+	ReplayOf       *uint                 `json:"replay_of"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookEnvelope is the JSON body of every delivery.
+type WebhookEnvelope struct {
+	ID        string           `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	CompanyID uint             `json:"company_id"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data"`
+}
+
+// WebhookCargo is the cargo as integrators see it in webhook payloads.
+type WebhookCargo struct {
+	ID                 uint        `json:"id"`
+	TrackingNumber     string      `json:"tracking_number"`
+	Title              string      `json:"title"`
+	Status             CargoStatus `json:"status"`
+	CustomerID         *uint       `json:"customer_id"`
+	TruckID            *uint       `json:"truck_id"`
+	OriginAddress      string      `json:"origin_address"`
+	DestinationAddress string      `json:"destination_address"`
+	EstimatedDelivery  *time.Time  `json:"estimated_delivery"`
+	Price              *float64    `json:"price"`
+	PriceCurrency      string      `json:"price_currency"`
+}
+
+// NewWebhookCargo returns the webhook view of a cargo.
+func NewWebhookCargo(cargo *Cargo) WebhookCargo {
+	return WebhookCargo{
+		ID:                 cargo.ID,
+		TrackingNumber:     cargo.TrackingNumber,
+		Title:              cargo.Title,
+		Status:             cargo.Status,
+		CustomerID:         cargo.CustomerID,
+		TruckID:            cargo.TruckID,
+		OriginAddress:      cargo.OriginAddress,
+		DestinationAddress: cargo.DestinationAddress,
+		EstimatedDelivery:  cargo.EstimatedDelivery,
+		Price:              cargo.Price,
+		PriceCurrency:      cargo.PriceCurrency,
+	}
+}
+
+// WebhookCargoEvent is the data of cargo.event and cargo.status_changed.
+type WebhookCargoEvent struct {
+	Cargo       WebhookCargo `json:"cargo"`
+	EventType   string       `json:"event_type"`
+	Description string       `json:"description"`
+	Location    string       `json:"location"`
+	Latitude    *float64     `json:"latitude"`
+	Longitude   *float64     `json:"longitude"`
+	Timestamp   time.Time    `json:"timestamp"`
+}
+
+// WebhookTruckLocation is the data of truck.location.
+type WebhookTruckLocation struct {
+	TruckID      uint      `json:"truck_id"`
+	LicensePlate string    `json:"license_plate"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Speed        float64   `json:"speed"`
+	Heading      float64   `json:"heading"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+type CreateWebhookEndpointRequest struct {
+	URL         string             `json:"url" binding:"required,url"`
+	Description string             `json:"description"`
+	EventTypes  []WebhookEventType `json:"event_types" binding:"required,min=1"`
+}
+
+type UpdateWebhookEndpointRequest struct {
+	URL         string             `json:"url" binding:"omitempty,url"`
+	Description *string            `json:"description"`
+	EventTypes  []WebhookEventType `json:"event_types"`
+	IsActive    *bool              `json:"is_active"`
+}
+
+type WebhookDeliveryFilter struct {
+	EndpointID *uint                 `form:"endpoint_id"`
+	Status     WebhookDeliveryStatus `form:"status"`
+	EventType  WebhookEventType      `form:"event_type"`
+	Page       int                   `form:"page,default=1" binding:"min=1"`
+	Limit      int                   `form:"limit,default=20" binding:"min=1,max=100"`
+}
@@ -0,0 +1,25 @@
+package notify
+
+import "log"
+
+// LogSender writes messages to the application log instead of delivering
+// them. It stands in for a real provider in development.
+type LogSender struct {
+	channel string
+}
+
+func NewLogSender(channel string) *LogSender {
+	return &LogSender{channel: channel}
+}
+
+func (s *LogSender) Send(msg Message) error {
+	log.Printf("notify[%s] to=%s subject=%q body=%q", s.channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// NopSender discards every message.
+type NopSender struct{}
+
+func (NopSender) Send(msg Message) error {
+	return nil
+}
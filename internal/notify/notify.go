@@ -0,0 +1,15 @@
+package notify
+
+// Message is a rendered notification for one recipient. Subject is empty for
+// SMS.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages over one channel, e.g. email through an SMTP
+// relay or SMS through a gateway.
+type Sender interface {
+	Send(msg Message) error
+}
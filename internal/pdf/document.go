@@ -0,0 +1,200 @@
+// Package pdf writes simple vector PDF documents: filled rectangles, lines
+// and text in the built-in Helvetica fonts, which is all labels need.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Points per millimetre and per inch, PDF's unit being the point.
+const (
+	MM   = 72 / 25.4
+	Inch = 72.0
+)
+
+type Font string
+
+const (
+	Helvetica     Font = "F1"
+	HelveticaBold Font = "F2"
+)
+
+// Document collects pages in memory and writes them out in one go. All pages
+// share the same size; coordinates start at the bottom left corner.
+type Document struct {
+	Width  float64
+	Height float64
+	pages  []*bytes.Buffer
+}
+
+func New(width, height float64) *Document {
+	return &Document{Width: width, Height: height}
+}
+
+// AddPage starts a new page; drawing calls go to the last page added.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Rect fills a black rectangle.
+func (d *Document) Rect(x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// StrokeRect outlines a rectangle.
+func (d *Document) StrokeRect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s %s %s re S\n", num(lineWidth), num(x), num(y), num(w), num(h))
+}
+
+// Line strokes a straight line.
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n", num(lineWidth), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Polygon strokes a closed path through the given x, y pairs.
+func (d *Document) Polygon(lineWidth float64, points ...float64) {
+	p := d.page()
+	fmt.Fprintf(p, "%s w %s %s m", num(lineWidth), num(points[0]), num(points[1]))
+	for i := 2; i+1 < len(points); i += 2 {
+		fmt.Fprintf(p, " %s %s l", num(points[i]), num(points[i+1]))
+	}
+	p.WriteString(" s\n")
+}
+
+// Text draws a single line with its baseline at y. white draws it in white,
+// for text on a filled rectangle.
+func (d *Document) Text(x, y float64, font Font, size float64, text string, white bool) {
+	p := d.page()
+	if white {
+		p.WriteString("1 g\n")
+	}
+	fmt.Fprintf(p, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, num(size), num(x), num(y), escape(text))
+	if white {
+		p.WriteString("0 g\n")
+	}
+}
+
+// TextWidth estimates the width of text in Helvetica from an average glyph
+// width, close enough for wrapping and centring.
+func TextWidth(text string, font Font, size float64) float64 {
+	average := 0.52
+	if font == HelveticaBold {
+		average = 0.57
+	}
+	return float64(len([]rune(text))) * size * average
+}
+
+// Wrap breaks text into at most maxLines lines that fit width, ending the
+// last one with an ellipsis if the text does not fit.
+func Wrap(text string, font Font, size, width float64, maxLines int) []string {
+	var lines []string
+	line := ""
+	words := strings.Fields(text)
+	for _, word := range words {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || TextWidth(candidate, font, size) <= width {
+			line = candidate
+			continue
+		}
+		lines = append(lines, line)
+		line = word
+		if len(lines) == maxLines {
+			lines[maxLines-1] += "..."
+			return lines
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// WriteTo writes the document with the built-in Helvetica fonts.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page and its content stream.
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), num(d.Width), num(d.Height)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", 6+i*2))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(page.Bytes())
+		zw.Close()
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escape encodes text for a PDF string in WinAnsi, replacing characters the
+// built-in fonts cannot show.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"truck-management/internal/pdf"
+)
+
+// DangerousGoodsManifest lists the dangerous goods carried on one route, as
+// the driver must keep it in the cab.
+type DangerousGoodsManifest struct {
+	Company              string
+	Route                string
+	Date                 *time.Time
+	Truck                string
+	Placarded            bool
+	Driver               string
+	DriverCertification  string
+	DriverCertifiedUntil *time.Time
+	GeneratedAt          time.Time
+	Items                []DangerousGoodsItem
+	Warnings             []string // segregation conflicts among the items
+}
+
+type DangerousGoodsItem struct {
+	TrackingNumber     string
+	UNNumber           string
+	ProperShippingName string
+	HazardClass        string
+	PackingGroup       string
+	Quantity           float64
+	Unit               string
+	Pieces             int
+	Consignee          string
+	Destination        string
+	EmergencyPhone     string
+}
+
+// RenderDangerousGoodsManifest writes the manifest as a landscape A4 PDF: the
+// vehicle and driver, one row per shipment and a signature block.
+func RenderDangerousGoodsManifest(w io.Writer, manifest DangerousGoodsManifest) error {
+	doc := pdf.New(297*pdf.MM, 210*pdf.MM)
+	doc.AddPage()
+	left, right := margin, doc.Width-margin
+	y := doc.Height - margin
+
+	// Header
+	doc.Text(left, y-14, pdf.HelveticaBold, 16, "Dangerous Goods Manifest", false)
+	doc.Text(right-pdf.TextWidth(manifest.Company, pdf.HelveticaBold, 11), y-14, pdf.HelveticaBold, 11, manifest.Company, false)
+	y -= 24
+	doc.Line(left, y, right, y, 1.5)
+
+	y -= 16
+	doc.Text(left, y, pdf.HelveticaBold, 10, "Route: "+manifest.Route, false)
+	doc.Text(left+300, y, pdf.Helvetica, 9, "Date: "+formatTime(manifest.Date), false)
+	y -= 14
+	placarded := "yes"
+	if !manifest.Placarded {
+		placarded = "NO"
+	}
+	doc.Text(left, y, pdf.Helvetica, 9, fmt.Sprintf("Vehicle: %s   Placarded: %s", orDash(manifest.Truck), placarded), false)
+	doc.Text(left+300, y, pdf.Helvetica, 9, fmt.Sprintf("Driver: %s   Certification: %s valid until %s",
+		orDash(manifest.Driver), orDash(manifest.DriverCertification), formatTime(manifest.DriverCertifiedUntil)), false)
+	y -= 12
+	doc.Text(left, y, pdf.Helvetica, 9, "Generated: "+manifest.GeneratedAt.Format(timeLayout), false)
+
+	// Segregation warnings
+	for _, warning := range manifest.Warnings {
+		y -= 14
+		doc.Text(left, y, pdf.HelveticaBold, 9, "WARNING: "+truncate(warning, pdf.HelveticaBold, 9, right-left-60), false)
+	}
+
+	// Shipments
+	y -= 24
+	columns := []float64{left, left + 90, left + 140, left + 320, left + 360, left + 395, left + 465, left + 500, left + 640}
+	header := func() {
+		y = tableRow(doc, columns, y, pdf.HelveticaBold, "Tracking", "UN No.", "Proper shipping name", "Class", "PG", "Quantity", "Pieces", "Consignee / destination", "Emergency phone")
+		doc.Line(left, y+rowHeight-3, right, y+rowHeight-3, 0.5)
+	}
+	header()
+	for _, item := range manifest.Items {
+		if y < margin+rowHeight {
+			doc.AddPage()
+			y = doc.Height - margin
+			header()
+		}
+		packingGroup := item.PackingGroup
+		if packingGroup == "" {
+			packingGroup = "-"
+		}
+		consignee := item.Destination
+		if item.Consignee != "" {
+			consignee = item.Consignee + ", " + item.Destination
+		}
+		y = tableRow(doc, columns, y, pdf.Helvetica,
+			item.TrackingNumber,
+			item.UNNumber,
+			truncate(item.ProperShippingName, pdf.Helvetica, 8, columns[3]-columns[2]-6),
+			item.HazardClass,
+			packingGroup,
+			fmt.Sprintf("%.2f %s", item.Quantity, item.Unit),
+			fmt.Sprint(item.Pieces),
+			truncate(consignee, pdf.Helvetica, 8, columns[8]-columns[7]-6),
+			orDash(item.EmergencyPhone))
+	}
+	y -= 4
+	doc.Text(left, y, pdf.HelveticaBold, 9, fmt.Sprintf("%d dangerous goods shipments", len(manifest.Items)), false)
+
+	// Signatures
+	if y < margin+60 {
+		doc.AddPage()
+		y = doc.Height - margin
+	}
+	y -= 50
+	doc.Line(left, y, left+200, y, 0.5)
+	doc.Line(left+300, y, left+500, y, 0.5)
+	doc.Text(left, y-10, pdf.Helvetica, 8, "Shipper / dispatcher signature", false)
+	doc.Text(left+300, y-10, pdf.Helvetica, 8, "Driver signature", false)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
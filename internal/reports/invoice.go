@@ -0,0 +1,172 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"truck-management/internal/pdf"
+)
+
+const dateLayout = "2006-01-02"
+
+// InvoiceDocument is an invoice or credit note as printed.
+type InvoiceDocument struct {
+	CreditNote      bool
+	Draft           bool
+	Void            bool
+	Number          string
+	CreditedNumber  string // invoice a credit note refers to
+	CompanyName     string
+	CompanyAddress  string
+	CompanyContact  string
+	CustomerName    string
+	CustomerAddress string
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	IssuedAt        *time.Time
+	DueDate         *time.Time
+	Currency        string
+	Lines           []InvoiceDocumentLine
+	Subtotal        float64
+	TaxName         string
+	TaxRatePercent  float64
+	TaxAmount       float64
+	Total           float64
+	Reason          string
+	Notes           string
+}
+
+type InvoiceDocumentLine struct {
+	Description string
+	Date        *time.Time
+	Amount      float64
+}
+
+// RenderInvoice writes the document as an A4 PDF: seller and customer, one
+// row per shipment and the totals with tax.
+func RenderInvoice(w io.Writer, invoice InvoiceDocument) error {
+	doc := pdf.New(210*pdf.MM, 297*pdf.MM)
+	doc.AddPage()
+	left, right := margin, doc.Width-margin
+	y := doc.Height - margin
+
+	title := "Invoice"
+	if invoice.CreditNote {
+		title = "Credit Note"
+	}
+	switch {
+	case invoice.Void:
+		title += " (VOID)"
+	case invoice.Draft:
+		title += " (DRAFT)"
+	}
+
+	// Header
+	doc.Text(left, y-16, pdf.HelveticaBold, 18, title, false)
+	doc.Text(right-pdf.TextWidth(invoice.CompanyName, pdf.HelveticaBold, 12), y-14, pdf.HelveticaBold, 12, invoice.CompanyName, false)
+	sellerY := y - 28
+	for _, line := range []string{invoice.CompanyAddress, invoice.CompanyContact} {
+		if line == "" {
+			continue
+		}
+		line = truncate(line, pdf.Helvetica, 9, 220)
+		doc.Text(right-pdf.TextWidth(line, pdf.Helvetica, 9), sellerY, pdf.Helvetica, 9, line, false)
+		sellerY -= 11
+	}
+	y -= 56
+	doc.Line(left, y, right, y, 1.5)
+
+	// Document details
+	y -= 18
+	doc.Text(left, y, pdf.HelveticaBold, 10, "Bill to", false)
+	doc.Text(left+300, y, pdf.Helvetica, 9, "Number: "+orDash(invoice.Number), false)
+	y -= 14
+	doc.Text(left, y, pdf.Helvetica, 10, truncate(orDash(invoice.CustomerName), pdf.Helvetica, 10, 280), false)
+	doc.Text(left+300, y, pdf.Helvetica, 9, "Issued: "+formatDate(invoice.IssuedAt), false)
+	y -= 12
+	addressLines := pdf.Wrap(invoice.CustomerAddress, pdf.Helvetica, 9, 280, 2)
+	if len(addressLines) > 0 {
+		doc.Text(left, y, pdf.Helvetica, 9, addressLines[0], false)
+	}
+	if invoice.CreditNote {
+		doc.Text(left+300, y, pdf.Helvetica, 9, "Credits invoice: "+orDash(invoice.CreditedNumber), false)
+	} else {
+		doc.Text(left+300, y, pdf.Helvetica, 9, "Due: "+formatDate(invoice.DueDate), false)
+	}
+	y -= 12
+	if len(addressLines) > 1 {
+		doc.Text(left, y, pdf.Helvetica, 9, addressLines[1], false)
+	}
+	doc.Text(left+300, y, pdf.Helvetica, 9, fmt.Sprintf("Period: %s to %s", invoice.PeriodStart.Format(dateLayout), invoice.PeriodEnd.Format(dateLayout)), false)
+	if invoice.Reason != "" {
+		y -= 16
+		doc.Text(left, y, pdf.Helvetica, 9, "Reason: "+truncate(invoice.Reason, pdf.Helvetica, 9, right-left-40), false)
+	}
+
+	// Lines
+	y -= 26
+	columns := []float64{left, left + 70}
+	amountRight := right
+	header := func() {
+		y = tableRow(doc, columns, y, pdf.HelveticaBold, "Delivered", "Shipment")
+		label := "Amount (" + invoice.Currency + ")"
+		doc.Text(amountRight-pdf.TextWidth(label, pdf.HelveticaBold, 8), y+rowHeight, pdf.HelveticaBold, 8, label, false)
+		doc.Line(left, y+rowHeight-3, right, y+rowHeight-3, 0.5)
+	}
+	header()
+	for _, line := range invoice.Lines {
+		if y < margin+4*rowHeight {
+			doc.AddPage()
+			y = doc.Height - margin
+			header()
+		}
+		amount := formatAmount(line.Amount)
+		doc.Text(amountRight-pdf.TextWidth(amount, pdf.Helvetica, 8), y, pdf.Helvetica, 8, amount, false)
+		y = tableRow(doc, columns, y, pdf.Helvetica, formatDate(line.Date),
+			truncate(line.Description, pdf.Helvetica, 8, amountRight-columns[1]-70))
+	}
+
+	// Totals
+	if y < margin+60 {
+		doc.AddPage()
+		y = doc.Height - margin
+	}
+	y -= 6
+	doc.Line(left+300, y+rowHeight-3, right, y+rowHeight-3, 0.5)
+	totals := [][2]string{
+		{"Subtotal", formatAmount(invoice.Subtotal)},
+		{fmt.Sprintf("%s %g%%", invoice.TaxName, invoice.TaxRatePercent), formatAmount(invoice.TaxAmount)},
+		{"Total " + invoice.Currency, formatAmount(invoice.Total)},
+	}
+	for i, row := range totals {
+		font := pdf.Helvetica
+		if i == len(totals)-1 {
+			font = pdf.HelveticaBold
+		}
+		doc.Text(left+300, y, font, 9, row[0], false)
+		doc.Text(amountRight-pdf.TextWidth(row[1], font, 9), y, font, 9, row[1], false)
+		y -= 14
+	}
+
+	if invoice.Notes != "" {
+		y -= 10
+		for _, line := range pdf.Wrap(invoice.Notes, pdf.Helvetica, 9, right-left, 6) {
+			doc.Text(left, y, pdf.Helvetica, 9, line, false)
+			y -= 11
+		}
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(dateLayout)
+}
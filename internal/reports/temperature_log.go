@@ -0,0 +1,234 @@
+// Package reports renders compliance documents as PDF.
+package reports
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"truck-management/internal/pdf"
+)
+
+const (
+	margin     = 40.0
+	rowHeight  = 12.0
+	timeLayout = "2006-01-02 15:04 MST"
+)
+
+// TemperatureLog is the cold-chain record of one cargo: its required range,
+// every reading that applied to it and the excursions detected.
+type TemperatureLog struct {
+	Company          string
+	TrackingNumber   string
+	Title            string
+	MinTemperature   float64 // in °C
+	MaxTemperature   float64
+	ThresholdMinutes int
+	PickedUpAt       *time.Time
+	DeliveredAt      *time.Time
+	GeneratedAt      time.Time
+	Readings         []TemperatureSample
+	Excursions       []TemperatureExcursion
+}
+
+type TemperatureSample struct {
+	Time        time.Time
+	Temperature float64
+	Source      string // sensor or truck the reading came from
+}
+
+type TemperatureExcursion struct {
+	Direction string // above or below
+	Start     time.Time
+	End       *time.Time
+	Duration  time.Duration
+	Peak      float64
+	Alerted   bool
+}
+
+// RenderTemperatureLog writes the log as an A4 PDF: a summary with a chart of
+// the readings against the range, the excursions, then every reading.
+func RenderTemperatureLog(w io.Writer, log TemperatureLog) error {
+	doc := pdf.New(210*pdf.MM, 297*pdf.MM)
+	doc.AddPage()
+	left, right := margin, doc.Width-margin
+	y := doc.Height - margin
+
+	// Header
+	doc.Text(left, y-14, pdf.HelveticaBold, 16, "Temperature Log", false)
+	doc.Text(right-pdf.TextWidth(log.Company, pdf.HelveticaBold, 11), y-14, pdf.HelveticaBold, 11, log.Company, false)
+	y -= 24
+	doc.Line(left, y, right, y, 1.5)
+
+	y -= 16
+	doc.Text(left, y, pdf.HelveticaBold, 10, log.TrackingNumber, false)
+	doc.Text(left+140, y, pdf.Helvetica, 10, truncate(log.Title, pdf.Helvetica, 10, right-left-140), false)
+	y -= 14
+	doc.Text(left, y, pdf.Helvetica, 9, fmt.Sprintf("Required range: %s to %s, alert after %d min out of range",
+		formatTemperature(log.MinTemperature), formatTemperature(log.MaxTemperature), log.ThresholdMinutes), false)
+	y -= 12
+	doc.Text(left, y, pdf.Helvetica, 9, fmt.Sprintf("Picked up: %s   Delivered: %s", formatTime(log.PickedUpAt), formatTime(log.DeliveredAt)), false)
+	y -= 12
+	doc.Text(left, y, pdf.Helvetica, 9, "Generated: "+log.GeneratedAt.Format(timeLayout), false)
+
+	// Summary
+	y -= 20
+	doc.Text(left, y, pdf.HelveticaBold, 11, "Summary", false)
+	y -= 14
+	doc.Text(left, y, pdf.Helvetica, 9, summary(log), false)
+
+	// Chart
+	if len(log.Readings) > 1 {
+		chartHeight := 150.0
+		y -= 12 + chartHeight
+		drawChart(doc, log, left+30, y, right-left-30, chartHeight)
+		y -= 16
+	}
+
+	// Excursions
+	y -= 18
+	doc.Text(left, y, pdf.HelveticaBold, 11, "Excursions", false)
+	y -= 14
+	if len(log.Excursions) == 0 {
+		doc.Text(left, y, pdf.Helvetica, 9, "None: the cargo stayed within its range.", false)
+	} else {
+		columns := []float64{left, left + 60, left + 190, left + 320, left + 390, left + 450}
+		y = tableRow(doc, columns, y, pdf.HelveticaBold, "Direction", "Start", "End", "Duration", "Peak", "Alerted")
+		for _, e := range log.Excursions {
+			if y < margin+rowHeight {
+				doc.AddPage()
+				y = doc.Height - margin
+			}
+			end := "ongoing"
+			if e.End != nil {
+				end = e.End.Format(timeLayout)
+			}
+			alerted := "no"
+			if e.Alerted {
+				alerted = "yes"
+			}
+			y = tableRow(doc, columns, y, pdf.Helvetica, e.Direction, e.Start.Format(timeLayout), end,
+				formatDuration(e.Duration), formatTemperature(e.Peak), alerted)
+		}
+	}
+
+	// Readings
+	y -= 18
+	if y < margin+3*rowHeight {
+		doc.AddPage()
+		y = doc.Height - margin
+	}
+	doc.Text(left, y, pdf.HelveticaBold, 11, fmt.Sprintf("Readings (%d)", len(log.Readings)), false)
+	y -= 14
+	columns := []float64{left, left + 150, left + 230, left + 300}
+	header := func() {
+		y = tableRow(doc, columns, y, pdf.HelveticaBold, "Time", "Temperature", "In range", "Source")
+	}
+	header()
+	for _, r := range log.Readings {
+		if y < margin+rowHeight {
+			doc.AddPage()
+			y = doc.Height - margin
+			header()
+		}
+		inRange := "yes"
+		if r.Temperature < log.MinTemperature || r.Temperature > log.MaxTemperature {
+			inRange = "NO"
+		}
+		y = tableRow(doc, columns, y, pdf.Helvetica, r.Time.Format(timeLayout), formatTemperature(r.Temperature), inRange,
+			truncate(r.Source, pdf.Helvetica, 8, right-columns[3]))
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func summary(log TemperatureLog) string {
+	if len(log.Readings) == 0 {
+		return "No readings were recorded."
+	}
+
+	low, high, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, r := range log.Readings {
+		low = math.Min(low, r.Temperature)
+		high = math.Max(high, r.Temperature)
+		sum += r.Temperature
+	}
+	var outside time.Duration
+	for _, e := range log.Excursions {
+		outside += e.Duration
+	}
+
+	return fmt.Sprintf("%d readings, min %s, max %s, mean %s; %d excursions totalling %s out of range",
+		len(log.Readings), formatTemperature(low), formatTemperature(high), formatTemperature(sum/float64(len(log.Readings))),
+		len(log.Excursions), formatDuration(outside))
+}
+
+// drawChart plots the readings over time inside a frame, with the required
+// range as two horizontal lines.
+func drawChart(doc *pdf.Document, log TemperatureLog, x, y, width, height float64) {
+	low, high := log.MinTemperature, log.MaxTemperature
+	for _, r := range log.Readings {
+		low = math.Min(low, r.Temperature)
+		high = math.Max(high, r.Temperature)
+	}
+	pad := math.Max((high-low)*0.1, 0.5)
+	low, high = low-pad, high+pad
+
+	start := log.Readings[0].Time
+	span := log.Readings[len(log.Readings)-1].Time.Sub(start).Seconds()
+	if span <= 0 {
+		span = 1
+	}
+	px := func(t time.Time) float64 { return x + t.Sub(start).Seconds()/span*width }
+	py := func(temperature float64) float64 { return y + (temperature-low)/(high-low)*height }
+
+	doc.StrokeRect(x, y, width, height, 0.5)
+	for _, limit := range []float64{log.MinTemperature, log.MaxTemperature} {
+		doc.Line(x, py(limit), x+width, py(limit), 0.3)
+		label := formatTemperature(limit)
+		doc.Text(x-4-pdf.TextWidth(label, pdf.Helvetica, 7), py(limit)-2, pdf.Helvetica, 7, label, false)
+	}
+	for i := 1; i < len(log.Readings); i++ {
+		prev, cur := log.Readings[i-1], log.Readings[i]
+		doc.Line(px(prev.Time), py(prev.Temperature), px(cur.Time), py(cur.Temperature), 1)
+	}
+
+	doc.Text(x, y-10, pdf.Helvetica, 7, start.Format(timeLayout), false)
+	end := log.Readings[len(log.Readings)-1].Time.Format(timeLayout)
+	doc.Text(x+width-pdf.TextWidth(end, pdf.Helvetica, 7), y-10, pdf.Helvetica, 7, end, false)
+}
+
+func tableRow(doc *pdf.Document, columns []float64, y float64, font pdf.Font, cells ...string) float64 {
+	for i, cell := range cells {
+		doc.Text(columns[i], y, font, 8, cell, false)
+	}
+	return y - rowHeight
+}
+
+func truncate(text string, font pdf.Font, size, width float64) string {
+	lines := pdf.Wrap(text, font, size, width, 1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+func formatTemperature(t float64) string {
+	return fmt.Sprintf("%.1f °C", t)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(timeLayout)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
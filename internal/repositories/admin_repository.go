@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AdminRepository holds the database work of the operator commands that
+// reach across tenants or below the models.
+type AdminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) *AdminRepository {
+	return &AdminRepository{db: db}
+}
+
+// GetCompany returns a company, also when it has been deleted.
+func (r *AdminRepository) GetCompany(id uint) (*models.Company, error) {
+	var company models.Company
+	err := r.db.Unscoped().First(&company, id).Error
+	return &company, err
+}
+
+func (r *AdminRepository) CompanyLicenseExists(license string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Company{}).Where("license = ?", license).Count(&count).Error
+	return count > 0, err
+}
+
+// companyTables lists the tables with a company_id column.
+func (r *AdminRepository) companyTables() ([]string, error) {
+	var tables []string
+	err := r.db.Raw(`SELECT c.table_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND c.column_name = 'company_id' AND t.table_type = 'BASE TABLE'
+			AND NOT EXISTS (SELECT 1 FROM pg_inherits i JOIN pg_class p ON p.oid = i.inhrelid WHERE p.relname = c.table_name)
+		ORDER BY c.table_name`).Scan(&tables).Error
+	return tables, err
+}
+
+// CountCompanyRows counts the rows a company has in each table with a
+// company_id column, leaving out empty tables.
+func (r *AdminRepository) CountCompanyRows(companyID uint) (map[string]int64, error) {
+	tables, err := r.companyTables()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]int64)
+	for _, table := range tables {
+		var count int64
+		err := r.db.Table(quoteIdentifier(table)).Where("company_id = ?", companyID).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			rows[table] = count
+		}
+	}
+	return rows, nil
+}
+
+// GetCompanyFileKeys returns the blob store keys of the files a company
+// keeps: attachments, proof of delivery signatures, temperature logs and
+// photos, location archives and the uploads of unfinished cargo imports.
+func (r *AdminRepository) GetCompanyFileKeys(companyID uint) ([]string, error) {
+	var keys []string
+	err := r.db.Raw(`SELECT storage_key FROM attachments WHERE company_id = @company
+		UNION ALL SELECT signature_key FROM proof_of_deliveries WHERE company_id = @company AND signature_key <> ''
+		UNION ALL SELECT temperature_log_key FROM proof_of_deliveries WHERE company_id = @company AND temperature_log_key <> ''
+		UNION ALL SELECT p.storage_key FROM proof_of_delivery_photos p
+			JOIN proof_of_deliveries d ON d.id = p.proof_of_delivery_id WHERE d.company_id = @company
+		UNION ALL SELECT storage_key FROM location_archives WHERE company_id = @company
+		UNION ALL SELECT payload->>'storage_key' FROM jobs
+			WHERE company_id = @company AND type = @import AND status <> @succeeded AND payload->>'storage_key' <> ''`,
+		map[string]interface{}{"company": companyID, "import": models.JobTypeCargoImport, "succeeded": models.JobStatusSucceeded}).Scan(&keys).Error
+	return keys, err
+}
+
+// foreignKey is a reference from a row of one table to a row of another.
+type foreignKey struct {
+	Child  string
+	Parent string
+}
+
+// foreignKeys lists the references between the tables of the schema,
+// leaving out those of a table to itself.
+func (r *AdminRepository) foreignKeys() ([]foreignKey, error) {
+	var keys []foreignKey
+	err := r.db.Raw(`SELECT child.relname AS child, parent.relname AS parent FROM pg_constraint k
+		JOIN pg_class child ON child.oid = k.conrelid
+		JOIN pg_class parent ON parent.oid = k.confrelid
+		JOIN pg_namespace n ON n.oid = child.relnamespace
+		WHERE k.contype = 'f' AND n.nspname = current_schema() AND k.conrelid <> k.confrelid`).Scan(&keys).Error
+	return keys, err
+}
+
+// childrenFirst orders the tables so each comes before the tables it
+// references. Tables in a reference cycle keep their relative order.
+func childrenFirst(tables []string, keys []foreignKey) []string {
+	listed := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		listed[table] = true
+	}
+	children := make(map[string][]string)
+	for _, key := range keys {
+		if listed[key.Child] && listed[key.Parent] {
+			children[key.Parent] = append(children[key.Parent], key.Child)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+	var visit func(table string)
+	visit = func(table string) {
+		if visited[table] {
+			return
+		}
+		visited[table] = true
+		for _, child := range children[table] {
+			visit(child)
+		}
+		ordered = append(ordered, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return ordered
+}
+
+// PurgeCompany deletes a company for good, in one transaction. Its rows are
+// deleted by their company_id, referencing tables before the tables they
+// reference so no foreign key is left dangling on the way; rows without a
+// company_id go with their parents through ON DELETE CASCADE. The company
+// itself goes last.
+func (r *AdminRepository) PurgeCompany(companyID uint) error {
+	tables, err := r.companyTables()
+	if err != nil {
+		return err
+	}
+	keys, err := r.foreignKeys()
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range childrenFirst(tables, keys) {
+			if err := tx.Exec("DELETE FROM "+quoteIdentifier(table)+" WHERE company_id = ?", companyID).Error; err != nil {
+				return fmt.Errorf("delete from %s: %w", table, err)
+			}
+		}
+		return tx.Unscoped().Delete(&models.Company{}, companyID).Error
+	})
+}
+
+// GetTables lists the tables of the schema that hold rows, partitions
+// included and partitioned parents left out.
+func (r *AdminRepository) GetTables() ([]string, error) {
+	var tables []string
+	err := r.db.Raw(`SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind = 'r'
+		ORDER BY c.relname`).Scan(&tables).Error
+	return tables, err
+}
+
+// ReindexTable rebuilds a table's indexes without blocking writes.
+func (r *AdminRepository) ReindexTable(table string) error {
+	return r.db.Exec("REINDEX TABLE CONCURRENTLY " + quoteIdentifier(table)).Error
+}
+
+// Analyze refreshes the planner statistics of the database.
+func (r *AdminRepository) Analyze() error {
+	return r.db.Exec("ANALYZE").Error
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"errors"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *AttachmentRepository) GetByID(id uint, companyID uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("UploadedByUser").
+		First(&attachment).Error
+	return &attachment, err
+}
+
+// GetByIDUnscoped loads an attachment without tenant filtering. It is only used
+// to serve downloads whose signed URL has already been verified.
+func (r *AttachmentRepository) GetByIDUnscoped(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.First(&attachment, id).Error
+	return &attachment, err
+}
+
+func (r *AttachmentRepository) GetByOwner(companyID uint, filter models.AttachmentFilter) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	query := r.db.Where("company_id = ? AND owner_type = ? AND owner_id = ?", companyID, filter.OwnerType, filter.OwnerID).
+		Preload("UploadedByUser")
+
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+
+	err := query.Order("created_at DESC").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *AttachmentRepository) Delete(id uint, companyID uint) error {
+	return r.db.Where("company_id = ?", companyID).Delete(&models.Attachment{}, id).Error
+}
+
+func (r *AttachmentRepository) GetUsage(companyID uint) (int64, int64, error) {
+	var usage struct {
+		Used  int64
+		Count int64
+	}
+	err := r.db.Model(&models.Attachment{}).
+		Select("COALESCE(SUM(size), 0) AS used, COUNT(*) AS count").
+		Where("company_id = ?", companyID).
+		Scan(&usage).Error
+	return usage.Used, usage.Count, err
+}
+
+// OwnerExists checks that the entity an attachment points at belongs to the
+// company. Tasks carry no company_id of their own, so they go through visits.
+func (r *AttachmentRepository) OwnerExists(ownerType models.AttachmentOwnerType, ownerID uint, companyID uint) (bool, error) {
+	var query *gorm.DB
+	switch ownerType {
+	case models.AttachmentOwnerCargo:
+		query = r.db.Model(&models.Cargo{}).Where("id = ? AND company_id = ?", ownerID, companyID)
+	case models.AttachmentOwnerTruck:
+		query = r.db.Model(&models.Truck{}).Where("id = ? AND company_id = ?", ownerID, companyID)
+	case models.AttachmentOwnerRequest:
+		query = r.db.Model(&models.Request{}).Where("id = ? AND company_id = ?", ownerID, companyID)
+	case models.AttachmentOwnerVisit:
+		query = r.db.Model(&models.Visit{}).Where("id = ? AND company_id = ?", ownerID, companyID)
+	case models.AttachmentOwnerTask:
+		query = r.db.Model(&models.Task{}).
+			Joins("JOIN visits ON visits.id = tasks.visit_id AND visits.deleted_at IS NULL").
+			Where("tasks.id = ? AND visits.company_id = ?", ownerID, companyID)
+	default:
+		return false, errors.New("unknown attachment owner type")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
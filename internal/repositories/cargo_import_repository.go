@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CargoImportRepository struct {
+	db *gorm.DB
+}
+
+func NewCargoImportRepository(db *gorm.DB) *CargoImportRepository {
+	return &CargoImportRepository{db: db}
+}
+
+func (r *CargoImportRepository) Create(job *models.CargoImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *CargoImportRepository) GetByID(id uint, companyID uint) (*models.CargoImportJob, error) {
+	var job models.CargoImportJob
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("CreatedByUser").
+		Preload("Errors", func(db *gorm.DB) *gorm.DB {
+			return db.Order("row ASC, id ASC")
+		}).
+		First(&job).Error
+	return &job, err
+}
+
+func (r *CargoImportRepository) GetByCompanyID(companyID uint, filter models.CargoImportFilter) ([]models.CargoImportJob, int64, error) {
+	var jobs []models.CargoImportJob
+	var total int64
+
+	query := r.db.Model(&models.CargoImportJob{}).Where("company_id = ?", companyID)
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Preload("CreatedByUser").
+		Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+// UpdateProgress writes the job's counters and status without touching its errors.
+func (r *CargoImportRepository) UpdateProgress(job *models.CargoImportJob) error {
+	return r.db.Model(job).
+		Select("Status", "TotalRows", "ProcessedRows", "ValidRows", "CreatedRows", "Error", "StartedAt", "CompletedAt").
+		Updates(job).Error
+}
+
+func (r *CargoImportRepository) CreateErrors(rowErrors []models.CargoImportRowError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(rowErrors, 500).Error
+}
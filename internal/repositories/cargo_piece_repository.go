@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CargoPieceRepository struct {
+	db *gorm.DB
+}
+
+func NewCargoPieceRepository(db *gorm.DB) *CargoPieceRepository {
+	return &CargoPieceRepository{db: db}
+}
+
+// CreatePieces numbers new pieces after the cargo's existing ones and derives
+// their barcodes from the tracking number.
+func (r *CargoPieceRepository) CreatePieces(cargo *models.Cargo, pieces []models.CargoPiece) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		err := tx.Unscoped().Model(&models.CargoPiece{}).
+			Where("cargo_id = ?", cargo.ID).
+			Select("COALESCE(MAX(piece_number), 0)").
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range pieces {
+			pieces[i].CompanyID = cargo.CompanyID
+			pieces[i].CargoID = cargo.ID
+			pieces[i].PieceNumber = last + i + 1
+			pieces[i].Barcode = fmt.Sprintf("%s-%03d", cargo.TrackingNumber, pieces[i].PieceNumber)
+		}
+		return tx.Create(&pieces).Error
+	})
+}
+
+func (r *CargoPieceRepository) GetByCargoID(cargoID uint, companyID uint) ([]models.CargoPiece, error) {
+	var pieces []models.CargoPiece
+	err := r.db.Where("cargo_id = ? AND company_id = ?", cargoID, companyID).
+		Order("piece_number ASC").
+		Find(&pieces).Error
+	return pieces, err
+}
+
+func (r *CargoPieceRepository) GetByID(id uint, cargoID uint, companyID uint) (*models.CargoPiece, error) {
+	var piece models.CargoPiece
+	err := r.db.Where("id = ? AND cargo_id = ? AND company_id = ?", id, cargoID, companyID).
+		First(&piece).Error
+	return &piece, err
+}
+
+func (r *CargoPieceRepository) GetByBarcode(barcode string, companyID uint) (*models.CargoPiece, error) {
+	var piece models.CargoPiece
+	err := r.db.Where("barcode = ? AND company_id = ?", barcode, companyID).
+		First(&piece).Error
+	return &piece, err
+}
+
+func (r *CargoPieceRepository) GetByBarcodes(barcodes []string, companyID uint) ([]models.CargoPiece, error) {
+	var pieces []models.CargoPiece
+	err := r.db.Where("barcode IN ? AND company_id = ?", barcodes, companyID).
+		Find(&pieces).Error
+	return pieces, err
+}
+
+func (r *CargoPieceRepository) GetByHandlingUnitID(handlingUnitID uint) ([]models.CargoPiece, error) {
+	var pieces []models.CargoPiece
+	err := r.db.Where("handling_unit_id = ?", handlingUnitID).
+		Order("cargo_id ASC, piece_number ASC").
+		Find(&pieces).Error
+	return pieces, err
+}
+
+func (r *CargoPieceRepository) Update(piece *models.CargoPiece) error {
+	return r.db.Save(piece).Error
+}
+
+func (r *CargoPieceRepository) Delete(id uint) error {
+	return r.db.Delete(&models.CargoPiece{}, id).Error
+}
+
+// SetStatusForCargo moves every piece of a cargo that is still in one of the
+// from statuses to the given status, used when the whole cargo is scanned.
+func (r *CargoPieceRepository) SetStatusForCargo(cargoID uint, from []models.CargoPieceStatus, to models.CargoPieceStatus, at time.Time) error {
+	column := "picked_up_at"
+	if to == models.CargoPieceStatusDelivered {
+		column = "delivered_at"
+	}
+	return r.db.Model(&models.CargoPiece{}).
+		Where("cargo_id = ? AND status IN ?", cargoID, from).
+		Updates(map[string]interface{}{"status": to, column: at}).Error
+}
+
+// Totals sums the weight and volume of a cargo's pieces.
+func (r *CargoPieceRepository) Totals(cargoID uint) (count int64, weight float64, volume float64, err error) {
+	var totals struct {
+		Count  int64
+		Weight float64
+		Volume float64
+	}
+	err = r.db.Model(&models.CargoPiece{}).
+		Where("cargo_id = ?", cargoID).
+		Select("COUNT(*) AS count, COALESCE(SUM(weight), 0) AS weight, COALESCE(SUM(volume), 0) AS volume").
+		Scan(&totals).Error
+	return totals.Count, totals.Weight, totals.Volume, err
+}
+
+func (r *CargoPieceRepository) CreateHandlingUnit(unit *models.HandlingUnit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(unit).Error
+		if err != nil {
+			return err
+		}
+
+		// The barcode is derived from the ID, which is only known after insert
+		unit.Barcode = fmt.Sprintf("HU%08d", unit.ID)
+		return tx.Model(unit).Update("barcode", unit.Barcode).Error
+	})
+}
+
+func (r *CargoPieceRepository) GetHandlingUnits(companyID uint, filter models.HandlingUnitFilter) ([]models.HandlingUnit, int64, error) {
+	var units []models.HandlingUnit
+	var total int64
+
+	query := r.db.Model(&models.HandlingUnit{}).Where("company_id = ?", companyID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	// Count total
+	query.Count(&total)
+
+	// Apply pagination
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&units).Error
+
+	return units, total, err
+}
+
+func (r *CargoPieceRepository) GetHandlingUnit(id uint, companyID uint) (*models.HandlingUnit, error) {
+	var unit models.HandlingUnit
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Pieces", func(db *gorm.DB) *gorm.DB {
+			return db.Order("cargo_id ASC, piece_number ASC")
+		}).
+		First(&unit).Error
+	return &unit, err
+}
+
+func (r *CargoPieceRepository) GetHandlingUnitByBarcode(barcode string, companyID uint) (*models.HandlingUnit, error) {
+	var unit models.HandlingUnit
+	err := r.db.Where("barcode = ? AND company_id = ?", barcode, companyID).
+		First(&unit).Error
+	return &unit, err
+}
+
+func (r *CargoPieceRepository) UpdateHandlingUnit(unit *models.HandlingUnit) error {
+	return r.db.Omit("Pieces").Save(unit).Error
+}
+
+// AssignToHandlingUnit moves pieces onto a unit, or off any unit when
+// handlingUnitID is nil.
+func (r *CargoPieceRepository) AssignToHandlingUnit(pieceIDs []uint, handlingUnitID *uint) error {
+	return r.db.Model(&models.CargoPiece{}).
+		Where("id IN ?", pieceIDs).
+		Update("handling_unit_id", handlingUnitID).Error
+}
+
+// HandlingUnitTotals sums the pieces currently on a unit.
+func (r *CargoPieceRepository) HandlingUnitTotals(handlingUnitID uint) (count int64, weight float64, volume float64, err error) {
+	var totals struct {
+		Count  int64
+		Weight float64
+		Volume float64
+	}
+	err = r.db.Model(&models.CargoPiece{}).
+		Where("handling_unit_id = ?", handlingUnitID).
+		Select("COUNT(*) AS count, COALESCE(SUM(weight), 0) AS weight, COALESCE(SUM(volume), 0) AS volume").
+		Scan(&totals).Error
+	return totals.Count, totals.Weight, totals.Volume, err
+}
@@ -1,7 +1,6 @@
 package repositories
 
 import (
-	"fmt"
 	"truck-management/internal/models"
 
 	"gorm.io/gorm"
@@ -15,20 +14,82 @@ func NewCargoRepository(db *gorm.DB) *CargoRepository {
 	return &CargoRepository{db: db}
 }
 
+// Create inserts the cargo and records cargo.created in the outbox.
 func (r *CargoRepository) Create(cargo *models.Cargo) error {
-	return r.db.Create(cargo).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cargo).Error; err != nil {
+			return err
+		}
+		return recordCargoCreated(tx, cargo)
+	})
+}
+
+// CreateBatch inserts cargos together with their CargoEvents in a single
+// transaction, so either every row is stored or none is.
+func (r *CargoRepository) CreateBatch(cargos []models.Cargo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(cargos, 100).Error; err != nil {
+			return err
+		}
+		for i := range cargos {
+			if err := recordCargoCreated(tx, &cargos[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func recordCargoCreated(tx *gorm.DB, cargo *models.Cargo) error {
+	event, err := models.NewDomainEvent(cargo.CompanyID, models.DomainEventCargoCreated, "cargo", cargo.ID, models.CargoCreated{CargoID: cargo.ID})
+	if err != nil {
+		return err
+	}
+	return NewOutboxRepository(tx).Record(event)
 }
 
 func (r *CargoRepository) GetByCompanyID(companyID uint, filter models.CargoFilter) ([]models.Cargo, int64, error) {
 	var cargos []models.Cargo
 	var total int64
 
-	query := r.db.Where("company_id = ?", companyID).
+	query := applyCargoFilter(r.db.Where("company_id = ?", companyID), filter).
 		Preload("Truck").
 		Preload("AssignedByUser").
 		Preload("Company")
 
-	// Apply filters
+	// Count total
+	query.Model(&models.Cargo{}).Count(&total)
+
+	// Apply pagination
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&cargos).Error
+
+	return cargos, total, err
+}
+
+// StreamByCompanyID calls fn for every cargo matching the filter, reading rows
+// from the database one at a time. Pagination fields are ignored.
+func (r *CargoRepository) StreamByCompanyID(companyID uint, filter models.CargoFilter, fn func(*models.Cargo) error) error {
+	query := applyCargoFilter(r.db.Model(&models.Cargo{}).Where("company_id = ?", companyID), filter)
+	rows, err := query.Order("created_at ASC, id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cargo models.Cargo
+		if err := r.db.ScanRows(rows, &cargo); err != nil {
+			return err
+		}
+		if err := fn(&cargo); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func applyCargoFilter(query *gorm.DB, filter models.CargoFilter) *gorm.DB {
 	if filter.Status != "" {
 		query = query.Where("status = ?", filter.Status)
 	}
@@ -41,6 +102,9 @@ func (r *CargoRepository) GetByCompanyID(companyID uint, filter models.CargoFilt
 	if filter.TruckID != nil {
 		query = query.Where("truck_id = ?", *filter.TruckID)
 	}
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
 	if filter.Assigned != nil {
 		if *filter.Assigned {
 			query = query.Where("truck_id IS NOT NULL")
@@ -48,20 +112,25 @@ func (r *CargoRepository) GetByCompanyID(companyID uint, filter models.CargoFilt
 			query = query.Where("truck_id IS NULL")
 		}
 	}
+	if filter.AwaitingApproval != nil {
+		if *filter.AwaitingApproval {
+			query = query.Where("booked_by IS NOT NULL AND booking_approved_at IS NULL")
+		} else {
+			query = query.Where("booked_by IS NULL OR booking_approved_at IS NOT NULL")
+		}
+	}
 	if filter.Search != "" {
 		searchTerm := "%" + filter.Search + "%"
 		query = query.Where("title ILIKE ? OR tracking_number ILIKE ? OR description ILIKE ?", 
 			searchTerm, searchTerm, searchTerm)
 	}
-
-	// Count total
-	query.Model(&models.Cargo{}).Count(&total)
-
-	// Apply pagination
-	offset := (filter.Page - 1) * filter.Limit
-	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&cargos).Error
-
-	return cargos, total, err
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", filter.CreatedTo.AddDate(0, 0, 1))
+	}
+	return query
 }
 
 func (r *CargoRepository) GetByID(id uint, companyID uint) (*models.Cargo, error) {
@@ -71,8 +140,26 @@ func (r *CargoRepository) GetByID(id uint, companyID uint) (*models.Cargo, error
 		Preload("Truck.Driver").
 		Preload("AssignedByUser").
 		Preload("Company").
+		Preload("Customer").
 		Preload("CargoEvents").
 		Preload("CargoEvents.User").
+		Preload("ShipmentLegs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence ASC")
+		}).
+		Preload("Pieces", func(db *gorm.DB) *gorm.DB {
+			return db.Order("piece_number ASC")
+		}).
+		First(&cargo).Error
+	return &cargo, err
+}
+
+// FindByID looks a cargo up without a company scope, for background jobs
+// started from a domain event.
+func (r *CargoRepository) FindByID(id uint) (*models.Cargo, error) {
+	var cargo models.Cargo
+	err := r.db.Where("id = ?", id).
+		Preload("Company").
+		Preload("Pieces").
 		First(&cargo).Error
 	return &cargo, err
 }
@@ -86,6 +173,12 @@ func (r *CargoRepository) GetByTrackingNumber(trackingNumber string) (*models.Ca
 		Preload("Company").
 		Preload("CargoEvents").
 		Preload("CargoEvents.User").
+		Preload("ShipmentLegs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence ASC")
+		}).
+		Preload("Pieces", func(db *gorm.DB) *gorm.DB {
+			return db.Order("piece_number ASC")
+		}).
 		First(&cargo).Error
 	return &cargo, err
 }
@@ -106,8 +199,56 @@ func (r *CargoRepository) GetByTruckID(truckID uint, companyID uint) ([]models.C
 	return cargos, err
 }
 
+// GetByRouteID returns the cargo carried on a route: cargo with a shipment leg
+// on the route and, when the route has a truck, open cargo assigned to it.
+func (r *CargoRepository) GetByRouteID(routeID uint, truckID *uint, companyID uint) ([]models.Cargo, error) {
+	var cargos []models.Cargo
+	onRoute := r.db.Model(&models.ShipmentLeg{}).Select("cargo_id").Where("route_id = ?", routeID)
+	query := r.db.Where("company_id = ?", companyID)
+	if truckID != nil {
+		query = query.Where(r.db.Where("id IN (?)", onRoute).
+			Or("truck_id = ? AND status IN ?", *truckID, []models.CargoStatus{models.CargoStatusAssigned, models.CargoStatusInTransit, models.CargoStatusPartiallyDelivered}))
+	} else {
+		query = query.Where("id IN (?)", onRoute)
+	}
+	err := query.
+		Preload("Company").
+		Preload("Pieces", func(db *gorm.DB) *gorm.DB {
+			return db.Order("piece_number ASC")
+		}).
+		Order("id ASC").
+		Find(&cargos).Error
+	return cargos, err
+}
+
+// CreateEvent inserts a tracking event and records cargo.event_recorded in
+// the outbox.
 func (r *CargoRepository) CreateEvent(event *models.CargoEvent) error {
-	return r.db.Create(event).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		var cargo models.Cargo
+		if err := tx.Select("id", "company_id").First(&cargo, event.CargoID).Error; err != nil {
+			return err
+		}
+		recorded, err := models.NewDomainEvent(cargo.CompanyID, models.DomainEventCargoEventRecorded, "cargo", cargo.ID, models.CargoEventRecorded{
+			CargoID:      cargo.ID,
+			CargoEventID: event.ID,
+			EventType:    event.EventType,
+		})
+		if err != nil {
+			return err
+		}
+		return NewOutboxRepository(tx).Record(recorded)
+	})
+}
+
+func (r *CargoRepository) GetEventByID(id uint) (*models.CargoEvent, error) {
+	var event models.CargoEvent
+	err := r.db.First(&event, id).Error
+	return &event, err
 }
 
 func (r *CargoRepository) GetEventsByCargoID(cargoID uint) ([]models.CargoEvent, error) {
@@ -119,12 +260,6 @@ func (r *CargoRepository) GetEventsByCargoID(cargoID uint) ([]models.CargoEvent,
 	return events, err
 }
 
-func (r *CargoRepository) GenerateTrackingNumber(companyID uint) (string, error) {
-	var count int64
-	r.db.Model(&models.Cargo{}).Where("company_id = ?", companyID).Count(&count)
-	return fmt.Sprintf("TRK%d%06d", companyID, count+1), nil
-}
-
 func (r *CargoRepository) GetUnassignedCargos(companyID uint) ([]models.Cargo, error) {
 	var cargos []models.Cargo
 	err := r.db.Where("company_id = ? AND truck_id IS NULL AND status = ?", 
@@ -155,4 +290,24 @@ func (r *CargoRepository) GetCargoInTransit(companyID uint) ([]models.Cargo, err
 		Preload("Truck.LastLocation").
 		Find(&cargos).Error
 	return cargos, err
-}
\ No newline at end of file
+}
+// GetMonitoredByTruckID returns the picked-up cargo on a truck that has a
+// required temperature range, i.e. what the truck's reefer readings apply to.
+func (r *CargoRepository) GetMonitoredByTruckID(truckID uint, companyID uint) ([]models.Cargo, error) {
+	var cargos []models.Cargo
+	err := r.db.Where("truck_id = ? AND company_id = ? AND status IN ?", truckID, companyID,
+		[]models.CargoStatus{models.CargoStatusInTransit, models.CargoStatusPartiallyDelivered}).
+		Where("min_temperature IS NOT NULL AND max_temperature IS NOT NULL").
+		Find(&cargos).Error
+	return cargos, err
+}
+
+// GetOpenByTruckID returns the cargo a truck is loaded with or about to load:
+// assigned, in transit or partially delivered.
+func (r *CargoRepository) GetOpenByTruckID(truckID uint, companyID uint) ([]models.Cargo, error) {
+	var cargos []models.Cargo
+	err := r.db.Where("truck_id = ? AND company_id = ? AND status IN ?", truckID, companyID,
+		[]models.CargoStatus{models.CargoStatusAssigned, models.CargoStatusInTransit, models.CargoStatusPartiallyDelivered}).
+		Find(&cargos).Error
+	return cargos, err
+}
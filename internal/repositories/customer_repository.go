@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustomerRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository(db *gorm.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// Create stores a customer together with its contacts and addresses.
+func (r *CustomerRepository) Create(customer *models.Customer) error {
+	return r.db.Create(customer).Error
+}
+
+func (r *CustomerRepository) GetByCompanyID(companyID uint, filter models.CustomerFilter) ([]models.Customer, int64, error) {
+	var customers []models.Customer
+	var total int64
+
+	query := r.db.Model(&models.Customer{}).Where("company_id = ?", companyID)
+	if filter.Search != "" {
+		searchTerm := "%" + filter.Search + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ? OR phone ILIKE ?", searchTerm, searchTerm, searchTerm)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("name ASC, id ASC").Find(&customers).Error
+
+	return customers, total, err
+}
+
+func (r *CustomerRepository) GetByID(id uint, companyID uint) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Contacts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("is_primary DESC, name ASC")
+		}).
+		Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("is_default DESC, label ASC, id ASC")
+		}).
+		First(&customer).Error
+	return &customer, err
+}
+
+func (r *CustomerRepository) Update(customer *models.Customer) error {
+	return r.db.Omit(clause.Associations).Save(customer).Error
+}
+
+func (r *CustomerRepository) Delete(id uint, companyID uint) error {
+	return r.db.Where("company_id = ?", companyID).Delete(&models.Customer{}, id).Error
+}
+
+func (r *CustomerRepository) CreateContact(contact *models.CustomerContact) error {
+	return r.db.Create(contact).Error
+}
+
+func (r *CustomerRepository) GetContact(id uint, customerID uint) (*models.CustomerContact, error) {
+	var contact models.CustomerContact
+	err := r.db.Where("id = ? AND customer_id = ?", id, customerID).First(&contact).Error
+	return &contact, err
+}
+
+func (r *CustomerRepository) UpdateContact(contact *models.CustomerContact) error {
+	return r.db.Save(contact).Error
+}
+
+func (r *CustomerRepository) DeleteContact(id uint, customerID uint) error {
+	return r.db.Where("customer_id = ?", customerID).Delete(&models.CustomerContact{}, id).Error
+}
+
+// ClearPrimaryContact unsets the primary flag on the customer's other contacts.
+func (r *CustomerRepository) ClearPrimaryContact(customerID uint, exceptID uint) error {
+	return r.db.Model(&models.CustomerContact{}).
+		Where("customer_id = ? AND id <> ? AND is_primary", customerID, exceptID).
+		Update("is_primary", false).Error
+}
+
+func (r *CustomerRepository) CreateAddress(address *models.CustomerAddress) error {
+	return r.db.Create(address).Error
+}
+
+// GetAddressByID returns a saved address of the company with its customer.
+func (r *CustomerRepository) GetAddressByID(id uint, companyID uint) (*models.CustomerAddress, error) {
+	var address models.CustomerAddress
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Customer").
+		First(&address).Error
+	return &address, err
+}
+
+func (r *CustomerRepository) UpdateAddress(address *models.CustomerAddress) error {
+	return r.db.Omit(clause.Associations).Save(address).Error
+}
+
+func (r *CustomerRepository) DeleteAddress(id uint, customerID uint) error {
+	return r.db.Where("customer_id = ?", customerID).Delete(&models.CustomerAddress{}, id).Error
+}
+
+// ClearDefaultAddress unsets the default flag on the customer's other addresses.
+func (r *CustomerRepository) ClearDefaultAddress(customerID uint, exceptID uint) error {
+	return r.db.Model(&models.CustomerAddress{}).
+		Where("customer_id = ? AND id <> ? AND is_default", customerID, exceptID).
+		Update("is_default", false).Error
+}
+
+// SearchAddresses matches the query against the label, the address and the
+// customer name of the company's saved addresses of active customers. Default
+// addresses and matches at the start of the label come first.
+func (r *CustomerRepository) SearchAddresses(companyID uint, query models.AddressSearchQuery) ([]models.CustomerAddress, error) {
+	var addresses []models.CustomerAddress
+	searchTerm := "%" + query.Query + "%"
+	prefix := query.Query + "%"
+
+	db := r.db.Joins("JOIN customers ON customers.id = customer_addresses.customer_id AND customers.deleted_at IS NULL").
+		Where("customer_addresses.company_id = ? AND customers.is_active", companyID).
+		Where("customer_addresses.label ILIKE ? OR customer_addresses.address ILIKE ? OR customers.name ILIKE ?",
+			searchTerm, searchTerm, searchTerm)
+	if query.CustomerID != nil {
+		db = db.Where("customer_addresses.customer_id = ?", *query.CustomerID)
+	}
+
+	err := db.Preload("Customer").
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "customer_addresses.is_default DESC, (customer_addresses.label ILIKE ?) DESC, customer_addresses.label ASC, customer_addresses.id ASC",
+			Vars: []interface{}{prefix},
+		}}).
+		Limit(query.Limit).
+		Find(&addresses).Error
+	return addresses, err
+}
@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCargoAlreadyInvoiced is returned when cargo being billed was invoiced by
+// a concurrent request.
+var ErrCargoAlreadyInvoiced = errors.New("cargo has already been invoiced")
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// GetSettings returns the company's invoice settings, creating the default
+// (no tax, 30 days) on first use.
+func (r *InvoiceRepository) GetSettings(companyID uint) (*models.InvoiceSettings, error) {
+	var settings models.InvoiceSettings
+	err := r.db.Where("company_id = ?", companyID).First(&settings).Error
+	if err == nil {
+		return &settings, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	settings = models.InvoiceSettings{
+		CompanyID:            companyID,
+		TaxName:              "VAT",
+		PaymentTermsDays:     30,
+		InvoicePrefix:        "INV",
+		CreditNotePrefix:     "CN",
+		NextInvoiceNumber:    1,
+		NextCreditNoteNumber: 1,
+	}
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request may have won the insert race; read back whichever row exists.
+	err = r.db.Where("company_id = ?", companyID).First(&settings).Error
+	return &settings, err
+}
+
+func (r *InvoiceRepository) UpdateSettings(settings *models.InvoiceSettings) error {
+	return r.db.Model(settings).
+		Select("TaxName", "TaxRatePercent", "PaymentTermsDays", "InvoicePrefix", "CreditNotePrefix").
+		Updates(settings).Error
+}
+
+// NextNumber atomically reserves the next number of the invoice or credit note
+// sequence and returns the settings with the prefix to format it with.
+func (r *InvoiceRepository) NextNumber(companyID uint, invoiceType models.InvoiceType) (*models.InvoiceSettings, int64, error) {
+	if _, err := r.GetSettings(companyID); err != nil {
+		return nil, 0, err
+	}
+
+	column := "next_invoice_number"
+	if invoiceType == models.InvoiceTypeCreditNote {
+		column = "next_credit_note_number"
+	}
+
+	var settings models.InvoiceSettings
+	err := r.db.Raw(
+		"UPDATE invoice_settings SET "+column+" = "+column+" + 1, updated_at = NOW() WHERE company_id = ? RETURNING *",
+		companyID,
+	).Scan(&settings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if settings.ID == 0 {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+
+	if invoiceType == models.InvoiceTypeCreditNote {
+		return &settings, settings.NextCreditNoteNumber - 1, nil
+	}
+	return &settings, settings.NextInvoiceNumber - 1, nil
+}
+
+// GetBillableCargo returns the delivered cargo of a period that is not on an
+// invoice yet, with its customer, oldest delivery first. Cargo without a
+// customer account can be selected by its shipper contact instead.
+func (r *InvoiceRepository) GetBillableCargo(companyID uint, from, to time.Time, customerID *uint, customerName string) ([]models.Cargo, error) {
+	var cargos []models.Cargo
+	query := r.db.Where("company_id = ? AND status = ? AND invoice_id IS NULL", companyID, models.CargoStatusDelivered).
+		Where("actual_delivery >= ? AND actual_delivery < ?", from, to)
+	if customerID != nil {
+		query = query.Where("customer_id = ?", *customerID)
+	}
+	if customerName != "" {
+		query = query.Where("customer_id IS NULL AND origin_contact = ?", customerName)
+	}
+	err := query.Preload("Customer").Order("actual_delivery ASC, id ASC").Find(&cargos).Error
+	return cargos, err
+}
+
+// CreateInvoices stores draft invoices with their lines and marks the billed
+// cargo, all or nothing. Cargo invoiced meanwhile by another request fails the
+// whole batch with ErrCargoAlreadyInvoiced.
+func (r *InvoiceRepository) CreateInvoices(invoices []models.Invoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range invoices {
+			if err := tx.Create(&invoices[i]).Error; err != nil {
+				return err
+			}
+
+			var cargoIDs []uint
+			for _, line := range invoices[i].Lines {
+				if line.CargoID != nil {
+					cargoIDs = append(cargoIDs, *line.CargoID)
+				}
+			}
+			result := tx.Model(&models.Cargo{}).
+				Where("id IN ? AND invoice_id IS NULL", cargoIDs).
+				Update("invoice_id", invoices[i].ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(cargoIDs)) {
+				return ErrCargoAlreadyInvoiced
+			}
+		}
+		return nil
+	})
+}
+
+func (r *InvoiceRepository) CreateCreditNote(note *models.Invoice) error {
+	return r.db.Create(note).Error
+}
+
+func (r *InvoiceRepository) GetByID(id uint, companyID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Company").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("Lines.Cargo").
+		Preload("CreditedInvoice").
+		First(&invoice).Error
+	return &invoice, err
+}
+
+func (r *InvoiceRepository) GetByCompanyID(companyID uint, filter models.InvoiceFilter) ([]models.Invoice, int64, error) {
+	var invoices []models.Invoice
+	var total int64
+
+	query := applyInvoiceFilter(r.db.Model(&models.Invoice{}).Where("company_id = ?", companyID), filter)
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Preload("CreditedInvoice").
+		Offset(offset).Limit(filter.Limit).Order("created_at DESC, id DESC").Find(&invoices).Error
+
+	return invoices, total, err
+}
+
+// StreamByCompanyID calls fn for each invoice matching the filter, with its
+// lines and their cargo, in issue order.
+func (r *InvoiceRepository) StreamByCompanyID(companyID uint, filter models.InvoiceFilter, fn func(*models.Invoice) error) error {
+	query := applyInvoiceFilter(r.db.Model(&models.Invoice{}).Where("company_id = ?", companyID), filter)
+
+	var invoices []models.Invoice
+	var fnErr error
+	err := query.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).
+		Preload("Lines.Cargo").
+		Preload("CreditedInvoice").
+		Order("issued_at ASC, id ASC").
+		FindInBatches(&invoices, 200, func(tx *gorm.DB, batch int) error {
+			for i := range invoices {
+				if fnErr = fn(&invoices[i]); fnErr != nil {
+					return fnErr
+				}
+			}
+			return nil
+		}).Error
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+func applyInvoiceFilter(query *gorm.DB, filter models.InvoiceFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.CustomerName != "" {
+		query = query.Where("customer_name = ?", filter.CustomerName)
+	}
+	if filter.ExcludeDrafts {
+		query = query.Where("status <> ?", models.InvoiceStatusDraft)
+	}
+	if filter.IssuedFrom != nil {
+		query = query.Where("issued_at >= ?", *filter.IssuedFrom)
+	}
+	if filter.IssuedTo != nil {
+		query = query.Where("issued_at < ?", filter.IssuedTo.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+func (r *InvoiceRepository) Update(invoice *models.Invoice) error {
+	return r.db.Omit(clause.Associations).Save(invoice).Error
+}
+
+// Void marks an invoice void and, for invoices, releases its cargo so it can
+// be billed again.
+func (r *InvoiceRepository) Void(invoice *models.Invoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(invoice).Error; err != nil {
+			return err
+		}
+		if invoice.Type != models.InvoiceTypeInvoice {
+			return nil
+		}
+		return tx.Model(&models.Cargo{}).Where("invoice_id = ?", invoice.ID).Update("invoice_id", nil).Error
+	})
+}
+
+// GetCreditedAmounts returns, per cargo, the amount already credited against
+// an invoice by credit notes that are not void.
+func (r *InvoiceRepository) GetCreditedAmounts(invoiceID uint) (map[uint]float64, error) {
+	var rows []struct {
+		CargoID uint
+		Amount  float64
+	}
+	err := r.db.Model(&models.InvoiceLine{}).
+		Select("invoice_lines.cargo_id, SUM(invoice_lines.amount) AS amount").
+		Joins("JOIN invoices ON invoices.id = invoice_lines.invoice_id").
+		Where("invoices.credited_invoice_id = ? AND invoices.status <> ?", invoiceID, models.InvoiceStatusVoid).
+		Group("invoice_lines.cargo_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	credited := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		credited[row.CargoID] = row.Amount
+	}
+	return credited, nil
+}
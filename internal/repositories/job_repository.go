@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Enqueue adds a job to the queue. Inside a unit of work it is only visible
+// to workers once the transaction commits.
+func (r *JobRepository) Enqueue(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+// Claim locks up to limit due jobs for the worker and counts the attempt.
+// Rows another worker is claiming are skipped rather than waited for.
+func (r *JobRepository) Claim(worker string, types []string, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.Raw(`
+		UPDATE jobs SET status = ?, locked_at = ?, locked_by = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE status = ? AND run_at <= ? AND type IN ?
+			ORDER BY run_at ASC, id ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobStatusRunning, time.Now(), worker, time.Now(),
+		models.JobStatusQueued, time.Now(), types, limit,
+	).Scan(&jobs).Error
+	return jobs, err
+}
+
+func (r *JobRepository) Complete(job *models.Job) error {
+	now := time.Now()
+	return r.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.JobStatusSucceeded,
+		"locked_at":   nil,
+		"locked_by":   "",
+		"last_error":  "",
+		"finished_at": now,
+	}).Error
+}
+
+// Retry puts a failed job back in the queue to run again at runAt.
+func (r *JobRepository) Retry(job *models.Job, cause error, runAt time.Time) error {
+	return r.db.Model(job).Updates(map[string]interface{}{
+		"status":     models.JobStatusQueued,
+		"locked_at":  nil,
+		"locked_by":  "",
+		"last_error": cause.Error(),
+		"run_at":     runAt,
+	}).Error
+}
+
+// Bury moves a job that ran out of attempts to the dead-letter view.
+func (r *JobRepository) Bury(job *models.Job, cause error) error {
+	now := time.Now()
+	return r.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.JobStatusDead,
+		"locked_at":   nil,
+		"locked_by":   "",
+		"last_error":  cause.Error(),
+		"finished_at": now,
+	}).Error
+}
+
+// Heartbeat refreshes the lock of a running job. It reports false once the
+// worker no longer holds the attempt, e.g. because the job was requeued as
+// stale; a new claim counts another attempt, so it cannot be mistaken for ours.
+func (r *JobRepository) Heartbeat(job *models.Job, worker string) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?", job.ID, models.JobStatusRunning, worker, job.Attempts).
+		Update("locked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// RequeueStale returns jobs whose worker stopped before finishing them, e.g.
+// because its process was killed, to the queue.
+func (r *JobRepository) RequeueStale(lockedBefore time.Time) (int64, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, lockedBefore).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusQueued,
+			"locked_at":  nil,
+			"locked_by":  "",
+			"last_error": "worker stopped before finishing",
+			"run_at":     time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteSucceededBefore prunes jobs that finished successfully before the
+// given time; dead jobs are kept for inspection.
+func (r *JobRepository) DeleteSucceededBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND finished_at < ?", models.JobStatusSucceeded, before).Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *JobRepository) GetByCompanyID(companyID uint, filter models.JobFilter) ([]models.Job, int64, error) {
+	var jobs []models.Job
+	var total int64
+
+	query := r.db.Model(&models.Job{}).Where("company_id = ?", companyID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC, id DESC").Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+func (r *JobRepository) GetByID(id uint, companyID uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&job).Error
+	return &job, err
+}
+
+// Revive queues a dead job again with a fresh set of attempts.
+func (r *JobRepository) Revive(id uint, companyID uint) error {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND company_id = ? AND status = ?", id, companyID, models.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusQueued,
+			"attempts":    0,
+			"run_at":      time.Now(),
+			"finished_at": nil,
+		})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
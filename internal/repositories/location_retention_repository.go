@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tripGap is the pause between two points that ends a trip when locations
+// are downsampled per trip.
+const tripGap = "15 minutes"
+
+type LocationRetentionRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRetentionRepository(db *gorm.DB) *LocationRetentionRepository {
+	return &LocationRetentionRepository{db: db}
+}
+
+// GetPolicy returns the company's retention policy, creating the default one
+// on first use.
+func (r *LocationRetentionRepository) GetPolicy(companyID uint) (*models.LocationRetentionPolicy, error) {
+	var policy models.LocationRetentionPolicy
+	err := r.db.Where("company_id = ?", companyID).First(&policy).Error
+	if err == nil {
+		return &policy, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	policy = models.LocationRetentionPolicy{
+		CompanyID:        companyID,
+		RawDays:          30,
+		DownsampleMode:   models.LocationDownsampleMinute,
+		ArchiveAfterDays: 365,
+	}
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("company_id = ?", companyID).First(&policy).Error
+	return &policy, err
+}
+
+func (r *LocationRetentionRepository) UpdatePolicy(policy *models.LocationRetentionPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+func (r *LocationRetentionRepository) GetCompanyIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Company{}).Order("id ASC").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// OldestPointBefore returns the time of the company's oldest location before
+// t, or nil when there is none.
+func (r *LocationRetentionRepository) OldestPointBefore(companyID uint, t time.Time) (*time.Time, error) {
+	var oldest *time.Time
+	err := r.db.Model(&models.TruckLocation{}).
+		Where("company_id = ? AND timestamp < ?", companyID, t).
+		Select("MIN(timestamp)").Scan(&oldest).Error
+	return oldest, err
+}
+
+// Downsample deletes the company's locations in [from, to) that the mode
+// does not keep and returns how many went.
+func (r *LocationRetentionRepository) Downsample(companyID uint, mode models.LocationDownsampleMode, from, to time.Time) (int64, error) {
+	var query string
+	switch mode {
+	case models.LocationDownsampleMinute:
+		query = `DELETE FROM truck_locations tl USING (
+			SELECT id, timestamp, ROW_NUMBER() OVER (
+				PARTITION BY truck_id, date_trunc('minute', timestamp) ORDER BY timestamp, id
+			) AS rn
+			FROM truck_locations
+			WHERE company_id = ? AND timestamp >= ? AND timestamp < ?
+		) d
+		WHERE tl.id = d.id AND tl.timestamp = d.timestamp AND d.rn > 1`
+	case models.LocationDownsampleTrip:
+		query = `DELETE FROM truck_locations tl USING (
+			SELECT id, timestamp,
+				timestamp - LAG(timestamp) OVER w AS since_previous,
+				LEAD(timestamp) OVER w - timestamp AS until_next
+			FROM truck_locations
+			WHERE company_id = ? AND timestamp >= ? AND timestamp < ?
+			WINDOW w AS (PARTITION BY truck_id ORDER BY timestamp, id)
+		) d
+		WHERE tl.id = d.id AND tl.timestamp = d.timestamp
+			AND d.since_previous <= INTERVAL '` + tripGap + `'
+			AND d.until_next <= INTERVAL '` + tripGap + `'`
+	default:
+		return 0, nil
+	}
+
+	result := r.db.Exec(query, companyID, from, to)
+	return result.RowsAffected, result.Error
+}
+
+// EachPoint calls fn with the company's locations in [from, to), by truck
+// and time, without loading them all at once.
+func (r *LocationRetentionRepository) EachPoint(companyID uint, from, to time.Time, fn func(location *models.TruckLocation) error) error {
+	rows, err := r.db.Model(&models.TruckLocation{}).
+		Where("company_id = ? AND timestamp >= ? AND timestamp < ?", companyID, from, to).
+		Order("truck_id ASC, timestamp ASC, id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var location models.TruckLocation
+		if err := r.db.ScanRows(rows, &location); err != nil {
+			return err
+		}
+		if err := fn(&location); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SaveArchive records an archive file and deletes the locations it holds, in
+// one transaction. Archiving a day again replaces its record.
+func (r *LocationRetentionRepository) SaveArchive(archive *models.LocationArchive, from, to time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "company_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"storage_key", "format", "points", "size_bytes", "created_at"}),
+		}).Create(archive).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("company_id = ? AND timestamp >= ? AND timestamp < ?", archive.CompanyID, from, to).
+			Delete(&models.TruckLocation{}).Error
+	})
+}
+
+func (r *LocationRetentionRepository) GetArchives(companyID uint, filter models.LocationArchiveFilter) ([]models.LocationArchive, int64, error) {
+	var archives []models.LocationArchive
+	var total int64
+
+	query := r.db.Model(&models.LocationArchive{}).Where("company_id = ?", companyID)
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("day DESC").Find(&archives).Error
+
+	return archives, total, err
+}
+
+func (r *LocationRetentionRepository) GetArchiveByID(id uint, companyID uint) (*models.LocationArchive, error) {
+	var archive models.LocationArchive
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&archive).Error
+	return &archive, err
+}
+
+// GetArchiveByDay returns the company's archive of day, or nil when the day
+// has not been archived.
+func (r *LocationRetentionRepository) GetArchiveByDay(companyID uint, day time.Time) (*models.LocationArchive, error) {
+	var archive models.LocationArchive
+	err := r.db.Where("company_id = ? AND day = ?", companyID, day.Format("2006-01-02")).First(&archive).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &archive, err
+}
+
+// GetStorage returns the location data every company keeps, largest first.
+func (r *LocationRetentionRepository) GetStorage() ([]models.LocationStorage, error) {
+	var storage []models.LocationStorage
+	err := r.db.Raw(`SELECT c.id AS company_id, c.name AS company_name,
+			COALESCE(l.points, 0) AS points, COALESCE(l.bytes, 0) AS bytes, l.oldest_point,
+			COALESCE(a.files, 0) AS archive_files, COALESCE(a.points, 0) AS archived_points,
+			COALESCE(a.bytes, 0) AS archived_bytes
+		FROM companies c
+		LEFT JOIN (
+			SELECT company_id, COUNT(*) AS points, SUM(pg_column_size(tl.*)) AS bytes, MIN(timestamp) AS oldest_point
+			FROM truck_locations tl GROUP BY company_id
+		) l ON l.company_id = c.id
+		LEFT JOIN (
+			SELECT company_id, COUNT(*) AS files, SUM(points) AS points, SUM(size_bytes) AS bytes
+			FROM location_archives GROUP BY company_id
+		) a ON a.company_id = c.id
+		WHERE c.deleted_at IS NULL
+		ORDER BY bytes DESC, c.id ASC`).Scan(&storage).Error
+	return storage, err
+}
+
+// truckLocationPartition names the monthly partition of truck_locations
+// holding the month of t.
+func truckLocationPartition(t time.Time) string {
+	return fmt.Sprintf("truck_locations_p%04d%02d", t.Year(), int(t.Month()))
+}
+
+// EnsurePartitions creates the monthly partitions of truck_locations from
+// the month of from on, months of them, if they do not exist yet.
+func (r *LocationRetentionRepository) EnsurePartitions(from time.Time, months int) error {
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0)
+		err := r.db.Exec(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF truck_locations FOR VALUES FROM ('%s') TO ('%s')",
+			truckLocationPartition(month), month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339),
+		)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropEmptyPartitionsBefore drops the monthly partitions of truck_locations
+// that end before t and hold no rows any more, and returns their names.
+func (r *LocationRetentionRepository) DropEmptyPartitionsBefore(t time.Time) ([]string, error) {
+	var partitions []string
+	err := r.db.Raw(`SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = 'truck_locations' AND c.relname ~ '^truck_locations_p[0-9]{6}$'
+		ORDER BY c.relname`).Scan(&partitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	for _, name := range partitions {
+		month, err := time.Parse("200601", name[len("truck_locations_p"):])
+		if err != nil || month.AddDate(0, 1, 0).After(t) {
+			continue
+		}
+
+		var hasRows bool
+		err = r.db.Raw(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", name)).Scan(&hasRows).Error
+		if err != nil {
+			return dropped, err
+		}
+		if hasRows {
+			continue
+		}
+		if err := r.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", name)).Error; err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"errors"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// GetSettings returns the company's notification settings, creating the
+// default (both channels on, English) on first use.
+func (r *NotificationRepository) GetSettings(companyID uint) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	err := r.db.Where("company_id = ?", companyID).First(&settings).Error
+	if err == nil {
+		return &settings, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	settings = models.NotificationSettings{
+		CompanyID:     companyID,
+		EmailEnabled:  true,
+		SMSEnabled:    true,
+		DefaultLocale: "en",
+	}
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request may have won the insert race; read back whichever row exists.
+	err = r.db.Where("company_id = ?", companyID).First(&settings).Error
+	return &settings, err
+}
+
+func (r *NotificationRepository) UpdateSettings(settings *models.NotificationSettings) error {
+	return r.db.Save(settings).Error
+}
+
+func (r *NotificationRepository) GetTemplates(companyID uint) ([]models.NotificationTemplate, error) {
+	var templates []models.NotificationTemplate
+	err := r.db.Where("company_id = ?", companyID).
+		Order("trigger ASC, channel ASC, locale ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *NotificationRepository) GetTemplate(companyID uint, trigger models.NotificationTrigger, channel models.NotificationChannel, locale string) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	err := r.db.Where("company_id = ? AND trigger = ? AND channel = ? AND locale = ?", companyID, trigger, channel, locale).
+		First(&template).Error
+	return &template, err
+}
+
+// SaveTemplate inserts the template or replaces the one with the same
+// trigger, channel and locale.
+func (r *NotificationRepository) SaveTemplate(template *models.NotificationTemplate) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "trigger"}, {Name: "channel"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "body", "is_active", "updated_at"}),
+	}).Create(template).Error
+}
+
+func (r *NotificationRepository) DeleteTemplate(id uint, companyID uint) error {
+	result := r.db.Where("company_id = ?", companyID).Delete(&models.NotificationTemplate{}, id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+func (r *NotificationRepository) IsOptedOut(companyID uint, channel models.NotificationChannel, recipient string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.NotificationOptOut{}).
+		Where("company_id = ? AND channel = ? AND recipient = ?", companyID, channel, recipient).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateOptOut records an opt-out; opting out twice keeps the first record.
+func (r *NotificationRepository) CreateOptOut(optOut *models.NotificationOptOut) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(optOut).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Where("company_id = ? AND channel = ? AND recipient = ?", optOut.CompanyID, optOut.Channel, optOut.Recipient).
+		First(optOut).Error
+}
+
+func (r *NotificationRepository) GetOptOuts(companyID uint) ([]models.NotificationOptOut, error) {
+	var optOuts []models.NotificationOptOut
+	err := r.db.Where("company_id = ?", companyID).Order("created_at DESC").Find(&optOuts).Error
+	return optOuts, err
+}
+
+func (r *NotificationRepository) DeleteOptOut(id uint, companyID uint) error {
+	result := r.db.Where("company_id = ?", companyID).Delete(&models.NotificationOptOut{}, id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// HasSent reports whether a message of the trigger already went out for the
+// cargo over the channel.
+func (r *NotificationRepository) HasSent(cargoID uint, trigger models.NotificationTrigger, channel models.NotificationChannel) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.NotificationLog{}).
+		Where("cargo_id = ? AND trigger = ? AND channel = ? AND status = ?", cargoID, trigger, channel, models.NotificationStatusSent).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *NotificationRepository) CreateLog(entry *models.NotificationLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *NotificationRepository) GetLogs(companyID uint, filter models.NotificationLogFilter) ([]models.NotificationLog, int64, error) {
+	var logs []models.NotificationLog
+	var total int64
+
+	query := r.db.Model(&models.NotificationLog{}).Where("company_id = ?", companyID)
+	if filter.CargoID != nil {
+		query = query.Where("cargo_id = ?", *filter.CargoID)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC, id DESC").Find(&logs).Error
+
+	return logs, total, err
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Record adds a domain event to the outbox. Inside a unit of work it commits
+// or rolls back with the change it describes.
+func (r *OutboxRepository) Record(event *models.DomainEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Relay queues the jobs route returns for up to limit unpublished events and
+// marks them published, all in one transaction. Events locked by another
+// relay are skipped, so replicas can relay concurrently.
+func (r *OutboxRepository) Relay(limit int, route func(event models.DomainEvent) ([]models.Job, error)) (int, error) {
+	var relayed int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var events []models.DomainEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("id ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+			jobs, err := route(event)
+			if err != nil {
+				return err
+			}
+			if len(jobs) > 0 {
+				if err := tx.Create(&jobs).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		relayed = len(events)
+		return tx.Model(&models.DomainEvent{}).Where("id IN ?", ids).Update("published_at", time.Now()).Error
+	})
+	return relayed, err
+}
+
+// DeletePublishedBefore prunes relayed events older than before.
+func (r *OutboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("published_at < ?", before).Delete(&models.DomainEvent{})
+	return result.RowsAffected, result.Error
+}
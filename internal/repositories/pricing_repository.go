@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"errors"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PricingRepository struct {
+	db *gorm.DB
+}
+
+func NewPricingRepository(db *gorm.DB) *PricingRepository {
+	return &PricingRepository{db: db}
+}
+
+// GetSettings returns the company's pricing settings, creating the default
+// (no surcharges, no minimum) on first use.
+func (r *PricingRepository) GetSettings(companyID uint) (*models.PricingSettings, error) {
+	var settings models.PricingSettings
+	err := r.db.Where("company_id = ?", companyID).First(&settings).Error
+	if err == nil {
+		return &settings, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	settings = models.PricingSettings{
+		CompanyID:        companyID,
+		Currency:         "USD",
+		VolumetricFactor: 250,
+	}
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request may have won the insert race; read back whichever row exists.
+	err = r.db.Where("company_id = ?", companyID).First(&settings).Error
+	return &settings, err
+}
+
+func (r *PricingRepository) UpdateSettings(settings *models.PricingSettings) error {
+	return r.db.Save(settings).Error
+}
+
+func (r *PricingRepository) CreateRate(rate *models.FreightRate) error {
+	return r.db.Create(rate).Error
+}
+
+func (r *PricingRepository) GetRates(companyID uint) ([]models.FreightRate, error) {
+	var rates []models.FreightRate
+	err := r.db.Where("company_id = ?", companyID).
+		Order("name ASC, min_distance_km ASC, min_weight ASC").
+		Find(&rates).Error
+	return rates, err
+}
+
+func (r *PricingRepository) GetActiveRates(companyID uint) ([]models.FreightRate, error) {
+	var rates []models.FreightRate
+	err := r.db.Where("company_id = ? AND is_active = ?", companyID, true).
+		Order("id ASC").
+		Find(&rates).Error
+	return rates, err
+}
+
+func (r *PricingRepository) GetRateByID(id uint, companyID uint) (*models.FreightRate, error) {
+	var rate models.FreightRate
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&rate).Error
+	return &rate, err
+}
+
+func (r *PricingRepository) UpdateRate(rate *models.FreightRate) error {
+	return r.db.Save(rate).Error
+}
+
+func (r *PricingRepository) DeleteRate(id uint, companyID uint) error {
+	return r.db.Where("id = ? AND company_id = ?", id, companyID).Delete(&models.FreightRate{}).Error
+}
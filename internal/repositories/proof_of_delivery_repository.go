@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ProofOfDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewProofOfDeliveryRepository(db *gorm.DB) *ProofOfDeliveryRepository {
+	return &ProofOfDeliveryRepository{db: db}
+}
+
+func (r *ProofOfDeliveryRepository) Create(pod *models.ProofOfDelivery) error {
+	return r.db.Create(pod).Error
+}
+
+func (r *ProofOfDeliveryRepository) GetByCargoID(cargoID uint, companyID uint) (*models.ProofOfDelivery, error) {
+	var pod models.ProofOfDelivery
+	err := r.db.Where("cargo_id = ? AND company_id = ?", cargoID, companyID).
+		Preload("CargoEvent").
+		Preload("CapturedByUser").
+		Preload("Photos").
+		First(&pod).Error
+	return &pod, err
+}
+
+func (r *ProofOfDeliveryRepository) ExistsForCargo(cargoID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ProofOfDelivery{}).Where("cargo_id = ?", cargoID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ProofOfDeliveryRepository) GetPhoto(photoID uint, podID uint) (*models.ProofOfDeliveryPhoto, error) {
+	var photo models.ProofOfDeliveryPhoto
+	err := r.db.Where("id = ? AND proof_of_delivery_id = ?", photoID, podID).First(&photo).Error
+	return &photo, err
+}
@@ -22,7 +22,7 @@ func (r *RouteRepository) GetByCompanyID(companyID uint, filter models.RouteFilt
 	var routes []models.Route
 	var total int64
 
-	query := r.db.Where("company_id = ?", companyID).
+	query := applyRouteFilter(r.db.Where("company_id = ?", companyID), filter).
 		Preload("Branch").
 		Preload("Truck").
 		Preload("Driver").
@@ -35,7 +35,39 @@ func (r *RouteRepository) GetByCompanyID(companyID uint, filter models.RouteFilt
 		query = query.Where("driver_id = ?", *driverID)
 	}
 
-	// Apply filters
+	// Count total
+	query.Model(&models.Route{}).Count(&total)
+
+	// Apply pagination
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&routes).Error
+
+	return routes, total, err
+}
+
+// StreamByCompanyID calls fn for every route matching the filter, reading rows
+// from the database one at a time. Pagination fields are ignored.
+func (r *RouteRepository) StreamByCompanyID(companyID uint, filter models.RouteFilter, fn func(*models.Route) error) error {
+	query := applyRouteFilter(r.db.Model(&models.Route{}).Where("company_id = ?", companyID), filter)
+	rows, err := query.Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var route models.Route
+		if err := r.db.ScanRows(rows, &route); err != nil {
+			return err
+		}
+		if err := fn(&route); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func applyRouteFilter(query *gorm.DB, filter models.RouteFilter) *gorm.DB {
 	if filter.Status != "" {
 		query = query.Where("status = ?", filter.Status)
 	}
@@ -48,15 +80,7 @@ func (r *RouteRepository) GetByCompanyID(companyID uint, filter models.RouteFilt
 	if filter.BranchID != nil {
 		query = query.Where("branch_id = ?", *filter.BranchID)
 	}
-
-	// Count total
-	query.Model(&models.Route{}).Count(&total)
-
-	// Apply pagination
-	offset := (filter.Page - 1) * filter.Limit
-	err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&routes).Error
-
-	return routes, total, err
+	return query
 }
 
 func (r *RouteRepository) GetByID(id uint, companyID uint, driverID *uint) (*models.Route, error) {
@@ -92,7 +116,7 @@ func (r *RouteRepository) CreateStop(stop *models.RouteStop) error {
 
 func (r *RouteRepository) GetStopsByRouteID(routeID uint) ([]models.RouteStop, error) {
 	var stops []models.RouteStop
-	err := r.db.Where("route_id = ?", routeID).Order("stop_order ASC").Find(&stops).Error
+	err := r.db.Where("route_id = ?", routeID).Preload("Customer").Order("stop_order ASC").Find(&stops).Error
 	return stops, err
 }
 
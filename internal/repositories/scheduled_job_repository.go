@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledJobRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledJobRepository(db *gorm.DB) *ScheduledJobRepository {
+	return &ScheduledJobRepository{db: db}
+}
+
+// Sync stores a job registered by the code. A new job is created as given; an
+// existing one keeps its pause state and timing, and takes the new schedule
+// and next run only when its schedule changed.
+func (r *ScheduledJobRepository) Sync(job *models.ScheduledJob) error {
+	err := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(job).Error
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Model(&models.ScheduledJob{}).Where("name = ?", job.Name).
+		Update("description", job.Description).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.ScheduledJob{}).Where("name = ? AND schedule <> ?", job.Name, job.Schedule).
+		Updates(map[string]interface{}{
+			"schedule":    job.Schedule,
+			"next_run_at": job.NextRunAt,
+		}).Error
+}
+
+func (r *ScheduledJobRepository) GetAll() ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	err := r.db.Order("name ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *ScheduledJobRepository) GetByName(name string) (*models.ScheduledJob, error) {
+	var job models.ScheduledJob
+	err := r.db.Where("name = ?", name).First(&job).Error
+	return &job, err
+}
+
+// Start moves a due job to its next run, unless another leader already did:
+// the update only applies while the job is as it was read. It reports
+// whether this caller should run the job.
+func (r *ScheduledJobRepository) Start(job *models.ScheduledJob, startedAt time.Time, nextRunAt *time.Time) (bool, error) {
+	query := r.db.Model(&models.ScheduledJob{}).Where("id = ?", job.ID)
+	if job.NextRunAt != nil {
+		query = query.Where("next_run_at = ?", *job.NextRunAt)
+	} else {
+		query = query.Where("next_run_at IS NULL")
+	}
+	if job.RunRequestedAt != nil {
+		query = query.Where("run_requested_at = ?", *job.RunRequestedAt)
+	} else {
+		query = query.Where("run_requested_at IS NULL")
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"last_run_at":      startedAt,
+		"last_status":      models.ScheduledJobRunRunning,
+		"next_run_at":      nextRunAt,
+		"run_requested_at": nil,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *ScheduledJobRepository) SetLastStatus(name string, status models.ScheduledJobRunStatus) error {
+	return r.db.Model(&models.ScheduledJob{}).Where("name = ?", name).Update("last_status", status).Error
+}
+
+// RequestRun asks the leader to run the job at its next check, even when
+// it is paused.
+func (r *ScheduledJobRepository) RequestRun(name string) error {
+	result := r.db.Model(&models.ScheduledJob{}).Where("name = ?", name).Update("run_requested_at", time.Now())
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// SetPaused pauses or resumes a job; a resumed job runs next at nextRunAt.
+func (r *ScheduledJobRepository) SetPaused(name string, paused bool, nextRunAt *time.Time) error {
+	updates := map[string]interface{}{"is_paused": paused}
+	if !paused {
+		updates["next_run_at"] = nextRunAt
+	}
+	result := r.db.Model(&models.ScheduledJob{}).Where("name = ?", name).Updates(updates)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+func (r *ScheduledJobRepository) CreateRun(run *models.ScheduledJobRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *ScheduledJobRepository) UpdateRun(run *models.ScheduledJobRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *ScheduledJobRepository) GetRuns(name string, filter models.ScheduledJobRunFilter) ([]models.ScheduledJobRun, int64, error) {
+	var runs []models.ScheduledJobRun
+	var total int64
+
+	query := r.db.Model(&models.ScheduledJobRun{}).Where("job_name = ?", name)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Order("started_at DESC, id DESC").Find(&runs).Error
+
+	return runs, total, err
+}
+
+// FailRunning marks runs still running as failed. Only the leader runs jobs,
+// so when a new leader takes over these were cut short by the old one.
+func (r *ScheduledJobRepository) FailRunning(cause string) (int64, error) {
+	now := time.Now()
+	result := r.db.Model(&models.ScheduledJobRun{}).Where("status = ?", models.ScheduledJobRunRunning).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledJobRunFailed,
+			"finished_at": now,
+			"error":       cause,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	err := r.db.Model(&models.ScheduledJob{}).Where("last_status = ?", models.ScheduledJobRunRunning).
+		Update("last_status", models.ScheduledJobRunFailed).Error
+	return result.RowsAffected, err
+}
+
+func (r *ScheduledJobRepository) DeleteRunsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("started_at < ?", before).Delete(&models.ScheduledJobRun{})
+	return result.RowsAffected, result.Error
+}
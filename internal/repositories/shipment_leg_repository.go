@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ShipmentLegRepository struct {
+	db *gorm.DB
+}
+
+func NewShipmentLegRepository(db *gorm.DB) *ShipmentLegRepository {
+	return &ShipmentLegRepository{db: db}
+}
+
+// Create appends a leg after the cargo's current last leg.
+func (r *ShipmentLegRepository) Create(leg *models.ShipmentLeg) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		err := tx.Model(&models.ShipmentLeg{}).
+			Where("cargo_id = ?", leg.CargoID).
+			Select("COALESCE(MAX(sequence), 0)").
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		leg.Sequence = last + 1
+		return tx.Create(leg).Error
+	})
+}
+
+func (r *ShipmentLegRepository) GetByCargoID(cargoID uint, companyID uint) ([]models.ShipmentLeg, error) {
+	var legs []models.ShipmentLeg
+	err := r.db.Where("cargo_id = ? AND company_id = ?", cargoID, companyID).
+		Preload("Truck").
+		Preload("Truck.Driver").
+		Preload("Route").
+		Preload("OriginBranch").
+		Preload("DestinationBranch").
+		Order("sequence ASC").
+		Find(&legs).Error
+	return legs, err
+}
+
+func (r *ShipmentLegRepository) GetByID(id uint, cargoID uint, companyID uint) (*models.ShipmentLeg, error) {
+	var leg models.ShipmentLeg
+	err := r.db.Where("id = ? AND cargo_id = ? AND company_id = ?", id, cargoID, companyID).
+		Preload("Truck").
+		Preload("Truck.Driver").
+		Preload("Route").
+		Preload("OriginBranch").
+		Preload("DestinationBranch").
+		First(&leg).Error
+	return &leg, err
+}
+
+func (r *ShipmentLegRepository) Update(leg *models.ShipmentLeg) error {
+	return r.db.Omit("Truck", "Route", "OriginBranch", "DestinationBranch").Save(leg).Error
+}
+
+// Delete removes a leg. Later legs keep their sequence numbers; only the order
+// matters.
+func (r *ShipmentLegRepository) Delete(leg *models.ShipmentLeg) error {
+	return r.db.Unscoped().Delete(&models.ShipmentLeg{}, leg.ID).Error
+}
+
+// CompleteOpenLegs marks legs still in transit as arrived, used when the cargo
+// is delivered outside the leg workflow.
+func (r *ShipmentLegRepository) CompleteOpenLegs(cargoID uint, at time.Time) error {
+	return r.db.Model(&models.ShipmentLeg{}).
+		Where("cargo_id = ? AND status = ?", cargoID, models.ShipmentLegStatusInTransit).
+		Updates(map[string]interface{}{"status": models.ShipmentLegStatusCompleted, "arrived_at": at}).Error
+}
+
+func (r *ShipmentLegRepository) CreateHandover(handover *models.CargoHandover) error {
+	return r.db.Create(handover).Error
+}
+
+func (r *ShipmentLegRepository) GetHandovers(cargoID uint, companyID uint) ([]models.CargoHandover, error) {
+	var handovers []models.CargoHandover
+	err := r.db.Where("cargo_id = ? AND company_id = ?", cargoID, companyID).
+		Preload("FromDriver").
+		Preload("ToDriver").
+		Preload("Branch").
+		Order("handed_over_at ASC").
+		Find(&handovers).Error
+	return handovers, err
+}
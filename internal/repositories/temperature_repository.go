@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TruckPeriod is a span of time during which a cargo rode on a truck; To is
+// nil while it still does.
+type TruckPeriod struct {
+	TruckID uint
+	From    time.Time
+	To      *time.Time
+}
+
+type TemperatureRepository struct {
+	db *gorm.DB
+}
+
+func NewTemperatureRepository(db *gorm.DB) *TemperatureRepository {
+	return &TemperatureRepository{db: db}
+}
+
+func (r *TemperatureRepository) CreateReadings(readings []models.TemperatureReading) error {
+	return r.db.Create(&readings).Error
+}
+
+// GetReadingsForCargo returns the readings that applied to a cargo in time
+// order: those of its own sensors plus those of the trucks that carried it,
+// limited to the periods it was on board.
+func (r *TemperatureRepository) GetReadingsForCargo(cargoID uint, companyID uint, periods []TruckPeriod) ([]models.TemperatureReading, error) {
+	var readings []models.TemperatureReading
+
+	applies := r.db.Where("cargo_id = ?", cargoID)
+	for _, period := range periods {
+		onTruck := r.db.Where("cargo_id IS NULL AND truck_id = ? AND recorded_at >= ?", period.TruckID, period.From)
+		if period.To != nil {
+			onTruck = onTruck.Where("recorded_at <= ?", *period.To)
+		}
+		applies = applies.Or(onTruck)
+	}
+
+	err := r.db.Where("company_id = ?", companyID).
+		Where(applies).
+		Order("recorded_at ASC, id ASC").
+		Find(&readings).Error
+	return readings, err
+}
+
+func (r *TemperatureRepository) CreateExcursion(excursion *models.TemperatureExcursion) error {
+	return r.db.Create(excursion).Error
+}
+
+func (r *TemperatureRepository) UpdateExcursion(excursion *models.TemperatureExcursion) error {
+	return r.db.Omit("Cargo", "AcknowledgedUser").Save(excursion).Error
+}
+
+func (r *TemperatureRepository) GetOpenExcursion(cargoID uint) (*models.TemperatureExcursion, error) {
+	var excursion models.TemperatureExcursion
+	err := r.db.Where("cargo_id = ? AND status = ?", cargoID, models.TemperatureExcursionOpen).
+		Order("started_at DESC").
+		First(&excursion).Error
+	return &excursion, err
+}
+
+func (r *TemperatureRepository) GetExcursionByID(id uint, companyID uint) (*models.TemperatureExcursion, error) {
+	var excursion models.TemperatureExcursion
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Cargo").
+		Preload("AcknowledgedUser").
+		First(&excursion).Error
+	return &excursion, err
+}
+
+func (r *TemperatureRepository) GetExcursionsByCargoID(cargoID uint, companyID uint) ([]models.TemperatureExcursion, error) {
+	var excursions []models.TemperatureExcursion
+	err := r.db.Where("cargo_id = ? AND company_id = ?", cargoID, companyID).
+		Preload("AcknowledgedUser").
+		Order("started_at ASC").
+		Find(&excursions).Error
+	return excursions, err
+}
+
+func (r *TemperatureRepository) GetExcursions(companyID uint, filter models.TemperatureExcursionFilter) ([]models.TemperatureExcursion, int64, error) {
+	var excursions []models.TemperatureExcursion
+	var total int64
+
+	query := r.db.Model(&models.TemperatureExcursion{}).Where("company_id = ?", companyID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CargoID != nil {
+		query = query.Where("cargo_id = ?", *filter.CargoID)
+	}
+	if filter.TruckID != nil {
+		query = query.Where("truck_id = ?", *filter.TruckID)
+	}
+	if filter.Alerted != nil {
+		if *filter.Alerted {
+			query = query.Where("alerted_at IS NOT NULL")
+		} else {
+			query = query.Where("alerted_at IS NULL")
+		}
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Preload("Cargo").
+		Preload("AcknowledgedUser").
+		Offset(offset).Limit(filter.Limit).Order("started_at DESC").Find(&excursions).Error
+
+	return excursions, total, err
+}
+
+// CloseOpenExcursions ends a cargo's open excursion, e.g. on delivery when no
+// reading will bring it back in range.
+func (r *TemperatureRepository) CloseOpenExcursions(cargoID uint, at time.Time) error {
+	return r.db.Model(&models.TemperatureExcursion{}).
+		Where("cargo_id = ? AND status = ?", cargoID, models.TemperatureExcursionOpen).
+		Updates(map[string]interface{}{"status": models.TemperatureExcursionClosed, "ended_at": at}).Error
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TrackingNumberRepository struct {
+	db *gorm.DB
+}
+
+func NewTrackingNumberRepository(db *gorm.DB) *TrackingNumberRepository {
+	return &TrackingNumberRepository{db: db}
+}
+
+// GetFormat returns the company's format, creating the default one on first use.
+// The sequence of a new format starts after every cargo row ever created for the
+// company (soft-deleted rows included) so it never reissues a legacy number.
+func (r *TrackingNumberRepository) GetFormat(companyID uint) (*models.TrackingNumberFormat, error) {
+	var format models.TrackingNumberFormat
+	err := r.db.Where("company_id = ?", companyID).First(&format).Error
+	if err == nil {
+		return &format, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var issued int64
+	err = r.db.Unscoped().Model(&models.Cargo{}).Where("company_id = ?", companyID).Count(&issued).Error
+	if err != nil {
+		return nil, err
+	}
+
+	format = models.TrackingNumberFormat{
+		CompanyID:      companyID,
+		Prefix:         "TRK",
+		DateFormat:     models.TrackingDateFormatNone,
+		SequenceLength: 6,
+		CheckDigit:     models.TrackingCheckDigitNone,
+		NextValue:      issued + 1,
+		// Unverified callers could otherwise walk the sequence
+		RequireVerification: true,
+	}
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&format).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request may have won the insert race; read back whichever row exists.
+	err = r.db.Where("company_id = ?", companyID).First(&format).Error
+	return &format, err
+}
+
+func (r *TrackingNumberRepository) UpdateFormat(format *models.TrackingNumberFormat) error {
+	return r.db.Model(format).Select("Prefix", "DateFormat", "SequenceLength", "CheckDigit", "RequireVerification").Updates(format).Error
+}
+
+// NextSequence atomically reserves the next sequence value. The row lock taken by
+// the UPDATE serialises concurrent callers, so two cargo creations can never
+// receive the same value.
+func (r *TrackingNumberRepository) NextSequence(companyID uint) (*models.TrackingNumberFormat, int64, error) {
+	return r.ReserveSequences(companyID, 1)
+}
+
+// ReserveSequences atomically reserves count consecutive values and returns the
+// first of them. Values reserved by a caller that later fails are not reused.
+func (r *TrackingNumberRepository) ReserveSequences(companyID uint, count int) (*models.TrackingNumberFormat, int64, error) {
+	if _, err := r.GetFormat(companyID); err != nil {
+		return nil, 0, err
+	}
+
+	var format models.TrackingNumberFormat
+	err := r.db.Raw(
+		"UPDATE tracking_number_formats SET next_value = next_value + ?, updated_at = NOW() WHERE company_id = ? RETURNING *",
+		count, companyID,
+	).Scan(&format).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if format.ID == 0 {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+
+	return &format, format.NextValue - int64(count), nil
+}
+
+func (r *TrackingNumberRepository) GetFormatsByPrefix(prefix string) ([]models.TrackingNumberFormat, error) {
+	var formats []models.TrackingNumberFormat
+	err := r.db.Where("prefix = ?", prefix).Find(&formats).Error
+	return formats, err
+}
@@ -22,13 +22,45 @@ func (r *TruckRepository) GetByCompanyID(companyID uint, filter models.TruckFilt
 	var trucks []models.Truck
 	var total int64
 
-	query := r.db.Where("company_id = ?", companyID).
+	query := applyTruckFilter(r.db.Where("company_id = ?", companyID), filter).
 		Preload("Driver").
 		Preload("Branch").
 		Preload("ApprovedByUser").
 		Preload("LastLocation")
 
-	// Apply filters
+	// Count total
+	query.Model(&models.Truck{}).Count(&total)
+
+	// Apply pagination
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Offset(offset).Limit(filter.Limit).Find(&trucks).Error
+
+	return trucks, total, err
+}
+
+// StreamByCompanyID calls fn for every truck matching the filter, reading rows
+// from the database one at a time. Pagination fields are ignored.
+func (r *TruckRepository) StreamByCompanyID(companyID uint, filter models.TruckFilter, fn func(*models.Truck) error) error {
+	query := applyTruckFilter(r.db.Model(&models.Truck{}).Where("company_id = ?", companyID), filter)
+	rows, err := query.Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var truck models.Truck
+		if err := r.db.ScanRows(rows, &truck); err != nil {
+			return err
+		}
+		if err := fn(&truck); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func applyTruckFilter(query *gorm.DB, filter models.TruckFilter) *gorm.DB {
 	if filter.Status != "" {
 		query = query.Where("status = ?", filter.Status)
 	}
@@ -47,15 +79,7 @@ func (r *TruckRepository) GetByCompanyID(companyID uint, filter models.TruckFilt
 	if filter.Approved != nil {
 		query = query.Where("is_approved = ?", *filter.Approved)
 	}
-
-	// Count total
-	query.Model(&models.Truck{}).Count(&total)
-
-	// Apply pagination
-	offset := (filter.Page - 1) * filter.Limit
-	err := query.Offset(offset).Limit(filter.Limit).Find(&trucks).Error
-
-	return trucks, total, err
+	return query
 }
 
 func (r *TruckRepository) GetByID(id uint, companyID uint) (*models.Truck, error) {
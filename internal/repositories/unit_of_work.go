@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Tx holds the repositories of a unit of work, all bound to its database
+// transaction.
+type Tx struct {
+	Companies       *CompanyRepository
+	Branches        *BranchRepository
+	Users           *UserRepository
+	TrackingNumbers *TrackingNumberRepository
+	Cargos          *CargoRepository
+	Trucks          *TruckRepository
+	Pieces          *CargoPieceRepository
+	Legs            *ShipmentLegRepository
+	Temperatures    *TemperatureRepository
+	PODs            *ProofOfDeliveryRepository
+	Routes          *RouteRepository
+	Requests        *RequestRepository
+	CargoImports    *CargoImportRepository
+	Webhooks        *WebhookRepository
+	Outbox          *OutboxRepository
+	Jobs            *JobRepository
+}
+
+// UnitOfWork runs a service operation in one database transaction, so its
+// rows, its domain events and the jobs it queues commit or roll back
+// together.
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do calls fn with repositories bound to a new transaction. The transaction
+// commits when fn returns nil and rolls back when it returns an error or
+// panics.
+func (u *UnitOfWork) Do(fn func(tx *Tx) error) error {
+	return u.db.Transaction(func(db *gorm.DB) error {
+		return fn(&Tx{
+			Companies:       NewCompanyRepository(db),
+			Branches:        NewBranchRepository(db),
+			Users:           NewUserRepository(db),
+			TrackingNumbers: NewTrackingNumberRepository(db),
+			Cargos:          NewCargoRepository(db),
+			Trucks:          NewTruckRepository(db),
+			Pieces:          NewCargoPieceRepository(db),
+			Legs:            NewShipmentLegRepository(db),
+			Temperatures:    NewTemperatureRepository(db),
+			PODs:            NewProofOfDeliveryRepository(db),
+			Routes:          NewRouteRepository(db),
+			Requests:        NewRequestRepository(db),
+			CargoImports:    NewCargoImportRepository(db),
+			Webhooks:        NewWebhookRepository(db),
+			Outbox:          NewOutboxRepository(db),
+			Jobs:            NewJobRepository(db),
+		})
+	})
+}
+
+// Record adds a domain event about one aggregate to the outbox of the unit of
+// work.
+func (tx *Tx) Record(companyID uint, eventType string, aggregateType string, aggregateID uint, payload interface{}) error {
+	event, err := models.NewDomainEvent(companyID, eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+	return tx.Outbox.Record(event)
+}
@@ -28,11 +28,60 @@ func (r *VisitRepository) GetByCompanyID(companyID uint) ([]models.Visit, error)
 	return visits, err
 }
 
+// StreamByCompanyID calls fn for every visit matching the filter, reading rows
+// from the database one at a time. Pagination fields are ignored.
+func (r *VisitRepository) StreamByCompanyID(companyID uint, filter models.VisitFilter, fn func(*models.Visit) error) error {
+	query := applyVisitFilter(r.db.Model(&models.Visit{}).Where("company_id = ?", companyID), filter)
+	rows, err := query.Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var visit models.Visit
+		if err := r.db.ScanRows(rows, &visit); err != nil {
+			return err
+		}
+		if err := fn(&visit); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func applyVisitFilter(query *gorm.DB, filter models.VisitFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TruckID != nil {
+		query = query.Where("truck_id = ?", *filter.TruckID)
+	}
+	if filter.DriverID != nil {
+		query = query.Where("driver_id = ?", *filter.DriverID)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if filter.Search != "" {
+		searchTerm := "%" + filter.Search + "%"
+		query = query.Where("customer_name ILIKE ? OR address ILIKE ?", searchTerm, searchTerm)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", filter.CreatedTo.AddDate(0, 0, 1))
+	}
+	return query
+}
+
 func (r *VisitRepository) GetByID(id uint, companyID uint) (*models.Visit, error) {
 	var visit models.Visit
 	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
 		Preload("Truck").
 		Preload("Driver").
+		Preload("Customer").
 		Preload("Tasks").
 		First(&visit).Error
 	return &visit, err
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"time"
+	"truck-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+func (r *WebhookRepository) GetEndpoints(companyID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Where("company_id = ?", companyID).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepository) GetActiveEndpoints(companyID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Where("company_id = ? AND is_active = ?", companyID, true).Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepository) GetEndpointByID(id uint, companyID uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).First(&endpoint).Error
+	return &endpoint, err
+}
+
+func (r *WebhookRepository) UpdateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes the endpoint together with its delivery log.
+func (r *WebhookRepository) DeleteEndpoint(id uint, companyID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("company_id = ?", companyID).Delete(&models.WebhookEndpoint{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// CreateEvent stores an event together with a pending delivery for each
+// endpoint, so the event is either queued for all of them or not at all.
+func (r *WebhookRepository) CreateEvent(event *models.WebhookEvent, endpoints []models.WebhookEndpoint) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		if len(endpoints) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		deliveries = make([]models.WebhookDelivery, len(endpoints))
+		for i, endpoint := range endpoints {
+			deliveries[i] = models.WebhookDelivery{
+				CompanyID:     event.CompanyID,
+				EndpointID:    endpoint.ID,
+				EventID:       event.ID,
+				Status:        models.WebhookDeliveryStatusPending,
+				NextAttemptAt: &now,
+			}
+		}
+		return tx.Create(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// FindDelivery returns a delivery with its event and endpoint regardless of
+// company, for the job sending it.
+func (r *WebhookRepository) FindDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Preload("Event").Preload("Endpoint").First(&delivery, id).Error
+	return &delivery, err
+}
+
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Omit("Event", "Endpoint").Save(delivery).Error
+}
+
+func (r *WebhookRepository) GetDeliveryByID(id uint, companyID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Where("id = ? AND company_id = ?", id, companyID).
+		Preload("Event").
+		First(&delivery).Error
+	return &delivery, err
+}
+
+func (r *WebhookRepository) GetDeliveries(companyID uint, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&models.WebhookDelivery{}).Where("webhook_deliveries.company_id = ?", companyID)
+	if filter.EndpointID != nil {
+		query = query.Where("webhook_deliveries.endpoint_id = ?", *filter.EndpointID)
+	}
+	if filter.Status != "" {
+		query = query.Where("webhook_deliveries.status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Joins("JOIN webhook_events ON webhook_events.id = webhook_deliveries.event_id").
+			Where("webhook_events.type = ?", filter.EventType)
+	}
+	query.Count(&total)
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.Preload("Event").
+		Offset(offset).Limit(filter.Limit).
+		Order("webhook_deliveries.created_at DESC, webhook_deliveries.id DESC").
+		Find(&deliveries).Error
+
+	return deliveries, total, err
+}
@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+const (
+	checkInterval = 10 * time.Second
+	// leaderLockKey is the Postgres advisory lock held by the leader.
+	leaderLockKey int64 = 0x7472756b_73636864 // "trukschd"
+)
+
+// Task is the work of a scheduled job.
+type Task func(ctx context.Context) error
+
+type task struct {
+	schedule    *Schedule
+	spec        string
+	description string
+	fn          Task
+}
+
+// Scheduler runs registered jobs on their cron schedules. Every replica runs
+// one, but only the replica holding the leader advisory lock runs jobs; if it
+// dies its session ends, the lock is released and another replica takes
+// over.
+type Scheduler struct {
+	db       *gorm.DB
+	repo     *repositories.ScheduledJobRepository
+	instance string
+	tasks    map[string]*task
+
+	leader  *sql.Conn // session holding the leader lock
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func New(db *gorm.DB, repo *repositories.ScheduledJobRepository) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		db:       db,
+		repo:     repo,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		tasks:    make(map[string]*task),
+		running:  make(map[string]bool),
+	}
+}
+
+// Register adds a job running fn on the cron schedule spec. It panics on an
+// invalid schedule, as jobs are registered at startup.
+func (s *Scheduler) Register(name string, spec string, description string, fn Task) {
+	schedule, err := Parse(spec)
+	if err != nil {
+		panic(fmt.Sprintf("scheduled job %s: %v", name, err))
+	}
+	s.tasks[name] = &task{schedule: schedule, spec: spec, description: description, fn: fn}
+}
+
+// Run stores the registered jobs and checks for due ones until ctx is
+// cancelled, then waits for running jobs and gives up leadership.
+func (s *Scheduler) Run(ctx context.Context) {
+	now := time.Now()
+	for name, t := range s.tasks {
+		next := t.schedule.Next(now)
+		err := s.repo.Sync(&models.ScheduledJob{
+			Name:        name,
+			Schedule:    t.spec,
+			Description: t.description,
+			NextRunAt:   &next,
+		})
+		if err != nil {
+			log.Printf("register scheduled job %s: %v", name, err)
+		}
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		if s.lead(ctx) {
+			s.runDue(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			s.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead reports whether this replica is the leader, trying to become it if no
+// replica is.
+func (s *Scheduler) lead(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if s.leader != nil {
+		if err := s.leader.PingContext(ctx); err == nil {
+			return true
+		}
+		log.Printf("scheduler lost leadership")
+		s.leader.Close()
+		s.leader = nil
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return false
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		return false
+	}
+
+	s.leader = conn
+	log.Printf("scheduler leader is %s", s.instance)
+	if n, err := s.repo.FailRunning("interrupted: the previous scheduler leader stopped"); err != nil {
+		log.Printf("fail interrupted scheduled runs: %v", err)
+	} else if n > 0 {
+		log.Printf("marked %d interrupted scheduled runs failed", n)
+	}
+	return true
+}
+
+func (s *Scheduler) resign() {
+	if s.leader == nil {
+		return
+	}
+	s.leader.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	s.leader.Close()
+	s.leader = nil
+}
+
+// runDue starts the jobs whose time has come or that were triggered by hand.
+// A job still running from its previous turn is not started again.
+func (s *Scheduler) runDue(ctx context.Context) {
+	jobs, err := s.repo.GetAll()
+	if err != nil {
+		log.Printf("load scheduled jobs: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range jobs {
+		job := &jobs[i]
+		t, ok := s.tasks[job.Name]
+		if !ok {
+			continue
+		}
+
+		trigger := models.ScheduledJobTriggerSchedule
+		switch {
+		case job.RunRequestedAt != nil:
+			trigger = models.ScheduledJobTriggerManual
+		case job.IsPaused || job.NextRunAt == nil || job.NextRunAt.After(now):
+			continue
+		}
+
+		s.mu.Lock()
+		busy := s.running[job.Name]
+		s.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		next := job.NextRunAt // a manual run leaves the schedule alone
+		if !job.IsPaused && (job.NextRunAt == nil || !job.NextRunAt.After(now)) {
+			next = nil
+			if at := t.schedule.Next(now); !at.IsZero() {
+				next = &at
+			}
+		}
+		started, err := s.repo.Start(job, now, next)
+		if err != nil {
+			log.Printf("start scheduled job %s: %v", job.Name, err)
+			continue
+		}
+		if !started {
+			continue
+		}
+
+		s.mu.Lock()
+		s.running[job.Name] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func(name string, trigger string) {
+			defer s.wg.Done()
+			s.run(ctx, name, t, trigger)
+			s.mu.Lock()
+			delete(s.running, name)
+			s.mu.Unlock()
+		}(job.Name, trigger)
+	}
+}
+
+// run executes a job and records it in the job history.
+func (s *Scheduler) run(ctx context.Context, name string, t *task, trigger string) {
+	run := &models.ScheduledJobRun{
+		JobName:   name,
+		Trigger:   trigger,
+		Status:    models.ScheduledJobRunRunning,
+		Instance:  s.instance,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Printf("record scheduled job %s: %v", name, err)
+	}
+
+	err := call(ctx, t.fn)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.ScheduledJobRunSucceeded
+	if err != nil {
+		run.Status = models.ScheduledJobRunFailed
+		run.Error = err.Error()
+		log.Printf("scheduled job %s failed: %v", name, err)
+	}
+
+	if run.ID != 0 {
+		if err := s.repo.UpdateRun(run); err != nil {
+			log.Printf("record scheduled job %s: %v", name, err)
+		}
+	}
+	if err := s.repo.SetLastStatus(name, run.Status); err != nil {
+		log.Printf("record scheduled job %s: %v", name, err)
+	}
+}
+
+func call(ctx context.Context, fn Task) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/storage"
+	"truck-management/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const demoCompanyLicense = "DL001"
+
+// AdminService backs the operator commands of the server binary:
+// bootstrapping tenants and their admins, database maintenance and purging
+// tenants.
+type AdminService struct {
+	adminRepo   *repositories.AdminRepository
+	companyRepo *repositories.CompanyRepository
+	userRepo    *repositories.UserRepository
+	uow         *repositories.UnitOfWork
+	store       storage.BlobStore
+}
+
+func NewAdminService(adminRepo *repositories.AdminRepository, companyRepo *repositories.CompanyRepository, userRepo *repositories.UserRepository, uow *repositories.UnitOfWork, store storage.BlobStore) *AdminService {
+	return &AdminService{
+		adminRepo:   adminRepo,
+		companyRepo: companyRepo,
+		userRepo:    userRepo,
+		uow:         uow,
+		store:       store,
+	}
+}
+
+// CreateAdmin creates an admin user of a company and returns it with its
+// password, which is generated when none is given.
+func (s *AdminService) CreateAdmin(req models.CreateAdminRequest) (*models.User, string, error) {
+	if _, err := s.companyRepo.GetByID(req.CompanyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("company %d not found", req.CompanyID)
+		}
+		return nil, "", err
+	}
+	if req.Email == "" {
+		return nil, "", errors.New("email is required")
+	}
+	if _, err := s.userRepo.GetByEmail(req.Email); err == nil {
+		return nil, "", errors.New("user already exists")
+	}
+
+	password := req.Password
+	if password == "" {
+		var err error
+		if password, err = utils.NewPassword(); err != nil {
+			return nil, "", err
+		}
+	}
+	user, err := createUser(s.userRepo, req.CompanyID, req.Email, password, req.FirstName, req.LastName, models.RoleAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+func createUser(users *repositories.UserRepository, companyID uint, email, password, firstName, lastName string, role models.UserRole) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:     email,
+		Password:  hashedPassword,
+		FirstName: firstName,
+		LastName:  lastName,
+		Role:      role,
+		CompanyID: &companyID,
+		IsActive:  true,
+	}
+	if err := users.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SeedDemo creates the demo tenant: a company with branches, an admin,
+// drivers with their trucks and cargo in every stage. It refuses to run
+// twice, and a failed seed leaves nothing behind.
+func (s *AdminService) SeedDemo() (*models.DemoSeed, error) {
+	exists, err := s.adminRepo.CompanyLicenseExists(demoCompanyLicense)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("a company with license %s exists, the demo data has been seeded", demoCompanyLicense)
+	}
+
+	password, err := utils.NewPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	seed := &models.DemoSeed{Password: password}
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		company := &models.Company{
+			Name:     "Demo Logistics Inc",
+			Address:  "123 Main St, Springfield, IL 62701",
+			Phone:    "1-555-0123",
+			Email:    "info@demologistics.com",
+			License:  demoCompanyLicense,
+			IsActive: true,
+		}
+		if err := tx.Companies.Create(company); err != nil {
+			return err
+		}
+		seed.CompanyID, seed.CompanyName = company.ID, company.Name
+
+		admin, err := createUser(tx.Users, company.ID, "admin@demologistics.com", password, "Admin", "User", models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		seed.AdminEmail = admin.Email
+
+		branches := []models.Branch{
+			{CompanyID: company.ID, Name: "Main Branch", Address: "123 Main St, Springfield, IL 62701", Phone: "1-555-0123", Email: "main@demologistics.com", ManagerID: &admin.ID, IsActive: true},
+			{CompanyID: company.ID, Name: "North Depot", Address: "900 Industrial Ave, Peoria, IL 61602", Phone: "1-555-0188", Email: "north@demologistics.com", IsActive: true},
+		}
+		for i := range branches {
+			if err := tx.Branches.Create(&branches[i]); err != nil {
+				return err
+			}
+		}
+		seed.Branches = len(branches)
+
+		drivers := []struct {
+			firstName, lastName, plate, model, color string
+			status                                   models.TruckStatus
+		}{
+			{"Maria", "Lopez", "DEMO-001", "Volvo FH16", "White", models.TruckStatusOnline},
+			{"James", "Carter", "DEMO-002", "Scania R500", "Blue", models.TruckStatusInUse},
+			{"Aisha", "Khan", "DEMO-003", "Freightliner Cascadia", "Red", models.TruckStatusOnline},
+			{"Tom", "Novak", "DEMO-004", "MAN TGX", "Silver", models.TruckStatusMaintenance},
+		}
+		now := time.Now()
+		var trucks []models.Truck
+		for i, d := range drivers {
+			email := fmt.Sprintf("%s@demologistics.com", strings.ToLower(d.firstName))
+			driver, err := createUser(tx.Users, company.ID, email, password, d.firstName, d.lastName, models.RoleDriver)
+			if err != nil {
+				return err
+			}
+			seed.DriverEmails = append(seed.DriverEmails, driver.Email)
+
+			truck := models.Truck{
+				CompanyID:    company.ID,
+				BranchID:     &branches[i%len(branches)].ID,
+				LicensePlate: d.plate,
+				Model:        d.model,
+				Year:         2020 + i,
+				Color:        d.color,
+				Status:       d.status,
+				DriverID:     &driver.ID,
+				IsApproved:   true,
+				ApprovedBy:   &admin.ID,
+				ApprovedAt:   &now,
+				IsActive:     true,
+			}
+			if err := tx.Trucks.Create(&truck); err != nil {
+				return err
+			}
+			driver.BranchID = truck.BranchID
+			driver.TruckID = &truck.ID
+			if err := tx.Users.Update(driver); err != nil {
+				return err
+			}
+			trucks = append(trucks, truck)
+		}
+		seed.Trucks = len(trucks)
+
+		frozenMin, frozenMax := -25.0, -18.0
+		cargos := []models.Cargo{
+			{Title: "Office furniture", Type: models.CargoTypeGeneral, Priority: models.CargoPriorityMedium, Status: models.CargoStatusPending, Weight: 850, Volume: 12, Value: 9400,
+				OriginAddress: "123 Main St, Springfield, IL 62701", DestinationAddress: "455 Lake Shore Dr, Chicago, IL 60611", DestinationPostcode: "60611", DestinationContact: "Dana White"},
+			{Title: "Frozen produce", Type: models.CargoTypePerishable, Priority: models.CargoPriorityHigh, Status: models.CargoStatusPending, Weight: 2200, Volume: 18, Value: 15800,
+				OriginAddress: "900 Industrial Ave, Peoria, IL 61602", DestinationAddress: "12 Market St, St. Louis, MO 63101", DestinationPostcode: "63101", DestinationContact: "Luis Ortega",
+				MinTemperature: &frozenMin, MaxTemperature: &frozenMax},
+			{Title: "Glass panels", Type: models.CargoTypeFragile, Priority: models.CargoPriorityMedium, Status: models.CargoStatusAssigned, TruckID: &trucks[0].ID, Weight: 640, Volume: 6, Value: 7200,
+				OriginAddress: "123 Main St, Springfield, IL 62701", DestinationAddress: "77 Grand Ave, Milwaukee, WI 53203", DestinationPostcode: "53203", DestinationContact: "Erin Park"},
+			{Title: "Industrial pumps", Type: models.CargoTypeOversized, Priority: models.CargoPriorityUrgent, Status: models.CargoStatusAssigned, TruckID: &trucks[2].ID, Weight: 5100, Volume: 22, Value: 48000,
+				OriginAddress: "900 Industrial Ave, Peoria, IL 61602", DestinationAddress: "300 Riverside Plaza, Chicago, IL 60606", DestinationPostcode: "60606", DestinationContact: "Sam Reed"},
+			{Title: "Electronics", Type: models.CargoTypeGeneral, Priority: models.CargoPriorityHigh, Status: models.CargoStatusInTransit, TruckID: &trucks[1].ID, Weight: 1200, Volume: 9, Value: 86000,
+				OriginAddress: "123 Main St, Springfield, IL 62701", DestinationAddress: "1 Monument Circle, Indianapolis, IN 46204", DestinationPostcode: "46204", DestinationContact: "Priya Shah"},
+			{Title: "Printed books", Type: models.CargoTypeGeneral, Priority: models.CargoPriorityLow, Status: models.CargoStatusDelivered, Weight: 980, Volume: 5, Value: 6100,
+				OriginAddress: "900 Industrial Ave, Peoria, IL 61602", DestinationAddress: "20 Vine St, Cincinnati, OH 45202", DestinationPostcode: "45202", DestinationContact: "Noah Kim"},
+		}
+		trackingNumbers, err := generateTrackingNumbers(tx.TrackingNumbers, company.ID, len(cargos))
+		if err != nil {
+			return err
+		}
+		for i := range cargos {
+			// The demo cargo obeys the rules cargo created through the API does
+			if err := validateCargoTemperature(cargos[i].Type, cargos[i].MinTemperature, cargos[i].MaxTemperature); err != nil {
+				return fmt.Errorf("demo cargo %s: %w", cargos[i].Title, err)
+			}
+			token, err := utils.NewTrackingToken()
+			if err != nil {
+				return err
+			}
+			cargos[i].CompanyID = company.ID
+			cargos[i].TrackingNumber = trackingNumbers[i]
+			cargos[i].TrackingToken = token
+			cargos[i].Currency = "USD"
+			cargos[i].ExcursionThresholdMinutes = models.DefaultExcursionThresholdMinutes
+			if cargos[i].Status == models.CargoStatusDelivered {
+				delivered := now.Add(-24 * time.Hour)
+				cargos[i].ActualDelivery = &delivered
+			}
+		}
+		if err := tx.Cargos.CreateBatch(cargos); err != nil {
+			return err
+		}
+		for _, cargo := range cargos {
+			event := &models.CargoEvent{
+				CargoID:     cargo.ID,
+				EventType:   "status_change",
+				Description: "Status set to " + string(cargo.Status) + " by the demo seed",
+				UserID:      &admin.ID,
+				Timestamp:   now,
+			}
+			if err := tx.Cargos.CreateEvent(event); err != nil {
+				return err
+			}
+		}
+		seed.Cargos = len(cargos)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// tenantBlobPrefixes are the blob store prefixes a company's files are kept
+// under.
+func tenantBlobPrefixes(companyID uint) []string {
+	return []string{
+		fmt.Sprintf("attachments/%d/", companyID),
+		fmt.Sprintf("imports/%d/", companyID),
+		fmt.Sprintf("pod/%d/", companyID),
+		fmt.Sprintf("location-archives/%d/", companyID),
+	}
+}
+
+// PurgeTenant reports what purging a company removes and, when confirmed,
+// deletes its rows and then everything under its blob prefixes, including
+// files no row points at any more. Prefixes that cannot be cleared are logged
+// and counted; the rows are gone either way.
+func (s *AdminService) PurgeTenant(companyID uint, confirm bool) (*models.TenantPurge, error) {
+	company, err := s.adminRepo.GetCompany(companyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("company %d not found", companyID)
+		}
+		return nil, err
+	}
+
+	rows, err := s.adminRepo.CountCompanyRows(companyID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.adminRepo.GetCompanyFileKeys(companyID)
+	if err != nil {
+		return nil, err
+	}
+	purge := &models.TenantPurge{CompanyID: company.ID, CompanyName: company.Name, Rows: rows, Files: len(keys)}
+	if !confirm {
+		return purge, nil
+	}
+
+	if err := s.adminRepo.PurgeCompany(companyID); err != nil {
+		return nil, err
+	}
+	purge.Purged = true
+
+	purge.Files = 0
+	for _, prefix := range tenantBlobPrefixes(companyID) {
+		deleted, err := s.store.DeletePrefix(prefix)
+		purge.Files += deleted
+		if err != nil {
+			log.Printf("purge company %d: delete files under %s: %v", companyID, prefix, err)
+			purge.FileErrors++
+		}
+	}
+	return purge, nil
+}
+
+// Reindex rebuilds the indexes of every table, calling progress after each,
+// and refreshes the planner statistics.
+func (s *AdminService) Reindex(progress func(table string, took time.Duration)) error {
+	tables, err := s.adminRepo.GetTables()
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		started := time.Now()
+		if err := s.adminRepo.ReindexTable(table); err != nil {
+			return fmt.Errorf("reindex %s: %w", table, err)
+		}
+		progress(table, time.Since(started))
+	}
+	return s.adminRepo.Analyze()
+}
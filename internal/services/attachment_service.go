@@ -0,0 +1,256 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/storage"
+	"truck-management/internal/utils"
+)
+
+const (
+	defaultAttachmentURLExpiry = 15 * time.Minute
+	maxAttachmentURLExpiry     = 7 * 24 * time.Hour
+)
+
+var ErrAttachmentQuotaExceeded = errors.New("attachment storage quota exceeded")
+
+// attachmentTypes lists the sniffed content types accepted for upload. Office
+// documents sniff as application/zip.
+var attachmentTypes = map[string]bool{
+	"application/pdf":           true,
+	"application/zip":           true,
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"text/plain; charset=utf-8": true,
+	"text/csv; charset=utf-8":   true,
+}
+
+type AttachmentService struct {
+	attachmentRepo *repositories.AttachmentRepository
+	store          storage.BlobStore
+	maxFileSize    int64
+	tenantQuota    int64
+}
+
+func NewAttachmentService(attachmentRepo *repositories.AttachmentRepository, store storage.BlobStore, maxFileSize int64, tenantQuota int64) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		maxFileSize:    maxFileSize,
+		tenantQuota:    tenantQuota,
+	}
+}
+
+func (s *AttachmentService) UploadAttachment(companyID uint, userID uint, req models.UploadAttachmentRequest, fh *multipart.FileHeader) (*models.Attachment, error) {
+	if fh == nil {
+		return nil, errors.New("file is required")
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contentType, err := sniffContentType(f)
+	if err != nil {
+		return nil, err
+	}
+	if !attachmentTypes[contentType] {
+		return nil, errors.New("unsupported file type " + contentType)
+	}
+
+	return s.Attach(companyID, &userID, req.OwnerType, req.OwnerID, req.Category, path.Base(fh.Filename), contentType, f, fh.Size)
+}
+
+// Attach stores a file for an owner entity. It is used both for user uploads
+// and for documents the system generates itself, such as reports.
+func (s *AttachmentService) Attach(companyID uint, uploadedBy *uint, ownerType models.AttachmentOwnerType, ownerID uint, category string, fileName string, contentType string, r io.Reader, size int64) (*models.Attachment, error) {
+	exists, err := s.attachmentRepo.OwnerExists(ownerType, ownerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(string(ownerType) + " not found")
+	}
+
+	if size > s.maxFileSize {
+		return nil, fmt.Errorf("file exceeds the %d MB limit", s.maxFileSize>>20)
+	}
+
+	used, _, err := s.attachmentRepo.GetUsage(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if used+size > s.tenantQuota {
+		return nil, ErrAttachmentQuotaExceeded
+	}
+
+	key := fmt.Sprintf("attachments/%d/%s/%d/%d-%s", companyID, ownerType, ownerID, time.Now().UnixNano(), safeFileName(fileName))
+	hash := sha256.New()
+	err = s.store.Put(key, io.TeeReader(r, hash), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		CompanyID:   companyID,
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		Category:    category,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		UploadedBy:  uploadedBy,
+	}
+
+	err = s.attachmentRepo.Create(attachment)
+	if err != nil {
+		s.store.Delete(key)
+		return nil, err
+	}
+
+	return s.attachmentRepo.GetByID(attachment.ID, companyID)
+}
+
+func (s *AttachmentService) GetAttachments(companyID uint, filter models.AttachmentFilter) ([]models.Attachment, error) {
+	return s.attachmentRepo.GetByOwner(companyID, filter)
+}
+
+func (s *AttachmentService) GetAttachment(id uint, companyID uint) (*models.Attachment, error) {
+	return s.attachmentRepo.GetByID(id, companyID)
+}
+
+func (s *AttachmentService) DeleteAttachment(id uint, companyID uint) error {
+	attachment, err := s.attachmentRepo.GetByID(id, companyID)
+	if err != nil {
+		return err
+	}
+
+	err = s.attachmentRepo.Delete(id, companyID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Delete(attachment.StorageKey)
+}
+
+func (s *AttachmentService) GetUsage(companyID uint) (*models.AttachmentUsage, error) {
+	used, count, err := s.attachmentRepo.GetUsage(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AttachmentUsage{
+		UsedBytes:  used,
+		QuotaBytes: s.tenantQuota,
+		Count:      count,
+	}, nil
+}
+
+// GetDownloadURL presigns a direct object store URL when the backend supports
+// it, and otherwise signs a link to the API's own download endpoint.
+func (s *AttachmentService) GetDownloadURL(id uint, companyID uint, expiry time.Duration, apiBaseURL string) (*models.AttachmentURLResponse, error) {
+	attachment, err := s.attachmentRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiry <= 0 {
+		expiry = defaultAttachmentURLExpiry
+	}
+	if expiry > maxAttachmentURLExpiry {
+		expiry = maxAttachmentURLExpiry
+	}
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+
+	if presigner, ok := s.store.(storage.Presigner); ok {
+		url, err := presigner.PresignGet(attachment.StorageKey, expiry, attachment.FileName)
+		if err != nil {
+			return nil, err
+		}
+		return &models.AttachmentURLResponse{URL: url, ExpiresAt: expiresAt}, nil
+	}
+
+	signature := utils.SignURL(attachmentResource(attachment.ID), expiresAt)
+	url := fmt.Sprintf("%s/files/%d?expires=%d&signature=%s", strings.TrimRight(apiBaseURL, "/"), attachment.ID, expiresAt.Unix(), signature)
+	return &models.AttachmentURLResponse{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+func (s *AttachmentService) OpenAttachment(id uint, companyID uint) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.attachmentRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.store.Get(attachment.StorageKey)
+	return attachment, r, err
+}
+
+func (s *AttachmentService) OpenSignedAttachment(id uint, expires string, signature string) (*models.Attachment, io.ReadCloser, error) {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !utils.VerifySignedURL(attachmentResource(id), expiresUnix, signature) {
+		return nil, nil, errors.New("invalid or expired download link")
+	}
+
+	attachment, err := s.attachmentRepo.GetByIDUnscoped(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.store.Get(attachment.StorageKey)
+	return attachment, r, err
+}
+
+func attachmentResource(id uint) string {
+	return "attachment:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func sniffContentType(f multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "text/plain; charset=utf-8" && strings.Contains(string(head[:n]), ",") {
+		// DetectContentType has no CSV signature; comma separated text is close enough.
+		contentType = "text/csv; charset=utf-8"
+	}
+	return contentType, nil
+}
+
+func safeFileName(name string) string {
+	var b strings.Builder
+	for _, r := range path.Base(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "file"
+	}
+	return b.String()
+}
@@ -36,7 +36,7 @@ func (s *AuthService) Register(req models.RegisterRequest) (*models.User, error)
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		CompanyID: req.CompanyID,
-		Role:      models.UserRole("visitor"),
+		Role:      models.RoleDraft,
 	}
 
 	err = s.userRepo.Create(user)
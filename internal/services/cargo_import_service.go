@@ -0,0 +1,501 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/spreadsheet"
+	"truck-management/internal/storage"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	// Imports up to this many rows are processed within the request; larger
+	// files are queued for the job runner and polled through the import job.
+	cargoImportSyncRows = 200
+	cargoImportMaxRows  = 10000
+	cargoImportPreview  = 50
+)
+
+var cargoImportTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+// excelEpoch is day zero of the serial date numbers spreadsheets store.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// cargoImportJob is the payload of a cargo_import.process job. The uploaded
+// file waits in the blob store until the job has read it.
+type cargoImportJob struct {
+	ImportJobID uint     `json:"import_job_id"`
+	Columns     []string `json:"columns"`
+	StorageKey  string   `json:"storage_key"`
+	FileName    string   `json:"file_name"`
+	Sheet       string   `json:"sheet"`
+}
+
+type CargoImportService struct {
+	importRepo   *repositories.CargoImportRepository
+	cargoService *CargoService
+	uow          *repositories.UnitOfWork
+	store        storage.BlobStore
+}
+
+func NewCargoImportService(importRepo *repositories.CargoImportRepository, cargoService *CargoService, uow *repositories.UnitOfWork, store storage.BlobStore) *CargoImportService {
+	return &CargoImportService{
+		importRepo:   importRepo,
+		cargoService: cargoService,
+		uow:          uow,
+		store:        store,
+	}
+}
+
+// StartImport parses the uploaded file and validates its header. Small files are
+// processed before returning; for larger ones the returned job is still pending
+// and async is true.
+func (s *CargoImportService) StartImport(companyID uint, userID uint, req models.CargoImportRequest, fh *multipart.FileHeader) (job *models.CargoImportJob, async bool, err error) {
+	if fh == nil {
+		return nil, false, errors.New("file is required")
+	}
+
+	mapping := map[string]string{}
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, false, errors.New("mapping must be a JSON object of column header to field name")
+		}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	reader, err := spreadsheet.Open(fh.Filename, f, fh.Size, req.Sheet)
+	if err != nil {
+		return nil, false, err
+	}
+	rows, err := spreadsheet.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) < 2 {
+		return nil, false, errors.New("file has no data rows")
+	}
+	if len(rows)-1 > cargoImportMaxRows {
+		return nil, false, fmt.Errorf("file has more than %d rows", cargoImportMaxRows)
+	}
+
+	columns, err := mapCargoImportColumns(rows[0].Cells, mapping)
+	if err != nil {
+		return nil, false, err
+	}
+
+	job = &models.CargoImportJob{
+		CompanyID: companyID,
+		CreatedBy: userID,
+		FileName:  fh.Filename,
+		DryRun:    req.DryRun,
+		Status:    models.CargoImportStatusPending,
+		TotalRows: len(rows) - 1,
+	}
+	if job.TotalRows > cargoImportSyncRows {
+		err = s.enqueue(job, columns, req.Sheet, fh)
+		if err != nil {
+			return nil, false, err
+		}
+		return job, true, nil
+	}
+
+	err = s.importRepo.Create(job)
+	if err != nil {
+		return nil, false, err
+	}
+
+	preview := s.process(job, columns, rows[1:])
+	job, err = s.importRepo.GetByID(job.ID, companyID)
+	if err != nil {
+		return nil, false, err
+	}
+	job.Preview = preview
+	return job, false, nil
+}
+
+// enqueue creates the import job together with the background job that
+// processes it, keeping the upload in the blob store in between.
+func (s *CargoImportService) enqueue(job *models.CargoImportJob, columns []string, sheet string, fh *multipart.FileHeader) error {
+	return s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.CargoImports.Create(job); err != nil {
+			return err
+		}
+
+		payload := cargoImportJob{
+			ImportJobID: job.ID,
+			Columns:     columns,
+			StorageKey:  fmt.Sprintf("imports/%d/%d/%s", job.CompanyID, job.ID, path.Base(fh.Filename)),
+			FileName:    fh.Filename,
+			Sheet:       sheet,
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := s.store.Put(payload.StorageKey, f, fh.Header.Get("Content-Type")); err != nil {
+			return err
+		}
+
+		queued, err := models.NewJob(&job.CompanyID, models.JobTypeCargoImport, payload)
+		if err == nil {
+			err = tx.Jobs.Enqueue(queued)
+		}
+		if err != nil {
+			s.store.Delete(payload.StorageKey)
+		}
+		return err
+	})
+}
+
+// ProcessImport is the cargo_import.process job. An import found processing
+// was interrupted by a restart; as its rows may be half created it is failed
+// rather than run again.
+func (s *CargoImportService) ProcessImport(ctx context.Context, job *models.Job) error {
+	var payload cargoImportJob
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	if job.CompanyID == nil {
+		return errors.New("cargo import job has no company")
+	}
+	importJob, err := s.importRepo.GetByID(payload.ImportJobID, *job.CompanyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch importJob.Status {
+	case models.CargoImportStatusPending:
+	case models.CargoImportStatusProcessing:
+		s.finish(importJob, errors.New("import interrupted, please upload the file again"))
+		s.store.Delete(payload.StorageKey)
+		return nil
+	default:
+		return nil
+	}
+
+	r, err := s.store.Get(payload.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	reader, err := spreadsheet.Open(payload.FileName, bytes.NewReader(data), int64(len(data)), payload.Sheet)
+	if err == nil {
+		var rows []spreadsheet.Row
+		rows, err = spreadsheet.ReadAll(reader)
+		if err == nil {
+			s.process(importJob, payload.Columns, rows[1:])
+		}
+	}
+	if err != nil {
+		s.finish(importJob, err)
+	}
+
+	if err := s.store.Delete(payload.StorageKey); err != nil {
+		log.Printf("Failed to delete upload of cargo import job %d: %v", importJob.ID, err)
+	}
+	return nil
+}
+
+func (s *CargoImportService) GetImportJobs(companyID uint, filter models.CargoImportFilter) ([]models.CargoImportJob, int64, error) {
+	return s.importRepo.GetByCompanyID(companyID, filter)
+}
+
+func (s *CargoImportService) GetImportJob(id uint, companyID uint) (*models.CargoImportJob, error) {
+	return s.importRepo.GetByID(id, companyID)
+}
+
+// process validates every row, records row errors and, unless the job is a dry
+// run, creates the valid rows in one transaction. It returns the dry-run preview.
+func (s *CargoImportService) process(job *models.CargoImportJob, columns []string, rows []spreadsheet.Row) (preview []models.CreateCargoRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.finish(job, fmt.Errorf("import aborted: %v", r))
+		}
+	}()
+
+	now := time.Now()
+	job.Status = models.CargoImportStatusProcessing
+	job.StartedAt = &now
+	s.importRepo.UpdateProgress(job)
+
+	var valid []models.CreateCargoRequest
+	var rowErrors []models.CargoImportRowError
+	for i, row := range rows {
+		req, errs := parseCargoImportRow(columns, row)
+		if len(errs) == 0 {
+			valid = append(valid, req)
+		}
+		for _, e := range errs {
+			e.JobID = job.ID
+			rowErrors = append(rowErrors, e)
+		}
+
+		job.ProcessedRows = i + 1
+		job.ValidRows = len(valid)
+		if job.ProcessedRows%100 == 0 {
+			s.importRepo.UpdateProgress(job)
+		}
+	}
+
+	err := s.importRepo.CreateErrors(rowErrors)
+	if err != nil {
+		s.finish(job, err)
+		return nil
+	}
+
+	if job.DryRun {
+		if len(valid) > cargoImportPreview {
+			valid = valid[:cargoImportPreview]
+		}
+		s.finish(job, nil)
+		return valid
+	}
+
+	if len(valid) > 0 {
+		_, err = s.cargoService.CreateCargos(job.CompanyID, valid)
+		if err == nil {
+			job.CreatedRows = len(valid)
+		}
+	}
+	s.finish(job, err)
+	return nil
+}
+
+func (s *CargoImportService) finish(job *models.CargoImportJob, err error) {
+	now := time.Now()
+	job.CompletedAt = &now
+	job.Status = models.CargoImportStatusCompleted
+	if err != nil {
+		job.Status = models.CargoImportStatusFailed
+		job.Error = err.Error()
+	}
+
+	if err := s.importRepo.UpdateProgress(job); err != nil {
+		log.Printf("Failed to update cargo import job %d: %v", job.ID, err)
+	}
+}
+
+// cargoImportFields maps the json name of each CreateCargoRequest field to its
+// struct index.
+var cargoImportFields = func() map[string]int {
+	fields := map[string]int{}
+	t := reflect.TypeOf(models.CreateCargoRequest{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+var cargoImportRequired = []string{"title", "weight", "origin_address", "destination_address"}
+
+// mapCargoImportColumns resolves each header to a cargo field name, or "" for
+// columns that are ignored.
+func mapCargoImportColumns(header []string, mapping map[string]string) ([]string, error) {
+	normalized := make(map[string]string, len(mapping))
+	for column, field := range mapping {
+		if _, ok := cargoImportFields[field]; !ok {
+			return nil, errors.New("mapping refers to unknown field " + field)
+		}
+		normalized[normalizeImportHeader(column)] = field
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, h := range header {
+		key := normalizeImportHeader(h)
+		field, ok := normalized[key]
+		if !ok {
+			if _, known := cargoImportFields[key]; known {
+				field = key
+			}
+		}
+		if field == "" {
+			continue
+		}
+		if seen[field] {
+			return nil, errors.New("more than one column maps to " + field)
+		}
+		seen[field] = true
+		columns[i] = field
+	}
+
+	for _, field := range cargoImportRequired {
+		// A saved address column stands in for the address itself
+		if !seen[field] && !seen[field+"_id"] {
+			return nil, errors.New("no column maps to required field " + field)
+		}
+	}
+	return columns, nil
+}
+
+func normalizeImportHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(h)
+}
+
+func parseCargoImportRow(columns []string, row spreadsheet.Row) (models.CreateCargoRequest, []models.CargoImportRowError) {
+	var req models.CreateCargoRequest
+	var errs []models.CargoImportRowError
+	failed := map[string]bool{}
+
+	v := reflect.ValueOf(&req).Elem()
+	for i, field := range columns {
+		if field == "" || i >= len(row.Cells) {
+			continue
+		}
+		cell := strings.TrimSpace(row.Cells[i])
+		if cell == "" {
+			continue
+		}
+
+		if err := setImportField(v.Field(cargoImportFields[field]), cell); err != nil {
+			failed[field] = true
+			errs = append(errs, models.CargoImportRowError{Row: row.Number, Field: field, Message: err.Error()})
+		}
+	}
+
+	err := binding.Validator.ValidateStruct(&req)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		t := v.Type()
+		for _, fe := range validationErrors {
+			sf, _ := t.FieldByName(fe.StructField())
+			field := strings.Split(sf.Tag.Get("json"), ",")[0]
+			if failed[field] {
+				continue
+			}
+			errs = append(errs, models.CargoImportRowError{Row: row.Number, Field: field, Message: validationMessage(fe)})
+		}
+	} else if err != nil {
+		errs = append(errs, models.CargoImportRowError{Row: row.Number, Message: err.Error()})
+	}
+	if err := validateCargoTemperature(req.Type, req.MinTemperature, req.MaxTemperature); err != nil && !failed["min_temperature"] && !failed["max_temperature"] {
+		errs = append(errs, models.CargoImportRowError{Row: row.Number, Field: "min_temperature", Message: err.Error()})
+	}
+	if err := validateCargoHazmat(req.Type, normalizeUNNumber(req.UNNumber), req.HazardClass, req.PackingGroup, req.HazmatQuantity); err != nil && !failed["packing_group"] && !failed["hazmat_quantity"] {
+		errs = append(errs, models.CargoImportRowError{Row: row.Number, Message: err.Error()})
+	}
+
+	return req, errs
+}
+
+func setImportField(f reflect.Value, cell string) error {
+	switch f.Interface().(type) {
+	case string:
+		f.SetString(cell)
+	case models.CargoType, models.CargoPriority:
+		f.SetString(strings.ToLower(cell))
+	case models.HazardClass, models.PackingGroup:
+		f.SetString(strings.ToUpper(cell))
+	case float64:
+		n, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		f.SetFloat(n)
+	case *float64:
+		n, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		f.Set(reflect.ValueOf(&n))
+	case *int:
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		f.Set(reflect.ValueOf(&n))
+	case *uint:
+		n, err := strconv.ParseUint(cell, 10, 32)
+		if err != nil {
+			return errors.New("must be an ID")
+		}
+		id := uint(n)
+		f.Set(reflect.ValueOf(&id))
+	case bool:
+		switch strings.ToLower(cell) {
+		case "1", "true", "yes", "y":
+			f.SetBool(true)
+		case "0", "false", "no", "n":
+			f.SetBool(false)
+		default:
+			return errors.New("must be yes or no")
+		}
+	case *time.Time:
+		t, err := parseImportTime(cell)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(&t))
+	}
+	return nil
+}
+
+func parseImportTime(cell string) (time.Time, error) {
+	for _, layout := range cargoImportTimeLayouts {
+		if t, err := time.Parse(layout, cell); err == nil {
+			return t, nil
+		}
+	}
+
+	// Spreadsheet dates arrive as a day count with the time as the fraction.
+	if serial, err := strconv.ParseFloat(cell, 64); err == nil && serial > 0 {
+		days := math.Floor(serial)
+		seconds := math.Round((serial - days) * 86400)
+		return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), nil
+	}
+
+	return time.Time{}, errors.New("must be a date such as 2006-01-02 or 2006-01-02T15:04:05Z")
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
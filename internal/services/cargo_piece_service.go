@@ -0,0 +1,511 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+type CargoPieceService struct {
+	pieceRepo *repositories.CargoPieceRepository
+	cargoRepo *repositories.CargoRepository
+	legRepo   *repositories.ShipmentLegRepository
+}
+
+func NewCargoPieceService(pieceRepo *repositories.CargoPieceRepository, cargoRepo *repositories.CargoRepository, legRepo *repositories.ShipmentLegRepository) *CargoPieceService {
+	return &CargoPieceService{
+		pieceRepo: pieceRepo,
+		cargoRepo: cargoRepo,
+		legRepo:   legRepo,
+	}
+}
+
+func (s *CargoPieceService) GetPieces(cargoID uint, companyID uint) ([]models.CargoPiece, error) {
+	// Verify cargo belongs to company
+	_, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pieceRepo.GetByCargoID(cargoID, companyID)
+}
+
+func (s *CargoPieceService) AddPieces(cargoID uint, companyID uint, req models.CreateCargoPiecesRequest) ([]models.CargoPiece, error) {
+	cargo, err := s.openCargo(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pieces := make([]models.CargoPiece, len(req.Pieces))
+	for i, input := range req.Pieces {
+		pieces[i] = models.CargoPiece{
+			Description: input.Description,
+			Weight:      input.Weight,
+			Volume:      input.Volume,
+			Status:      models.CargoPieceStatusPending,
+		}
+	}
+
+	err = s.pieceRepo.CreatePieces(cargo, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rollUp(cargo); err != nil {
+		return nil, err
+	}
+	return s.pieceRepo.GetByCargoID(cargoID, companyID)
+}
+
+// SplitCargo divides a cargo without pieces into count equal pieces. The last
+// piece takes the rounding remainder so the totals stay unchanged.
+func (s *CargoPieceService) SplitCargo(cargoID uint, companyID uint, req models.SplitCargoRequest) ([]models.CargoPiece, error) {
+	cargo, err := s.openCargo(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cargo.Pieces) > 0 {
+		return nil, errors.New("cargo is already split into pieces")
+	}
+
+	weight := roundTo(cargo.Weight/float64(req.Count), 3)
+	volume := roundTo(cargo.Volume/float64(req.Count), 3)
+
+	pieces := make([]models.CargoPiece, req.Count)
+	for i := range pieces {
+		pieces[i] = models.CargoPiece{
+			Description: fmt.Sprintf("%s (%d/%d)", cargo.Title, i+1, req.Count),
+			Weight:      weight,
+			Volume:      volume,
+			Status:      models.CargoPieceStatusPending,
+		}
+	}
+	last := &pieces[req.Count-1]
+	last.Weight = roundTo(cargo.Weight-weight*float64(req.Count-1), 3)
+	last.Volume = roundTo(cargo.Volume-volume*float64(req.Count-1), 3)
+
+	err = s.pieceRepo.CreatePieces(cargo, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pieceRepo.GetByCargoID(cargoID, companyID)
+}
+
+func (s *CargoPieceService) DeletePiece(cargoID uint, pieceID uint, companyID uint) error {
+	cargo, err := s.openCargo(cargoID, companyID)
+	if err != nil {
+		return err
+	}
+
+	piece, err := s.pieceRepo.GetByID(pieceID, cargoID, companyID)
+	if err != nil {
+		return errors.New("piece not found")
+	}
+	if piece.Status != models.CargoPieceStatusPending {
+		return errors.New("only pending pieces can be removed")
+	}
+
+	err = s.pieceRepo.Delete(piece.ID)
+	if err != nil {
+		return err
+	}
+
+	if piece.HandlingUnitID != nil {
+		if err := s.rollUpHandlingUnit(*piece.HandlingUnitID, companyID); err != nil {
+			return err
+		}
+	}
+	return s.rollUp(cargo)
+}
+
+// ScanPiece records a pickup or delivery scan of a piece barcode, or of every
+// piece on a handling unit when a unit barcode is scanned. Only the driver of
+// the truck carrying a piece's cargo may scan it.
+func (s *CargoPieceService) ScanPiece(companyID uint, driverID uint, req models.ScanPieceRequest) (*models.ScanPieceResponse, error) {
+	var pieces []models.CargoPiece
+	piece, err := s.pieceRepo.GetByBarcode(req.Barcode, companyID)
+	if err == nil {
+		pieces = []models.CargoPiece{*piece}
+	} else {
+		unit, err := s.pieceRepo.GetHandlingUnitByBarcode(req.Barcode, companyID)
+		if err != nil {
+			return nil, errors.New("barcode not found")
+		}
+		pieces, err = s.pieceRepo.GetByHandlingUnitID(unit.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(pieces) == 0 {
+			return nil, errors.New("handling unit is empty")
+		}
+	}
+
+	from := []models.CargoPieceStatus{models.CargoPieceStatusPending}
+	to := models.CargoPieceStatusPickedUp
+	verb := "picked up"
+	if req.Event == "delivery" {
+		from = append(from, models.CargoPieceStatusPickedUp)
+		to = models.CargoPieceStatusDelivered
+		verb = "delivered"
+	}
+
+	// Check every cargo first so a unit scan is applied all or nothing
+	cargos := map[uint]*models.Cargo{}
+	for _, p := range pieces {
+		if _, ok := cargos[p.CargoID]; ok {
+			continue
+		}
+		cargo, err := s.cargoRepo.GetByID(p.CargoID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if cargo.Status == models.CargoStatusCancelled {
+			return nil, errors.New("cargo " + cargo.TrackingNumber + " is cancelled")
+		}
+		if cargo.Truck == nil || cargo.Truck.DriverID == nil || *cargo.Truck.DriverID != driverID {
+			return nil, errors.New("cargo " + cargo.TrackingNumber + " is not assigned to your truck")
+		}
+		cargos[p.CargoID] = cargo
+	}
+
+	now := time.Now()
+	var scanned []models.CargoPiece
+	for i := range pieces {
+		p := &pieces[i]
+		if !hasPieceStatus(p.Status, from) {
+			continue
+		}
+
+		p.Status = to
+		if to == models.CargoPieceStatusDelivered {
+			p.DeliveredAt = &now
+			if p.PickedUpAt == nil {
+				p.PickedUpAt = &now
+			}
+		} else {
+			p.PickedUpAt = &now
+		}
+		err := s.pieceRepo.Update(p)
+		if err != nil {
+			return nil, err
+		}
+
+		cargo := cargos[p.CargoID]
+		event := &models.CargoEvent{
+			CargoID:     p.CargoID,
+			EventType:   "piece_" + req.Event,
+			Description: fmt.Sprintf("Piece %d/%d (%s) %s", p.PieceNumber, len(cargo.Pieces), p.Barcode, verb),
+			Location:    req.Location,
+			Latitude:    req.Latitude,
+			Longitude:   req.Longitude,
+			UserID:      &driverID,
+			Timestamp:   now,
+		}
+		s.cargoRepo.CreateEvent(event)
+
+		scanned = append(scanned, *p)
+	}
+	if len(scanned) == 0 {
+		return nil, errors.New("pieces are already " + verb)
+	}
+
+	response := &models.ScanPieceResponse{Pieces: scanned}
+	for cargoID := range cargos {
+		cargo, err := s.syncCargo(cargoID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		response.Cargos = append(response.Cargos, *cargo)
+	}
+	return response, nil
+}
+
+func (s *CargoPieceService) CreateHandlingUnit(companyID uint, userID uint, req models.CreateHandlingUnitRequest) (*models.HandlingUnit, error) {
+	unitType := req.Type
+	if unitType == "" {
+		unitType = models.HandlingUnitTypePallet
+	}
+
+	unit := &models.HandlingUnit{
+		CompanyID:  companyID,
+		Type:       unitType,
+		Label:      req.Label,
+		Status:     models.HandlingUnitStatusOpen,
+		TareWeight: req.TareWeight,
+		Weight:     req.TareWeight,
+		CreatedBy:  userID,
+	}
+
+	err := s.pieceRepo.CreateHandlingUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pieceRepo.GetHandlingUnit(unit.ID, companyID)
+}
+
+func (s *CargoPieceService) GetHandlingUnits(companyID uint, filter models.HandlingUnitFilter) ([]models.HandlingUnit, int64, error) {
+	return s.pieceRepo.GetHandlingUnits(companyID, filter)
+}
+
+func (s *CargoPieceService) GetHandlingUnit(id uint, companyID uint) (*models.HandlingUnit, error) {
+	return s.pieceRepo.GetHandlingUnit(id, companyID)
+}
+
+// AddToHandlingUnit consolidates pieces, given by barcode, onto an open unit.
+// Pieces may come from different cargos but not from another unit.
+func (s *CargoPieceService) AddToHandlingUnit(id uint, companyID uint, req models.AddHandlingUnitPiecesRequest) (*models.HandlingUnit, error) {
+	unit, err := s.openHandlingUnit(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	pieces, err := s.pieceRepo.GetByBarcodes(req.Barcodes, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	pieceIDs := make([]uint, 0, len(pieces))
+	for _, p := range pieces {
+		found[p.Barcode] = true
+		if p.Status == models.CargoPieceStatusDelivered {
+			return nil, errors.New("piece " + p.Barcode + " is already delivered")
+		}
+		if p.HandlingUnitID != nil && *p.HandlingUnitID != unit.ID {
+			return nil, errors.New("piece " + p.Barcode + " is on another handling unit")
+		}
+		pieceIDs = append(pieceIDs, p.ID)
+	}
+	for _, barcode := range req.Barcodes {
+		if !found[barcode] {
+			return nil, errors.New("piece " + barcode + " not found")
+		}
+	}
+
+	err = s.pieceRepo.AssignToHandlingUnit(pieceIDs, &unit.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rollUpHandlingUnit(unit.ID, companyID); err != nil {
+		return nil, err
+	}
+	return s.pieceRepo.GetHandlingUnit(unit.ID, companyID)
+}
+
+func (s *CargoPieceService) RemoveFromHandlingUnit(id uint, pieceID uint, companyID uint) (*models.HandlingUnit, error) {
+	unit, err := s.openHandlingUnit(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	onUnit := false
+	for _, p := range unit.Pieces {
+		if p.ID == pieceID {
+			onUnit = true
+			break
+		}
+	}
+	if !onUnit {
+		return nil, errors.New("piece is not on this handling unit")
+	}
+
+	err = s.pieceRepo.AssignToHandlingUnit([]uint{pieceID}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rollUpHandlingUnit(unit.ID, companyID); err != nil {
+		return nil, err
+	}
+	return s.pieceRepo.GetHandlingUnit(unit.ID, companyID)
+}
+
+func (s *CargoPieceService) CloseHandlingUnit(id uint, companyID uint) (*models.HandlingUnit, error) {
+	unit, err := s.openHandlingUnit(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(unit.Pieces) == 0 {
+		return nil, errors.New("handling unit is empty")
+	}
+
+	now := time.Now()
+	unit.Status = models.HandlingUnitStatusClosed
+	unit.ClosedAt = &now
+
+	err = s.pieceRepo.UpdateHandlingUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pieceRepo.GetHandlingUnit(unit.ID, companyID)
+}
+
+func (s *CargoPieceService) openCargo(cargoID uint, companyID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	switch cargo.Status {
+	case models.CargoStatusDelivered, models.CargoStatusPartiallyDelivered, models.CargoStatusCancelled:
+		return nil, errors.New("cargo is already " + string(cargo.Status))
+	}
+	return cargo, nil
+}
+
+func (s *CargoPieceService) openHandlingUnit(id uint, companyID uint) (*models.HandlingUnit, error) {
+	unit, err := s.pieceRepo.GetHandlingUnit(id, companyID)
+	if err != nil {
+		return nil, errors.New("handling unit not found")
+	}
+	if unit.Status != models.HandlingUnitStatusOpen {
+		return nil, errors.New("handling unit is closed")
+	}
+	return unit, nil
+}
+
+// rollUp sets the cargo's weight and volume to the sum of its pieces.
+func (s *CargoPieceService) rollUp(cargo *models.Cargo) error {
+	count, weight, volume, err := s.pieceRepo.Totals(cargo.ID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	cargo.Weight = weight
+	cargo.Volume = volume
+	cargo.ShipmentLegs = nil
+	cargo.CargoEvents = nil
+	cargo.Pieces = nil
+	return s.cargoRepo.Update(cargo)
+}
+
+func (s *CargoPieceService) rollUpHandlingUnit(id uint, companyID uint) error {
+	unit, err := s.pieceRepo.GetHandlingUnit(id, companyID)
+	if err != nil {
+		return err
+	}
+
+	count, weight, volume, err := s.pieceRepo.HandlingUnitTotals(id)
+	if err != nil {
+		return err
+	}
+
+	unit.PieceCount = int(count)
+	unit.Weight = unit.TareWeight + weight
+	unit.Volume = volume
+	return s.pieceRepo.UpdateHandlingUnit(unit)
+}
+
+// syncCargo derives the cargo's status from its pieces after a scan and
+// returns the reloaded cargo.
+func (s *CargoPieceService) syncCargo(cargoID uint, companyID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldStatus := cargo.Status
+	if applyPiecesToCargo(cargo, cargo.Pieces) {
+		cargo.ShipmentLegs = nil
+		cargo.CargoEvents = nil
+		cargo.Pieces = nil
+		err = s.cargoRepo.Update(cargo)
+		if err != nil {
+			return nil, err
+		}
+
+		if cargo.Status == models.CargoStatusDelivered {
+			// Delivery of the last piece ends the final leg of a multi-leg shipment
+			s.legRepo.CompleteOpenLegs(cargo.ID, *cargo.ActualDelivery)
+		}
+
+		if oldStatus != cargo.Status {
+			event := &models.CargoEvent{
+				CargoID:     cargo.ID,
+				EventType:   "status_change",
+				Description: "Status changed from " + string(oldStatus) + " to " + string(cargo.Status),
+				Timestamp:   time.Now(),
+			}
+			s.cargoRepo.CreateEvent(event)
+		}
+	}
+
+	return s.cargoRepo.GetByID(cargoID, companyID)
+}
+
+// applyPiecesToCargo sets the cargo's status and pickup and delivery times
+// from its pieces and reports whether anything changed: delivered once every
+// piece is, partially delivered once some are, and in transit once any piece
+// is picked up. Cancelled cargo is left alone.
+func applyPiecesToCargo(cargo *models.Cargo, pieces []models.CargoPiece) bool {
+	if len(pieces) == 0 || cargo.Status == models.CargoStatusCancelled {
+		return false
+	}
+
+	var pickedUp, delivered int
+	var firstPickup, lastDelivery *time.Time
+	for i := range pieces {
+		p := &pieces[i]
+		if p.PickedUpAt != nil && (firstPickup == nil || p.PickedUpAt.Before(*firstPickup)) {
+			firstPickup = p.PickedUpAt
+		}
+		switch p.Status {
+		case models.CargoPieceStatusPickedUp:
+			pickedUp++
+		case models.CargoPieceStatusDelivered:
+			delivered++
+			if p.DeliveredAt != nil && (lastDelivery == nil || p.DeliveredAt.After(*lastDelivery)) {
+				lastDelivery = p.DeliveredAt
+			}
+		}
+	}
+
+	status := cargo.Status
+	switch {
+	case delivered == len(pieces):
+		status = models.CargoStatusDelivered
+	case delivered > 0:
+		status = models.CargoStatusPartiallyDelivered
+	case pickedUp > 0:
+		status = models.CargoStatusInTransit
+	}
+
+	changed := status != cargo.Status
+	cargo.Status = status
+	if cargo.ActualPickup == nil && firstPickup != nil {
+		cargo.ActualPickup = firstPickup
+		changed = true
+	}
+	if status == models.CargoStatusDelivered && cargo.ActualDelivery == nil {
+		if lastDelivery == nil {
+			now := time.Now()
+			lastDelivery = &now
+		}
+		cargo.ActualDelivery = lastDelivery
+		changed = true
+	}
+	return changed
+}
+
+func hasPieceStatus(status models.CargoPieceStatus, statuses []models.CargoPieceStatus) bool {
+	for _, s := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -1,86 +1,146 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"time"
 	"truck-management/internal/models"
 	"truck-management/internal/repositories"
+	"truck-management/internal/utils"
+
+	"gorm.io/gorm"
 )
 
+// ErrBookingNotApproved is returned when dispatching a portal booking that no
+// moderator has approved yet.
+var ErrBookingNotApproved = errors.New("cargo booking awaits approval")
+
 type CargoService struct {
-	cargoRepo *repositories.CargoRepository
-	truckRepo *repositories.TruckRepository
+	cargoRepo       *repositories.CargoRepository
+	truckRepo       *repositories.TruckRepository
+	podRepo         *repositories.ProofOfDeliveryRepository
+	legRepo         *repositories.ShipmentLegRepository
+	pieceRepo       *repositories.CargoPieceRepository
+	tempRepo        *repositories.TemperatureRepository
+	trackingNumbers *TrackingNumberService
+	pricing         *PricingService
+	customers       *CustomerService
+	uow             *repositories.UnitOfWork
 }
 
-func NewCargoService(cargoRepo *repositories.CargoRepository, truckRepo *repositories.TruckRepository) *CargoService {
+func NewCargoService(cargoRepo *repositories.CargoRepository, truckRepo *repositories.TruckRepository, podRepo *repositories.ProofOfDeliveryRepository, legRepo *repositories.ShipmentLegRepository, pieceRepo *repositories.CargoPieceRepository, tempRepo *repositories.TemperatureRepository, trackingNumbers *TrackingNumberService, pricing *PricingService, customers *CustomerService, uow *repositories.UnitOfWork) *CargoService {
 	return &CargoService{
-		cargoRepo: cargoRepo,
-		truckRepo: truckRepo,
+		cargoRepo:       cargoRepo,
+		truckRepo:       truckRepo,
+		podRepo:         podRepo,
+		legRepo:         legRepo,
+		pieceRepo:       pieceRepo,
+		tempRepo:        tempRepo,
+		trackingNumbers: trackingNumbers,
+		pricing:         pricing,
+		customers:       customers,
+		uow:             uow,
 	}
 }
 
 func (s *CargoService) CreateCargo(companyID uint, req models.CreateCargoRequest) (*models.Cargo, error) {
-	trackingNumber, err := s.cargoRepo.GenerateTrackingNumber(companyID)
-	if err != nil {
+	return s.createCargo(companyID, req, nil)
+}
+
+// BookCargo creates a cargo booked by a customer user in the portal. It stays
+// pending until a moderator approves the booking.
+func (s *CargoService) BookCargo(companyID uint, userID uint, req models.CreateCargoRequest) (*models.Cargo, error) {
+	return s.createCargo(companyID, req, &userID)
+}
+
+func (s *CargoService) createCargo(companyID uint, req models.CreateCargoRequest, bookedBy *uint) (*models.Cargo, error) {
+	if err := validateCargoTemperature(req.Type, req.MinTemperature, req.MaxTemperature); err != nil {
+		return nil, err
+	}
+	if err := validateCargoHazmat(req.Type, normalizeUNNumber(req.UNNumber), req.HazardClass, req.PackingGroup, req.HazmatQuantity); err != nil {
 		return nil, err
 	}
 
-	cargo := &models.Cargo{
-		CompanyID:            companyID,
-		TrackingNumber:       trackingNumber,
-		Title:                req.Title,
-		Description:          req.Description,
-		Type:                 req.Type,
-		Priority:             req.Priority,
-		Status:               models.CargoStatusPending,
-		Weight:               req.Weight,
-		Volume:               req.Volume,
-		Value:                req.Value,
-		Currency:             req.Currency,
-		OriginAddress:        req.OriginAddress,
-		OriginLatitude:       req.OriginLatitude,
-		OriginLongitude:      req.OriginLongitude,
-		OriginContact:        req.OriginContact,
-		OriginPhone:          req.OriginPhone,
-		DestinationAddress:   req.DestinationAddress,
-		DestinationLatitude:  req.DestinationLatitude,
-		DestinationLongitude: req.DestinationLongitude,
-		DestinationContact:   req.DestinationContact,
-		DestinationPhone:     req.DestinationPhone,
-		PickupTime:           req.PickupTime,
-		DeliveryTime:         req.DeliveryTime,
-		EstimatedDelivery:    req.EstimatedDelivery,
-		Instructions:         req.Instructions,
-		SpecialHandling:      req.SpecialHandling,
+	trackingNumber, err := s.trackingNumbers.Generate(companyID)
+	if err != nil {
+		return nil, err
 	}
 
-	if cargo.Currency == "" {
-		cargo.Currency = "USD"
+	cargo := newCargoFromRequest(companyID, trackingNumber, req)
+	cargo.BookedBy = bookedBy
+	if cargo.TrackingToken, err = utils.NewTrackingToken(); err != nil {
+		return nil, err
 	}
-	if cargo.Type == "" {
-		cargo.Type = models.CargoTypeGeneral
+	if err := s.customers.fillCargo(companyID, cargo); err != nil {
+		return nil, err
 	}
-	if cargo.Priority == "" {
-		cargo.Priority = models.CargoPriorityMedium
+	if err := s.pricing.PriceCargos(companyID, []*models.Cargo{cargo}); err != nil {
+		return nil, err
 	}
 
-	err = s.cargoRepo.Create(cargo)
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Create(cargo); err != nil {
+			return err
+		}
+
+		// Create initial event
+		event := newCargoCreatedEvent(cargo)
+		event.CargoID = cargo.ID
+		if bookedBy != nil {
+			event.EventType = "booked"
+			event.Description = "Cargo booked by the customer, awaiting approval"
+			event.UserID = bookedBy
+		}
+		return tx.Cargos.CreateEvent(&event)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Create initial event
-	event := &models.CargoEvent{
-		CargoID:     cargo.ID,
-		EventType:   "created",
-		Description: "Cargo created and ready for assignment",
-		Location:    cargo.OriginAddress,
-		Latitude:    cargo.OriginLatitude,
-		Longitude:   cargo.OriginLongitude,
-		Timestamp:   time.Now(),
+	return s.cargoRepo.GetByID(cargo.ID, companyID)
+}
+
+// CreateCargos creates several cargos, each with its "created" event, in one
+// transaction. Tracking numbers reserved for a failed batch are skipped.
+func (s *CargoService) CreateCargos(companyID uint, reqs []models.CreateCargoRequest) ([]models.Cargo, error) {
+	for _, req := range reqs {
+		if err := validateCargoTemperature(req.Type, req.MinTemperature, req.MaxTemperature); err != nil {
+			return nil, fmt.Errorf("%s: %w", req.Title, err)
+		}
+		if err := validateCargoHazmat(req.Type, normalizeUNNumber(req.UNNumber), req.HazardClass, req.PackingGroup, req.HazmatQuantity); err != nil {
+			return nil, fmt.Errorf("%s: %w", req.Title, err)
+		}
 	}
-	s.cargoRepo.CreateEvent(event)
 
-	return s.cargoRepo.GetByID(cargo.ID, companyID)
+	trackingNumbers, err := s.trackingNumbers.GenerateBatch(companyID, len(reqs))
+	if err != nil {
+		return nil, err
+	}
+
+	cargos := make([]models.Cargo, len(reqs))
+	priced := make([]*models.Cargo, len(reqs))
+	for i, req := range reqs {
+		cargo := newCargoFromRequest(companyID, trackingNumbers[i], req)
+		if cargo.TrackingToken, err = utils.NewTrackingToken(); err != nil {
+			return nil, err
+		}
+		if err := s.customers.fillCargo(companyID, cargo); err != nil {
+			return nil, fmt.Errorf("%s: %w", req.Title, err)
+		}
+		cargo.CargoEvents = []models.CargoEvent{newCargoCreatedEvent(cargo)}
+		cargos[i] = *cargo
+		priced[i] = &cargos[i]
+	}
+	if err := s.pricing.PriceCargos(companyID, priced); err != nil {
+		return nil, err
+	}
+
+	err = s.cargoRepo.CreateBatch(cargos)
+	if err != nil {
+		return nil, err
+	}
+
+	return cargos, nil
 }
 
 func (s *CargoService) GetCargos(companyID uint, filter models.CargoFilter) ([]models.Cargo, int64, error) {
@@ -91,8 +151,15 @@ func (s *CargoService) GetCargo(id uint, companyID uint) (*models.Cargo, error)
 	return s.cargoRepo.GetByID(id, companyID)
 }
 
+// GetCargoByTracking looks up a cargo by tracking number. The check digit is
+// verified first so a mistyped number is rejected rather than matched to
+// another shipment; numbers without a check digit go straight to the lookup.
 func (s *CargoService) GetCargoByTracking(trackingNumber string) (*models.Cargo, error) {
-	return s.cargoRepo.GetByTrackingNumber(trackingNumber)
+	if err := s.trackingNumbers.Validate(trackingNumber); err != nil {
+		return nil, err
+	}
+
+	return s.cargoRepo.GetByTrackingNumber(utils.NormalizeTrackingNumber(trackingNumber))
 }
 
 func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCargoRequest) (*models.Cargo, error) {
@@ -102,6 +169,7 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 	}
 
 	oldStatus := cargo.Status
+	oldEstimatedDelivery := cargo.EstimatedDelivery
 
 	// Update fields
 	if req.Title != "" {
@@ -119,10 +187,11 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 	if req.Status != "" {
 		cargo.Status = req.Status
 	}
-	if req.Weight > 0 {
+	// Weight and volume of a cargo with pieces are rolled up from the pieces
+	if req.Weight > 0 && len(cargo.Pieces) == 0 {
 		cargo.Weight = req.Weight
 	}
-	if req.Volume > 0 {
+	if req.Volume > 0 && len(cargo.Pieces) == 0 {
 		cargo.Volume = req.Volume
 	}
 	if req.Value > 0 {
@@ -131,6 +200,34 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 	if req.Currency != "" {
 		cargo.Currency = req.Currency
 	}
+	// A price set by hand replaces the rated one
+	if req.Price != nil {
+		cargo.Price = req.Price
+		cargo.FreightRateID = nil
+		if cargo.PriceCurrency == "" {
+			settings, err := s.pricing.GetSettings(companyID)
+			if err != nil {
+				return nil, err
+			}
+			cargo.PriceCurrency = settings.Currency
+		}
+	}
+	if req.CustomerID != nil {
+		cargo.CustomerID = req.CustomerID
+		cargo.Customer = nil
+	}
+	// A newly chosen saved address replaces the old location and contact
+	if req.OriginAddressID != nil && (cargo.OriginAddressID == nil || *cargo.OriginAddressID != *req.OriginAddressID) {
+		cargo.OriginAddressID = req.OriginAddressID
+		cargo.OriginAddress, cargo.OriginLatitude, cargo.OriginLongitude = "", nil, nil
+		cargo.OriginContact, cargo.OriginPhone = "", ""
+	}
+	if req.DestinationAddressID != nil && (cargo.DestinationAddressID == nil || *cargo.DestinationAddressID != *req.DestinationAddressID) {
+		cargo.DestinationAddressID = req.DestinationAddressID
+		cargo.DestinationAddress, cargo.DestinationLatitude, cargo.DestinationLongitude = "", nil, nil
+		cargo.DestinationContact, cargo.DestinationPhone = "", ""
+		cargo.DestinationPostcode = ""
+	}
 	if req.OriginAddress != "" {
 		cargo.OriginAddress = req.OriginAddress
 	}
@@ -149,6 +246,9 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 	if req.DestinationAddress != "" {
 		cargo.DestinationAddress = req.DestinationAddress
 	}
+	if req.DestinationPostcode != "" {
+		cargo.DestinationPostcode = req.DestinationPostcode
+	}
 	if req.DestinationLatitude != nil {
 		cargo.DestinationLatitude = req.DestinationLatitude
 	}
@@ -161,6 +261,12 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 	if req.DestinationPhone != "" {
 		cargo.DestinationPhone = req.DestinationPhone
 	}
+	if req.DestinationEmail != "" {
+		cargo.DestinationEmail = req.DestinationEmail
+	}
+	if req.RecipientLocale != "" {
+		cargo.RecipientLocale = req.RecipientLocale
+	}
 	if req.PickupTime != nil {
 		cargo.PickupTime = req.PickupTime
 	}
@@ -174,26 +280,89 @@ func (s *CargoService) UpdateCargo(id uint, companyID uint, req models.UpdateCar
 		cargo.Instructions = req.Instructions
 	}
 	cargo.SpecialHandling = req.SpecialHandling
-
-	err = s.cargoRepo.Update(cargo)
-	if err != nil {
+	if req.MinTemperature != nil {
+		cargo.MinTemperature = req.MinTemperature
+	}
+	if req.MaxTemperature != nil {
+		cargo.MaxTemperature = req.MaxTemperature
+	}
+	if req.ExcursionThresholdMinutes != nil {
+		cargo.ExcursionThresholdMinutes = *req.ExcursionThresholdMinutes
+	}
+	if req.UNNumber != "" {
+		cargo.UNNumber = normalizeUNNumber(req.UNNumber)
+	}
+	if req.ProperShippingName != "" {
+		cargo.ProperShippingName = req.ProperShippingName
+	}
+	if req.HazardClass != "" {
+		cargo.HazardClass = req.HazardClass
+	}
+	if req.PackingGroup != "" {
+		cargo.PackingGroup = req.PackingGroup
+	}
+	if req.HazmatQuantity > 0 {
+		cargo.HazmatQuantity = req.HazmatQuantity
+	}
+	if req.HazmatQuantityUnit != "" {
+		cargo.HazmatQuantityUnit = req.HazmatQuantityUnit
+	}
+	if req.EmergencyPhone != "" {
+		cargo.EmergencyPhone = req.EmergencyPhone
+	}
+	if err := validateCargoTemperature(cargo.Type, cargo.MinTemperature, cargo.MaxTemperature); err != nil {
+		return nil, err
+	}
+	if err := validateCargoHazmat(cargo.Type, cargo.UNNumber, cargo.HazardClass, cargo.PackingGroup, cargo.HazmatQuantity); err != nil {
+		return nil, err
+	}
+	if err := s.customers.fillCargo(companyID, cargo); err != nil {
 		return nil, err
 	}
 
-	// Create status change event if status changed
-	if oldStatus != cargo.Status {
-		event := &models.CargoEvent{
-			CargoID:     cargo.ID,
-			EventType:   "status_change",
-			Description: "Status changed from " + string(oldStatus) + " to " + string(cargo.Status),
-			Timestamp:   time.Now(),
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
 		}
-		s.cargoRepo.CreateEvent(event)
+
+		// Create status change event if status changed
+		if oldStatus != cargo.Status {
+			event := &models.CargoEvent{
+				CargoID:     cargo.ID,
+				EventType:   "status_change",
+				Description: "Status changed from " + string(oldStatus) + " to " + string(cargo.Status),
+				Timestamp:   time.Now(),
+			}
+			if err := tx.Cargos.CreateEvent(event); err != nil {
+				return err
+			}
+		}
+
+		// A later estimate for an open shipment is a delay the consignee hears about
+		if isDelay(oldEstimatedDelivery, cargo.EstimatedDelivery) &&
+			cargo.Status != models.CargoStatusDelivered && cargo.Status != models.CargoStatusCancelled {
+			return tx.Cargos.CreateEvent(&models.CargoEvent{
+				CargoID:     cargo.ID,
+				EventType:   "delayed",
+				Description: "Estimated delivery moved to " + cargo.EstimatedDelivery.Format("2006-01-02 15:04"),
+				Timestamp:   time.Now(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.cargoRepo.GetByID(id, companyID)
 }
 
+// isDelay reports whether the estimated delivery moved later than a previous
+// estimate.
+func isDelay(previous, current *time.Time) bool {
+	return previous != nil && current != nil && current.After(*previous)
+}
+
 func (s *CargoService) DeleteCargo(id uint, companyID uint) error {
 	return s.cargoRepo.Delete(id, companyID)
 }
@@ -210,6 +379,22 @@ func (s *CargoService) AssignCargoToTruck(cargoID uint, companyID uint, truckID
 	if err != nil {
 		return nil, err
 	}
+	if len(cargo.ShipmentLegs) > 0 {
+		return nil, errors.New("cargo has shipment legs; assign trucks to its legs instead")
+	}
+	if cargo.AwaitingApproval() {
+		return nil, ErrBookingNotApproved
+	}
+
+	// Dangerous goods need a placarded truck and must not ride with
+	// incompatible classes
+	onboard, err := s.cargoRepo.GetOpenByTruckID(truckID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHazmatLoad(cargo, truck, onboard); err != nil {
+		return nil, err
+	}
 
 	// Update cargo assignment
 	cargo.TruckID = &truckID
@@ -218,26 +403,31 @@ func (s *CargoService) AssignCargoToTruck(cargoID uint, companyID uint, truckID
 	now := time.Now()
 	cargo.AssignedAt = &now
 
-	err = s.cargoRepo.Update(cargo)
-	if err != nil {
-		return nil, err
-	}
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
 
-	// Update truck status if it was offline
-	if truck.Status == models.TruckStatusOffline {
-		truck.Status = models.TruckStatusInUse
-		s.truckRepo.Update(truck)
-	}
+		// Update truck status if it was offline
+		if truck.Status == models.TruckStatusOffline {
+			truck.Status = models.TruckStatusInUse
+			if err := tx.Trucks.Update(truck); err != nil {
+				return err
+			}
+		}
 
-	// Create assignment event
-	event := &models.CargoEvent{
-		CargoID:     cargo.ID,
-		EventType:   "assigned",
-		Description: "Cargo assigned to truck " + truck.LicensePlate,
-		UserID:      &assignedBy,
-		Timestamp:   time.Now(),
+		// Create assignment event
+		return tx.Cargos.CreateEvent(&models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   "assigned",
+			Description: "Cargo assigned to truck " + truck.LicensePlate,
+			UserID:      &assignedBy,
+			Timestamp:   time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	s.cargoRepo.CreateEvent(event)
 
 	return s.cargoRepo.GetByID(cargoID, companyID)
 }
@@ -251,6 +441,9 @@ func (s *CargoService) UnassignCargoFromTruck(cargoID uint, companyID uint, user
 	if cargo.TruckID == nil {
 		return cargo, nil // Already unassigned
 	}
+	if len(cargo.ShipmentLegs) > 0 {
+		return nil, errors.New("cargo has shipment legs; change the trucks of its legs instead")
+	}
 
 	truckID := *cargo.TruckID
 	cargo.TruckID = nil
@@ -258,48 +451,198 @@ func (s *CargoService) UnassignCargoFromTruck(cargoID uint, companyID uint, user
 	cargo.AssignedBy = nil
 	cargo.AssignedAt = nil
 
-	err = s.cargoRepo.Update(cargo)
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
+		if err := releaseTruck(tx, truckID, companyID); err != nil {
+			return err
+		}
+
+		// Create unassignment event
+		return tx.Cargos.CreateEvent(&models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   "unassigned",
+			Description: "Cargo unassigned from truck",
+			UserID:      &userID,
+			Timestamp:   time.Now(),
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if truck has other assigned cargos
-	otherCargos, _ := s.cargoRepo.GetByTruckID(truckID, companyID)
-	if len(otherCargos) == 0 {
-		// No other cargos, set truck to online
-		truck, err := s.truckRepo.GetByID(truckID, companyID)
-		if err == nil {
-			truck.Status = models.TruckStatusOnline
-			s.truckRepo.Update(truck)
-		}
+	return s.cargoRepo.GetByID(cargoID, companyID)
+}
+
+// ApproveBooking releases a portal booking for dispatch.
+func (s *CargoService) ApproveBooking(cargoID uint, companyID uint, userID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !cargo.AwaitingApproval() {
+		return nil, errors.New("cargo is not awaiting approval")
+	}
+	if cargo.Status != models.CargoStatusPending {
+		return nil, fmt.Errorf("cargo %s is %s", cargo.TrackingNumber, cargo.Status)
 	}
 
-	// Create unassignment event
-	event := &models.CargoEvent{
-		CargoID:     cargo.ID,
-		EventType:   "unassigned",
-		Description: "Cargo unassigned from truck",
-		UserID:      &userID,
-		Timestamp:   time.Now(),
+	now := time.Now()
+	cargo.BookingApprovedBy = &userID
+	cargo.BookingApprovedAt = &now
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
+		return tx.Cargos.CreateEvent(&models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   "booking_approved",
+			Description: "Booking approved",
+			UserID:      &userID,
+			Timestamp:   now,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	s.cargoRepo.CreateEvent(event)
 
 	return s.cargoRepo.GetByID(cargoID, companyID)
 }
 
+// RejectBooking cancels a portal booking that has not been approved.
+func (s *CargoService) RejectBooking(cargoID uint, companyID uint, userID uint, reason string) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !cargo.AwaitingApproval() {
+		return nil, errors.New("cargo is not awaiting approval")
+	}
+
+	return s.cancelBeforePickup(cargo, userID, "booking_rejected", "Booking rejected: "+reason)
+}
+
+// CancelBooking cancels a cargo on behalf of the customer. Only cargo that
+// has not been picked up yet can be cancelled.
+func (s *CargoService) CancelBooking(cargoID uint, companyID uint, userID uint, reason string) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Booking cancelled by the customer"
+	if reason != "" {
+		description += ": " + reason
+	}
+	return s.cancelBeforePickup(cargo, userID, "cancelled", description)
+}
+
+// cancelBeforePickup cancels pending or assigned cargo, releasing its truck,
+// and records the cancellation as an event.
+func (s *CargoService) cancelBeforePickup(cargo *models.Cargo, userID uint, eventType string, description string) (*models.Cargo, error) {
+	if cargo.Status != models.CargoStatusPending && cargo.Status != models.CargoStatusAssigned {
+		return nil, fmt.Errorf("cargo %s is %s and can no longer be cancelled", cargo.TrackingNumber, cargo.Status)
+	}
+	for _, leg := range cargo.ShipmentLegs {
+		if leg.Status == models.ShipmentLegStatusInTransit || leg.Status == models.ShipmentLegStatusCompleted {
+			return nil, fmt.Errorf("cargo %s has left its origin and can no longer be cancelled", cargo.TrackingNumber)
+		}
+	}
+
+	truckID := cargo.TruckID
+	cargo.TruckID = nil
+	cargo.Truck = nil
+	cargo.AssignedBy = nil
+	cargo.AssignedAt = nil
+	cargo.Status = models.CargoStatusCancelled
+	err := s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
+		if truckID != nil {
+			if err := releaseTruck(tx, *truckID, cargo.CompanyID); err != nil {
+				return err
+			}
+		}
+		return tx.Cargos.CreateEvent(&models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   eventType,
+			Description: description,
+			UserID:      &userID,
+			Timestamp:   time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cargoRepo.GetByID(cargo.ID, cargo.CompanyID)
+}
+
+// releaseTruck sets a truck left without cargo back online.
+func releaseTruck(tx *repositories.Tx, truckID uint, companyID uint) error {
+	otherCargos, err := tx.Cargos.GetByTruckID(truckID, companyID)
+	if err != nil || len(otherCargos) > 0 {
+		return err
+	}
+	truck, err := tx.Trucks.GetByID(truckID, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	truck.Status = models.TruckStatusOnline
+	return tx.Trucks.Update(truck)
+}
+
 func (s *CargoService) GetCargosByTruck(truckID uint, companyID uint) ([]models.Cargo, error) {
 	return s.cargoRepo.GetByTruckID(truckID, companyID)
 }
 
 func (s *CargoService) CreateCargoEvent(cargoID uint, companyID uint, userID uint, req models.CreateCargoEventRequest) (*models.CargoEvent, error) {
 	// Verify cargo belongs to company
-	_, err := s.cargoRepo.GetByID(cargoID, companyID)
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := s.newCargoEvent(cargo, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		return recordCargoEvent(tx, cargo, event)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	event := &models.CargoEvent{
-		CargoID:     cargoID,
+	return event, nil
+}
+
+// newCargoEvent checks that the event may be recorded for the cargo and
+// builds it.
+func (s *CargoService) newCargoEvent(cargo *models.Cargo, userID uint, req models.CreateCargoEventRequest) (*models.CargoEvent, error) {
+	// Pickups and deliveries move the cargo through the status state machine
+	var err error
+	switch req.EventType {
+	case "pickup":
+		err = checkCargoTransition(cargo, models.CargoStatusInTransit)
+		if err == nil {
+			err = s.checkPickupHazmat(cargo, cargo.CompanyID)
+		}
+	case "delivery":
+		err = checkCargoTransition(cargo, models.CargoStatusDelivered)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CargoEvent{
+		CargoID:     cargo.ID,
 		EventType:   req.EventType,
 		Description: req.Description,
 		Location:    req.Location,
@@ -307,30 +650,74 @@ func (s *CargoService) CreateCargoEvent(cargoID uint, companyID uint, userID uin
 		Longitude:   req.Longitude,
 		UserID:      &userID,
 		Timestamp:   time.Now(),
+	}, nil
+}
+
+// recordCargoEvent stores the event and applies its status change to the
+// cargo, its pieces, legs and temperature excursions.
+func recordCargoEvent(tx *repositories.Tx, cargo *models.Cargo, event *models.CargoEvent) error {
+	// Update cargo status based on event type
+	now := time.Now()
+	switch event.EventType {
+	case "pickup":
+		cargo.Status = models.CargoStatusInTransit
+		cargo.ActualPickup = &now
+		if err := tx.Pieces.SetStatusForCargo(cargo.ID, []models.CargoPieceStatus{models.CargoPieceStatusPending}, models.CargoPieceStatusPickedUp, now); err != nil {
+			return err
+		}
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
+	case "delivery":
+		cargo.Status = models.CargoStatusDelivered
+		cargo.ActualDelivery = &now
+		// Delivery ends the final leg of a multi-leg shipment
+		if err := tx.Legs.CompleteOpenLegs(cargo.ID, now); err != nil {
+			return err
+		}
+		if err := tx.Pieces.SetStatusForCargo(cargo.ID, []models.CargoPieceStatus{models.CargoPieceStatusPending, models.CargoPieceStatusPickedUp}, models.CargoPieceStatusDelivered, now); err != nil {
+			return err
+		}
+		// Temperature monitoring ends with the delivery
+		if err := tx.Temperatures.CloseOpenExcursions(cargo.ID, now); err != nil {
+			return err
+		}
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
 	}
 
-	err = s.cargoRepo.CreateEvent(event)
-	if err != nil {
-		return nil, err
+	return tx.Cargos.CreateEvent(event)
+}
+
+// checkPickupHazmat applies the dispatch rules for dangerous goods to the
+// truck picking the cargo up, together with what it already carries.
+func (s *CargoService) checkPickupHazmat(cargo *models.Cargo, companyID uint) error {
+	if !cargo.IsDangerousGoods() || cargo.TruckID == nil {
+		return nil
 	}
 
-	// Update cargo status based on event type
-	cargo, _ := s.cargoRepo.GetByID(cargoID, companyID)
-	if cargo != nil {
-		switch req.EventType {
-		case "pickup":
-			cargo.Status = models.CargoStatusInTransit
-			now := time.Now()
-			cargo.ActualPickup = &now
-		case "delivery":
-			cargo.Status = models.CargoStatusDelivered
-			now := time.Now()
-			cargo.ActualDelivery = &now
-		}
-		s.cargoRepo.Update(cargo)
+	truck, err := s.truckRepo.GetByID(*cargo.TruckID, companyID)
+	if err != nil {
+		return err
+	}
+	onboard, err := s.cargoRepo.GetOpenByTruckID(truck.ID, companyID)
+	if err != nil {
+		return err
 	}
+	return checkHazmatDispatch(truck, withCargo(onboard, cargo))
+}
 
-	return event, nil
+// checkCargoTransition rejects a status change the state machine does not
+// allow, naming the cargo so drivers scanning several know which one.
+func checkCargoTransition(cargo *models.Cargo, to models.CargoStatus) error {
+	if cargo.Status == to {
+		return fmt.Errorf("cargo %s is already %s", cargo.TrackingNumber, to)
+	}
+	if !cargo.Status.CanTransitionTo(to) {
+		return fmt.Errorf("cargo %s cannot go from %s to %s", cargo.TrackingNumber, cargo.Status, to)
+	}
+	return nil
 }
 
 func (s *CargoService) GetCargoEvents(cargoID uint, companyID uint) ([]models.CargoEvent, error) {
@@ -361,29 +748,32 @@ func (s *CargoService) UpdateCargoLocation(cargoID uint, companyID uint, userID
 	now := time.Now()
 	cargo.LastUpdated = &now
 
-	err = s.cargoRepo.Update(cargo)
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Cargos.Update(cargo); err != nil {
+			return err
+		}
+
+		// Create location update event
+		return tx.Cargos.CreateEvent(&models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   "location_update",
+			Description: "Location updated during transit",
+			Location:    req.Location,
+			Latitude:    &req.Latitude,
+			Longitude:   &req.Longitude,
+			UserID:      &userID,
+			Timestamp:   time.Now(),
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Create location update event
-	event := &models.CargoEvent{
-		CargoID:     cargo.ID,
-		EventType:   "location_update",
-		Description: "Location updated during transit",
-		Location:    req.Location,
-		Latitude:    &req.Latitude,
-		Longitude:   &req.Longitude,
-		UserID:      &userID,
-		Timestamp:   time.Now(),
-	}
-	s.cargoRepo.CreateEvent(event)
-
 	return s.cargoRepo.GetByID(cargoID, companyID)
 }
 
 func (s *CargoService) GetCargoTracking(trackingNumber string) (*models.CargoTrackingResponse, error) {
-	cargo, err := s.cargoRepo.GetByTrackingNumber(trackingNumber)
+	cargo, err := s.GetCargoByTracking(trackingNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -395,8 +785,12 @@ func (s *CargoService) GetCargoTracking(trackingNumber string) (*models.CargoTra
 		recentEvents = events[:10]
 	}
 
-	// Calculate progress based on status
+	// Calculate progress based on status, or on the legs travelled so far
 	progress := s.calculateProgress(cargo.Status)
+	journey := journeyOf(cargo.ShipmentLegs)
+	if len(journey) > 0 && cargo.Status == models.CargoStatusInTransit {
+		progress = 25 + 0.75*legProgress(cargo.ShipmentLegs)
+	}
 
 	// Get truck location if assigned
 	var truckLocation *models.TruckLocation
@@ -413,17 +807,137 @@ func (s *CargoService) GetCargoTracking(trackingNumber string) (*models.CargoTra
 		estimatedETA = cargo.EstimatedDelivery
 	}
 
+	// Only a redacted summary of the proof of delivery is public
+	var podSummary *models.PODSummary
+	if cargo.Status == models.CargoStatusDelivered {
+		pod, err := s.podRepo.GetByCargoID(cargo.ID, cargo.CompanyID)
+		if err == nil {
+			podSummary = summarizeProofOfDelivery(pod)
+		}
+	}
+
 	return &models.CargoTrackingResponse{
-		Cargo:         *cargo,
-		CurrentStatus: string(cargo.Status),
-		Progress:      progress,
-		EstimatedETA:  estimatedETA,
-		LastUpdate:    cargo.LastUpdated,
-		RecentEvents:  recentEvents,
-		TruckLocation: truckLocation,
+		Cargo:           *cargo,
+		CurrentStatus:   string(cargo.Status),
+		Progress:        progress,
+		EstimatedETA:    estimatedETA,
+		LastUpdate:      cargo.LastUpdated,
+		RecentEvents:    recentEvents,
+		TruckLocation:   truckLocation,
+		ProofOfDelivery: podSummary,
+		Journey:         journey,
 	}, nil
 }
 
+// RotateTrackingToken replaces the cargo's public tracking token, revoking
+// links shared with the old one.
+func (s *CargoService) RotateTrackingToken(cargoID uint, companyID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cargo.TrackingToken, err = utils.NewTrackingToken(); err != nil {
+		return nil, err
+	}
+	if err := s.cargoRepo.Update(cargo); err != nil {
+		return nil, err
+	}
+	return cargo, nil
+}
+
+// GetPublicTracking returns the unauthenticated tracking view of a cargo.
+// Callers presenting the tracking token or the destination postcode see the
+// addresses, event locations, journey, truck position and proof of delivery
+// summary; companies may require that before showing anything at all, in
+// which case unverified callers get gorm.ErrRecordNotFound. Events are only
+// listed when detailed is set.
+func (s *CargoService) GetPublicTracking(trackingNumber string, verification models.TrackingVerification, detailed bool) (*models.PublicTracking, error) {
+	tracking, err := s.GetCargoTracking(trackingNumber)
+	if err != nil {
+		return nil, err
+	}
+	cargo := &tracking.Cargo
+
+	verified := utils.VerifyTrackingToken(cargo.TrackingToken, verification.Token) ||
+		(cargo.DestinationPostcode != "" && verification.Postcode != "" &&
+			utils.NormalizePostcode(cargo.DestinationPostcode) == utils.NormalizePostcode(verification.Postcode))
+	if !verified {
+		format, err := s.trackingNumbers.GetFormat(cargo.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if format.RequireVerification {
+			return nil, gorm.ErrRecordNotFound
+		}
+	}
+
+	public := &models.PublicTracking{
+		TrackingNumber: cargo.TrackingNumber,
+		Carrier:        cargo.Company.Name,
+		Status:         cargo.Status,
+		Progress:       tracking.Progress,
+		EstimatedETA:   tracking.EstimatedETA,
+		LastUpdate:     tracking.LastUpdate,
+		Pieces:         len(cargo.Pieces),
+		Verified:       verified,
+	}
+
+	if detailed {
+		events, _ := s.cargoRepo.GetEventsByCargoID(cargo.ID)
+		for _, event := range events {
+			description, ok := publicEventDescriptions[event.EventType]
+			if !ok {
+				continue
+			}
+			publicEvent := models.PublicTrackingEvent{
+				EventType:   event.EventType,
+				Description: description,
+				Timestamp:   event.Timestamp,
+			}
+			if verified {
+				publicEvent.Location = event.Location
+			}
+			public.Events = append(public.Events, publicEvent)
+		}
+	}
+
+	if verified {
+		public.OriginAddress = cargo.OriginAddress
+		public.DestinationAddress = cargo.DestinationAddress
+		public.Journey = tracking.Journey
+		public.ProofOfDelivery = tracking.ProofOfDelivery
+		if tracking.TruckLocation != nil && cargo.Status == models.CargoStatusInTransit {
+			public.CurrentLocation = &models.PublicLocation{
+				Latitude:  tracking.TruckLocation.Latitude,
+				Longitude: tracking.TruckLocation.Longitude,
+				Timestamp: tracking.TruckLocation.Timestamp,
+			}
+		}
+	}
+
+	return public, nil
+}
+
+// publicEventDescriptions are the milestones shown on public tracking, with
+// the wording used there. Other events stay internal.
+var publicEventDescriptions = map[string]string{
+	"created":          "Shipment created",
+	"booked":           "Shipment booked",
+	"booking_approved": "Booking confirmed",
+	"booking_rejected": "Booking declined",
+	"assigned":         "Scheduled for pickup",
+	"unassigned":       "Pickup being rescheduled",
+	"pickup":           "Picked up",
+	"leg_departed":     "Departed",
+	"leg_arrived":      "Arrived at hub",
+	"arrival":          "Arrived at hub",
+	"handover":         "In transit",
+	"delayed":          "Delivery delayed",
+	"delivery":         "Delivered",
+	"cancelled":        "Shipment cancelled",
+}
+
 func (s *CargoService) calculateProgress(status models.CargoStatus) float64 {
 	switch status {
 	case models.CargoStatusPending:
@@ -432,6 +946,8 @@ func (s *CargoService) calculateProgress(status models.CargoStatus) float64 {
 		return 25.0
 	case models.CargoStatusInTransit:
 		return 75.0
+	case models.CargoStatusPartiallyDelivered:
+		return 90.0
 	case models.CargoStatusDelivered:
 		return 100.0
 	case models.CargoStatusCancelled:
@@ -508,4 +1024,99 @@ func (s *CargoService) calculateDistance(lat1, lon1, lat2, lon2 float64) float64
 
 	a := 0.5 - 0.5*((dLat*0.5)+(dLon*0.5))
 	return R * 2 * (1 - a)
-}
\ No newline at end of file
+}
+
+func newCargoFromRequest(companyID uint, trackingNumber string, req models.CreateCargoRequest) *models.Cargo {
+	cargo := &models.Cargo{
+		CompanyID:            companyID,
+		TrackingNumber:       trackingNumber,
+		Title:                req.Title,
+		Description:          req.Description,
+		Type:                 req.Type,
+		Priority:             req.Priority,
+		Status:               models.CargoStatusPending,
+		Weight:               req.Weight,
+		Volume:               req.Volume,
+		Value:                req.Value,
+		Currency:             req.Currency,
+		Price:                req.Price,
+		CustomerID:           req.CustomerID,
+		OriginAddressID:      req.OriginAddressID,
+		OriginAddress:        req.OriginAddress,
+		OriginLatitude:       req.OriginLatitude,
+		OriginLongitude:      req.OriginLongitude,
+		OriginContact:        req.OriginContact,
+		OriginPhone:          req.OriginPhone,
+		DestinationAddressID: req.DestinationAddressID,
+		DestinationAddress:   req.DestinationAddress,
+		DestinationPostcode:  req.DestinationPostcode,
+		DestinationLatitude:  req.DestinationLatitude,
+		DestinationLongitude: req.DestinationLongitude,
+		DestinationContact:   req.DestinationContact,
+		DestinationPhone:     req.DestinationPhone,
+		DestinationEmail:     req.DestinationEmail,
+		RecipientLocale:      req.RecipientLocale,
+		PickupTime:           req.PickupTime,
+		DeliveryTime:         req.DeliveryTime,
+		EstimatedDelivery:    req.EstimatedDelivery,
+		Instructions:         req.Instructions,
+		SpecialHandling:      req.SpecialHandling,
+		MinTemperature:       req.MinTemperature,
+		MaxTemperature:       req.MaxTemperature,
+		UNNumber:             normalizeUNNumber(req.UNNumber),
+		ProperShippingName:   req.ProperShippingName,
+		HazardClass:          req.HazardClass,
+		PackingGroup:         req.PackingGroup,
+		HazmatQuantity:       req.HazmatQuantity,
+		HazmatQuantityUnit:   req.HazmatQuantityUnit,
+		EmergencyPhone:       req.EmergencyPhone,
+	}
+
+	if cargo.Currency == "" {
+		cargo.Currency = "USD"
+	}
+	if cargo.Type == "" {
+		cargo.Type = models.CargoTypeGeneral
+	}
+	if cargo.Priority == "" {
+		cargo.Priority = models.CargoPriorityMedium
+	}
+	if cargo.HazmatQuantity > 0 && cargo.HazmatQuantityUnit == "" {
+		cargo.HazmatQuantityUnit = "kg"
+	}
+	cargo.ExcursionThresholdMinutes = models.DefaultExcursionThresholdMinutes
+	if req.ExcursionThresholdMinutes != nil {
+		cargo.ExcursionThresholdMinutes = *req.ExcursionThresholdMinutes
+	}
+
+	return cargo
+}
+
+// validateCargoTemperature requires perishable cargo to carry a temperature
+// range and any range given to be the right way round.
+func validateCargoTemperature(cargoType models.CargoType, min, max *float64) error {
+	if (min == nil) != (max == nil) {
+		return errors.New("min_temperature and max_temperature must be set together")
+	}
+	if min == nil {
+		if cargoType == models.CargoTypePerishable {
+			return errors.New("perishable cargo requires min_temperature and max_temperature")
+		}
+		return nil
+	}
+	if *min >= *max {
+		return errors.New("min_temperature must be below max_temperature")
+	}
+	return nil
+}
+
+func newCargoCreatedEvent(cargo *models.Cargo) models.CargoEvent {
+	return models.CargoEvent{
+		EventType:   "created",
+		Description: "Cargo created and ready for assignment",
+		Location:    cargo.OriginAddress,
+		Latitude:    cargo.OriginLatitude,
+		Longitude:   cargo.OriginLongitude,
+		Timestamp:   time.Now(),
+	}
+}
@@ -0,0 +1,485 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+type CustomerService struct {
+	customerRepo *repositories.CustomerRepository
+}
+
+func NewCustomerService(customerRepo *repositories.CustomerRepository) *CustomerService {
+	return &CustomerService{customerRepo: customerRepo}
+}
+
+func (s *CustomerService) CreateCustomer(companyID uint, req models.CreateCustomerRequest) (*models.Customer, error) {
+	if err := validateDeliveryWindow(req.DeliveryWindowStart, req.DeliveryWindowEnd); err != nil {
+		return nil, err
+	}
+
+	customer := &models.Customer{
+		CompanyID:           companyID,
+		Name:                req.Name,
+		Email:               req.Email,
+		Phone:               req.Phone,
+		BillingAddress:      req.BillingAddress,
+		Notes:               req.Notes,
+		IsActive:            true,
+		DeliveryWindowStart: req.DeliveryWindowStart,
+		DeliveryWindowEnd:   req.DeliveryWindowEnd,
+	}
+
+	primary := false
+	for _, c := range req.Contacts {
+		customer.Contacts = append(customer.Contacts, models.CustomerContact{
+			Name:      c.Name,
+			Role:      c.Role,
+			Email:     c.Email,
+			Phone:     c.Phone,
+			IsPrimary: c.IsPrimary && !primary,
+		})
+		primary = primary || c.IsPrimary
+	}
+
+	hasDefault := false
+	for _, a := range req.Addresses {
+		if err := validateDeliveryWindow(a.DeliveryWindowStart, a.DeliveryWindowEnd); err != nil {
+			return nil, err
+		}
+		customer.Addresses = append(customer.Addresses, models.CustomerAddress{
+			CompanyID:           companyID,
+			Label:               a.Label,
+			Address:             a.Address,
+			Latitude:            a.Latitude,
+			Longitude:           a.Longitude,
+			ContactName:         a.ContactName,
+			ContactPhone:        a.ContactPhone,
+			DeliveryWindowStart: a.DeliveryWindowStart,
+			DeliveryWindowEnd:   a.DeliveryWindowEnd,
+			Instructions:        a.Instructions,
+			IsDefault:           a.IsDefault && !hasDefault,
+		})
+		hasDefault = hasDefault || a.IsDefault
+	}
+	// The first address is the default unless another one was picked
+	if !hasDefault && len(customer.Addresses) > 0 {
+		customer.Addresses[0].IsDefault = true
+	}
+
+	err := s.customerRepo.Create(customer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.customerRepo.GetByID(customer.ID, companyID)
+}
+
+func (s *CustomerService) GetCustomers(companyID uint, filter models.CustomerFilter) ([]models.Customer, int64, error) {
+	return s.customerRepo.GetByCompanyID(companyID, filter)
+}
+
+func (s *CustomerService) GetCustomer(id uint, companyID uint) (*models.Customer, error) {
+	return s.customerRepo.GetByID(id, companyID)
+}
+
+func (s *CustomerService) UpdateCustomer(id uint, companyID uint, req models.UpdateCustomerRequest) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		customer.Name = req.Name
+	}
+	if req.Email != "" {
+		customer.Email = req.Email
+	}
+	if req.Phone != "" {
+		customer.Phone = req.Phone
+	}
+	if req.BillingAddress != "" {
+		customer.BillingAddress = req.BillingAddress
+	}
+	if req.Notes != nil {
+		customer.Notes = *req.Notes
+	}
+	if req.DeliveryWindowStart != nil {
+		customer.DeliveryWindowStart = *req.DeliveryWindowStart
+	}
+	if req.DeliveryWindowEnd != nil {
+		customer.DeliveryWindowEnd = *req.DeliveryWindowEnd
+	}
+	if req.IsActive != nil {
+		customer.IsActive = *req.IsActive
+	}
+	if err := validateDeliveryWindow(customer.DeliveryWindowStart, customer.DeliveryWindowEnd); err != nil {
+		return nil, err
+	}
+
+	err = s.customerRepo.Update(customer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.customerRepo.GetByID(id, companyID)
+}
+
+// DeleteCustomer removes a customer from the address book. Cargo, visits and
+// invoices keep the names and addresses copied from it.
+func (s *CustomerService) DeleteCustomer(id uint, companyID uint) error {
+	if _, err := s.customerRepo.GetByID(id, companyID); err != nil {
+		return err
+	}
+	return s.customerRepo.Delete(id, companyID)
+}
+
+func (s *CustomerService) AddContact(customerID uint, companyID uint, req models.CreateCustomerContactRequest) (*models.CustomerContact, error) {
+	if _, err := s.customerRepo.GetByID(customerID, companyID); err != nil {
+		return nil, err
+	}
+
+	contact := &models.CustomerContact{
+		CustomerID: customerID,
+		Name:       req.Name,
+		Role:       req.Role,
+		Email:      req.Email,
+		Phone:      req.Phone,
+		IsPrimary:  req.IsPrimary,
+	}
+
+	err := s.customerRepo.CreateContact(contact)
+	if err != nil {
+		return nil, err
+	}
+	if contact.IsPrimary {
+		if err := s.customerRepo.ClearPrimaryContact(customerID, contact.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return contact, nil
+}
+
+func (s *CustomerService) UpdateContact(id uint, customerID uint, companyID uint, req models.UpdateCustomerContactRequest) (*models.CustomerContact, error) {
+	if _, err := s.customerRepo.GetByID(customerID, companyID); err != nil {
+		return nil, err
+	}
+	contact, err := s.customerRepo.GetContact(id, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		contact.Name = req.Name
+	}
+	if req.Role != "" {
+		contact.Role = req.Role
+	}
+	if req.Email != "" {
+		contact.Email = req.Email
+	}
+	if req.Phone != "" {
+		contact.Phone = req.Phone
+	}
+	if req.IsPrimary != nil {
+		contact.IsPrimary = *req.IsPrimary
+	}
+
+	err = s.customerRepo.UpdateContact(contact)
+	if err != nil {
+		return nil, err
+	}
+	if contact.IsPrimary {
+		if err := s.customerRepo.ClearPrimaryContact(customerID, contact.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return contact, nil
+}
+
+func (s *CustomerService) DeleteContact(id uint, customerID uint, companyID uint) error {
+	if _, err := s.customerRepo.GetByID(customerID, companyID); err != nil {
+		return err
+	}
+	return s.customerRepo.DeleteContact(id, customerID)
+}
+
+func (s *CustomerService) AddAddress(customerID uint, companyID uint, req models.CreateCustomerAddressRequest) (*models.CustomerAddress, error) {
+	customer, err := s.customerRepo.GetByID(customerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDeliveryWindow(req.DeliveryWindowStart, req.DeliveryWindowEnd); err != nil {
+		return nil, err
+	}
+
+	address := &models.CustomerAddress{
+		CompanyID:           companyID,
+		CustomerID:          customerID,
+		Label:               req.Label,
+		Address:             req.Address,
+		Latitude:            req.Latitude,
+		Longitude:           req.Longitude,
+		ContactName:         req.ContactName,
+		ContactPhone:        req.ContactPhone,
+		DeliveryWindowStart: req.DeliveryWindowStart,
+		DeliveryWindowEnd:   req.DeliveryWindowEnd,
+		Instructions:        req.Instructions,
+		IsDefault:           req.IsDefault || len(customer.Addresses) == 0,
+	}
+
+	err = s.customerRepo.CreateAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if address.IsDefault {
+		if err := s.customerRepo.ClearDefaultAddress(customerID, address.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return address, nil
+}
+
+func (s *CustomerService) UpdateAddress(id uint, customerID uint, companyID uint, req models.UpdateCustomerAddressRequest) (*models.CustomerAddress, error) {
+	address, err := s.customerRepo.GetAddressByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if address.CustomerID != customerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if req.Label != "" {
+		address.Label = req.Label
+	}
+	if req.Address != "" {
+		address.Address = req.Address
+	}
+	if req.Latitude != nil {
+		address.Latitude = req.Latitude
+	}
+	if req.Longitude != nil {
+		address.Longitude = req.Longitude
+	}
+	if req.ContactName != "" {
+		address.ContactName = req.ContactName
+	}
+	if req.ContactPhone != "" {
+		address.ContactPhone = req.ContactPhone
+	}
+	if req.DeliveryWindowStart != nil {
+		address.DeliveryWindowStart = *req.DeliveryWindowStart
+	}
+	if req.DeliveryWindowEnd != nil {
+		address.DeliveryWindowEnd = *req.DeliveryWindowEnd
+	}
+	if req.Instructions != nil {
+		address.Instructions = *req.Instructions
+	}
+	if req.IsDefault != nil {
+		address.IsDefault = *req.IsDefault
+	}
+	if err := validateDeliveryWindow(address.DeliveryWindowStart, address.DeliveryWindowEnd); err != nil {
+		return nil, err
+	}
+
+	err = s.customerRepo.UpdateAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if address.IsDefault {
+		if err := s.customerRepo.ClearDefaultAddress(customerID, address.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return address, nil
+}
+
+func (s *CustomerService) DeleteAddress(id uint, customerID uint, companyID uint) error {
+	if _, err := s.customerRepo.GetByID(customerID, companyID); err != nil {
+		return err
+	}
+	return s.customerRepo.DeleteAddress(id, customerID)
+}
+
+// SearchAddresses backs address autocomplete when booking cargo, visits and
+// route stops.
+func (s *CustomerService) SearchAddresses(companyID uint, query models.AddressSearchQuery) ([]models.CustomerAddress, error) {
+	return s.customerRepo.SearchAddresses(companyID, query)
+}
+
+// fillCargo completes cargo booked against the address book. Saved pickup and
+// delivery addresses supply whatever location, coordinates, contact and
+// instructions the request left out, and the customer defaults to the one the
+// pickup address belongs to.
+func (s *CustomerService) fillCargo(companyID uint, cargo *models.Cargo) error {
+	if cargo.OriginAddressID != nil {
+		address, err := s.savedAddress(companyID, *cargo.OriginAddressID, "origin_address_id")
+		if err != nil {
+			return err
+		}
+		if cargo.CustomerID == nil {
+			cargo.CustomerID = &address.CustomerID
+		}
+		fillLocation(address, &cargo.OriginAddress, &cargo.OriginLatitude, &cargo.OriginLongitude)
+		fillContact(address, &cargo.OriginContact, &cargo.OriginPhone)
+	}
+	if cargo.DestinationAddressID != nil {
+		address, err := s.savedAddress(companyID, *cargo.DestinationAddressID, "destination_address_id")
+		if err != nil {
+			return err
+		}
+		fillLocation(address, &cargo.DestinationAddress, &cargo.DestinationLatitude, &cargo.DestinationLongitude)
+		fillContact(address, &cargo.DestinationContact, &cargo.DestinationPhone)
+		if cargo.Instructions == "" {
+			cargo.Instructions = address.Instructions
+		}
+	}
+	if cargo.CustomerID != nil {
+		if _, err := s.activeCustomer(companyID, *cargo.CustomerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillVisit links a visit to a customer and saved address and fills in the
+// customer name and location the request left out.
+func (s *CustomerService) fillVisit(companyID uint, visit *models.Visit) error {
+	customer, address, err := s.lookup(companyID, visit.CustomerID, visit.CustomerAddressID)
+	if err != nil {
+		return err
+	}
+	if customer != nil {
+		visit.CustomerID = &customer.ID
+		if visit.CustomerName == "" {
+			visit.CustomerName = customer.Name
+		}
+	}
+	if address != nil {
+		fillLocation(address, &visit.Address, &visit.Latitude, &visit.Longitude)
+	}
+	return nil
+}
+
+// fillRouteStop links a route stop to a customer and saved address and copies
+// the address's contact, instructions and delivery window onto the stop.
+func (s *CustomerService) fillRouteStop(companyID uint, stop *models.RouteStop) error {
+	customer, address, err := s.lookup(companyID, stop.CustomerID, stop.CustomerAddressID)
+	if err != nil {
+		return err
+	}
+	if customer != nil {
+		stop.CustomerID = &customer.ID
+	}
+	if address != nil {
+		fillLocation(address, &stop.Address, &stop.Latitude, &stop.Longitude)
+		fillContact(address, &stop.ContactName, &stop.ContactPhone)
+		if stop.Instructions == "" {
+			stop.Instructions = address.Instructions
+		}
+		stop.DeliveryWindow = address.DeliveryWindow(customer)
+	} else if customer != nil && customer.DeliveryWindowStart != "" {
+		stop.DeliveryWindow = customer.DeliveryWindowStart + "-" + customer.DeliveryWindowEnd
+	}
+	return nil
+}
+
+// lookup loads the customer and saved address a visit or stop refers to and
+// checks that they belong together. The customer defaults to the address's.
+func (s *CustomerService) lookup(companyID uint, customerID *uint, addressID *uint) (*models.Customer, *models.CustomerAddress, error) {
+	var address *models.CustomerAddress
+	if addressID != nil {
+		var err error
+		address, err = s.savedAddress(companyID, *addressID, "customer_address_id")
+		if err != nil {
+			return nil, nil, err
+		}
+		if customerID != nil && *customerID != address.CustomerID {
+			return nil, nil, errors.New("the address does not belong to the customer")
+		}
+		customerID = &address.CustomerID
+	}
+	if customerID == nil {
+		return nil, nil, nil
+	}
+
+	customer, err := s.activeCustomer(companyID, *customerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return customer, address, nil
+}
+
+func (s *CustomerService) activeCustomer(companyID uint, id uint) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByID(id, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !customer.IsActive {
+		return nil, errors.New("customer " + customer.Name + " is inactive")
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) savedAddress(companyID uint, id uint, field string) (*models.CustomerAddress, error) {
+	address, err := s.customerRepo.GetAddressByID(id, companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New(field + " refers to an unknown address")
+	}
+	return address, err
+}
+
+func fillLocation(address *models.CustomerAddress, location *string, lat, lng **float64) {
+	if *location == "" {
+		*location = address.Address
+	}
+	if *lat == nil && *lng == nil {
+		*lat, *lng = address.Latitude, address.Longitude
+	}
+}
+
+func fillContact(address *models.CustomerAddress, name, phone *string) {
+	if *name == "" {
+		*name = address.ContactName
+		if *name == "" && address.Customer != nil {
+			*name = address.Customer.Name
+		}
+	}
+	if *phone == "" {
+		*phone = address.ContactPhone
+		if *phone == "" && address.Customer != nil {
+			*phone = address.Customer.Phone
+		}
+	}
+}
+
+// validateDeliveryWindow accepts no window or an HH:MM start before its end.
+func validateDeliveryWindow(start, end string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+	from, err := time.Parse("15:04", start)
+	if err != nil {
+		return errors.New("delivery_window_start must be a time such as 08:00")
+	}
+	to, err := time.Parse("15:04", end)
+	if err != nil {
+		return errors.New("delivery_window_end must be a time such as 17:00")
+	}
+	if !from.Before(to) {
+		return errors.New("delivery_window_start must be before delivery_window_end")
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/spreadsheet"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type exportColumn struct {
+	name  string
+	index []int
+}
+
+type ExportService struct {
+	cargoRepo   *repositories.CargoRepository
+	truckRepo   *repositories.TruckRepository
+	routeRepo   *repositories.RouteRepository
+	visitRepo   *repositories.VisitRepository
+	invoiceRepo *repositories.InvoiceRepository
+}
+
+func NewExportService(cargoRepo *repositories.CargoRepository, truckRepo *repositories.TruckRepository, routeRepo *repositories.RouteRepository, visitRepo *repositories.VisitRepository, invoiceRepo *repositories.InvoiceRepository) *ExportService {
+	return &ExportService{
+		cargoRepo:   cargoRepo,
+		truckRepo:   truckRepo,
+		routeRepo:   routeRepo,
+		visitRepo:   visitRepo,
+		invoiceRepo: invoiceRepo,
+	}
+}
+
+// ValidateColumns checks a comma separated column list against the exportable
+// fields of model. Callers check it before streaming so a typo can still be
+// reported as a 400.
+func (s *ExportService) ValidateColumns(model interface{}, requested string) error {
+	_, err := resolveExportColumns(reflect.TypeOf(model), requested)
+	return err
+}
+
+func (s *ExportService) ExportCargos(w io.Writer, companyID uint, filter models.CargoFilter, req models.ExportRequest) error {
+	return writeExport(w, "Cargo", reflect.TypeOf(models.Cargo{}), req, func(emit func(interface{}) error) error {
+		return s.cargoRepo.StreamByCompanyID(companyID, filter, func(cargo *models.Cargo) error {
+			return emit(cargo)
+		})
+	})
+}
+
+func (s *ExportService) ExportTrucks(w io.Writer, companyID uint, filter models.TruckFilter, req models.ExportRequest) error {
+	return writeExport(w, "Trucks", reflect.TypeOf(models.Truck{}), req, func(emit func(interface{}) error) error {
+		return s.truckRepo.StreamByCompanyID(companyID, filter, func(truck *models.Truck) error {
+			return emit(truck)
+		})
+	})
+}
+
+func (s *ExportService) ExportRoutes(w io.Writer, companyID uint, filter models.RouteFilter, req models.ExportRequest) error {
+	return writeExport(w, "Routes", reflect.TypeOf(models.Route{}), req, func(emit func(interface{}) error) error {
+		return s.routeRepo.StreamByCompanyID(companyID, filter, func(route *models.Route) error {
+			return emit(route)
+		})
+	})
+}
+
+func (s *ExportService) ExportVisits(w io.Writer, companyID uint, filter models.VisitFilter, req models.ExportRequest) error {
+	return writeExport(w, "Visits", reflect.TypeOf(models.Visit{}), req, func(emit func(interface{}) error) error {
+		return s.visitRepo.StreamByCompanyID(companyID, filter, func(visit *models.Visit) error {
+			return emit(visit)
+		})
+	})
+}
+
+// ExportInvoices streams one row per invoice line for the accounting system.
+// Drafts are left out unless asked for by status; credit note amounts are
+// negative.
+func (s *ExportService) ExportInvoices(w io.Writer, companyID uint, filter models.InvoiceFilter, req models.ExportRequest) error {
+	return writeExport(w, "Invoices", reflect.TypeOf(models.InvoiceExportRow{}), req, func(emit func(interface{}) error) error {
+		return s.invoiceRepo.StreamByCompanyID(companyID, filter, func(invoice *models.Invoice) error {
+			if invoice.Status == models.InvoiceStatusDraft && filter.Status == "" {
+				return nil
+			}
+
+			sign := 1.0
+			if invoice.Type == models.InvoiceTypeCreditNote {
+				sign = -1
+			}
+			for _, line := range invoice.Lines {
+				tax := roundMoney(line.Amount * invoice.TaxRatePercent / 100)
+				row := models.InvoiceExportRow{
+					Type:           string(invoice.Type),
+					Status:         string(invoice.Status),
+					CustomerName:   invoice.CustomerName,
+					IssuedAt:       invoice.IssuedAt,
+					DueDate:        invoice.DueDate,
+					Description:    line.Description,
+					Currency:       invoice.Currency,
+					NetAmount:      sign * line.Amount,
+					TaxRatePercent: invoice.TaxRatePercent,
+					TaxAmount:      sign * tax,
+					GrossAmount:    sign * roundMoney(line.Amount+tax),
+				}
+				if invoice.Number != nil {
+					row.Number = *invoice.Number
+				}
+				if invoice.CreditedInvoice != nil && invoice.CreditedInvoice.Number != nil {
+					row.CreditedNumber = *invoice.CreditedInvoice.Number
+				}
+				if line.Cargo != nil {
+					row.TrackingNumber = line.Cargo.TrackingNumber
+				}
+				if err := emit(&row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func writeExport(w io.Writer, sheetName string, t reflect.Type, req models.ExportRequest, stream func(emit func(interface{}) error) error) error {
+	columns, err := resolveExportColumns(t, req.Columns)
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriterSize(w, 32*1024)
+	var rows spreadsheet.RowWriter
+	switch req.Format {
+	case models.ExportFormatXLSX:
+		rows, err = spreadsheet.NewXLSXWriter(buffered, sheetName)
+	case models.ExportFormatNDJSON:
+		rows = newNDJSONWriter(buffered, columns)
+	default:
+		rows = spreadsheet.NewCSVWriter(buffered)
+	}
+	if err != nil {
+		return err
+	}
+
+	if req.Format != models.ExportFormatNDJSON {
+		header := make([]interface{}, len(columns))
+		for i, col := range columns {
+			header[i] = col.name
+		}
+		if err := rows.Write(header); err != nil {
+			return err
+		}
+	}
+
+	err = stream(func(record interface{}) error {
+		v := reflect.ValueOf(record).Elem()
+		row := make([]interface{}, len(columns))
+		for i, col := range columns {
+			row[i] = exportValue(v.FieldByIndex(col.index))
+		}
+		return rows.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// resolveExportColumns lists the scalar fields of t by json name: strings,
+// numbers, booleans and times, but no associations.
+func resolveExportColumns(t reflect.Type, requested string) ([]exportColumn, error) {
+	var all []exportColumn
+	byName := map[string]exportColumn{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || !isExportable(f.Type) {
+			continue
+		}
+		col := exportColumn{name: name, index: f.Index}
+		all = append(all, col)
+		byName[name] = col
+	}
+
+	if strings.TrimSpace(requested) == "" {
+		return all, nil
+	}
+
+	var columns []exportColumn
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		col, ok := byName[name]
+		if !ok {
+			return nil, errors.New("unknown column " + name)
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no columns selected")
+	}
+	return columns, nil
+}
+
+func isExportable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// exportValue unwraps pointers and named types so writers only see plain
+// strings, numbers, booleans and times.
+func exportValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return nil
+}
+
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	columns []exportColumn
+}
+
+func newNDJSONWriter(w io.Writer, columns []exportColumn) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w), columns: columns}
+}
+
+func (n *ndjsonWriter) Write(row []interface{}) error {
+	object := make(map[string]interface{}, len(row))
+	for i, v := range row {
+		object[n.columns[i].name] = v
+	}
+	return n.enc.Encode(object)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
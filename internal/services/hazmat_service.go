@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/reports"
+	"truck-management/internal/repositories"
+)
+
+var unNumberPattern = regexp.MustCompile(`^UN\d{4}$`)
+
+// HazmatService produces the dangerous goods paperwork of routes.
+type HazmatService struct {
+	cargoRepo *repositories.CargoRepository
+	routeRepo *repositories.RouteRepository
+}
+
+func NewHazmatService(cargoRepo *repositories.CargoRepository, routeRepo *repositories.RouteRepository) *HazmatService {
+	return &HazmatService{
+		cargoRepo: cargoRepo,
+		routeRepo: routeRepo,
+	}
+}
+
+// RouteManifest renders the dangerous goods manifest of a route as PDF: every
+// dangerous goods cargo carried on the route with its UN number, class,
+// packing group and quantity. Drivers only see their own routes.
+func (s *HazmatService) RouteManifest(w io.Writer, routeID uint, companyID uint, userRole models.UserRole, userID uint) error {
+	var driverID *uint
+	if userRole == models.RoleDriver {
+		driverID = &userID
+	}
+	route, err := s.routeRepo.GetByID(routeID, companyID, driverID)
+	if err != nil {
+		return err
+	}
+
+	cargos, err := s.cargoRepo.GetByRouteID(route.ID, route.TruckID, companyID)
+	if err != nil {
+		return err
+	}
+
+	manifest := reports.DangerousGoodsManifest{
+		Route:       route.Name,
+		Date:        route.StartTime,
+		GeneratedAt: time.Now(),
+	}
+	if route.Truck != nil {
+		manifest.Truck = route.Truck.LicensePlate
+		manifest.Placarded = route.Truck.HazmatPlacarded
+	}
+	if route.Driver != nil {
+		manifest.Driver = route.Driver.FirstName + " " + route.Driver.LastName
+		manifest.DriverCertification = route.Driver.HazmatCertificationNumber
+		manifest.DriverCertifiedUntil = route.Driver.HazmatCertifiedUntil
+	}
+
+	for _, cargo := range cargos {
+		if !cargo.IsDangerousGoods() {
+			continue
+		}
+		manifest.Company = cargo.Company.Name
+		manifest.Items = append(manifest.Items, reports.DangerousGoodsItem{
+			TrackingNumber:     cargo.TrackingNumber,
+			UNNumber:           cargo.UNNumber,
+			ProperShippingName: cargo.ProperShippingName,
+			HazardClass:        string(cargo.HazardClass),
+			PackingGroup:       string(cargo.PackingGroup),
+			Quantity:           cargo.HazmatQuantity,
+			Unit:               cargo.HazmatQuantityUnit,
+			Pieces:             len(cargo.Pieces),
+			Consignee:          cargo.DestinationContact,
+			Destination:        cargo.DestinationAddress,
+			EmergencyPhone:     cargo.EmergencyPhone,
+		})
+	}
+	if len(manifest.Items) == 0 {
+		return errors.New("route carries no dangerous goods")
+	}
+	manifest.Warnings = segregationConflicts(dangerousGoods(cargos))
+
+	return reports.RenderDangerousGoodsManifest(w, manifest)
+}
+
+// validateCargoHazmat requires hazardous cargo to carry its UN number, hazard
+// class, packing group where the class uses one, and quantity.
+func validateCargoHazmat(cargoType models.CargoType, unNumber string, class models.HazardClass, group models.PackingGroup, quantity float64) error {
+	if cargoType != models.CargoTypeHazardous && unNumber == "" && class == "" {
+		return nil
+	}
+
+	if !unNumberPattern.MatchString(unNumber) {
+		return errors.New("un_number must be UN followed by four digits, e.g. UN1203")
+	}
+	if !class.Valid() {
+		return errors.New("hazard_class must be a UN class or division such as 3 or 2.1")
+	}
+	if class.UsesPackingGroup() && group == "" {
+		return fmt.Errorf("packing_group is required for class %s", class)
+	}
+	if !class.UsesPackingGroup() && group != "" {
+		return fmt.Errorf("class %s does not use packing groups", class)
+	}
+	if quantity <= 0 {
+		return errors.New("hazmat_quantity is required for dangerous goods")
+	}
+	return nil
+}
+
+func normalizeUNNumber(unNumber string) string {
+	unNumber = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(unNumber), " ", ""))
+	if unNumber != "" && !strings.HasPrefix(unNumber, "UN") {
+		unNumber = "UN" + unNumber
+	}
+	return unNumber
+}
+
+// checkHazmatLoad checks that dangerous goods cargo may be assigned to a truck:
+// the truck must be placarded, its driver (if any yet) certified, and the
+// cargo must not be segregated from dangerous goods already on the truck.
+func checkHazmatLoad(cargo *models.Cargo, truck *models.Truck, onboard []models.Cargo) error {
+	if !cargo.IsDangerousGoods() {
+		return nil
+	}
+
+	if !truck.HazmatPlacarded {
+		return fmt.Errorf("truck %s is not placarded for dangerous goods", truck.LicensePlate)
+	}
+	if truck.Driver != nil && !truck.Driver.HazmatCertifiedAt(time.Now()) {
+		return fmt.Errorf("driver %s %s of truck %s has no valid hazmat certification", truck.Driver.FirstName, truck.Driver.LastName, truck.LicensePlate)
+	}
+
+	for _, other := range onboard {
+		if other.ID == cargo.ID || !other.IsDangerousGoods() {
+			continue
+		}
+		if cargo.HazardClass.SegregatedFrom(other.HazardClass) {
+			return fmt.Errorf("cargo %s (class %s) must not be loaded with cargo %s (class %s) on truck %s",
+				cargo.TrackingNumber, cargo.HazardClass, other.TrackingNumber, other.HazardClass, truck.LicensePlate)
+		}
+	}
+	return nil
+}
+
+// checkHazmatDispatch checks a truck about to leave with the given cargo: if
+// any of it is dangerous goods the truck must be placarded and have a
+// certified driver, and no two classes on board may be segregated.
+func checkHazmatDispatch(truck *models.Truck, cargos []models.Cargo) error {
+	dangerous := dangerousGoods(cargos)
+	if len(dangerous) == 0 {
+		return nil
+	}
+
+	if !truck.HazmatPlacarded {
+		return fmt.Errorf("truck %s is not placarded for dangerous goods", truck.LicensePlate)
+	}
+	if truck.Driver == nil {
+		return fmt.Errorf("truck %s has no driver to carry dangerous goods", truck.LicensePlate)
+	}
+	if !truck.Driver.HazmatCertifiedAt(time.Now()) {
+		return fmt.Errorf("driver %s %s of truck %s has no valid hazmat certification", truck.Driver.FirstName, truck.Driver.LastName, truck.LicensePlate)
+	}
+	if conflicts := segregationConflicts(dangerous); len(conflicts) > 0 {
+		return errors.New(conflicts[0])
+	}
+	return nil
+}
+
+func dangerousGoods(cargos []models.Cargo) []models.Cargo {
+	var dangerous []models.Cargo
+	for _, cargo := range cargos {
+		if cargo.IsDangerousGoods() {
+			dangerous = append(dangerous, cargo)
+		}
+	}
+	return dangerous
+}
+
+// segregationConflicts describes every pair of cargo whose classes must not
+// share a vehicle.
+func segregationConflicts(cargos []models.Cargo) []string {
+	var conflicts []string
+	for i := range cargos {
+		for j := i + 1; j < len(cargos); j++ {
+			if cargos[i].HazardClass.SegregatedFrom(cargos[j].HazardClass) {
+				conflicts = append(conflicts, fmt.Sprintf("cargo %s (class %s) must not be loaded with cargo %s (class %s)",
+					cargos[i].TrackingNumber, cargos[i].HazardClass, cargos[j].TrackingNumber, cargos[j].HazardClass))
+			}
+		}
+	}
+	return conflicts
+}
+
+// withCargo returns cargos with cargo added, or replacing its stale copy.
+func withCargo(cargos []models.Cargo, cargo *models.Cargo) []models.Cargo {
+	result := []models.Cargo{*cargo}
+	for _, other := range cargos {
+		if other.ID != cargo.ID {
+			result = append(result, other)
+		}
+	}
+	return result
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/reports"
+	"truck-management/internal/repositories"
+)
+
+var invoicePrefixPattern = regexp.MustCompile(`^[A-Z]{1,8}$`)
+
+type InvoiceService struct {
+	invoiceRepo *repositories.InvoiceRepository
+}
+
+func NewInvoiceService(invoiceRepo *repositories.InvoiceRepository) *InvoiceService {
+	return &InvoiceService{invoiceRepo: invoiceRepo}
+}
+
+func (s *InvoiceService) GetSettings(companyID uint) (*models.InvoiceSettings, error) {
+	return s.invoiceRepo.GetSettings(companyID)
+}
+
+func (s *InvoiceService) UpdateSettings(companyID uint, req models.UpdateInvoiceSettingsRequest) (*models.InvoiceSettings, error) {
+	settings, err := s.invoiceRepo.GetSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TaxName != "" {
+		settings.TaxName = req.TaxName
+	}
+	if req.TaxRatePercent != nil {
+		settings.TaxRatePercent = *req.TaxRatePercent
+	}
+	if req.PaymentTermsDays != nil {
+		settings.PaymentTermsDays = *req.PaymentTermsDays
+	}
+	if req.InvoicePrefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*req.InvoicePrefix))
+		if !invoicePrefixPattern.MatchString(prefix) {
+			return nil, errors.New("invoice_prefix must be 1 to 8 letters")
+		}
+		settings.InvoicePrefix = prefix
+	}
+	if req.CreditNotePrefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*req.CreditNotePrefix))
+		if !invoicePrefixPattern.MatchString(prefix) {
+			return nil, errors.New("credit_note_prefix must be 1 to 8 letters")
+		}
+		settings.CreditNotePrefix = prefix
+	}
+	if settings.InvoicePrefix == settings.CreditNotePrefix {
+		return nil, errors.New("invoices and credit notes need different prefixes")
+	}
+
+	err = s.invoiceRepo.UpdateSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoiceRepo.GetSettings(companyID)
+}
+
+// GenerateInvoices creates one draft invoice per customer and currency for the
+// delivered cargo of the period that has a price and is not billed yet. Cargo
+// booked without a customer account is billed to its shipper (origin contact).
+func (s *InvoiceService) GenerateInvoices(companyID uint, userID uint, req models.GenerateInvoicesRequest) (*models.GenerateInvoicesResponse, error) {
+	start := dateOnly(req.PeriodStart)
+	end := dateOnly(req.PeriodEnd)
+	if end.Before(start) {
+		return nil, errors.New("period_end must not be before period_start")
+	}
+
+	settings, err := s.invoiceRepo.GetSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+	cargos, err := s.invoiceRepo.GetBillableCargo(companyID, start, end.AddDate(0, 0, 1), req.CustomerID, req.CustomerName)
+	if err != nil {
+		return nil, err
+	}
+
+	type billingKey struct {
+		customerID uint
+		customer   string
+		currency   string
+	}
+	groups := map[billingKey]*models.Invoice{}
+	var keys []billingKey
+	result := &models.GenerateInvoicesResponse{Invoices: []models.Invoice{}, Unpriced: []string{}}
+
+	for i := range cargos {
+		cargo := &cargos[i]
+		if cargo.Price == nil {
+			result.Unpriced = append(result.Unpriced, cargo.TrackingNumber)
+			continue
+		}
+
+		key := billingKey{customer: cargo.OriginContact, currency: cargo.PriceCurrency}
+		address := ""
+		if cargo.Customer != nil {
+			key.customerID, key.customer = cargo.Customer.ID, cargo.Customer.Name
+			address = cargo.Customer.BillingAddress
+		}
+		invoice, ok := groups[key]
+		if !ok {
+			invoice = &models.Invoice{
+				CompanyID:       companyID,
+				Type:            models.InvoiceTypeInvoice,
+				Status:          models.InvoiceStatusDraft,
+				CustomerID:      cargo.CustomerID,
+				CustomerName:    key.customer,
+				CustomerAddress: address,
+				PeriodStart:     start,
+				PeriodEnd:       end,
+				Currency:        key.currency,
+				TaxName:         settings.TaxName,
+				TaxRatePercent:  settings.TaxRatePercent,
+				CreatedBy:       userID,
+			}
+			groups[key] = invoice
+			keys = append(keys, key)
+		}
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			CargoID:     &cargo.ID,
+			Description: cargoLineDescription(cargo),
+			DeliveredAt: cargo.ActualDelivery,
+			Amount:      *cargo.Price,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].customer != keys[j].customer {
+			return keys[i].customer < keys[j].customer
+		}
+		if keys[i].customerID != keys[j].customerID {
+			return keys[i].customerID < keys[j].customerID
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	for _, key := range keys {
+		invoice := groups[key]
+		totalInvoice(invoice)
+		result.Invoices = append(result.Invoices, *invoice)
+	}
+	if len(result.Invoices) == 0 {
+		return result, nil
+	}
+
+	err = s.invoiceRepo.CreateInvoices(result.Invoices)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *InvoiceService) GetInvoices(companyID uint, filter models.InvoiceFilter) ([]models.Invoice, int64, error) {
+	return s.invoiceRepo.GetByCompanyID(companyID, filter)
+}
+
+func (s *InvoiceService) GetInvoice(id uint, companyID uint) (*models.Invoice, error) {
+	return s.invoiceRepo.GetByID(id, companyID)
+}
+
+// IssueInvoice numbers a draft and starts its payment terms.
+func (s *InvoiceService) IssueInvoice(id uint, companyID uint) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceStatusDraft {
+		return nil, errors.New("only draft invoices can be issued")
+	}
+
+	settings, sequence, err := s.invoiceRepo.NextNumber(companyID, invoice.Type)
+	if err != nil {
+		return nil, err
+	}
+	prefix := settings.InvoicePrefix
+	if invoice.Type == models.InvoiceTypeCreditNote {
+		prefix = settings.CreditNotePrefix
+	}
+	number := fmt.Sprintf("%s-%06d", prefix, sequence)
+
+	now := time.Now()
+	invoice.Number = &number
+	invoice.Status = models.InvoiceStatusIssued
+	invoice.IssuedAt = &now
+	if invoice.Type == models.InvoiceTypeInvoice {
+		due := dateOnly(now).AddDate(0, 0, settings.PaymentTermsDays)
+		invoice.DueDate = &due
+	}
+
+	err = s.invoiceRepo.Update(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoiceRepo.GetByID(id, companyID)
+}
+
+// MarkInvoicePaid records payment of an invoice, or the refund of a credit
+// note.
+func (s *InvoiceService) MarkInvoicePaid(id uint, companyID uint) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceStatusIssued {
+		return nil, errors.New("only issued invoices can be marked paid")
+	}
+
+	now := time.Now()
+	invoice.Status = models.InvoiceStatusPaid
+	invoice.PaidAt = &now
+
+	err = s.invoiceRepo.Update(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoiceRepo.GetByID(id, companyID)
+}
+
+// VoidInvoice cancels a draft or issued invoice. The cargo of a voided invoice
+// becomes billable again; issued numbers are kept so the sequence has no gaps.
+func (s *InvoiceService) VoidInvoice(id uint, companyID uint) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	switch invoice.Status {
+	case models.InvoiceStatusPaid:
+		return nil, errors.New("paid invoices cannot be voided; raise a credit note instead")
+	case models.InvoiceStatusVoid:
+		return invoice, nil
+	}
+
+	now := time.Now()
+	invoice.Status = models.InvoiceStatusVoid
+	invoice.VoidedAt = &now
+
+	err = s.invoiceRepo.Void(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoiceRepo.GetByID(id, companyID)
+}
+
+// CreateCreditNote drafts a credit note against an issued invoice for
+// cancelled or damaged shipments. Each line credits one cargo of the invoice,
+// by default whatever of its amount has not been credited yet.
+func (s *InvoiceService) CreateCreditNote(invoiceID uint, companyID uint, userID uint, req models.CreateCreditNoteRequest) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(invoiceID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Type != models.InvoiceTypeInvoice {
+		return nil, errors.New("credit notes can only be raised against invoices")
+	}
+	if invoice.Status != models.InvoiceStatusIssued && invoice.Status != models.InvoiceStatusPaid {
+		return nil, errors.New("credit notes can only be raised against issued or paid invoices")
+	}
+
+	credited, err := s.invoiceRepo.GetCreditedAmounts(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &models.Invoice{
+		CompanyID:         companyID,
+		Type:              models.InvoiceTypeCreditNote,
+		Status:            models.InvoiceStatusDraft,
+		CustomerName:      invoice.CustomerName,
+		PeriodStart:       invoice.PeriodStart,
+		PeriodEnd:         invoice.PeriodEnd,
+		Currency:          invoice.Currency,
+		TaxName:           invoice.TaxName,
+		TaxRatePercent:    invoice.TaxRatePercent,
+		CustomerID:        invoice.CustomerID,
+		CustomerAddress:   invoice.CustomerAddress,
+		CreditedInvoiceID: &invoice.ID,
+		Reason:            req.Reason,
+		CreatedBy:         userID,
+	}
+
+	seen := map[uint]bool{}
+	for _, lineReq := range req.Lines {
+		if seen[lineReq.CargoID] {
+			return nil, fmt.Errorf("cargo %d is listed more than once", lineReq.CargoID)
+		}
+		seen[lineReq.CargoID] = true
+
+		line := findInvoiceLine(invoice, lineReq.CargoID)
+		if line == nil {
+			return nil, fmt.Errorf("cargo %d is not on invoice", lineReq.CargoID)
+		}
+		remaining := roundMoney(line.Amount - credited[lineReq.CargoID])
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%s has already been credited in full", line.Description)
+		}
+		amount := remaining
+		if lineReq.Amount != nil {
+			amount = roundMoney(*lineReq.Amount)
+		}
+		if amount > remaining {
+			return nil, fmt.Errorf("at most %.2f %s of %s can still be credited", remaining, invoice.Currency, line.Description)
+		}
+
+		note.Lines = append(note.Lines, models.InvoiceLine{
+			CargoID:     line.CargoID,
+			Description: line.Description,
+			DeliveredAt: line.DeliveredAt,
+			Amount:      amount,
+		})
+	}
+	totalInvoice(note)
+
+	err = s.invoiceRepo.CreateCreditNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invoiceRepo.GetByID(note.ID, companyID)
+}
+
+// RenderInvoice writes an invoice or credit note as PDF.
+func (s *InvoiceService) RenderInvoice(w io.Writer, id uint, companyID uint) error {
+	invoice, err := s.invoiceRepo.GetByID(id, companyID)
+	if err != nil {
+		return err
+	}
+
+	doc := reports.InvoiceDocument{
+		CreditNote:      invoice.Type == models.InvoiceTypeCreditNote,
+		Draft:           invoice.Status == models.InvoiceStatusDraft,
+		Void:            invoice.Status == models.InvoiceStatusVoid,
+		CompanyName:     invoice.Company.Name,
+		CompanyAddress:  invoice.Company.Address,
+		CompanyContact:  strings.TrimSpace(invoice.Company.Phone + "  " + invoice.Company.Email),
+		CustomerName:    invoice.CustomerName,
+		CustomerAddress: invoice.CustomerAddress,
+		PeriodStart:     invoice.PeriodStart,
+		PeriodEnd:       invoice.PeriodEnd,
+		IssuedAt:        invoice.IssuedAt,
+		DueDate:         invoice.DueDate,
+		Currency:        invoice.Currency,
+		Subtotal:        invoice.Subtotal,
+		TaxName:         invoice.TaxName,
+		TaxRatePercent:  invoice.TaxRatePercent,
+		TaxAmount:       invoice.TaxAmount,
+		Total:           invoice.Total,
+		Reason:          invoice.Reason,
+		Notes:           invoice.Notes,
+	}
+	if invoice.Number != nil {
+		doc.Number = *invoice.Number
+	}
+	if invoice.CreditedInvoice != nil && invoice.CreditedInvoice.Number != nil {
+		doc.CreditedNumber = *invoice.CreditedInvoice.Number
+	}
+	for _, line := range invoice.Lines {
+		doc.Lines = append(doc.Lines, reports.InvoiceDocumentLine{
+			Description: line.Description,
+			Date:        line.DeliveredAt,
+			Amount:      line.Amount,
+		})
+	}
+
+	return reports.RenderInvoice(w, doc)
+}
+
+// totalInvoice sums the lines and applies the tax rate.
+func totalInvoice(invoice *models.Invoice) {
+	subtotal := 0.0
+	for _, line := range invoice.Lines {
+		subtotal += line.Amount
+	}
+	invoice.Subtotal = roundMoney(subtotal)
+	invoice.TaxAmount = roundMoney(invoice.Subtotal * invoice.TaxRatePercent / 100)
+	invoice.Total = roundMoney(invoice.Subtotal + invoice.TaxAmount)
+}
+
+func findInvoiceLine(invoice *models.Invoice, cargoID uint) *models.InvoiceLine {
+	for i := range invoice.Lines {
+		if invoice.Lines[i].CargoID != nil && *invoice.Lines[i].CargoID == cargoID {
+			return &invoice.Lines[i]
+		}
+	}
+	return nil
+}
+
+func cargoLineDescription(cargo *models.Cargo) string {
+	return fmt.Sprintf("%s %s: %s to %s", cargo.TrackingNumber, cargo.Title, cargo.OriginAddress, cargo.DestinationAddress)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
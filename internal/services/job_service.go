@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+// JobService exposes a company's background jobs, including the dead-letter
+// view of jobs that ran out of attempts.
+type JobService struct {
+	jobRepo *repositories.JobRepository
+}
+
+func NewJobService(jobRepo *repositories.JobRepository) *JobService {
+	return &JobService{jobRepo: jobRepo}
+}
+
+func (s *JobService) GetJobs(companyID uint, filter models.JobFilter) ([]models.Job, int64, error) {
+	return s.jobRepo.GetByCompanyID(companyID, filter)
+}
+
+func (s *JobService) GetJob(id uint, companyID uint) (*models.Job, error) {
+	return s.jobRepo.GetByID(id, companyID)
+}
+
+// RetryJob queues a dead job again with a fresh set of attempts.
+func (s *JobService) RetryJob(id uint, companyID uint) (*models.Job, error) {
+	job, err := s.jobRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusDead {
+		return nil, fmt.Errorf("job is %s; only dead jobs can be retried", job.Status)
+	}
+
+	if err := s.jobRepo.Revive(id, companyID); err != nil {
+		return nil, err
+	}
+	return s.jobRepo.GetByID(id, companyID)
+}
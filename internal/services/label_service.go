@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"truck-management/internal/labels"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+type LabelService struct {
+	cargoRepo *repositories.CargoRepository
+	routeRepo *repositories.RouteRepository
+}
+
+func NewLabelService(cargoRepo *repositories.CargoRepository, routeRepo *repositories.RouteRepository) *LabelService {
+	return &LabelService{
+		cargoRepo: cargoRepo,
+		routeRepo: routeRepo,
+	}
+}
+
+// CargoLabel renders the shipment label of a cargo.
+func (s *LabelService) CargoLabel(w io.Writer, cargoID uint, companyID uint, req models.LabelRequest) error {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return err
+	}
+
+	return renderLabels(w, req, []labels.Label{cargoLabel(cargo)})
+}
+
+// PieceLabels renders one label per piece of a cargo.
+func (s *LabelService) PieceLabels(w io.Writer, cargoID uint, companyID uint, req models.LabelRequest) error {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return err
+	}
+	if len(cargo.Pieces) == 0 {
+		return errors.New("cargo has no pieces")
+	}
+
+	return renderLabels(w, req, pieceLabels(cargo))
+}
+
+// RouteLabels renders the labels of all cargo on a route in one batch: one
+// per piece for cargo split into pieces, otherwise one per cargo. Drivers only
+// see their own routes.
+func (s *LabelService) RouteLabels(w io.Writer, routeID uint, companyID uint, userRole models.UserRole, userID uint, req models.LabelRequest) error {
+	var driverID *uint
+	if userRole == models.RoleDriver {
+		driverID = &userID
+	}
+	route, err := s.routeRepo.GetByID(routeID, companyID, driverID)
+	if err != nil {
+		return err
+	}
+
+	cargos, err := s.cargoRepo.GetByRouteID(route.ID, route.TruckID, companyID)
+	if err != nil {
+		return err
+	}
+
+	var batch []labels.Label
+	for i := range cargos {
+		if len(cargos[i].Pieces) > 0 {
+			batch = append(batch, pieceLabels(&cargos[i])...)
+		} else {
+			batch = append(batch, cargoLabel(&cargos[i]))
+		}
+	}
+	if len(batch) == 0 {
+		return errors.New("route has no cargo")
+	}
+
+	return renderLabels(w, req, batch)
+}
+
+func renderLabels(w io.Writer, req models.LabelRequest, batch []labels.Label) error {
+	return labels.Render(w, labels.Format(req.Format), labels.Size(req.Size), batch)
+}
+
+func cargoLabel(cargo *models.Cargo) labels.Label {
+	return labels.Label{
+		Company:            cargo.Company.Name,
+		TrackingNumber:     cargo.TrackingNumber,
+		Barcode:            cargo.TrackingNumber,
+		QRData:             cargo.TrackingNumber,
+		Title:              cargo.Title,
+		OriginAddress:      cargo.OriginAddress,
+		DestinationAddress: cargo.DestinationAddress,
+		DestinationContact: cargo.DestinationContact,
+		DestinationPhone:   cargo.DestinationPhone,
+		Weight:             cargo.Weight,
+		Priority:           string(cargo.Priority),
+		Fragile:            cargo.Type == models.CargoTypeFragile,
+		Hazardous:          cargo.Type == models.CargoTypeHazardous,
+	}
+}
+
+func pieceLabels(cargo *models.Cargo) []labels.Label {
+	batch := make([]labels.Label, len(cargo.Pieces))
+	for i, piece := range cargo.Pieces {
+		label := cargoLabel(cargo)
+		label.Barcode = piece.Barcode
+		label.Piece = fmt.Sprintf("%d/%d", piece.PieceNumber, len(cargo.Pieces))
+		label.Weight = piece.Weight
+		if piece.Description != "" {
+			label.Title = piece.Description
+		}
+		batch[i] = label
+	}
+	return batch
+}
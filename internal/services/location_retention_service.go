@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/storage"
+)
+
+const (
+	// locationRetentionMaxDays caps the days downsampled and archived per
+	// company in one run, so a first run on a large backlog catches up over
+	// several nights instead of holding the scheduler for hours.
+	locationRetentionMaxDays = 31
+	// locationPartitionsAhead is how many monthly partitions of
+	// truck_locations exist ahead of time, the current month included.
+	locationPartitionsAhead = 3
+	locationArchiveFormat   = "ndjson.gz"
+)
+
+// LocationRetentionService applies the companies' retention policies to
+// truck locations: it downsamples points past the raw period, moves points
+// past the archive period to the blob store and drops the partitions that
+// emptied out.
+type LocationRetentionService struct {
+	retentionRepo *repositories.LocationRetentionRepository
+	store         storage.BlobStore
+}
+
+func NewLocationRetentionService(retentionRepo *repositories.LocationRetentionRepository, store storage.BlobStore) *LocationRetentionService {
+	return &LocationRetentionService{retentionRepo: retentionRepo, store: store}
+}
+
+func (s *LocationRetentionService) GetPolicy(companyID uint) (*models.LocationRetentionPolicy, error) {
+	return s.retentionRepo.GetPolicy(companyID)
+}
+
+func (s *LocationRetentionService) UpdatePolicy(companyID uint, req models.UpdateLocationRetentionPolicyRequest) (*models.LocationRetentionPolicy, error) {
+	policy, err := s.retentionRepo.GetPolicy(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RawDays != nil {
+		policy.RawDays = *req.RawDays
+	}
+	if req.DownsampleMode != "" {
+		policy.DownsampleMode = req.DownsampleMode
+	}
+	if req.ArchiveAfterDays != nil {
+		policy.ArchiveAfterDays = *req.ArchiveAfterDays
+	}
+	if policy.ArchiveAfterDays != 0 && policy.ArchiveAfterDays <= policy.RawDays {
+		return nil, errors.New("archive_after_days must be greater than raw_days, or 0 to never archive")
+	}
+
+	if err := s.retentionRepo.UpdatePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *LocationRetentionService) GetArchives(companyID uint, filter models.LocationArchiveFilter) ([]models.LocationArchive, int64, error) {
+	return s.retentionRepo.GetArchives(companyID, filter)
+}
+
+func (s *LocationRetentionService) OpenArchive(id uint, companyID uint) (*models.LocationArchive, io.ReadCloser, error) {
+	archive, err := s.retentionRepo.GetArchiveByID(id, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.store.Get(archive.StorageKey)
+	return archive, r, err
+}
+
+func (s *LocationRetentionService) GetStorage() ([]models.LocationStorage, error) {
+	return s.retentionRepo.GetStorage()
+}
+
+// Apply runs every company's retention policy. It is the
+// truck_locations_retention scheduled job.
+func (s *LocationRetentionService) Apply(ctx context.Context) error {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var errs []error
+	if err := s.retentionRepo.EnsurePartitions(today, locationPartitionsAhead); err != nil {
+		errs = append(errs, fmt.Errorf("create partitions: %w", err))
+	}
+
+	companyIDs, err := s.retentionRepo.GetCompanyIDs()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, companyID := range companyIDs {
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		if err := s.applyPolicy(ctx, companyID, today); err != nil {
+			errs = append(errs, fmt.Errorf("company %d: %w", companyID, err))
+		}
+	}
+
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dropped, err := s.retentionRepo.DropEmptyPartitionsBefore(firstOfMonth)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("drop partitions: %w", err))
+	}
+	for _, name := range dropped {
+		log.Printf("dropped empty location partition %s", name)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *LocationRetentionService) applyPolicy(ctx context.Context, companyID uint, today time.Time) error {
+	policy, err := s.retentionRepo.GetPolicy(companyID)
+	if err != nil {
+		return err
+	}
+
+	if policy.DownsampleMode != models.LocationDownsampleNone {
+		if err := s.downsample(ctx, policy, today.AddDate(0, 0, -policy.RawDays)); err != nil {
+			return err
+		}
+	}
+	if policy.ArchiveAfterDays > 0 {
+		if err := s.archive(ctx, companyID, today.AddDate(0, 0, -policy.ArchiveAfterDays)); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	policy.LastAppliedAt = &now
+	return s.retentionRepo.UpdatePolicy(policy)
+}
+
+// downsample thins out the company's points day by day from where the last
+// run stopped up to cutoff.
+func (s *LocationRetentionService) downsample(ctx context.Context, policy *models.LocationRetentionPolicy, cutoff time.Time) error {
+	from := policy.DownsampledThrough
+	if from == nil {
+		oldest, err := s.retentionRepo.OldestPointBefore(policy.CompanyID, cutoff)
+		if err != nil || oldest == nil {
+			return err
+		}
+		day := startOfDay(*oldest)
+		from = &day
+	}
+
+	var removed int64
+	for day, n := *from, 0; day.Before(cutoff) && n < locationRetentionMaxDays; day, n = day.AddDate(0, 0, 1), n+1 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		count, err := s.retentionRepo.Downsample(policy.CompanyID, policy.DownsampleMode, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		removed += count
+
+		through := day.AddDate(0, 0, 1)
+		policy.DownsampledThrough = &through
+		if err := s.retentionRepo.UpdatePolicy(policy); err != nil {
+			return err
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("downsampled company %d locations: %d points removed", policy.CompanyID, removed)
+	}
+	return nil
+}
+
+// archive moves the company's points before cutoff to the blob store, one
+// file per day, oldest day first.
+func (s *LocationRetentionService) archive(ctx context.Context, companyID uint, cutoff time.Time) error {
+	for n := 0; n < locationRetentionMaxDays; n++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		oldest, err := s.retentionRepo.OldestPointBefore(companyID, cutoff)
+		if err != nil || oldest == nil {
+			return err
+		}
+		if err := s.archiveDay(companyID, startOfDay(*oldest)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveDay writes a day of the company's points to a gzipped NDJSON file
+// and deletes them from the database. Points of a day archived before are
+// merged with its existing file into a new one: every write goes to a new
+// key, and the file it replaces is removed only once the archive record and
+// the delete have committed, so a failure leaves the previous archive intact
+// and no point in two places.
+func (s *LocationRetentionService) archiveDay(companyID uint, day time.Time) error {
+	existing, err := s.retentionRepo.GetArchiveByDay(companyID, day)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	var points int64
+
+	if existing != nil {
+		r, err := s.store.Get(existing.StorageKey)
+		if err != nil {
+			return err
+		}
+		n, err := copyArchive(zw, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("read archive %s: %w", existing.StorageKey, err)
+		}
+		points += n
+	}
+
+	enc := json.NewEncoder(zw)
+	next := day.AddDate(0, 0, 1)
+	err = s.retentionRepo.EachPoint(companyID, day, next, func(location *models.TruckLocation) error {
+		points++
+		return enc.Encode(models.LocationArchiveRecord{
+			TruckID:   location.TruckID,
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			Speed:     location.Speed,
+			Heading:   location.Heading,
+			Timestamp: location.Timestamp.UTC(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("location-archives/%d/%s-%d.%s", companyID, day.Format("2006/01/02"), time.Now().UnixNano(), locationArchiveFormat)
+	size := int64(buf.Len())
+	if err := s.store.Put(key, &buf, "application/gzip"); err != nil {
+		s.deleteArchiveFile(key)
+		return err
+	}
+
+	archive := &models.LocationArchive{
+		CompanyID:  companyID,
+		Day:        day,
+		StorageKey: key,
+		Format:     locationArchiveFormat,
+		Points:     points,
+		SizeBytes:  size,
+		CreatedAt:  time.Now(),
+	}
+	// When this fails the new file is left unreferenced rather than deleted:
+	// the commit may have gone through with only its answer lost.
+	if err := s.retentionRepo.SaveArchive(archive, day, next); err != nil {
+		return err
+	}
+	if existing != nil && existing.StorageKey != key {
+		s.deleteArchiveFile(existing.StorageKey)
+	}
+	return nil
+}
+
+// deleteArchiveFile removes an archive file that no record points to. A
+// failure only leaves an orphaned file behind, so it is logged.
+func (s *LocationRetentionService) deleteArchiveFile(key string) {
+	if err := s.store.Delete(key); err != nil {
+		log.Printf("delete location archive %s: %v", key, err)
+	}
+}
+
+// copyArchive copies the records of a gzipped NDJSON archive to w and
+// returns how many there were.
+func copyArchive(w io.Writer, r io.Reader) (int64, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	var n int64
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if _, err := w.Write(append(scanner.Bytes(), '\n')); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
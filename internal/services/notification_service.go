@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"truck-management/internal/models"
+	"truck-management/internal/notify"
+	"truck-management/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// notificationTriggers maps the cargo events the consignee is told about to
+// their notification trigger.
+var notificationTriggers = map[string]models.NotificationTrigger{
+	"assigned": models.NotificationTriggerAssigned,
+	"pickup":   models.NotificationTriggerPickedUp,
+	"delayed":  models.NotificationTriggerDelayed,
+	"delivery": models.NotificationTriggerDelivered,
+}
+
+// defaultNotificationTemplates are the English messages used when a company
+// has no template of its own for a trigger and channel.
+var defaultNotificationTemplates = map[models.NotificationTrigger]map[models.NotificationChannel]models.NotificationTemplate{
+	models.NotificationTriggerAssigned: {
+		models.NotificationChannelEmail: {
+			Subject: "Your shipment {{.TrackingNumber}} is scheduled for pickup",
+			Body:    "Hello {{.RecipientName}},\n\n{{.Carrier}} has scheduled your shipment {{.TrackingNumber}} from {{.Origin}} for pickup.{{if .EstimatedDelivery}} Estimated delivery: {{.EstimatedDelivery}}.{{end}}\n",
+		},
+		models.NotificationChannelSMS: {
+			Body: "{{.Carrier}}: shipment {{.TrackingNumber}} is scheduled for pickup.{{if .EstimatedDelivery}} ETA {{.EstimatedDelivery}}.{{end}}",
+		},
+	},
+	models.NotificationTriggerPickedUp: {
+		models.NotificationChannelEmail: {
+			Subject: "Your shipment {{.TrackingNumber}} is on its way",
+			Body:    "Hello {{.RecipientName}},\n\nyour shipment {{.TrackingNumber}} has been picked up and is on its way to {{.Destination}}.{{if .EstimatedDelivery}} Estimated delivery: {{.EstimatedDelivery}}.{{end}}\n",
+		},
+		models.NotificationChannelSMS: {
+			Body: "{{.Carrier}}: shipment {{.TrackingNumber}} has been picked up.{{if .EstimatedDelivery}} ETA {{.EstimatedDelivery}}.{{end}}",
+		},
+	},
+	models.NotificationTriggerDelayed: {
+		models.NotificationChannelEmail: {
+			Subject: "Your shipment {{.TrackingNumber}} is delayed",
+			Body:    "Hello {{.RecipientName}},\n\nyour shipment {{.TrackingNumber}} is delayed. The new estimated delivery is {{.EstimatedDelivery}}.\n",
+		},
+		models.NotificationChannelSMS: {
+			Body: "{{.Carrier}}: shipment {{.TrackingNumber}} is delayed. New ETA {{.EstimatedDelivery}}.",
+		},
+	},
+	models.NotificationTriggerDelivered: {
+		models.NotificationChannelEmail: {
+			Subject: "Your shipment {{.TrackingNumber}} has been delivered",
+			Body:    "Hello {{.RecipientName}},\n\nyour shipment {{.TrackingNumber}} has been delivered. {{.Event}}.\n",
+		},
+		models.NotificationChannelSMS: {
+			Body: "{{.Carrier}}: shipment {{.TrackingNumber}} has been delivered.",
+		},
+	},
+}
+
+// NotificationService tells the consignee of a cargo about its milestones by
+// email and SMS, worded by the company's templates in the recipient's
+// locale.
+type NotificationService struct {
+	notificationRepo *repositories.NotificationRepository
+	cargoRepo        *repositories.CargoRepository
+	senders          map[models.NotificationChannel]notify.Sender
+}
+
+func NewNotificationService(notificationRepo *repositories.NotificationRepository, cargoRepo *repositories.CargoRepository, email notify.Sender, sms notify.Sender) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		cargoRepo:        cargoRepo,
+		senders: map[models.NotificationChannel]notify.Sender{
+			models.NotificationChannelEmail: email,
+			models.NotificationChannelSMS:   sms,
+		},
+	}
+}
+
+// HandleDomainEvent is the notification.cargo_event job: it notifies the
+// consignee if a recorded cargo event is one of the notification triggers.
+// Status changes count as pickup or delivery when they leave the cargo in
+// transit or delivered. Failing to load the cargo or settings fails the job
+// so it is retried; a message that fails to send is only logged, as resending
+// the others could reach the consignee twice.
+func (s *NotificationService) HandleDomainEvent(ctx context.Context, job *models.Job) error {
+	var event models.DomainEvent
+	if err := job.Decode(&event); err != nil {
+		return err
+	}
+	var recorded models.CargoEventRecorded
+	if err := event.Decode(&recorded); err != nil {
+		return err
+	}
+
+	trigger, ok := notificationTriggers[recorded.EventType]
+	if !ok && recorded.EventType != "status_change" {
+		return nil
+	}
+
+	cargoEvent, err := s.cargoRepo.GetEventByID(recorded.CargoEventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.notify(*cargoEvent, trigger)
+}
+
+func (s *NotificationService) notify(event models.CargoEvent, trigger models.NotificationTrigger) error {
+	cargo, err := s.cargoRepo.FindByID(event.CargoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if trigger == "" {
+		switch cargo.Status {
+		case models.CargoStatusInTransit:
+			trigger = models.NotificationTriggerPickedUp
+		case models.CargoStatusDelivered:
+			trigger = models.NotificationTriggerDelivered
+		default:
+			return nil
+		}
+	}
+
+	settings, err := s.notificationRepo.GetSettings(cargo.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	recipients := map[models.NotificationChannel]string{}
+	if settings.EmailEnabled && cargo.DestinationEmail != "" {
+		recipients[models.NotificationChannelEmail] = cargo.DestinationEmail
+	}
+	if settings.SMSEnabled && cargo.DestinationPhone != "" {
+		recipients[models.NotificationChannelSMS] = cargo.DestinationPhone
+	}
+
+	for channel, recipient := range recipients {
+		if err := s.send(cargo, event, trigger, channel, recipient, settings.DefaultLocale); err != nil {
+			log.Printf("%s notification for cargo %d: %v", channel, cargo.ID, err)
+		}
+	}
+	return nil
+}
+
+// send delivers one message and records it in the delivery log. A milestone
+// other than a delay is sent at most once per cargo and channel.
+func (s *NotificationService) send(cargo *models.Cargo, event models.CargoEvent, trigger models.NotificationTrigger, channel models.NotificationChannel, recipient string, defaultLocale string) error {
+	if trigger != models.NotificationTriggerDelayed {
+		sent, err := s.notificationRepo.HasSent(cargo.ID, trigger, channel)
+		if err != nil || sent {
+			return err
+		}
+	}
+
+	tmpl, locale, err := s.template(cargo.CompanyID, trigger, channel, cargo.RecipientLocale, defaultLocale)
+	if err != nil {
+		return err
+	}
+	if !tmpl.IsActive {
+		return nil
+	}
+
+	entry := &models.NotificationLog{
+		CompanyID:    cargo.CompanyID,
+		CargoID:      cargo.ID,
+		CargoEventID: &event.ID,
+		Trigger:      trigger,
+		Channel:      channel,
+		Recipient:    recipient,
+		Locale:       locale,
+	}
+
+	optedOut, err := s.notificationRepo.IsOptedOut(cargo.CompanyID, channel, normalizeRecipient(channel, recipient))
+	if err != nil {
+		return err
+	}
+	if optedOut {
+		entry.Status = models.NotificationStatusOptedOut
+		return s.notificationRepo.CreateLog(entry)
+	}
+
+	data := notificationData(cargo, event)
+	entry.Subject, err = renderNotification(tmpl.Subject, data)
+	if err == nil {
+		entry.Body, err = renderNotification(tmpl.Body, data)
+	}
+	if err == nil {
+		err = s.senders[channel].Send(notify.Message{To: recipient, Subject: entry.Subject, Body: entry.Body})
+	}
+
+	entry.Status = models.NotificationStatusSent
+	if err != nil {
+		entry.Status = models.NotificationStatusFailed
+		entry.Error = err.Error()
+	}
+	return s.notificationRepo.CreateLog(entry)
+}
+
+// template picks the company's template in the recipient's locale, then in
+// the company's default locale, and falls back to the built-in English one.
+func (s *NotificationService) template(companyID uint, trigger models.NotificationTrigger, channel models.NotificationChannel, locales ...string) (*models.NotificationTemplate, string, error) {
+	for _, locale := range locales {
+		locale = strings.ToLower(locale)
+		if locale == "" {
+			continue
+		}
+		tmpl, err := s.notificationRepo.GetTemplate(companyID, trigger, channel, locale)
+		if err == nil {
+			return tmpl, locale, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+	}
+
+	tmpl := defaultNotificationTemplates[trigger][channel]
+	tmpl.Trigger, tmpl.Channel, tmpl.Locale, tmpl.IsActive = trigger, channel, "en", true
+	return &tmpl, "en", nil
+}
+
+func (s *NotificationService) GetSettings(companyID uint) (*models.NotificationSettings, error) {
+	return s.notificationRepo.GetSettings(companyID)
+}
+
+func (s *NotificationService) UpdateSettings(companyID uint, req models.UpdateNotificationSettingsRequest) (*models.NotificationSettings, error) {
+	settings, err := s.notificationRepo.GetSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EmailEnabled != nil {
+		settings.EmailEnabled = *req.EmailEnabled
+	}
+	if req.SMSEnabled != nil {
+		settings.SMSEnabled = *req.SMSEnabled
+	}
+	if req.DefaultLocale != "" {
+		settings.DefaultLocale = req.DefaultLocale
+	}
+
+	err = s.notificationRepo.UpdateSettings(settings)
+	return settings, err
+}
+
+func (s *NotificationService) GetTemplates(companyID uint) ([]models.NotificationTemplate, error) {
+	return s.notificationRepo.GetTemplates(companyID)
+}
+
+// SaveTemplate creates or replaces a template after checking that its subject
+// and body render.
+func (s *NotificationService) SaveTemplate(companyID uint, req models.SaveNotificationTemplateRequest) (*models.NotificationTemplate, error) {
+	if req.Channel == models.NotificationChannelEmail && req.Subject == "" {
+		return nil, errors.New("email templates need a subject")
+	}
+	if req.Channel == models.NotificationChannelSMS {
+		req.Subject = ""
+	}
+
+	sample := models.NotificationData{Carrier: "Carrier", TrackingNumber: "TRK-000001", Pieces: 1}
+	for name, source := range map[string]string{"subject": req.Subject, "body": req.Body} {
+		if _, err := renderNotification(source, sample); err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", name, err)
+		}
+	}
+
+	tmpl := &models.NotificationTemplate{
+		CompanyID: companyID,
+		Trigger:   req.Trigger,
+		Channel:   req.Channel,
+		Locale:    strings.ToLower(req.Locale),
+		Subject:   req.Subject,
+		Body:      req.Body,
+		IsActive:  true,
+	}
+	if req.IsActive != nil {
+		tmpl.IsActive = *req.IsActive
+	}
+
+	err := s.notificationRepo.SaveTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.GetTemplate(companyID, tmpl.Trigger, tmpl.Channel, tmpl.Locale)
+}
+
+func (s *NotificationService) DeleteTemplate(id uint, companyID uint) error {
+	return s.notificationRepo.DeleteTemplate(id, companyID)
+}
+
+func (s *NotificationService) GetOptOuts(companyID uint) ([]models.NotificationOptOut, error) {
+	return s.notificationRepo.GetOptOuts(companyID)
+}
+
+func (s *NotificationService) CreateOptOut(companyID uint, req models.CreateNotificationOptOutRequest) (*models.NotificationOptOut, error) {
+	recipient := normalizeRecipient(req.Channel, req.Recipient)
+	if recipient == "" {
+		return nil, errors.New("recipient is not a valid email address or phone number")
+	}
+
+	optOut := &models.NotificationOptOut{
+		CompanyID: companyID,
+		Channel:   req.Channel,
+		Recipient: recipient,
+	}
+	err := s.notificationRepo.CreateOptOut(optOut)
+	return optOut, err
+}
+
+func (s *NotificationService) DeleteOptOut(id uint, companyID uint) error {
+	return s.notificationRepo.DeleteOptOut(id, companyID)
+}
+
+func (s *NotificationService) GetLogs(companyID uint, filter models.NotificationLogFilter) ([]models.NotificationLog, int64, error) {
+	return s.notificationRepo.GetLogs(companyID, filter)
+}
+
+// normalizeRecipient makes opt-outs match however the address was typed:
+// emails are lower-cased and phone numbers reduced to their digits.
+func normalizeRecipient(channel models.NotificationChannel, recipient string) string {
+	if channel == models.NotificationChannelEmail {
+		return strings.ToLower(strings.TrimSpace(recipient))
+	}
+
+	var digits strings.Builder
+	for _, r := range recipient {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	return digits.String()
+}
+
+func notificationData(cargo *models.Cargo, event models.CargoEvent) models.NotificationData {
+	data := models.NotificationData{
+		TrackingNumber: cargo.TrackingNumber,
+		TrackingToken:  cargo.TrackingToken,
+		Title:          cargo.Title,
+		Status:         string(cargo.Status),
+		RecipientName:  cargo.DestinationContact,
+		Origin:         cargo.OriginAddress,
+		Destination:    cargo.DestinationAddress,
+		Pieces:         len(cargo.Pieces),
+		Event:          event.Description,
+		Carrier:        cargo.Company.Name,
+	}
+	if cargo.EstimatedDelivery != nil {
+		data.EstimatedDelivery = cargo.EstimatedDelivery.Format("2006-01-02 15:04")
+	}
+	return data
+}
+
+func renderNotification(source string, data models.NotificationData) (string, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
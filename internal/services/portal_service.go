@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// PortalService is the customer-facing side of the booking workflow. Every
+// call is scoped to the customer account of the signed-in customer user;
+// records of other customers are reported as not found.
+type PortalService struct {
+	cargoRepo    *repositories.CargoRepository
+	customerRepo *repositories.CustomerRepository
+	cargos       *CargoService
+	pricing      *PricingService
+	invoices     *InvoiceService
+	pods         *ProofOfDeliveryService
+}
+
+func NewPortalService(cargoRepo *repositories.CargoRepository, customerRepo *repositories.CustomerRepository, cargos *CargoService, pricing *PricingService, invoices *InvoiceService, pods *ProofOfDeliveryService) *PortalService {
+	return &PortalService{
+		cargoRepo:    cargoRepo,
+		customerRepo: customerRepo,
+		cargos:       cargos,
+		pricing:      pricing,
+		invoices:     invoices,
+		pods:         pods,
+	}
+}
+
+// Quote prices a prospective booking with the company's rate table.
+func (s *PortalService) Quote(companyID uint, customerID uint, req models.CreateCargoRequest) (*models.FreightQuote, error) {
+	if err := s.bookingRequest(companyID, customerID, &req); err != nil {
+		return nil, err
+	}
+	return s.pricing.Quote(companyID, req)
+}
+
+// CreateBooking books a cargo for the customer. It is priced like any other
+// cargo and stays pending until a moderator approves it.
+func (s *PortalService) CreateBooking(companyID uint, customerID uint, userID uint, req models.CreateCargoRequest) (*models.PortalShipment, error) {
+	if err := s.bookingRequest(companyID, customerID, &req); err != nil {
+		return nil, err
+	}
+
+	cargo, err := s.cargos.BookCargo(companyID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment := models.NewPortalShipment(cargo)
+	return &shipment, nil
+}
+
+func (s *PortalService) GetShipments(companyID uint, customerID uint, filter models.PortalShipmentFilter) ([]models.PortalShipment, int64, error) {
+	cargos, total, err := s.cargoRepo.GetByCompanyID(companyID, models.CargoFilter{
+		Status:     filter.Status,
+		CustomerID: &customerID,
+		Search:     filter.Search,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	shipments := make([]models.PortalShipment, len(cargos))
+	for i := range cargos {
+		shipments[i] = models.NewPortalShipment(&cargos[i])
+	}
+	return shipments, total, nil
+}
+
+func (s *PortalService) GetShipment(id uint, companyID uint, customerID uint) (*models.PortalShipment, error) {
+	cargo, err := s.ownCargo(id, companyID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment := models.NewPortalShipment(cargo)
+	return &shipment, nil
+}
+
+// GetTracking returns the progress of one of the customer's shipments, with
+// its events stripped of the staff who recorded them.
+func (s *PortalService) GetTracking(id uint, companyID uint, customerID uint) (*models.PortalTracking, error) {
+	cargo, err := s.ownCargo(id, companyID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	tracking, err := s.cargos.GetCargoTracking(cargo.TrackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.CargoEvent, len(tracking.RecentEvents))
+	for i, event := range tracking.RecentEvents {
+		event.UserID = nil
+		event.User = nil
+		events[i] = event
+	}
+
+	return &models.PortalTracking{
+		Shipment:        models.NewPortalShipment(cargo),
+		Progress:        tracking.Progress,
+		EstimatedETA:    tracking.EstimatedETA,
+		RecentEvents:    events,
+		TruckLocation:   tracking.TruckLocation,
+		ProofOfDelivery: tracking.ProofOfDelivery,
+		Journey:         tracking.Journey,
+	}, nil
+}
+
+// CancelBooking cancels one of the customer's shipments before pickup.
+func (s *PortalService) CancelBooking(id uint, companyID uint, customerID uint, userID uint, reason string) (*models.PortalShipment, error) {
+	if _, err := s.ownCargo(id, companyID, customerID); err != nil {
+		return nil, err
+	}
+
+	cargo, err := s.cargos.CancelBooking(id, companyID, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment := models.NewPortalShipment(cargo)
+	return &shipment, nil
+}
+
+func (s *PortalService) GetProofOfDelivery(cargoID uint, companyID uint, customerID uint) (*models.ProofOfDelivery, error) {
+	if _, err := s.ownCargo(cargoID, companyID, customerID); err != nil {
+		return nil, err
+	}
+
+	pod, err := s.pods.GetProofOfDelivery(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	pod.CapturedByUser = nil
+	return pod, nil
+}
+
+func (s *PortalService) OpenSignature(cargoID uint, companyID uint, customerID uint) (io.ReadCloser, string, error) {
+	if _, err := s.ownCargo(cargoID, companyID, customerID); err != nil {
+		return nil, "", err
+	}
+	return s.pods.OpenSignature(cargoID, companyID)
+}
+
+func (s *PortalService) OpenPhoto(cargoID uint, companyID uint, customerID uint, photoID uint) (io.ReadCloser, string, error) {
+	if _, err := s.ownCargo(cargoID, companyID, customerID); err != nil {
+		return nil, "", err
+	}
+	return s.pods.OpenPhoto(cargoID, companyID, photoID)
+}
+
+// GetInvoices lists the customer's issued invoices and credit notes; drafts
+// are not shown until they are issued.
+func (s *PortalService) GetInvoices(companyID uint, customerID uint, filter models.InvoiceFilter) ([]models.Invoice, int64, error) {
+	filter.CustomerID = &customerID
+	filter.CustomerName = ""
+	filter.ExcludeDrafts = true
+	return s.invoices.GetInvoices(companyID, filter)
+}
+
+// RenderInvoice writes one of the customer's issued invoices as PDF.
+func (s *PortalService) RenderInvoice(w io.Writer, id uint, companyID uint, customerID uint) error {
+	invoice, err := s.invoices.GetInvoice(id, companyID)
+	if err != nil {
+		return err
+	}
+	if invoice.CustomerID == nil || *invoice.CustomerID != customerID || invoice.Status == models.InvoiceStatusDraft {
+		return gorm.ErrRecordNotFound
+	}
+	return s.invoices.RenderInvoice(w, id, companyID)
+}
+
+// GetAddresses returns the customer's saved addresses to book from.
+func (s *PortalService) GetAddresses(companyID uint, customerID uint) ([]models.CustomerAddress, error) {
+	customer, err := s.customerRepo.GetByID(customerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return customer.Addresses, nil
+}
+
+// bookingRequest binds a booking to the customer: the customer is fixed, the
+// price comes from the rate table and saved addresses must be the customer's
+// own.
+func (s *PortalService) bookingRequest(companyID uint, customerID uint, req *models.CreateCargoRequest) error {
+	req.CustomerID = &customerID
+	req.Price = nil
+
+	for _, id := range []*uint{req.OriginAddressID, req.DestinationAddressID} {
+		if id == nil {
+			continue
+		}
+		address, err := s.customerRepo.GetAddressByID(*id, companyID)
+		if err != nil || address.CustomerID != customerID {
+			return errors.New("saved address not found")
+		}
+	}
+	return nil
+}
+
+func (s *PortalService) ownCargo(id uint, companyID uint, customerID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if cargo.CustomerID == nil || *cargo.CustomerID != customerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cargo, nil
+}
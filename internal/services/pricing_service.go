@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+var ErrNoFreightRate = errors.New("no freight rate applies to this shipment")
+
+type PricingService struct {
+	pricingRepo *repositories.PricingRepository
+}
+
+func NewPricingService(pricingRepo *repositories.PricingRepository) *PricingService {
+	return &PricingService{pricingRepo: pricingRepo}
+}
+
+func (s *PricingService) GetSettings(companyID uint) (*models.PricingSettings, error) {
+	return s.pricingRepo.GetSettings(companyID)
+}
+
+func (s *PricingService) UpdateSettings(companyID uint, req models.UpdatePricingSettingsRequest) (*models.PricingSettings, error) {
+	settings, err := s.pricingRepo.GetSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Currency != "" {
+		settings.Currency = strings.ToUpper(req.Currency)
+	}
+	if req.VolumetricFactor != nil {
+		settings.VolumetricFactor = *req.VolumetricFactor
+	}
+	if req.UrgentSurchargePercent != nil {
+		settings.UrgentSurchargePercent = *req.UrgentSurchargePercent
+	}
+	if req.HazardousSurchargePercent != nil {
+		settings.HazardousSurchargePercent = *req.HazardousSurchargePercent
+	}
+	if req.PerishableSurchargePercent != nil {
+		settings.PerishableSurchargePercent = *req.PerishableSurchargePercent
+	}
+	if req.SpecialHandlingFee != nil {
+		settings.SpecialHandlingFee = *req.SpecialHandlingFee
+	}
+	if req.FuelSurchargePercent != nil {
+		settings.FuelSurchargePercent = *req.FuelSurchargePercent
+	}
+	if req.MinimumCharge != nil {
+		settings.MinimumCharge = *req.MinimumCharge
+	}
+
+	err = s.pricingRepo.UpdateSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *PricingService) GetRates(companyID uint) ([]models.FreightRate, error) {
+	return s.pricingRepo.GetRates(companyID)
+}
+
+func (s *PricingService) CreateRate(companyID uint, req models.CreateFreightRateRequest) (*models.FreightRate, error) {
+	rate := &models.FreightRate{
+		CompanyID:        companyID,
+		Name:             req.Name,
+		OriginMatch:      strings.TrimSpace(req.OriginMatch),
+		DestinationMatch: strings.TrimSpace(req.DestinationMatch),
+		MinDistanceKm:    req.MinDistanceKm,
+		MaxDistanceKm:    req.MaxDistanceKm,
+		MinWeight:        req.MinWeight,
+		BaseCharge:       req.BaseCharge,
+		RatePerKm:        req.RatePerKm,
+		RatePerKg:        req.RatePerKg,
+		IsActive:         true,
+	}
+	if err := validateFreightRate(rate); err != nil {
+		return nil, err
+	}
+
+	err := s.pricingRepo.CreateRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *PricingService) UpdateRate(id uint, companyID uint, req models.UpdateFreightRateRequest) (*models.FreightRate, error) {
+	rate, err := s.pricingRepo.GetRateByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		rate.Name = req.Name
+	}
+	if req.OriginMatch != nil {
+		rate.OriginMatch = strings.TrimSpace(*req.OriginMatch)
+	}
+	if req.DestinationMatch != nil {
+		rate.DestinationMatch = strings.TrimSpace(*req.DestinationMatch)
+	}
+	if req.MinDistanceKm != nil {
+		rate.MinDistanceKm = *req.MinDistanceKm
+	}
+	if req.MaxDistanceKm != nil {
+		rate.MaxDistanceKm = req.MaxDistanceKm
+		if *req.MaxDistanceKm == 0 {
+			rate.MaxDistanceKm = nil
+		}
+	}
+	if req.MinWeight != nil {
+		rate.MinWeight = *req.MinWeight
+	}
+	if req.BaseCharge != nil {
+		rate.BaseCharge = *req.BaseCharge
+	}
+	if req.RatePerKm != nil {
+		rate.RatePerKm = *req.RatePerKm
+	}
+	if req.RatePerKg != nil {
+		rate.RatePerKg = *req.RatePerKg
+	}
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+	if err := validateFreightRate(rate); err != nil {
+		return nil, err
+	}
+
+	err = s.pricingRepo.UpdateRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *PricingService) DeleteRate(id uint, companyID uint) error {
+	return s.pricingRepo.DeleteRate(id, companyID)
+}
+
+// Quote prices a prospective shipment without booking it.
+func (s *PricingService) Quote(companyID uint, req models.CreateCargoRequest) (*models.FreightQuote, error) {
+	if err := validateCargoTemperature(req.Type, req.MinTemperature, req.MaxTemperature); err != nil {
+		return nil, err
+	}
+
+	table, err := s.loadRateTable(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return table.quote(newCargoFromRequest(companyID, "", req))
+}
+
+// PriceCargos stores the booked price on new cargo. Cargo given a price by hand
+// keeps it; cargo no rate applies to is booked unpriced.
+func (s *PricingService) PriceCargos(companyID uint, cargos []*models.Cargo) error {
+	table, err := s.loadRateTable(companyID)
+	if err != nil {
+		return err
+	}
+
+	for _, cargo := range cargos {
+		cargo.PriceCurrency = table.settings.Currency
+		if cargo.Price != nil {
+			continue
+		}
+
+		quote, err := table.quote(cargo)
+		if errors.Is(err, ErrNoFreightRate) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		cargo.Price = &quote.Total
+		cargo.FreightRateID = &quote.RateID
+	}
+	return nil
+}
+
+type rateTable struct {
+	settings *models.PricingSettings
+	rates    []models.FreightRate
+}
+
+func (s *PricingService) loadRateTable(companyID uint) (*rateTable, error) {
+	settings, err := s.pricingRepo.GetSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.pricingRepo.GetActiveRates(companyID)
+	if err != nil {
+		return nil, err
+	}
+	return &rateTable{settings: settings, rates: rates}, nil
+}
+
+// quote rates a cargo: the best matching rate gives the linehaul, percentage
+// surcharges apply to the linehaul, the minimum charge to the subtotal and the
+// fuel surcharge to the result.
+func (t *rateTable) quote(cargo *models.Cargo) (*models.FreightQuote, error) {
+	var distance *float64
+	if cargo.OriginLatitude != nil && cargo.OriginLongitude != nil && cargo.DestinationLatitude != nil && cargo.DestinationLongitude != nil {
+		d := roundMoney(greatCircleKm(*cargo.OriginLatitude, *cargo.OriginLongitude, *cargo.DestinationLatitude, *cargo.DestinationLongitude))
+		distance = &d
+	}
+	chargeable := math.Max(cargo.Weight, cargo.Volume*t.settings.VolumetricFactor)
+
+	rate := t.match(cargo, distance, chargeable)
+	if rate == nil {
+		if distance == nil {
+			return nil, fmt.Errorf("%w; distance rates need origin and destination coordinates", ErrNoFreightRate)
+		}
+		return nil, ErrNoFreightRate
+	}
+
+	quote := &models.FreightQuote{
+		Currency:         t.settings.Currency,
+		DistanceKm:       distance,
+		ChargeableWeight: roundMoney(chargeable),
+		RateID:           rate.ID,
+		RateName:         rate.Name,
+		Surcharges:       []models.QuoteLineItem{},
+	}
+	linehaul := rate.BaseCharge + rate.RatePerKg*chargeable
+	if distance != nil {
+		linehaul += rate.RatePerKm * *distance
+	}
+	quote.Linehaul = roundMoney(linehaul)
+
+	addPercent := func(code, description string, percent float64) {
+		if percent > 0 {
+			quote.Surcharges = append(quote.Surcharges, models.QuoteLineItem{
+				Code:        code,
+				Description: fmt.Sprintf("%s (%g%%)", description, percent),
+				Amount:      roundMoney(quote.Linehaul * percent / 100),
+			})
+		}
+	}
+	if cargo.Priority == models.CargoPriorityUrgent {
+		addPercent("urgent", "Urgent priority", t.settings.UrgentSurchargePercent)
+	}
+	if cargo.IsDangerousGoods() {
+		addPercent("hazardous", "Dangerous goods", t.settings.HazardousSurchargePercent)
+	}
+	if cargo.Type == models.CargoTypePerishable {
+		addPercent("perishable", "Temperature controlled", t.settings.PerishableSurchargePercent)
+	}
+	if cargo.SpecialHandling && t.settings.SpecialHandlingFee > 0 {
+		quote.Surcharges = append(quote.Surcharges, models.QuoteLineItem{
+			Code:        "special_handling",
+			Description: "Special handling",
+			Amount:      roundMoney(t.settings.SpecialHandlingFee),
+		})
+	}
+
+	subtotal := quote.Linehaul
+	for _, line := range quote.Surcharges {
+		subtotal += line.Amount
+	}
+	if subtotal < t.settings.MinimumCharge {
+		subtotal = t.settings.MinimumCharge
+		quote.MinimumApplied = true
+	}
+	quote.FuelSurcharge = roundMoney(subtotal * t.settings.FuelSurchargePercent / 100)
+	quote.Total = roundMoney(subtotal + quote.FuelSurcharge)
+
+	return quote, nil
+}
+
+// match picks the rate for a shipment: lane rates win over general ones (a
+// lane on both ends over one end), then the highest weight break reached, then
+// the narrowest distance band. Without a distance only rates that do not
+// depend on it apply.
+func (t *rateTable) match(cargo *models.Cargo, distance *float64, chargeable float64) *models.FreightRate {
+	var best *models.FreightRate
+	bestScore := -1
+	for i := range t.rates {
+		rate := &t.rates[i]
+		if !addressMatches(cargo.OriginAddress, rate.OriginMatch) || !addressMatches(cargo.DestinationAddress, rate.DestinationMatch) {
+			continue
+		}
+		if chargeable < rate.MinWeight {
+			continue
+		}
+		if distance == nil {
+			if rate.MinDistanceKm > 0 || rate.MaxDistanceKm != nil || rate.RatePerKm > 0 {
+				continue
+			}
+		} else if *distance < rate.MinDistanceKm || (rate.MaxDistanceKm != nil && *distance >= *rate.MaxDistanceKm) {
+			continue
+		}
+
+		score := 0
+		if rate.OriginMatch != "" {
+			score++
+		}
+		if rate.DestinationMatch != "" {
+			score++
+		}
+		if best == nil || score > bestScore ||
+			(score == bestScore && rate.MinWeight > best.MinWeight) ||
+			(score == bestScore && rate.MinWeight == best.MinWeight && rate.MinDistanceKm > best.MinDistanceKm) {
+			best, bestScore = rate, score
+		}
+	}
+	return best
+}
+
+func validateFreightRate(rate *models.FreightRate) error {
+	if rate.MaxDistanceKm != nil && *rate.MaxDistanceKm <= rate.MinDistanceKm {
+		return errors.New("max_distance_km must be above min_distance_km")
+	}
+	if rate.BaseCharge == 0 && rate.RatePerKm == 0 && rate.RatePerKg == 0 {
+		return errors.New("a rate needs a base_charge, rate_per_km or rate_per_kg")
+	}
+	return nil
+}
+
+func addressMatches(address, match string) bool {
+	return match == "" || strings.Contains(strings.ToLower(address), strings.ToLower(match))
+}
+
+// greatCircleKm is the haversine distance between two coordinates.
+func greatCircleKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
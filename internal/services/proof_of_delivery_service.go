@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/storage"
+)
+
+const (
+	maxPODFileSize   = 10 << 20
+	maxPODPhotoCount = 10
+)
+
+var podImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+type ProofOfDeliveryService struct {
+	podRepo      *repositories.ProofOfDeliveryRepository
+	cargoRepo    *repositories.CargoRepository
+	cargoService *CargoService
+	temperatures *TemperatureService
+	uow          *repositories.UnitOfWork
+	store        storage.BlobStore
+}
+
+func NewProofOfDeliveryService(podRepo *repositories.ProofOfDeliveryRepository, cargoRepo *repositories.CargoRepository, cargoService *CargoService, temperatures *TemperatureService, uow *repositories.UnitOfWork, store storage.BlobStore) *ProofOfDeliveryService {
+	return &ProofOfDeliveryService{
+		podRepo:      podRepo,
+		cargoRepo:    cargoRepo,
+		cargoService: cargoService,
+		temperatures: temperatures,
+		uow:          uow,
+		store:        store,
+	}
+}
+
+func (s *ProofOfDeliveryService) CaptureProofOfDelivery(cargoID uint, companyID uint, driverID uint, req models.CaptureProofOfDeliveryRequest, signature *multipart.FileHeader, photos []*multipart.FileHeader) (*models.ProofOfDelivery, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cargo.Truck == nil || cargo.Truck.DriverID == nil || *cargo.Truck.DriverID != driverID {
+		return nil, errors.New("cargo is not assigned to your truck")
+	}
+	if cargo.Status == models.CargoStatusDelivered || cargo.Status == models.CargoStatusCancelled {
+		return nil, errors.New("cargo is already " + string(cargo.Status))
+	}
+
+	exists, err := s.podRepo.ExistsForCargo(cargoID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("proof of delivery already captured")
+	}
+
+	if signature == nil {
+		return nil, errors.New("signature image is required")
+	}
+	if len(photos) == 0 {
+		return nil, errors.New("at least one photo is required")
+	}
+	if len(photos) > maxPODPhotoCount {
+		return nil, fmt.Errorf("at most %d photos can be attached", maxPODPhotoCount)
+	}
+
+	capturedAt := time.Now()
+	if req.CapturedAt != nil {
+		capturedAt = *req.CapturedAt
+	}
+
+	prefix := fmt.Sprintf("pod/%d/%d/%d", companyID, cargoID, capturedAt.UnixNano())
+	var storedKeys []string
+	cleanup := func() {
+		for _, key := range storedKeys {
+			s.store.Delete(key)
+		}
+	}
+
+	signatureKey, signatureType, err := s.storeImage(prefix+"/signature", signature)
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	storedKeys = append(storedKeys, signatureKey)
+
+	var podPhotos []models.ProofOfDeliveryPhoto
+	for i, photo := range photos {
+		key, contentType, err := s.storeImage(fmt.Sprintf("%s/photo-%d", prefix, i+1), photo)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("photo %s: %w", photo.Filename, err)
+		}
+		storedKeys = append(storedKeys, key)
+
+		podPhotos = append(podPhotos, models.ProofOfDeliveryPhoto{
+			StorageKey:  key,
+			FileName:    path.Base(photo.Filename),
+			ContentType: contentType,
+			Size:        photo.Size,
+		})
+	}
+
+	event, err := s.cargoService.newCargoEvent(cargo, driverID, models.CreateCargoEventRequest{
+		EventType:   "delivery",
+		Description: "Delivered to " + req.RecipientName,
+		Location:    req.Location,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	pod := &models.ProofOfDelivery{
+		CompanyID:            companyID,
+		CargoID:              cargoID,
+		RecipientName:        req.RecipientName,
+		SignatureKey:         signatureKey,
+		SignatureContentType: signatureType,
+		Latitude:             *req.Latitude,
+		Longitude:            *req.Longitude,
+		CapturedAt:           capturedAt,
+		CapturedBy:           driverID,
+		Notes:                req.Notes,
+		Photos:               podPhotos,
+	}
+
+	// Cold-chain cargo keeps its temperature log with the delivery record. The
+	// delivery stands without it; the log can still be rendered on demand.
+	if cargo.MinTemperature != nil && cargo.MaxTemperature != nil {
+		var report bytes.Buffer
+		err := s.temperatures.RenderLog(&report, cargoID, companyID)
+		if err == nil {
+			key := prefix + "/temperature-log.pdf"
+			if err = s.store.Put(key, &report, "application/pdf"); err == nil {
+				storedKeys = append(storedKeys, key)
+				pod.TemperatureLogKey = key
+				pod.HasTemperatureLog = true
+			}
+		}
+		if err != nil {
+			log.Printf("temperature log for cargo %d: %v", cargoID, err)
+		}
+	}
+
+	// The delivery and its proof are recorded together: without the proof the
+	// cargo must not read as delivered, and the stored images go with it.
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := recordCargoEvent(tx, cargo, event); err != nil {
+			return err
+		}
+		pod.CargoEventID = &event.ID
+		return tx.PODs.Create(pod)
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	return s.podRepo.GetByCargoID(cargoID, companyID)
+}
+
+func (s *ProofOfDeliveryService) GetProofOfDelivery(cargoID uint, companyID uint) (*models.ProofOfDelivery, error) {
+	return s.podRepo.GetByCargoID(cargoID, companyID)
+}
+
+func (s *ProofOfDeliveryService) OpenSignature(cargoID uint, companyID uint) (io.ReadCloser, string, error) {
+	pod, err := s.podRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r, err := s.store.Get(pod.SignatureKey)
+	return r, pod.SignatureContentType, err
+}
+
+// OpenTemperatureLog opens the temperature log report stored with the proof
+// of delivery of a cold-chain cargo.
+func (s *ProofOfDeliveryService) OpenTemperatureLog(cargoID uint, companyID uint) (io.ReadCloser, string, error) {
+	pod, err := s.podRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !pod.HasTemperatureLog {
+		return nil, "", storage.ErrNotFound
+	}
+
+	r, err := s.store.Get(pod.TemperatureLogKey)
+	return r, "application/pdf", err
+}
+
+func (s *ProofOfDeliveryService) OpenPhoto(cargoID uint, companyID uint, photoID uint) (io.ReadCloser, string, error) {
+	pod, err := s.podRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	photo, err := s.podRepo.GetPhoto(photoID, pod.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r, err := s.store.Get(photo.StorageKey)
+	return r, photo.ContentType, err
+}
+
+// storeImage sniffs the upload instead of trusting the client's content type
+// and stores it under key plus the matching extension.
+func (s *ProofOfDeliveryService) storeImage(key string, fh *multipart.FileHeader) (string, string, error) {
+	if fh.Size > maxPODFileSize {
+		return "", "", fmt.Errorf("file exceeds %d MB", maxPODFileSize>>20)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := podImageTypes[contentType]
+	if !ok {
+		return "", "", errors.New("unsupported image type " + contentType)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	key += ext
+	if err := s.store.Put(key, f, contentType); err != nil {
+		return "", "", err
+	}
+
+	return key, contentType, nil
+}
+
+func summarizeProofOfDelivery(pod *models.ProofOfDelivery) *models.PODSummary {
+	var initials []string
+	for _, part := range strings.Fields(pod.RecipientName) {
+		initials = append(initials, strings.ToUpper(string([]rune(part)[0]))+".")
+	}
+
+	return &models.PODSummary{
+		RecipientInitials: strings.Join(initials, " "),
+		DeliveredAt:       pod.CapturedAt,
+		HasSignature:      pod.SignatureKey != "",
+		PhotoCount:        len(pod.Photos),
+	}
+}
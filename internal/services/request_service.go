@@ -8,10 +8,11 @@ import (
 
 type RequestService struct {
 	requestRepo *repositories.RequestRepository
+	uow         *repositories.UnitOfWork
 }
 
-func NewRequestService(requestRepo *repositories.RequestRepository) *RequestService {
-	return &RequestService{requestRepo: requestRepo}
+func NewRequestService(requestRepo *repositories.RequestRepository, uow *repositories.UnitOfWork) *RequestService {
+	return &RequestService{requestRepo: requestRepo, uow: uow}
 }
 
 func (s *RequestService) CreateRequest(userID uint, companyID uint, req models.CreateRequestRequest) (*models.Request, error) {
@@ -25,12 +26,9 @@ func (s *RequestService) CreateRequest(userID uint, companyID uint, req models.C
 		Status:      models.RequestStatusPending,
 	}
 
-	err := s.requestRepo.Create(request)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.requestRepo.GetByID(request.ID, companyID)
+	return s.save(request, models.DomainEventRequestCreated, func(tx *repositories.Tx) error {
+		return tx.Requests.Create(request)
+	})
 }
 
 func (s *RequestService) GetRequests(companyID uint) ([]models.Request, error) {
@@ -48,12 +46,9 @@ func (s *RequestService) AcceptRequest(id uint, companyID uint, moderatorID uint
 	now := time.Now()
 	request.AcceptedAt = &now
 
-	err = s.requestRepo.Update(request)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.requestRepo.GetByID(id, companyID)
+	return s.save(request, models.DomainEventRequestAccepted, func(tx *repositories.Tx) error {
+		return tx.Requests.Update(request)
+	})
 }
 
 func (s *RequestService) TerminateRequest(id uint, companyID uint, moderatorID uint, reason string) (*models.Request, error) {
@@ -68,10 +63,28 @@ func (s *RequestService) TerminateRequest(id uint, companyID uint, moderatorID u
 	now := time.Now()
 	request.TerminatedAt = &now
 
-	err = s.requestRepo.Update(request)
+	return s.save(request, models.DomainEventRequestTerminated, func(tx *repositories.Tx) error {
+		return tx.Requests.Update(request)
+	})
+}
+
+// save writes a request with write and records eventType with the reloaded
+// request in the same unit of work.
+func (s *RequestService) save(request *models.Request, eventType string, write func(tx *repositories.Tx) error) (*models.Request, error) {
+	var saved *models.Request
+	err := s.uow.Do(func(tx *repositories.Tx) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		var err error
+		saved, err = tx.Requests.GetByID(request.ID, request.CompanyID)
+		if err != nil {
+			return err
+		}
+		return tx.Record(saved.CompanyID, eventType, "request", saved.ID, saved)
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	return s.requestRepo.GetByID(id, companyID)
-}
\ No newline at end of file
+	return saved, nil
+}
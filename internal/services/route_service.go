@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"time"
 	"truck-management/internal/models"
 	"truck-management/internal/repositories"
@@ -9,12 +10,18 @@ import (
 type RouteService struct {
 	routeRepo *repositories.RouteRepository
 	truckRepo *repositories.TruckRepository
+	cargoRepo *repositories.CargoRepository
+	customers *CustomerService
+	uow       *repositories.UnitOfWork
 }
 
-func NewRouteService(routeRepo *repositories.RouteRepository, truckRepo *repositories.TruckRepository) *RouteService {
+func NewRouteService(routeRepo *repositories.RouteRepository, truckRepo *repositories.TruckRepository, cargoRepo *repositories.CargoRepository, customers *CustomerService, uow *repositories.UnitOfWork) *RouteService {
 	return &RouteService{
 		routeRepo: routeRepo,
 		truckRepo: truckRepo,
+		cargoRepo: cargoRepo,
+		customers: customers,
+		uow:       uow,
 	}
 }
 
@@ -98,18 +105,51 @@ func (s *RouteService) ApproveRoute(id uint, companyID uint, approvedBy uint) (*
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkRouteHazmat(route); err != nil {
+		return nil, err
+	}
 
 	route.Status = models.RouteStatusActive
 	route.ApprovedBy = &approvedBy
 	now := time.Now()
 	route.ApprovedAt = &now
 
-	err = s.routeRepo.Update(route)
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Routes.Update(route); err != nil {
+			return err
+		}
+		approved, err := tx.Routes.GetByID(id, companyID, nil)
+		if err != nil {
+			return err
+		}
+		route = approved
+		return tx.Record(companyID, models.DomainEventRouteApproved, "route", id, route)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return route, nil
+}
 
-	return s.routeRepo.GetByID(id, companyID, nil)
+// checkRouteHazmat applies the dispatch rules for dangerous goods to the cargo
+// a route is about to carry, with the route's driver behind the wheel.
+func (s *RouteService) checkRouteHazmat(route *models.Route) error {
+	cargos, err := s.cargoRepo.GetByRouteID(route.ID, route.TruckID, route.CompanyID)
+	if err != nil {
+		return err
+	}
+	if len(dangerousGoods(cargos)) == 0 {
+		return nil
+	}
+	if route.Truck == nil {
+		return errors.New("a route carrying dangerous goods needs a truck")
+	}
+
+	truck := *route.Truck
+	if route.Driver != nil {
+		truck.Driver = route.Driver
+	}
+	return checkHazmatDispatch(&truck, cargos)
 }
 
 func (s *RouteService) DeleteRoute(id uint, companyID uint) error {
@@ -124,15 +164,20 @@ func (s *RouteService) CreateRouteStop(routeID uint, companyID uint, req models.
 	}
 
 	stop := &models.RouteStop{
-		RouteID:          routeID,
-		StopOrder:        req.StopOrder,
-		Address:          req.Address,
-		Latitude:         req.Latitude,
-		Longitude:        req.Longitude,
-		ContactName:      req.ContactName,
-		ContactPhone:     req.ContactPhone,
-		Instructions:     req.Instructions,
-		EstimatedArrival: req.EstimatedArrival,
+		RouteID:           routeID,
+		StopOrder:         req.StopOrder,
+		CustomerID:        req.CustomerID,
+		CustomerAddressID: req.CustomerAddressID,
+		Address:           req.Address,
+		Latitude:          req.Latitude,
+		Longitude:         req.Longitude,
+		ContactName:       req.ContactName,
+		ContactPhone:      req.ContactPhone,
+		Instructions:      req.Instructions,
+		EstimatedArrival:  req.EstimatedArrival,
+	}
+	if err := s.customers.fillRouteStop(companyID, stop); err != nil {
+		return nil, err
 	}
 
 	err = s.routeRepo.CreateStop(stop)
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/utils"
+)
+
+// ScanService turns a driver's barcode scan into the next step of the cargo's
+// journey, so drivers do not have to pick the cargo and the action by hand.
+type ScanService struct {
+	cargoRepo    *repositories.CargoRepository
+	pieceRepo    *repositories.CargoPieceRepository
+	truckRepo    *repositories.TruckRepository
+	legRepo      *repositories.ShipmentLegRepository
+	cargoService *CargoService
+	legService   *ShipmentLegService
+	pieceService *CargoPieceService
+}
+
+func NewScanService(cargoRepo *repositories.CargoRepository, pieceRepo *repositories.CargoPieceRepository, truckRepo *repositories.TruckRepository, legRepo *repositories.ShipmentLegRepository, cargoService *CargoService, legService *ShipmentLegService, pieceService *CargoPieceService) *ScanService {
+	return &ScanService{
+		cargoRepo:    cargoRepo,
+		pieceRepo:    pieceRepo,
+		truckRepo:    truckRepo,
+		legRepo:      legRepo,
+		cargoService: cargoService,
+		legService:   legService,
+		pieceService: pieceService,
+	}
+}
+
+// Scan resolves a tracking number, piece barcode or handling unit barcode
+// within the company and applies the next transition for the scanning driver:
+//   - a piece or unit is picked up when pending and delivered once picked up;
+//   - cargo without legs is picked up when assigned and delivered in transit;
+//   - on a multi-leg shipment the driver's first open leg is departed (pickup),
+//     taken over from the previous leg's driver (handover), ended at its hub
+//     (arrival) or, on the final leg, delivered.
+func (s *ScanService) Scan(companyID uint, driverID uint, req models.ScanRequest) (*models.ScanResponse, error) {
+	truck, err := s.truckRepo.GetByDriverID(driverID, companyID)
+	if err != nil {
+		return nil, errors.New("you are not assigned to a truck")
+	}
+
+	if response, ok, err := s.scanPiece(companyID, driverID, req); ok {
+		return response, err
+	}
+
+	cargo, err := s.cargoRepo.GetByTrackingNumber(utils.NormalizeTrackingNumber(req.Code))
+	if err != nil || cargo.CompanyID != companyID {
+		return nil, fmt.Errorf("code %s does not match any cargo, piece or handling unit", req.Code)
+	}
+
+	switch cargo.Status {
+	case models.CargoStatusDelivered, models.CargoStatusCancelled:
+		return nil, fmt.Errorf("cargo %s is already %s", cargo.TrackingNumber, cargo.Status)
+	}
+
+	if len(cargo.ShipmentLegs) > 0 {
+		return s.scanLeg(cargo, companyID, driverID, truck, req)
+	}
+
+	if cargo.TruckID == nil {
+		return nil, fmt.Errorf("cargo %s is not assigned to any truck", cargo.TrackingNumber)
+	}
+	if *cargo.TruckID != truck.ID {
+		assigned := "another truck"
+		if cargo.Truck != nil {
+			assigned = "truck " + cargo.Truck.LicensePlate
+		}
+		return nil, fmt.Errorf("cargo %s is assigned to %s, not to your truck %s", cargo.TrackingNumber, assigned, truck.LicensePlate)
+	}
+
+	action := models.ScanActionDelivery
+	if cargo.Status == models.CargoStatusAssigned {
+		action = models.ScanActionPickup
+	}
+	return s.recordEvent(cargo, companyID, driverID, action, req)
+}
+
+// scanPiece handles piece and handling unit barcodes; ok is false when the
+// code is neither.
+func (s *ScanService) scanPiece(companyID uint, driverID uint, req models.ScanRequest) (*models.ScanResponse, bool, error) {
+	var pieces []models.CargoPiece
+	if piece, err := s.pieceRepo.GetByBarcode(req.Code, companyID); err == nil {
+		pieces = []models.CargoPiece{*piece}
+	} else if unit, err := s.pieceRepo.GetHandlingUnitByBarcode(req.Code, companyID); err == nil {
+		pieces, _ = s.pieceRepo.GetByHandlingUnitID(unit.ID)
+	} else {
+		return nil, false, nil
+	}
+
+	// Pick up while anything is still pending, deliver after that
+	action := models.ScanActionDelivery
+	for _, piece := range pieces {
+		if piece.Status == models.CargoPieceStatusPending {
+			action = models.ScanActionPickup
+			break
+		}
+	}
+
+	result, err := s.pieceService.ScanPiece(companyID, driverID, models.ScanPieceRequest{
+		Barcode:   req.Code,
+		Event:     string(action),
+		Location:  req.Location,
+		Latitude:  &req.Latitude,
+		Longitude: &req.Longitude,
+	})
+	if err != nil {
+		return nil, true, err
+	}
+
+	return &models.ScanResponse{
+		Action: action,
+		Cargos: result.Cargos,
+		Pieces: result.Pieces,
+	}, true, nil
+}
+
+func (s *ScanService) scanLeg(cargo *models.Cargo, companyID uint, driverID uint, truck *models.Truck, req models.ScanRequest) (*models.ScanResponse, error) {
+	var leg, previous *models.ShipmentLeg
+	var last *models.ShipmentLeg
+	for i := range cargo.ShipmentLegs {
+		current := &cargo.ShipmentLegs[i]
+		if current.Status == models.ShipmentLegStatusCancelled {
+			continue
+		}
+		if leg == nil && current.Status != models.ShipmentLegStatusCompleted && sameUint(current.TruckID, &truck.ID) {
+			leg, previous = current, last
+		}
+		last = current
+	}
+	if leg == nil {
+		return nil, fmt.Errorf("no open leg of cargo %s is assigned to your truck %s", cargo.TrackingNumber, truck.LicensePlate)
+	}
+
+	location := models.ShipmentLegLocationRequest{
+		Location:  req.Location,
+		Latitude:  &req.Latitude,
+		Longitude: &req.Longitude,
+	}
+
+	var action models.ScanAction
+	var err error
+	switch {
+	case leg.Status == models.ShipmentLegStatusInTransit && leg.ID == last.ID:
+		return s.recordEvent(cargo, companyID, driverID, models.ScanActionDelivery, req)
+	case leg.Status == models.ShipmentLegStatusInTransit:
+		action = models.ScanActionArrival
+		_, err = s.legService.ArriveLeg(cargo.ID, leg.ID, companyID, driverID, location)
+	case previous != nil && !s.hasHandover(cargo.ID, leg.ID, companyID):
+		// Taking custody completes the previous leg; the new leg leaves with it
+		action = models.ScanActionHandover
+		_, err = s.legService.Handover(cargo.ID, leg.ID, companyID, driverID, models.CreateHandoverRequest{
+			Location:  req.Location,
+			Latitude:  &req.Latitude,
+			Longitude: &req.Longitude,
+			Notes:     req.Notes,
+		})
+		if err == nil {
+			_, err = s.legService.DepartLeg(cargo.ID, leg.ID, companyID, driverID, location)
+		}
+	default:
+		action = models.ScanActionPickup
+		_, err = s.legService.DepartLeg(cargo.ID, leg.ID, companyID, driverID, location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.cargoRepo.GetByID(cargo.ID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.ScanResponse{Action: action, Cargos: []models.Cargo{*updated}}
+	response.Leg, _ = s.legRepo.GetByID(leg.ID, cargo.ID, companyID)
+	return response, nil
+}
+
+func (s *ScanService) hasHandover(cargoID uint, legID uint, companyID uint) bool {
+	handovers, _ := s.legRepo.GetHandovers(cargoID, companyID)
+	for _, handover := range handovers {
+		if handover.ToLegID == legID {
+			return true
+		}
+	}
+	return false
+}
+
+// recordEvent records a pickup or delivery event, which moves the cargo
+// through the status state machine.
+func (s *ScanService) recordEvent(cargo *models.Cargo, companyID uint, driverID uint, action models.ScanAction, req models.ScanRequest) (*models.ScanResponse, error) {
+	description := "Picked up (scanned)"
+	if action == models.ScanActionDelivery {
+		description = "Delivered (scanned)"
+	}
+	if req.Notes != "" {
+		description += ": " + req.Notes
+	}
+
+	event, err := s.cargoService.CreateCargoEvent(cargo.ID, companyID, driverID, models.CreateCargoEventRequest{
+		EventType:   string(action),
+		Description: description,
+		Location:    req.Location,
+		Latitude:    &req.Latitude,
+		Longitude:   &req.Longitude,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.cargoRepo.GetByID(cargo.ID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ScanResponse{
+		Action: action,
+		Cargos: []models.Cargo{*updated},
+		Event:  event,
+	}, nil
+}
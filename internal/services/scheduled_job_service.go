@@ -0,0 +1,72 @@
+package services
+
+import (
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/scheduler"
+)
+
+// ScheduledJobService lets admins inspect and steer the recurring system
+// jobs. Changes are stored and picked up by the scheduler leader, whichever
+// replica that is.
+type ScheduledJobService struct {
+	scheduledJobRepo *repositories.ScheduledJobRepository
+}
+
+func NewScheduledJobService(scheduledJobRepo *repositories.ScheduledJobRepository) *ScheduledJobService {
+	return &ScheduledJobService{scheduledJobRepo: scheduledJobRepo}
+}
+
+func (s *ScheduledJobService) GetJobs() ([]models.ScheduledJob, error) {
+	return s.scheduledJobRepo.GetAll()
+}
+
+func (s *ScheduledJobService) GetJob(name string) (*models.ScheduledJob, error) {
+	return s.scheduledJobRepo.GetByName(name)
+}
+
+func (s *ScheduledJobService) GetRuns(name string, filter models.ScheduledJobRunFilter) ([]models.ScheduledJobRun, int64, error) {
+	if _, err := s.scheduledJobRepo.GetByName(name); err != nil {
+		return nil, 0, err
+	}
+	return s.scheduledJobRepo.GetRuns(name, filter)
+}
+
+// TriggerJob asks for a run of the job at the leader's next check, paused or
+// not. Its schedule is unaffected.
+func (s *ScheduledJobService) TriggerJob(name string) (*models.ScheduledJob, error) {
+	if err := s.scheduledJobRepo.RequestRun(name); err != nil {
+		return nil, err
+	}
+	return s.scheduledJobRepo.GetByName(name)
+}
+
+func (s *ScheduledJobService) PauseJob(name string) (*models.ScheduledJob, error) {
+	if err := s.scheduledJobRepo.SetPaused(name, true, nil); err != nil {
+		return nil, err
+	}
+	return s.scheduledJobRepo.GetByName(name)
+}
+
+// ResumeJob unpauses the job from its next scheduled time on; runs missed
+// while it was paused are skipped.
+func (s *ScheduledJobService) ResumeJob(name string) (*models.ScheduledJob, error) {
+	job, err := s.scheduledJobRepo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := scheduler.Parse(job.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	if at := schedule.Next(time.Now()); !at.IsZero() {
+		next = &at
+	}
+	if err := s.scheduledJobRepo.SetPaused(name, false, next); err != nil {
+		return nil, err
+	}
+	return s.scheduledJobRepo.GetByName(name)
+}
@@ -0,0 +1,586 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+)
+
+type ShipmentLegService struct {
+	legRepo    *repositories.ShipmentLegRepository
+	cargoRepo  *repositories.CargoRepository
+	truckRepo  *repositories.TruckRepository
+	routeRepo  *repositories.RouteRepository
+	branchRepo *repositories.BranchRepository
+}
+
+func NewShipmentLegService(legRepo *repositories.ShipmentLegRepository, cargoRepo *repositories.CargoRepository, truckRepo *repositories.TruckRepository, routeRepo *repositories.RouteRepository, branchRepo *repositories.BranchRepository) *ShipmentLegService {
+	return &ShipmentLegService{
+		legRepo:    legRepo,
+		cargoRepo:  cargoRepo,
+		truckRepo:  truckRepo,
+		routeRepo:  routeRepo,
+		branchRepo: branchRepo,
+	}
+}
+
+func (s *ShipmentLegService) GetLegs(cargoID uint, companyID uint) ([]models.ShipmentLeg, error) {
+	// Verify cargo belongs to company
+	_, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.legRepo.GetByCargoID(cargoID, companyID)
+}
+
+func (s *ShipmentLegService) CreateLeg(cargoID uint, companyID uint, req models.CreateShipmentLegRequest) (*models.ShipmentLeg, error) {
+	cargo, err := s.openCargo(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if cargo.AwaitingApproval() {
+		return nil, ErrBookingNotApproved
+	}
+
+	leg := &models.ShipmentLeg{
+		CompanyID:           companyID,
+		CargoID:             cargoID,
+		TruckID:             req.TruckID,
+		RouteID:             req.RouteID,
+		OriginBranchID:      req.OriginBranchID,
+		OriginAddress:       req.OriginAddress,
+		DestinationBranchID: req.DestinationBranchID,
+		DestinationAddress:  req.DestinationAddress,
+		Status:              models.ShipmentLegStatusPending,
+		PlannedDeparture:    req.PlannedDeparture,
+		PlannedArrival:      req.PlannedArrival,
+		Notes:               req.Notes,
+	}
+	if err := s.resolveLeg(leg, companyID); err != nil {
+		return nil, err
+	}
+	if err := s.checkLegHazmat(cargo, leg, companyID); err != nil {
+		return nil, err
+	}
+
+	// The journey starts where the cargo is picked up
+	if len(cargo.ShipmentLegs) == 0 && leg.OriginAddress == "" {
+		leg.OriginAddress = cargo.OriginAddress
+	}
+
+	err = s.legRepo.Create(leg)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.CargoEvent{
+		CargoID:     cargoID,
+		EventType:   "leg_planned",
+		Description: fmt.Sprintf("Leg %d planned: %s to %s", leg.Sequence, leg.OriginAddress, leg.DestinationAddress),
+		Timestamp:   time.Now(),
+	}
+	s.cargoRepo.CreateEvent(event)
+
+	if err := s.syncCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+	return s.legRepo.GetByID(leg.ID, cargoID, companyID)
+}
+
+func (s *ShipmentLegService) UpdateLeg(cargoID uint, legID uint, companyID uint, req models.UpdateShipmentLegRequest) (*models.ShipmentLeg, error) {
+	leg, err := s.legRepo.GetByID(legID, cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if leg.Status != models.ShipmentLegStatusPending {
+		return nil, errors.New("only pending legs can be changed")
+	}
+
+	if req.TruckID != nil {
+		leg.TruckID = req.TruckID
+	}
+	if req.RouteID != nil {
+		leg.RouteID = req.RouteID
+	}
+	if req.OriginBranchID != nil {
+		leg.OriginBranchID = req.OriginBranchID
+		leg.OriginAddress = ""
+	}
+	if req.OriginAddress != "" {
+		leg.OriginAddress = req.OriginAddress
+	}
+	if req.DestinationBranchID != nil {
+		leg.DestinationBranchID = req.DestinationBranchID
+		leg.DestinationAddress = ""
+	}
+	if req.DestinationAddress != "" {
+		leg.DestinationAddress = req.DestinationAddress
+	}
+	if req.PlannedDeparture != nil {
+		leg.PlannedDeparture = req.PlannedDeparture
+	}
+	if req.PlannedArrival != nil {
+		leg.PlannedArrival = req.PlannedArrival
+	}
+	if req.Notes != "" {
+		leg.Notes = req.Notes
+	}
+	if err := s.resolveLeg(leg, companyID); err != nil {
+		return nil, err
+	}
+	if req.TruckID != nil {
+		cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkLegHazmat(cargo, leg, companyID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.legRepo.Update(leg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.syncCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+	return s.legRepo.GetByID(legID, cargoID, companyID)
+}
+
+func (s *ShipmentLegService) DeleteLeg(cargoID uint, legID uint, companyID uint) error {
+	leg, err := s.legRepo.GetByID(legID, cargoID, companyID)
+	if err != nil {
+		return err
+	}
+	if leg.Status != models.ShipmentLegStatusPending {
+		return errors.New("only pending legs can be removed")
+	}
+
+	err = s.legRepo.Delete(leg)
+	if err != nil {
+		return err
+	}
+
+	return s.syncCargo(cargoID, companyID)
+}
+
+// DepartLeg starts a leg. Every earlier leg must already be finished.
+func (s *ShipmentLegService) DepartLeg(cargoID uint, legID uint, companyID uint, driverID uint, req models.ShipmentLegLocationRequest) (*models.ShipmentLeg, error) {
+	cargo, err := s.openCargo(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	legs, err := s.legRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	leg, previous := findLeg(legs, legID)
+	if leg == nil {
+		return nil, errors.New("leg not found")
+	}
+	if !isLegDriver(leg, driverID) {
+		return nil, errors.New("leg is not assigned to your truck")
+	}
+	if leg.Status != models.ShipmentLegStatusPending {
+		return nil, errors.New("leg is already " + string(leg.Status))
+	}
+	if previous != nil && previous.Status != models.ShipmentLegStatusCompleted {
+		return nil, fmt.Errorf("leg %d has not been completed yet", previous.Sequence)
+	}
+	if cargo.IsDangerousGoods() {
+		truck, err := s.truckRepo.GetByID(*leg.TruckID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		onboard, err := s.cargoRepo.GetOpenByTruckID(truck.ID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkHazmatDispatch(truck, withCargo(onboard, cargo)); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	leg.Status = models.ShipmentLegStatusInTransit
+	leg.DepartedAt = &now
+	err = s.legRepo.Update(leg)
+	if err != nil {
+		return nil, err
+	}
+
+	s.createLegEvent(cargoID, driverID, "leg_departed", fmt.Sprintf("Leg %d departed from %s", leg.Sequence, leg.OriginAddress), req)
+
+	if err := s.syncCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+	return s.legRepo.GetByID(legID, cargoID, companyID)
+}
+
+// ArriveLeg completes an intermediate leg at its destination, typically a hub.
+// The final leg is completed by the delivery itself.
+func (s *ShipmentLegService) ArriveLeg(cargoID uint, legID uint, companyID uint, driverID uint, req models.ShipmentLegLocationRequest) (*models.ShipmentLeg, error) {
+	legs, err := s.legRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	leg, _ := findLeg(legs, legID)
+	if leg == nil {
+		return nil, errors.New("leg not found")
+	}
+	if !isLegDriver(leg, driverID) {
+		return nil, errors.New("leg is not assigned to your truck")
+	}
+	if leg.Status != models.ShipmentLegStatusInTransit {
+		return nil, errors.New("leg is not in transit")
+	}
+	if isFinalLeg(legs, leg) {
+		return nil, errors.New("the final leg is completed by recording the delivery")
+	}
+
+	now := time.Now()
+	leg.Status = models.ShipmentLegStatusCompleted
+	leg.ArrivedAt = &now
+	err = s.legRepo.Update(leg)
+	if err != nil {
+		return nil, err
+	}
+
+	s.createLegEvent(cargoID, driverID, "leg_arrived", fmt.Sprintf("Leg %d arrived at %s", leg.Sequence, leg.DestinationAddress), req)
+
+	if err := s.syncCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+	return s.legRepo.GetByID(legID, cargoID, companyID)
+}
+
+// Handover records the driver of a leg taking custody of the cargo from the
+// driver of the previous leg. An earlier leg still in transit is completed.
+func (s *ShipmentLegService) Handover(cargoID uint, legID uint, companyID uint, driverID uint, req models.CreateHandoverRequest) (*models.CargoHandover, error) {
+	if _, err := s.openCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+
+	legs, err := s.legRepo.GetByCargoID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	leg, previous := findLeg(legs, legID)
+	if leg == nil {
+		return nil, errors.New("leg not found")
+	}
+	if previous == nil {
+		return nil, errors.New("the first leg has no previous driver to take over from")
+	}
+	if !isLegDriver(leg, driverID) {
+		return nil, errors.New("leg is not assigned to your truck")
+	}
+	if leg.Status != models.ShipmentLegStatusPending {
+		return nil, errors.New("leg is already " + string(leg.Status))
+	}
+	if previous.Status == models.ShipmentLegStatusPending {
+		return nil, fmt.Errorf("leg %d has not departed yet", previous.Sequence)
+	}
+
+	now := time.Now()
+	if previous.Status == models.ShipmentLegStatusInTransit {
+		previous.Status = models.ShipmentLegStatusCompleted
+		previous.ArrivedAt = &now
+		err = s.legRepo.Update(previous)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	handover := &models.CargoHandover{
+		CompanyID:    companyID,
+		CargoID:      cargoID,
+		FromLegID:    &previous.ID,
+		ToLegID:      leg.ID,
+		ToDriverID:   driverID,
+		BranchID:     previous.DestinationBranchID,
+		Location:     req.Location,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Notes:        req.Notes,
+		HandedOverAt: now,
+	}
+	if previous.Truck != nil {
+		handover.FromDriverID = previous.Truck.DriverID
+	}
+	if handover.BranchID == nil {
+		handover.BranchID = leg.OriginBranchID
+	}
+	if handover.Location == "" {
+		handover.Location = previous.DestinationAddress
+	}
+
+	err = s.legRepo.CreateHandover(handover)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Custody transferred from leg %d to leg %d", previous.Sequence, leg.Sequence)
+	if leg.Truck != nil {
+		description += " (truck " + leg.Truck.LicensePlate + ")"
+	}
+	s.createLegEvent(cargoID, driverID, "handover", description, models.ShipmentLegLocationRequest{
+		Location:  handover.Location,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	})
+
+	if err := s.syncCargo(cargoID, companyID); err != nil {
+		return nil, err
+	}
+	return handover, nil
+}
+
+func (s *ShipmentLegService) GetHandovers(cargoID uint, companyID uint) ([]models.CargoHandover, error) {
+	// Verify cargo belongs to company
+	_, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.legRepo.GetHandovers(cargoID, companyID)
+}
+
+func (s *ShipmentLegService) openCargo(cargoID uint, companyID uint) (*models.Cargo, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if cargo.Status == models.CargoStatusDelivered || cargo.Status == models.CargoStatusCancelled {
+		return nil, errors.New("cargo is already " + string(cargo.Status))
+	}
+	return cargo, nil
+}
+
+// resolveLeg verifies that referenced trucks, routes and branches belong to
+// the company and fills empty addresses from the branches.
+func (s *ShipmentLegService) resolveLeg(leg *models.ShipmentLeg, companyID uint) error {
+	if leg.TruckID != nil {
+		if _, err := s.truckRepo.GetByID(*leg.TruckID, companyID); err != nil {
+			return errors.New("truck not found")
+		}
+	}
+	if leg.RouteID != nil {
+		if _, err := s.routeRepo.GetByID(*leg.RouteID, companyID, nil); err != nil {
+			return errors.New("route not found")
+		}
+	}
+	if leg.OriginBranchID != nil {
+		branch, err := s.branchRepo.GetByID(*leg.OriginBranchID, companyID)
+		if err != nil {
+			return errors.New("origin branch not found")
+		}
+		if leg.OriginAddress == "" {
+			leg.OriginAddress = branch.Address
+		}
+	}
+	if leg.DestinationBranchID != nil {
+		branch, err := s.branchRepo.GetByID(*leg.DestinationBranchID, companyID)
+		if err != nil {
+			return errors.New("destination branch not found")
+		}
+		if leg.DestinationAddress == "" {
+			leg.DestinationAddress = branch.Address
+		}
+	}
+	return nil
+}
+
+// checkLegHazmat applies the loading rules for dangerous goods to the truck of
+// a leg, against the cargo that truck currently carries.
+func (s *ShipmentLegService) checkLegHazmat(cargo *models.Cargo, leg *models.ShipmentLeg, companyID uint) error {
+	if leg.TruckID == nil || !cargo.IsDangerousGoods() {
+		return nil
+	}
+
+	truck, err := s.truckRepo.GetByID(*leg.TruckID, companyID)
+	if err != nil {
+		return err
+	}
+	onboard, err := s.cargoRepo.GetOpenByTruckID(truck.ID, companyID)
+	if err != nil {
+		return err
+	}
+	return checkHazmatLoad(cargo, truck, onboard)
+}
+
+func (s *ShipmentLegService) createLegEvent(cargoID uint, userID uint, eventType string, description string, req models.ShipmentLegLocationRequest) {
+	event := &models.CargoEvent{
+		CargoID:     cargoID,
+		EventType:   eventType,
+		Description: description,
+		Location:    req.Location,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		UserID:      &userID,
+		Timestamp:   time.Now(),
+	}
+	s.cargoRepo.CreateEvent(event)
+}
+
+// syncCargo derives the cargo's status and current truck from its legs.
+func (s *ShipmentLegService) syncCargo(cargoID uint, companyID uint) error {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return err
+	}
+
+	oldStatus := cargo.Status
+	if !applyLegsToCargo(cargo, cargo.ShipmentLegs) {
+		return nil
+	}
+
+	cargo.ShipmentLegs = nil
+	cargo.CargoEvents = nil
+	cargo.Pieces = nil
+	err = s.cargoRepo.Update(cargo)
+	if err != nil {
+		return err
+	}
+
+	if oldStatus != cargo.Status {
+		event := &models.CargoEvent{
+			CargoID:     cargo.ID,
+			EventType:   "status_change",
+			Description: "Status changed from " + string(oldStatus) + " to " + string(cargo.Status),
+			Timestamp:   time.Now(),
+		}
+		s.cargoRepo.CreateEvent(event)
+	}
+	return nil
+}
+
+// applyLegsToCargo sets the cargo's status, current truck and pickup time from
+// its legs and reports whether anything changed. Delivered, partially delivered
+// and cancelled cargo is left alone: delivery completes the final leg, not the
+// other way round.
+func applyLegsToCargo(cargo *models.Cargo, legs []models.ShipmentLeg) bool {
+	switch cargo.Status {
+	case models.CargoStatusDelivered, models.CargoStatusPartiallyDelivered, models.CargoStatusCancelled:
+		return false
+	}
+
+	var active []models.ShipmentLeg
+	for _, leg := range legs {
+		if leg.Status != models.ShipmentLegStatusCancelled {
+			active = append(active, leg)
+		}
+	}
+	if len(active) == 0 {
+		return false
+	}
+
+	status := models.CargoStatusPending
+	var current *models.ShipmentLeg
+	for i := range active {
+		leg := &active[i]
+		switch leg.Status {
+		case models.ShipmentLegStatusInTransit, models.ShipmentLegStatusCompleted:
+			status = models.CargoStatusInTransit
+		case models.ShipmentLegStatusPending:
+			if leg.TruckID != nil && status == models.CargoStatusPending {
+				status = models.CargoStatusAssigned
+			}
+		}
+		if current == nil && leg.Status != models.ShipmentLegStatusCompleted {
+			current = leg
+		}
+	}
+
+	changed := cargo.Status != status
+	cargo.Status = status
+
+	if current != nil && !sameUint(cargo.TruckID, current.TruckID) {
+		cargo.TruckID = current.TruckID
+		cargo.Truck = nil
+		changed = true
+	}
+	if cargo.ActualPickup == nil && active[0].DepartedAt != nil {
+		cargo.ActualPickup = active[0].DepartedAt
+		changed = true
+	}
+	return changed
+}
+
+// legProgress reports how far along its legs a cargo is, from 0 to 100.
+func legProgress(legs []models.ShipmentLeg) float64 {
+	var total, done float64
+	for _, leg := range legs {
+		switch leg.Status {
+		case models.ShipmentLegStatusCancelled:
+			continue
+		case models.ShipmentLegStatusCompleted:
+			done++
+		case models.ShipmentLegStatusInTransit:
+			done += 0.5
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	return 100 * done / total
+}
+
+func journeyOf(legs []models.ShipmentLeg) []models.JourneyLeg {
+	var journey []models.JourneyLeg
+	for _, leg := range legs {
+		if leg.Status == models.ShipmentLegStatusCancelled {
+			continue
+		}
+		journey = append(journey, models.JourneyLeg{
+			Sequence:       len(journey) + 1,
+			Origin:         leg.OriginAddress,
+			Destination:    leg.DestinationAddress,
+			Status:         leg.Status,
+			PlannedArrival: leg.PlannedArrival,
+			DepartedAt:     leg.DepartedAt,
+			ArrivedAt:      leg.ArrivedAt,
+		})
+	}
+	return journey
+}
+
+// findLeg returns the leg with the given ID and the active leg before it.
+func findLeg(legs []models.ShipmentLeg, legID uint) (leg *models.ShipmentLeg, previous *models.ShipmentLeg) {
+	for i := range legs {
+		if legs[i].ID == legID {
+			return &legs[i], previous
+		}
+		if legs[i].Status != models.ShipmentLegStatusCancelled {
+			previous = &legs[i]
+		}
+	}
+	return nil, nil
+}
+
+func isFinalLeg(legs []models.ShipmentLeg, leg *models.ShipmentLeg) bool {
+	for _, other := range legs {
+		if other.Sequence > leg.Sequence && other.Status != models.ShipmentLegStatusCancelled {
+			return false
+		}
+	}
+	return true
+}
+
+func isLegDriver(leg *models.ShipmentLeg, driverID uint) bool {
+	return leg.Truck != nil && leg.Truck.DriverID != nil && *leg.Truck.DriverID == driverID
+}
+
+func sameUint(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/reports"
+	"truck-management/internal/repositories"
+)
+
+// Readings may be stamped slightly ahead of the server clock by the sensor.
+const maxReadingClockSkew = 5 * time.Minute
+
+// TemperatureService records reefer telemetry and watches perishable cargo for
+// temperature excursions.
+type TemperatureService struct {
+	tempRepo  *repositories.TemperatureRepository
+	cargoRepo *repositories.CargoRepository
+	truckRepo *repositories.TruckRepository
+}
+
+func NewTemperatureService(tempRepo *repositories.TemperatureRepository, cargoRepo *repositories.CargoRepository, truckRepo *repositories.TruckRepository) *TemperatureService {
+	return &TemperatureService{
+		tempRepo:  tempRepo,
+		cargoRepo: cargoRepo,
+		truckRepo: truckRepo,
+	}
+}
+
+// RecordReadings stores a batch of readings from a truck's reefer unit or a
+// cargo's own sensor and checks them against the range of every picked-up
+// cargo they apply to. Excursions that have now lasted longer than the cargo's
+// threshold are returned as alerts. Drivers may only report for their truck.
+func (s *TemperatureService) RecordReadings(companyID uint, userID uint, userRole models.UserRole, req models.TemperatureTelemetryRequest) (*models.TemperatureTelemetryResponse, error) {
+	if (req.TruckID == nil) == (req.CargoID == nil) {
+		return nil, errors.New("exactly one of truck_id and cargo_id is required")
+	}
+
+	var truckID *uint
+	var monitored []models.Cargo
+	if req.TruckID != nil {
+		truck, err := s.truckRepo.GetByID(*req.TruckID, companyID)
+		if err != nil {
+			return nil, errors.New("truck not found")
+		}
+		if userRole == models.RoleDriver && (truck.DriverID == nil || *truck.DriverID != userID) {
+			return nil, errors.New("you can only report readings for your own truck")
+		}
+		truckID = &truck.ID
+
+		monitored, err = s.cargoRepo.GetMonitoredByTruckID(truck.ID, companyID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cargo, err := s.cargoRepo.GetByID(*req.CargoID, companyID)
+		if err != nil {
+			return nil, errors.New("cargo not found")
+		}
+		if userRole == models.RoleDriver && (cargo.Truck == nil || cargo.Truck.DriverID == nil || *cargo.Truck.DriverID != userID) {
+			return nil, errors.New("cargo is not on your truck")
+		}
+		if cargo.MinTemperature == nil || cargo.MaxTemperature == nil {
+			return nil, errors.New("cargo has no temperature range")
+		}
+		truckID = cargo.TruckID
+
+		switch cargo.Status {
+		case models.CargoStatusInTransit, models.CargoStatusPartiallyDelivered:
+			monitored = []models.Cargo{*cargo}
+		}
+	}
+
+	now := time.Now()
+	readings := make([]models.TemperatureReading, len(req.Readings))
+	for i, input := range req.Readings {
+		recordedAt := now
+		if input.RecordedAt != nil {
+			recordedAt = *input.RecordedAt
+		}
+		if recordedAt.After(now.Add(maxReadingClockSkew)) {
+			return nil, fmt.Errorf("reading %d is recorded in the future", i+1)
+		}
+
+		readings[i] = models.TemperatureReading{
+			CompanyID:   companyID,
+			TruckID:     truckID,
+			CargoID:     req.CargoID,
+			SensorID:    input.SensorID,
+			Temperature: *input.Temperature,
+			Humidity:    input.Humidity,
+			RecordedAt:  recordedAt,
+		}
+	}
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].RecordedAt.Before(readings[j].RecordedAt)
+	})
+
+	if err := s.tempRepo.CreateReadings(readings); err != nil {
+		return nil, err
+	}
+
+	response := &models.TemperatureTelemetryResponse{Accepted: len(readings), Alerts: []models.TemperatureExcursion{}}
+	for i := range monitored {
+		alerts, err := s.checkExcursions(&monitored[i], truckID, readings)
+		if err != nil {
+			return nil, err
+		}
+		response.Alerts = append(response.Alerts, alerts...)
+	}
+
+	return response, nil
+}
+
+// checkExcursions walks the readings in time order: a reading out of range
+// opens an excursion or extends the open one, and the first reading back in
+// range closes it. An excursion is alerted once, when it reaches the cargo's
+// threshold.
+func (s *TemperatureService) checkExcursions(cargo *models.Cargo, truckID *uint, readings []models.TemperatureReading) ([]models.TemperatureExcursion, error) {
+	low, high := *cargo.MinTemperature, *cargo.MaxTemperature
+	threshold := time.Duration(cargo.ExcursionThresholdMinutes) * time.Minute
+	if threshold <= 0 {
+		threshold = models.DefaultExcursionThresholdMinutes * time.Minute
+	}
+
+	open, err := s.tempRepo.GetOpenExcursion(cargo.ID)
+	if err != nil {
+		open = nil
+	}
+	dirty := false
+
+	save := func() error {
+		if open == nil || !dirty {
+			return nil
+		}
+		dirty = false
+		return s.tempRepo.UpdateExcursion(open)
+	}
+
+	var alerts []models.TemperatureExcursion
+	for _, reading := range readings {
+		// Late readings older than the open excursion's state are kept but
+		// cannot change it
+		if open != nil && reading.RecordedAt.Before(open.LastReadingAt) {
+			continue
+		}
+
+		direction := models.TemperatureExcursionDirection("")
+		switch {
+		case reading.Temperature > high:
+			direction = models.TemperatureExcursionAbove
+		case reading.Temperature < low:
+			direction = models.TemperatureExcursionBelow
+		}
+
+		if open != nil && open.Direction != direction {
+			endedAt := reading.RecordedAt
+			open.Status = models.TemperatureExcursionClosed
+			open.EndedAt = &endedAt
+			open.LastReadingAt = reading.RecordedAt
+			dirty = true
+			if err := save(); err != nil {
+				return nil, err
+			}
+			if open.AlertedAt != nil {
+				s.createEvent(cargo.ID, "temperature_recovered", fmt.Sprintf("Temperature back to %s after %s %s for %s",
+					formatCelsius(reading.Temperature), open.Direction, rangeLimit(open), open.Duration().Round(time.Minute)), reading.RecordedAt)
+			}
+			open = nil
+		}
+		if direction == "" {
+			continue
+		}
+
+		if open == nil {
+			open = &models.TemperatureExcursion{
+				CompanyID:       cargo.CompanyID,
+				CargoID:         cargo.ID,
+				TruckID:         truckID,
+				Direction:       direction,
+				Status:          models.TemperatureExcursionOpen,
+				MinTemperature:  low,
+				MaxTemperature:  high,
+				PeakTemperature: reading.Temperature,
+				StartedAt:       reading.RecordedAt,
+				LastReadingAt:   reading.RecordedAt,
+			}
+			if err := s.tempRepo.CreateExcursion(open); err != nil {
+				return nil, err
+			}
+		} else {
+			if (direction == models.TemperatureExcursionAbove && reading.Temperature > open.PeakTemperature) ||
+				(direction == models.TemperatureExcursionBelow && reading.Temperature < open.PeakTemperature) {
+				open.PeakTemperature = reading.Temperature
+			}
+			open.LastReadingAt = reading.RecordedAt
+			dirty = true
+		}
+
+		if open.AlertedAt == nil && open.Duration() >= threshold {
+			now := time.Now()
+			open.AlertedAt = &now
+			dirty = true
+			alert := *open
+			alert.Cargo = cargo
+			alerts = append(alerts, alert)
+			s.createEvent(cargo.ID, "temperature_excursion", fmt.Sprintf("Temperature %s %s for %s, peak %s",
+				open.Direction, rangeLimit(open), open.Duration().Round(time.Minute), formatCelsius(open.PeakTemperature)), reading.RecordedAt)
+		}
+	}
+
+	if err := save(); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (s *TemperatureService) createEvent(cargoID uint, eventType string, description string, at time.Time) {
+	s.cargoRepo.CreateEvent(&models.CargoEvent{
+		CargoID:     cargoID,
+		EventType:   eventType,
+		Description: description,
+		Timestamp:   at,
+	})
+}
+
+// GetCargoTemperature returns the readings that applied to a cargo while it
+// was carried, and its excursions.
+func (s *TemperatureService) GetCargoTemperature(cargoID uint, companyID uint) (*models.CargoTemperatureHistory, error) {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	readings, err := s.tempRepo.GetReadingsForCargo(cargo.ID, companyID, truckPeriods(cargo))
+	if err != nil {
+		return nil, err
+	}
+	excursions, err := s.tempRepo.GetExcursionsByCargoID(cargo.ID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CargoTemperatureHistory{
+		CargoID:                   cargo.ID,
+		MinTemperature:            cargo.MinTemperature,
+		MaxTemperature:            cargo.MaxTemperature,
+		ExcursionThresholdMinutes: cargo.ExcursionThresholdMinutes,
+		Readings:                  readings,
+		Excursions:                excursions,
+	}, nil
+}
+
+func (s *TemperatureService) GetExcursions(companyID uint, filter models.TemperatureExcursionFilter) ([]models.TemperatureExcursion, int64, error) {
+	return s.tempRepo.GetExcursions(companyID, filter)
+}
+
+// AcknowledgeExcursion records that a moderator has seen an alert; the
+// excursion itself stays open until the temperature recovers.
+func (s *TemperatureService) AcknowledgeExcursion(id uint, companyID uint, userID uint) (*models.TemperatureExcursion, error) {
+	excursion, err := s.tempRepo.GetExcursionByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if excursion.AcknowledgedAt != nil {
+		return nil, errors.New("excursion is already acknowledged")
+	}
+
+	now := time.Now()
+	excursion.AcknowledgedBy = &userID
+	excursion.AcknowledgedAt = &now
+	if err := s.tempRepo.UpdateExcursion(excursion); err != nil {
+		return nil, err
+	}
+
+	return s.tempRepo.GetExcursionByID(id, companyID)
+}
+
+// RenderLog writes the temperature log report of a cargo as PDF.
+func (s *TemperatureService) RenderLog(w io.Writer, cargoID uint, companyID uint) error {
+	cargo, err := s.cargoRepo.GetByID(cargoID, companyID)
+	if err != nil {
+		return err
+	}
+	if cargo.MinTemperature == nil || cargo.MaxTemperature == nil {
+		return errors.New("cargo has no temperature range")
+	}
+
+	history, err := s.GetCargoTemperature(cargo.ID, companyID)
+	if err != nil {
+		return err
+	}
+
+	plates := map[uint]string{}
+	source := func(reading models.TemperatureReading) string {
+		if reading.SensorID != "" {
+			return reading.SensorID
+		}
+		if reading.CargoID != nil || reading.TruckID == nil {
+			return "cargo sensor"
+		}
+		plate, ok := plates[*reading.TruckID]
+		if !ok {
+			if truck, err := s.truckRepo.GetByID(*reading.TruckID, companyID); err == nil {
+				plate = truck.LicensePlate
+			}
+			plates[*reading.TruckID] = plate
+		}
+		return "truck " + plate
+	}
+
+	log := reports.TemperatureLog{
+		Company:          cargo.Company.Name,
+		TrackingNumber:   cargo.TrackingNumber,
+		Title:            cargo.Title,
+		MinTemperature:   *cargo.MinTemperature,
+		MaxTemperature:   *cargo.MaxTemperature,
+		ThresholdMinutes: cargo.ExcursionThresholdMinutes,
+		PickedUpAt:       cargo.ActualPickup,
+		DeliveredAt:      cargo.ActualDelivery,
+		GeneratedAt:      time.Now(),
+	}
+	for _, reading := range history.Readings {
+		log.Readings = append(log.Readings, reports.TemperatureSample{
+			Time:        reading.RecordedAt,
+			Temperature: reading.Temperature,
+			Source:      source(reading),
+		})
+	}
+	for _, excursion := range history.Excursions {
+		log.Excursions = append(log.Excursions, reports.TemperatureExcursion{
+			Direction: string(excursion.Direction),
+			Start:     excursion.StartedAt,
+			End:       excursion.EndedAt,
+			Duration:  excursion.Duration(),
+			Peak:      excursion.PeakTemperature,
+			Alerted:   excursion.AlertedAt != nil,
+		})
+	}
+
+	return reports.RenderTemperatureLog(w, log)
+}
+
+// truckPeriods lists when the cargo rode on which truck: each departed leg of
+// a multi-leg shipment, otherwise its own truck from pickup to delivery.
+func truckPeriods(cargo *models.Cargo) []repositories.TruckPeriod {
+	var periods []repositories.TruckPeriod
+	if len(cargo.ShipmentLegs) > 0 {
+		for _, leg := range cargo.ShipmentLegs {
+			if leg.Status == models.ShipmentLegStatusCancelled || leg.TruckID == nil || leg.DepartedAt == nil {
+				continue
+			}
+			periods = append(periods, repositories.TruckPeriod{TruckID: *leg.TruckID, From: *leg.DepartedAt, To: leg.ArrivedAt})
+		}
+		return periods
+	}
+
+	if cargo.TruckID != nil && cargo.ActualPickup != nil {
+		periods = append(periods, repositories.TruckPeriod{TruckID: *cargo.TruckID, From: *cargo.ActualPickup, To: cargo.ActualDelivery})
+	}
+	return periods
+}
+
+func rangeLimit(excursion *models.TemperatureExcursion) string {
+	if excursion.Direction == models.TemperatureExcursionAbove {
+		return formatCelsius(excursion.MaxTemperature)
+	}
+	return formatCelsius(excursion.MinTemperature)
+}
+
+func formatCelsius(t float64) string {
+	return fmt.Sprintf("%.1f °C", t)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/utils"
+)
+
+var ErrInvalidTrackingNumber = errors.New("invalid tracking number")
+
+var trackingPrefixPattern = regexp.MustCompile(`^[A-Z]{0,8}$`)
+
+type TrackingNumberService struct {
+	trackingRepo *repositories.TrackingNumberRepository
+}
+
+func NewTrackingNumberService(trackingRepo *repositories.TrackingNumberRepository) *TrackingNumberService {
+	return &TrackingNumberService{trackingRepo: trackingRepo}
+}
+
+func (s *TrackingNumberService) GetFormat(companyID uint) (*models.TrackingNumberFormat, error) {
+	return s.trackingRepo.GetFormat(companyID)
+}
+
+func (s *TrackingNumberService) UpdateFormat(companyID uint, req models.UpdateTrackingNumberFormatRequest) (*models.TrackingNumberFormat, error) {
+	format, err := s.trackingRepo.GetFormat(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPrefix := format.Prefix
+	oldCheckDigit := format.CheckDigit
+
+	if req.Prefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*req.Prefix))
+		if !trackingPrefixPattern.MatchString(prefix) {
+			return nil, errors.New("prefix may only contain up to 8 letters")
+		}
+		format.Prefix = prefix
+	}
+	if req.DateFormat != "" {
+		format.DateFormat = req.DateFormat
+	}
+	if req.SequenceLength != 0 {
+		format.SequenceLength = req.SequenceLength
+	}
+	if req.CheckDigit != "" {
+		format.CheckDigit = req.CheckDigit
+	}
+	if req.RequireVerification != nil {
+		format.RequireVerification = *req.RequireVerification
+	}
+
+	// Numbers already handed out keep validating only while their prefix still maps
+	// to the scheme they were issued with.
+	if format.NextValue > 1 && format.CheckDigit != oldCheckDigit && format.Prefix == oldPrefix {
+		return nil, errors.New("check digit can only be changed together with the prefix once tracking numbers have been issued")
+	}
+
+	err = s.trackingRepo.UpdateFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.trackingRepo.GetFormat(companyID)
+}
+
+func (s *TrackingNumberService) Generate(companyID uint) (string, error) {
+	format, sequence, err := s.trackingRepo.NextSequence(companyID)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.FormatTrackingNumber(*format, sequence, time.Now()), nil
+}
+
+// GenerateBatch issues count consecutive tracking numbers with one sequence
+// reservation.
+func (s *TrackingNumberService) GenerateBatch(companyID uint, count int) ([]string, error) {
+	return generateTrackingNumbers(s.trackingRepo, companyID, count)
+}
+
+// generateTrackingNumbers issues count consecutive tracking numbers from the
+// given repository, which may be bound to a unit of work.
+func generateTrackingNumbers(trackingRepo *repositories.TrackingNumberRepository, companyID uint, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	format, first, err := trackingRepo.ReserveSequences(companyID, count)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	numbers := make([]string, count)
+	for i := range numbers {
+		numbers[i] = utils.FormatTrackingNumber(*format, first+int64(i), now)
+	}
+	return numbers, nil
+}
+
+// Validate rejects tracking numbers that are malformed or whose check digit does
+// not match the format that issued them, found by the prefix and company ID in
+// the number. A check digit change requires a new prefix, so the format still
+// describes every number it matches. Numbers matching no format predate
+// configurable formats or their current prefix and are left to the lookup.
+func (s *TrackingNumberService) Validate(trackingNumber string) error {
+	prefix, _, err := utils.SplitTrackingNumber(trackingNumber)
+	if err != nil {
+		return ErrInvalidTrackingNumber
+	}
+
+	formats, err := s.trackingRepo.GetFormatsByPrefix(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		if !utils.MatchesTrackingFormat(format, trackingNumber) {
+			continue
+		}
+		if !utils.VerifyTrackingNumber(format, trackingNumber) {
+			return ErrInvalidTrackingNumber
+		}
+		return nil
+	}
+
+	return nil
+}
@@ -8,10 +8,11 @@ import (
 
 type TruckService struct {
 	truckRepo *repositories.TruckRepository
+	uow       *repositories.UnitOfWork
 }
 
-func NewTruckService(truckRepo *repositories.TruckRepository) *TruckService {
-	return &TruckService{truckRepo: truckRepo}
+func NewTruckService(truckRepo *repositories.TruckRepository, uow *repositories.UnitOfWork) *TruckService {
+	return &TruckService{truckRepo: truckRepo, uow: uow}
 }
 
 func (s *TruckService) CreateTruck(companyID uint, req models.CreateTruckRequest) (*models.Truck, error) {
@@ -25,6 +26,7 @@ func (s *TruckService) CreateTruck(companyID uint, req models.CreateTruckRequest
 		DriverID:     req.DriverID,
 		Status:       models.TruckStatusOffline,
 		IsApproved:   false,
+		HazmatPlacarded: req.HazmatPlacarded,
 	}
 
 	err := s.truckRepo.Create(truck)
@@ -71,6 +73,9 @@ func (s *TruckService) UpdateTruck(id uint, companyID uint, req models.UpdateTru
 		truck.DriverID = req.DriverID
 	}
 	truck.IsApproved = req.IsApproved
+	if req.HazmatPlacarded != nil {
+		truck.HazmatPlacarded = *req.HazmatPlacarded
+	}
 
 	err = s.truckRepo.Update(truck)
 	if err != nil {
@@ -94,6 +99,7 @@ func (s *TruckService) UpdateTruckLocation(truckID uint, companyID uint, req mod
 	// Create location record
 	location := &models.TruckLocation{
 		TruckID:   truck.ID,
+		CompanyID: truck.CompanyID,
 		Latitude:  req.Latitude,
 		Longitude: req.Longitude,
 		Speed:     req.Speed,
@@ -101,18 +107,32 @@ func (s *TruckService) UpdateTruckLocation(truckID uint, companyID uint, req mod
 		Timestamp: time.Now(),
 	}
 
-	err = s.truckRepo.CreateLocation(location)
-	if err != nil {
-		return err
-	}
-
-	// Update truck status to online if it was offline
-	if truck.Status == models.TruckStatusOffline {
-		truck.Status = models.TruckStatusOnline
-		return s.truckRepo.Update(truck)
-	}
-
-	return nil
+	return s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Trucks.CreateLocation(location); err != nil {
+			return err
+		}
+
+		err := tx.Record(companyID, models.DomainEventTruckLocation, "truck", truck.ID, models.WebhookTruckLocation{
+			TruckID:      truck.ID,
+			LicensePlate: truck.LicensePlate,
+			Latitude:     location.Latitude,
+			Longitude:    location.Longitude,
+			Speed:        location.Speed,
+			Heading:      location.Heading,
+			Timestamp:    location.Timestamp,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Update truck status to online if it was offline
+		if truck.Status == models.TruckStatusOffline {
+			truck.Status = models.TruckStatusOnline
+			return tx.Trucks.Update(truck)
+		}
+
+		return nil
+	})
 }
 
 func (s *TruckService) GetOnlineTrucks(companyID uint) ([]models.Truck, error) {
@@ -130,12 +150,21 @@ func (s *TruckService) ApproveTruck(id uint, companyID uint, approvedBy uint) (*
 	now := time.Now()
 	truck.ApprovedAt = &now
 
-	err = s.truckRepo.Update(truck)
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Trucks.Update(truck); err != nil {
+			return err
+		}
+		approved, err := tx.Trucks.GetByID(id, companyID)
+		if err != nil {
+			return err
+		}
+		truck = approved
+		return tx.Record(companyID, models.DomainEventTruckApproved, "truck", id, truck)
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	return s.truckRepo.GetByID(id, companyID)
+	return truck, nil
 }
 
 func (s *TruckService) GetDriverTruck(driverID uint, companyID uint) (*models.Truck, error) {
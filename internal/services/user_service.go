@@ -8,11 +8,12 @@ import (
 )
 
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo     *repositories.UserRepository
+	customerRepo *repositories.CustomerRepository
 }
 
-func NewUserService(userRepo *repositories.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo *repositories.UserRepository, customerRepo *repositories.CustomerRepository) *UserService {
+	return &UserService{userRepo: userRepo, customerRepo: customerRepo}
 }
 
 func (s *UserService) CreateUser(companyID uint, req models.CreateUserRequest) (*models.User, error) {
@@ -37,6 +38,13 @@ func (s *UserService) CreateUser(companyID uint, req models.CreateUserRequest) (
 		CompanyID: &companyID,
 		BranchID:  req.BranchID,
 		TruckID:   req.TruckID,
+		CustomerID: req.CustomerID,
+		HazmatCertificationNumber: req.HazmatCertificationNumber,
+		HazmatCertifiedUntil:      req.HazmatCertifiedUntil,
+	}
+
+	if err := s.checkCustomerAccount(user, companyID); err != nil {
+		return nil, err
 	}
 
 	err = s.userRepo.Create(user)
@@ -86,8 +94,21 @@ func (s *UserService) UpdateUser(id uint, companyID uint, req models.UpdateUserR
 	if req.TruckID != nil {
 		user.TruckID = req.TruckID
 	}
+	if req.HazmatCertificationNumber != "" {
+		user.HazmatCertificationNumber = req.HazmatCertificationNumber
+	}
+	if req.HazmatCertifiedUntil != nil {
+		user.HazmatCertifiedUntil = req.HazmatCertifiedUntil
+	}
+	if req.CustomerID != nil {
+		user.CustomerID = req.CustomerID
+	}
 	user.IsActive = req.IsActive
 
+	if err := s.checkCustomerAccount(user, companyID); err != nil {
+		return nil, err
+	}
+
 	err = s.userRepo.Update(user)
 	if err != nil {
 		return nil, err
@@ -106,6 +127,27 @@ func (s *UserService) DeleteUser(id uint, companyID uint) error {
 	return s.userRepo.Delete(id, companyID)
 }
 
+// checkCustomerAccount makes sure a customer user is linked to an active
+// customer of the company, and that staff users are not linked to one.
+func (s *UserService) checkCustomerAccount(user *models.User, companyID uint) error {
+	if user.Role != models.RoleCustomer {
+		user.CustomerID = nil
+		return nil
+	}
+	if user.CustomerID == nil {
+		return errors.New("customer users need a customer_id")
+	}
+
+	customer, err := s.customerRepo.GetByID(*user.CustomerID, companyID)
+	if err != nil {
+		return errors.New("customer not found")
+	}
+	if !customer.IsActive {
+		return errors.New("customer is inactive")
+	}
+	return nil
+}
+
 func (s *UserService) GetDrivers(companyID uint) ([]models.User, error) {
 	return s.userRepo.GetDrivers(companyID)
 }
@@ -8,23 +8,29 @@ import (
 
 type VisitService struct {
 	visitRepo *repositories.VisitRepository
+	customers *CustomerService
 }
 
-func NewVisitService(visitRepo *repositories.VisitRepository) *VisitService {
-	return &VisitService{visitRepo: visitRepo}
+func NewVisitService(visitRepo *repositories.VisitRepository, customers *CustomerService) *VisitService {
+	return &VisitService{visitRepo: visitRepo, customers: customers}
 }
 
 func (s *VisitService) CreateVisit(companyID uint, req models.CreateVisitRequest) (*models.Visit, error) {
 	visit := &models.Visit{
-		CompanyID:    companyID,
-		TruckID:      req.TruckID,
-		DriverID:     req.DriverID,
-		CustomerName: req.CustomerName,
-		Address:      req.Address,
-		Latitude:     req.Latitude,
-		Longitude:    req.Longitude,
-		Notes:        req.Notes,
-		Status:       models.VisitStatusPending,
+		CompanyID:         companyID,
+		TruckID:           req.TruckID,
+		DriverID:          req.DriverID,
+		CustomerID:        req.CustomerID,
+		CustomerName:      req.CustomerName,
+		CustomerAddressID: req.CustomerAddressID,
+		Address:           req.Address,
+		Latitude:          req.Latitude,
+		Longitude:         req.Longitude,
+		Notes:             req.Notes,
+		Status:            models.VisitStatusPending,
+	}
+	if err := s.customers.fillVisit(companyID, visit); err != nil {
+		return nil, err
 	}
 
 	err := s.visitRepo.Create(visit)
@@ -0,0 +1,522 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+	"truck-management/internal/jobs"
+	"truck-management/internal/models"
+	"truck-management/internal/repositories"
+	"truck-management/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// webhookStatusEvents are the cargo events that change the cargo's status and
+// are also published as cargo.status_changed.
+var webhookStatusEvents = map[string]bool{
+	"status_change":    true,
+	"assigned":         true,
+	"unassigned":       true,
+	"pickup":           true,
+	"delivery":         true,
+	"cancelled":        true,
+	"booking_rejected": true,
+}
+
+// webhookResponseLimit is how much of a successful endpoint's answer a
+// delivery keeps for the delivery log.
+const webhookResponseLimit = 256
+
+// webhookBlockedPrefixes are the address ranges webhooks may not reach
+// besides the loopback, private, link-local and unspecified ones: shared
+// address space, benchmarking, reserved and NAT64 ranges that can lead back
+// into internal networks.
+var webhookBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// webhookDeliveryJob is the payload of a webhook.deliver job.
+type webhookDeliveryJob struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// WebhookService publishes domain events to the webhook endpoints of a
+// company. Publishing stores the event with a delivery per subscribed
+// endpoint and queues a job for each delivery; failed deliveries are retried
+// by the job runner with exponential backoff.
+type WebhookService struct {
+	webhookRepo *repositories.WebhookRepository
+	cargoRepo   *repositories.CargoRepository
+	uow         *repositories.UnitOfWork
+	client      *http.Client
+}
+
+func NewWebhookService(webhookRepo *repositories.WebhookRepository, cargoRepo *repositories.CargoRepository, uow *repositories.UnitOfWork) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		cargoRepo:   cargoRepo,
+		uow:         uow,
+		client:      newWebhookClient(),
+	}
+}
+
+// newWebhookClient returns the client deliveries are sent with. It connects
+// only to public addresses, checked on the address actually dialled, so a
+// host that resolves to an internal address at send time (DNS rebinding) or
+// redirects to one is refused as well. Proxies from the environment are not
+// used, as they would dial on the client's behalf.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(addr) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// isPublicAddr reports whether addr is an address on the internet, as
+// opposed to this host, a private network or a reserved range.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range webhookBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// HandleDomainEvent is the webhook.publish job: it publishes a domain event
+// from the outbox as the matching webhook events. A cargo.event_recorded is
+// published as cargo.event, and also as cargo.status_changed when it moved
+// the cargo's status.
+func (s *WebhookService) HandleDomainEvent(ctx context.Context, job *models.Job) error {
+	var event models.DomainEvent
+	if err := job.Decode(&event); err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case models.DomainEventCargoCreated:
+		cargo, err := s.cargoRepo.FindByID(event.AggregateID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.uow.Do(func(tx *repositories.Tx) error {
+			return s.publish(tx, event.CompanyID, models.WebhookEventCargoCreated, models.NewWebhookCargo(cargo))
+		})
+
+	case models.DomainEventCargoEventRecorded:
+		var recorded models.CargoEventRecorded
+		if err := event.Decode(&recorded); err != nil {
+			return err
+		}
+		cargo, err := s.cargoRepo.FindByID(recorded.CargoID)
+		if err == nil {
+			var cargoEvent *models.CargoEvent
+			cargoEvent, err = s.cargoRepo.GetEventByID(recorded.CargoEventID)
+			if err == nil {
+				return s.publishCargoEvent(event.CompanyID, cargo, cargoEvent)
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+
+	default:
+		return s.uow.Do(func(tx *repositories.Tx) error {
+			return s.publish(tx, event.CompanyID, models.WebhookEventType(event.Type), event.Payload)
+		})
+	}
+}
+
+func (s *WebhookService) publishCargoEvent(companyID uint, cargo *models.Cargo, event *models.CargoEvent) error {
+	data := models.WebhookCargoEvent{
+		Cargo:       models.NewWebhookCargo(cargo),
+		EventType:   event.EventType,
+		Description: event.Description,
+		Location:    event.Location,
+		Latitude:    event.Latitude,
+		Longitude:   event.Longitude,
+		Timestamp:   event.Timestamp,
+	}
+
+	return s.uow.Do(func(tx *repositories.Tx) error {
+		if err := s.publish(tx, companyID, models.WebhookEventCargoEvent, data); err != nil {
+			return err
+		}
+		if webhookStatusEvents[event.EventType] {
+			return s.publish(tx, companyID, models.WebhookEventCargoStatusChanged, data)
+		}
+		return nil
+	})
+}
+
+// publish queues an event for every active endpoint of the company that
+// subscribes to its type. Nothing is stored when no endpoint does.
+func (s *WebhookService) publish(tx *repositories.Tx, companyID uint, eventType models.WebhookEventType, data interface{}) error {
+	endpoints, err := tx.Webhooks.GetActiveEndpoints(companyID)
+	if err != nil {
+		return err
+	}
+	var subscribed []models.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	eventID, err := utils.NewWebhookEventID()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(models.WebhookEnvelope{
+		ID:        eventID,
+		Type:      eventType,
+		CompanyID: companyID,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := tx.Webhooks.CreateEvent(&models.WebhookEvent{
+		CompanyID: companyID,
+		EventID:   eventID,
+		Type:      eventType,
+		Payload:   payload,
+		CreatedAt: now,
+	}, subscribed)
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		if err := enqueueWebhookDelivery(tx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func enqueueWebhookDelivery(tx *repositories.Tx, delivery *models.WebhookDelivery) error {
+	job, err := models.NewJob(&delivery.CompanyID, models.JobTypeWebhookDeliver, webhookDeliveryJob{DeliveryID: delivery.ID})
+	if err != nil {
+		return err
+	}
+	return tx.Jobs.Enqueue(job)
+}
+
+// Deliver is the webhook.deliver job: it sends a delivery once and records the
+// outcome. A failure is returned so the runner retries it, until the job's
+// last attempt marks the delivery failed.
+func (s *WebhookService) Deliver(ctx context.Context, job *models.Job) error {
+	var payload webhookDeliveryJob
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+	delivery, err := s.webhookRepo.FindDelivery(payload.DeliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // the endpoint was deleted
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != models.WebhookDeliveryStatusPending {
+		return nil
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	sendErr := s.send(ctx, delivery, now)
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.NextAttemptAt = nil
+	case job.Attempts >= job.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = sendErr.Error()
+	default:
+		next := now.Add(jobs.RetryDelay(job.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error {
+	if delivery.Endpoint == nil || delivery.Event == nil {
+		return errors.New("endpoint or event no longer exists")
+	}
+	if !delivery.Endpoint.IsActive {
+		return errors.New("endpoint is disabled")
+	}
+
+	body := []byte(delivery.Event.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "truck-management-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.Event.EventID)
+	req.Header.Set("X-Webhook-Event", string(delivery.Event.Type))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", utils.SignWebhook(delivery.Endpoint.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	delivery.ResponseStatus = &status
+	if status < 200 || status > 299 {
+		return fmt.Errorf("endpoint answered %d", status)
+	}
+
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	delivery.ResponseBody = string(answer)
+	return nil
+}
+
+func (s *WebhookService) CreateEndpoint(companyID uint, req models.CreateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := validateWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.NewWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		CompanyID:   companyID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  eventTypes,
+		Secret:      secret,
+		IsActive:    true,
+	}
+	err = s.webhookRepo.CreateEndpoint(endpoint)
+	return endpoint, err
+}
+
+// GetEndpoints lists the company's endpoints without their secrets.
+func (s *WebhookService) GetEndpoints(companyID uint) ([]models.WebhookEndpoint, error) {
+	endpoints, err := s.webhookRepo.GetEndpoints(companyID)
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, err
+}
+
+func (s *WebhookService) GetEndpoint(id uint, companyID uint) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpointByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(id uint, companyID uint, req models.UpdateWebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpointByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		eventTypes, err := validateWebhookEventTypes(req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.EventTypes = eventTypes
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := s.webhookRepo.UpdateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(id uint, companyID uint) error {
+	return s.webhookRepo.DeleteEndpoint(id, companyID)
+}
+
+// RotateSecret gives the endpoint a new signing secret, returned once.
+func (s *WebhookService) RotateSecret(id uint, companyID uint) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpointByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret, err = utils.NewWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.webhookRepo.UpdateEndpoint(endpoint)
+	return endpoint, err
+}
+
+func (s *WebhookService) GetDeliveries(companyID uint, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	return s.webhookRepo.GetDeliveries(companyID, filter)
+}
+
+func (s *WebhookService) GetDelivery(id uint, companyID uint) (*models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveryByID(id, companyID)
+}
+
+// ReplayDelivery sends the event of a delivery to its endpoint again as a new
+// delivery, with the original body and event ID.
+func (s *WebhookService) ReplayDelivery(id uint, companyID uint) (*models.WebhookDelivery, error) {
+	original, err := s.webhookRepo.GetDeliveryByID(id, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookRepo.GetEndpointByID(original.EndpointID, companyID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	replay := &models.WebhookDelivery{
+		CompanyID:     companyID,
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		Status:        models.WebhookDeliveryStatusPending,
+		NextAttemptAt: &now,
+		ReplayOf:      &original.ID,
+	}
+	err = s.uow.Do(func(tx *repositories.Tx) error {
+		if err := tx.Webhooks.CreateDelivery(replay); err != nil {
+			return err
+		}
+		return enqueueWebhookDelivery(tx, replay)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetDeliveryByID(replay.ID, companyID)
+}
+
+// validateWebhookURL accepts absolute http and https URLs whose host
+// resolves to public addresses only. The client checks again on every send,
+// as DNS may change.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("url host %s does not resolve", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return fmt.Errorf("url host %s resolves to %s, which is not a public address", u.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// validateWebhookEventTypes rejects unknown event types and drops duplicates.
+func validateWebhookEventTypes(eventTypes []models.WebhookEventType) ([]models.WebhookEventType, error) {
+	if len(eventTypes) == 0 {
+		return nil, errors.New("subscribe to at least one event type")
+	}
+
+	seen := make(map[models.WebhookEventType]bool)
+	var valid []models.WebhookEventType
+	for _, eventType := range eventTypes {
+		known := false
+		for _, t := range models.WebhookEventTypes {
+			if t == eventType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event type %q", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			valid = append(valid, eventType)
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,68 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, expected .csv or .xlsx")
+
+// RowReader yields one row of cell values per call and io.EOF after the last row.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+type File interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// Open picks a reader from the file name's extension. For workbooks, sheet
+// selects a worksheet by name; an empty sheet means the first one.
+func Open(fileName string, f File, size int64, sheet string) (RowReader, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		return r, nil
+	case ".xlsx":
+		return NewXLSXReader(f, size, sheet)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type Row struct {
+	Number int // 1-based, as shown by spreadsheet applications
+	Cells  []string
+}
+
+// ReadAll drains a reader, skipping rows whose cells are all blank.
+func ReadAll(r RowReader) ([]Row, error) {
+	var rows []Row
+	for number := 1; ; number++ {
+		cells, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isBlank(cells) {
+			rows = append(rows, Row{Number: number, Cells: cells})
+		}
+	}
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
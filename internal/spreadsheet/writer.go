@@ -0,0 +1,34 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// RowWriter writes a header followed by rows. Close must be called to flush
+// buffered output and, for XLSX, to finish the archive.
+type RowWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = FormatValue(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
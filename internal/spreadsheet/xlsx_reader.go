@@ -0,0 +1,280 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// XLSXReader streams the rows of one worksheet of an Office Open XML workbook.
+// Only cell values are read; styles and formulas are ignored.
+type XLSXReader struct {
+	sheet         io.ReadCloser
+	decoder       *xml.Decoder
+	sharedStrings []string
+	nextRow       int
+	pending       []string
+	pendingRow    int
+}
+
+func NewXLSXReader(r io.ReaderAt, size int64, sheetName string) (*XLSXReader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := findSheet(files, sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedStrings, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("worksheet " + sheetPath + " is missing")
+	}
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &XLSXReader{
+		sheet:         sheet,
+		decoder:       xml.NewDecoder(sheet),
+		sharedStrings: sharedStrings,
+		nextRow:       1,
+	}, nil
+}
+
+// Read returns the next row. Rows missing from the sheet XML are returned as
+// empty rows so row numbers stay aligned with what the user sees in Excel.
+func (x *XLSXReader) Read() ([]string, error) {
+	if x.pending != nil {
+		if x.pendingRow > x.nextRow {
+			x.nextRow++
+			return []string{}, nil
+		}
+		row := x.pending
+		x.pending = nil
+		x.nextRow++
+		return row, nil
+	}
+
+	for {
+		tok, err := x.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			x.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		rowNumber := x.nextRow
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "r" {
+				if n, err := strconv.Atoi(attr.Value); err == nil {
+					rowNumber = n
+				}
+			}
+		}
+
+		row, err := x.readRow()
+		if err != nil {
+			return nil, err
+		}
+
+		x.pending = row
+		x.pendingRow = rowNumber
+		return x.Read()
+	}
+}
+
+func (x *XLSXReader) readRow() ([]string, error) {
+	var row []string
+	for {
+		tok, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Local != "c" {
+				continue
+			}
+			var cell xlsxCell
+			if err := x.decoder.DecodeElement(&cell, &el); err != nil {
+				return nil, err
+			}
+
+			col := len(row)
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			row[col] = x.cellValue(cell)
+
+		case xml.EndElement:
+			if el.Name.Local == "row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+func (x *XLSXReader) cellValue(cell xlsxCell) string {
+	switch cell.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || i < 0 || i >= len(x.sharedStrings) {
+			return ""
+		}
+		return x.sharedStrings[i]
+	case "inlineStr":
+		return cell.Inline.text()
+	case "b":
+		if cell.Value == "1" {
+			return "true"
+		}
+		return "false"
+	default:
+		return cell.Value
+	}
+}
+
+type xlsxCell struct {
+	Ref    string     `xml:"r,attr"`
+	Type   string     `xml:"t,attr"`
+	Value  string     `xml:"v"`
+	Inline xlsxString `xml:"is"`
+}
+
+type xlsxString struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s xlsxString) text() string {
+	if len(s.Runs) == 0 {
+		return s.Text
+	}
+	var b strings.Builder
+	for _, run := range s.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var sst struct {
+		Items []xlsxString `xml:"si"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&sst); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		strs[i] = item.text()
+	}
+	return strs, nil
+}
+
+func findSheet(files map[string]*zip.File, name string) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+
+	for _, sheet := range workbook.Sheets {
+		if name != "" && !strings.EqualFold(sheet.Name, name) {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID != sheet.RID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+
+	if name != "" {
+		return "", errors.New("worksheet " + name + " not found")
+	}
+	return "", errors.New("workbook has no worksheets")
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f == nil {
+		return errors.New("not a valid xlsx file: workbook parts missing")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column.
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore persists opaque files under slash-separated keys such as
+// "pod/12/345/signature.png". Content types are tracked by the caller.
+type BlobStore interface {
+	Put(key string, r io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// DeletePrefix deletes every blob under a prefix ending in a slash, such
+	// as "pod/12/", and returns how many it deleted.
+	DeletePrefix(prefix string) (int, error)
+}
+
+// Presigner is implemented by stores that can hand out direct, time-limited
+// download URLs. Stores without it are served through the API instead.
+type Presigner interface {
+	PresignGet(key string, expires time.Duration, fileName string) (string, error)
+}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 	"truck-management/internal/models"
 
@@ -10,27 +9,40 @@ import (
 )
 
 type Claims struct {
-	UserID    uint            `json:"user_id"`
-	CompanyID *uint           `json:"company_id"`
-	Role      models.UserRole `json:"role"`
+	UserID     uint            `json:"user_id"`
+	CompanyID  *uint           `json:"company_id"`
+	Role       models.UserRole `json:"role"`
+	CustomerID *uint           `json:"customer_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+var jwtConfig struct {
+	secret         string
+	previousSecret string
+	ttl            time.Duration
+}
+
+// ConfigureJWT sets the secret tokens are signed with, the secret before the
+// last rotation that is still accepted, and how long tokens last. The server
+// calls it at startup.
+func ConfigureJWT(secret, previousSecret string, ttl time.Duration) {
+	jwtConfig.secret = secret
+	jwtConfig.previousSecret = previousSecret
+	jwtConfig.ttl = ttl
+}
+
 func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "your-super-secret-jwt-key-change-this-in-production"
-	}
-	return secret
+	return jwtConfig.secret
 }
 
 func GenerateJWT(user models.User) (string, error) {
 	claims := Claims{
-		UserID:    user.ID,
-		CompanyID: user.CompanyID,
-		Role:      user.Role,
+		UserID:     user.ID,
+		CompanyID:  user.CompanyID,
+		Role:       user.Role,
+		CustomerID: user.CustomerID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(jwtConfig.ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -39,10 +51,17 @@ func GenerateJWT(user models.User) (string, error) {
 	return token.SignedString([]byte(getJWTSecret()))
 }
 
+// getPreviousJWTSecret returns the secret tokens were signed with before the
+// last rotation. Tokens it signed stay valid until they expire.
+func getPreviousJWTSecret() string {
+	return jwtConfig.previousSecret
+}
+
 func ValidateJWT(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(getJWTSecret()), nil
-	})
+	token, err := parseJWT(tokenString, getJWTSecret())
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) && getPreviousJWTSecret() != "" {
+		token, err = parseJWT(tokenString, getPreviousJWTSecret())
+	}
 
 	if err != nil {
 		return nil, err
@@ -54,3 +73,14 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+func parseJWT(tokenString string, secret string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+}
+
+// NewJWTSecret returns a random secret for signing tokens.
+func NewJWTSecret() (string, error) {
+	return randomHex("", 32)
+}
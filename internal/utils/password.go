@@ -9,4 +9,10 @@ func HashPassword(password string) (string, error) {
 
 func CheckPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-}
\ No newline at end of file
+}
+
+// NewPassword returns a random password for accounts created by operators,
+// to be changed on first use.
+func NewPassword() (string, error) {
+	return randomHex("", 12)
+}
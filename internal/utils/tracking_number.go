@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"truck-management/internal/models"
+)
+
+var ErrMalformedTrackingNumber = errors.New("malformed tracking number")
+
+// trackingCompanyIDWidth is the fixed width of the company ID in a tracking
+// number. Without it company 1 with sequence 23 and company 12 with sequence 3
+// could issue the same number.
+const trackingCompanyIDWidth = 6
+
+func FormatTrackingNumber(format models.TrackingNumberFormat, sequence int64, at time.Time) string {
+	payload := trackingCompanyID(format.CompanyID) +
+		trackingDate(format.DateFormat, at) +
+		fmt.Sprintf("%0*d", format.SequenceLength, sequence)
+
+	return format.Prefix + payload + CheckDigit(format.CheckDigit, payload)
+}
+
+// SplitTrackingNumber separates the letter prefix from the numeric payload.
+// A trailing "X" is kept in the payload since it is a valid mod-11 check digit.
+func SplitTrackingNumber(trackingNumber string) (prefix string, payload string, err error) {
+	trackingNumber = NormalizeTrackingNumber(trackingNumber)
+
+	i := 0
+	for i < len(trackingNumber) && trackingNumber[i] >= 'A' && trackingNumber[i] <= 'Z' {
+		i++
+	}
+	prefix, payload = trackingNumber[:i], trackingNumber[i:]
+	if payload == "" {
+		return "", "", ErrMalformedTrackingNumber
+	}
+
+	for j := 0; j < len(payload); j++ {
+		if payload[j] >= '0' && payload[j] <= '9' {
+			continue
+		}
+		if payload[j] == 'X' && j == len(payload)-1 {
+			continue
+		}
+		return "", "", ErrMalformedTrackingNumber
+	}
+
+	return prefix, payload, nil
+}
+
+func NormalizeTrackingNumber(trackingNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(trackingNumber))
+}
+
+// NewTrackingToken returns a random token that unlocks the details of a single
+// shipment on public tracking.
+func NewTrackingToken() (string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.EncodeToString(b)), nil
+}
+
+// VerifyTrackingToken compares a presented token with the cargo's in constant
+// time. Cargo without a token cannot be unlocked by one.
+func VerifyTrackingToken(expected string, given string) bool {
+	if expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(strings.TrimSpace(given)))) == 1
+}
+
+// NormalizePostcode drops spaces and dashes and upper-cases a postcode, so
+// "sw1a 1aa" matches "SW1A1AA".
+func NormalizePostcode(postcode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(postcode)))
+}
+
+// MatchesTrackingFormat reports whether a tracking number carries the prefix
+// and company ID of the given format, i.e. whether the format issued it.
+func MatchesTrackingFormat(format models.TrackingNumberFormat, trackingNumber string) bool {
+	prefix, payload, err := SplitTrackingNumber(trackingNumber)
+	if err != nil || prefix != format.Prefix {
+		return false
+	}
+	return strings.HasPrefix(payload, trackingCompanyID(format.CompanyID))
+}
+
+// VerifyTrackingNumber reports whether a tracking number was issued with the
+// given format and ends in the check digit its algorithm yields. The date and
+// sequence lengths are not checked since they can change without the prefix.
+func VerifyTrackingNumber(format models.TrackingNumberFormat, trackingNumber string) bool {
+	if !MatchesTrackingFormat(format, trackingNumber) {
+		return false
+	}
+
+	_, payload, _ := SplitTrackingNumber(trackingNumber)
+	if format.CheckDigit == models.TrackingCheckDigitNone || format.CheckDigit == "" {
+		return !strings.HasSuffix(payload, "X")
+	}
+	body, check := payload[:len(payload)-1], payload[len(payload)-1:]
+	return CheckDigit(format.CheckDigit, body) == check
+}
+
+func CheckDigit(algorithm models.TrackingCheckDigit, digits string) string {
+	switch algorithm {
+	case models.TrackingCheckDigitLuhn:
+		return strconv.Itoa(luhnCheckDigit(digits))
+	case models.TrackingCheckDigitMod11:
+		return mod11CheckDigit(digits)
+	default:
+		return ""
+	}
+}
+
+func luhnCheckDigit(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// mod11CheckDigit uses weights 2..7 repeating from the rightmost digit.
+// A remainder of 10 is written as "X".
+func mod11CheckDigit(digits string) string {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 7 {
+			weight = 2
+		}
+	}
+
+	switch r := 11 - sum%11; r {
+	case 11:
+		return "0"
+	case 10:
+		return "X"
+	default:
+		return strconv.Itoa(r)
+	}
+}
+
+func trackingCompanyID(companyID uint) string {
+	return fmt.Sprintf("%0*d", trackingCompanyIDWidth, companyID)
+}
+
+func trackingDate(format models.TrackingDateFormat, at time.Time) string {
+	switch format {
+	case models.TrackingDateFormatYYMM:
+		return at.Format("0601")
+	case models.TrackingDateFormatYYMMDD:
+		return at.Format("060102")
+	case models.TrackingDateFormatYYYYMMDD:
+		return at.Format("20060102")
+	default:
+		return ""
+	}
+}
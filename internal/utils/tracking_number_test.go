@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"truck-management/internal/models"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		algorithm models.TrackingCheckDigit
+		digits    string
+		want      string
+	}{
+		{models.TrackingCheckDigitLuhn, "7992739871", "3"},
+		{models.TrackingCheckDigitLuhn, "411111111111111", "1"},
+		{models.TrackingCheckDigitLuhn, "0", "0"},
+		{models.TrackingCheckDigitMod11, "261533", "9"},
+		{models.TrackingCheckDigitMod11, "123456789", "2"},
+		{models.TrackingCheckDigitMod11, "6", "X"},
+		{models.TrackingCheckDigitMod11, "14", "0"},
+		{models.TrackingCheckDigitNone, "123456", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CheckDigit(tt.algorithm, tt.digits); got != tt.want {
+			t.Errorf("CheckDigit(%s, %q) = %q, want %q", tt.algorithm, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTrackingNumber(t *testing.T) {
+	at := time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format models.TrackingNumberFormat
+	}{
+		{"none", models.TrackingNumberFormat{CompanyID: 42, Prefix: "TRK", DateFormat: models.TrackingDateFormatYYMM, SequenceLength: 6, CheckDigit: models.TrackingCheckDigitNone}},
+		{"luhn", models.TrackingNumberFormat{CompanyID: 42, Prefix: "TRK", DateFormat: models.TrackingDateFormatYYMMDD, SequenceLength: 6, CheckDigit: models.TrackingCheckDigitLuhn}},
+		{"mod11", models.TrackingNumberFormat{CompanyID: 7, Prefix: "DL", DateFormat: models.TrackingDateFormatNone, SequenceLength: 8, CheckDigit: models.TrackingCheckDigitMod11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for sequence := int64(1); sequence <= 200; sequence++ {
+				number := FormatTrackingNumber(tt.format, sequence, at)
+				if !VerifyTrackingNumber(tt.format, number) {
+					t.Fatalf("VerifyTrackingNumber(%q) = false for a generated number", number)
+				}
+				if !VerifyTrackingNumber(tt.format, " "+strings.ToLower(number)+" ") {
+					t.Fatalf("VerifyTrackingNumber(%q) = false after normalisation", number)
+				}
+			}
+
+			other := tt.format
+			other.CompanyID++
+			if VerifyTrackingNumber(other, FormatTrackingNumber(tt.format, 1, at)) {
+				t.Error("number verified against another company's format")
+			}
+		})
+	}
+}
+
+func TestVerifyTrackingNumberRejectsTypo(t *testing.T) {
+	at := time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format models.TrackingNumberFormat
+	}{
+		{"luhn", models.TrackingNumberFormat{CompanyID: 42, Prefix: "TRK", DateFormat: models.TrackingDateFormatYYMMDD, SequenceLength: 6, CheckDigit: models.TrackingCheckDigitLuhn}},
+		{"mod11", models.TrackingNumberFormat{CompanyID: 7, Prefix: "DL", DateFormat: models.TrackingDateFormatNone, SequenceLength: 8, CheckDigit: models.TrackingCheckDigitMod11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number := FormatTrackingNumber(tt.format, 123456, at)
+
+			// Every single-digit substitution after the company ID, the
+			// check digit included, must be caught.
+			for i := len(tt.format.Prefix) + trackingCompanyIDWidth; i < len(number); i++ {
+				for d := byte('0'); d <= '9'; d++ {
+					if number[i] == d {
+						continue
+					}
+					typo := number[:i] + string(d) + number[i+1:]
+					if VerifyTrackingNumber(tt.format, typo) {
+						t.Errorf("VerifyTrackingNumber(%q) = true for a typo of %q", typo, number)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// NewWebhookSecret returns a random signing secret for a webhook endpoint.
+func NewWebhookSecret() (string, error) {
+	return randomHex("whsec_", 32)
+}
+
+// NewWebhookEventID returns a random identifier receivers can use to
+// deduplicate deliveries of the same event.
+func NewWebhookEventID() (string, error) {
+	return randomHex("evt_", 16)
+}
+
+// SignWebhook returns the X-Webhook-Signature value of a delivery: the
+// hex HMAC-SHA256 of "<timestamp>.<body>" under the endpoint's secret.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
@@ -24,7 +24,7 @@ type Client struct {
 
 func (c *Client) ReadPump() {
 	defer func() {
-		c.Hub.unregister <- c
+		c.Hub.unregisterClient(c)
 		c.Conn.Close()
 	}()
 
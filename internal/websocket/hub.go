@@ -1,9 +1,18 @@
 package websocket
 
 import (
+	"context"
 	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
+// CloseReconnect is the close code clients get when the server shuts down:
+// 1012 Service Restart, telling them to reconnect, which reaches another
+// instance or this one once it is back.
+const CloseReconnect = websocket.CloseServiceRestart
+
 type Message struct {
 	Type string      `json:"type"`
 	Data interface{} `json:"data"`
@@ -14,6 +23,8 @@ type Hub struct {
 	broadcast  chan Message
 	register   chan *Client
 	unregister chan *Client
+	stop       chan struct{}
+	done       chan struct{}
 }
 
 func NewHub() *Hub {
@@ -22,11 +33,51 @@ func NewHub() *Hub {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
 
+// Register adds a client. Once the hub has shut down, the client is closed
+// with CloseReconnect instead.
 func (h *Hub) Register(client *Client) {
-	h.register <- client
+	select {
+	case h.register <- client:
+	case <-h.done:
+		closeReconnect(client.Conn)
+		client.Conn.Close()
+	}
+}
+
+func (h *Hub) unregisterClient(client *Client) {
+	select {
+	case h.unregister <- client:
+	case <-h.done:
+	}
+}
+
+// Shutdown sends every client a close frame with CloseReconnect and stops
+// the hub. It returns early with ctx's error if ctx is done first.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	select {
+	case h.stop <- struct{}{}:
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func closeReconnect(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(CloseReconnect, "server shutting down, reconnect")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeWait))
 }
 
 func (h *Hub) Run() {
@@ -43,6 +94,16 @@ func (h *Hub) Run() {
 				log.Printf("Client disconnected. Total clients: %d", len(h.clients))
 			}
 
+		case <-h.stop:
+			for client := range h.clients {
+				closeReconnect(client.Conn)
+				delete(h.clients, client)
+				close(client.Send)
+			}
+			log.Printf("Closed all WebSocket clients for shutdown")
+			close(h.done)
+			return
+
 		case message := <-h.broadcast:
 			for client := range h.clients {
 				select {
@@ -1,18 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 	"truck-management/config"
 	"truck-management/docs"
 	"truck-management/internal/handlers"
+	"truck-management/internal/jobs"
 	"truck-management/internal/middleware"
+	"truck-management/internal/migrations"
+	"truck-management/internal/models"
 	"truck-management/internal/repositories"
+	"truck-management/internal/scheduler"
 	"truck-management/internal/services"
+	"truck-management/internal/utils"
 	"truck-management/internal/websocket"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
 // @title Truck Management API
@@ -36,27 +52,44 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	if err := runCommand(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runServe runs the serve command: it starts the HTTP server with the
+// background job runner and scheduler.
+func runServe(args []string) error {
+	cfg, err := parseFlags(flag.NewFlagSet("serve", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if cfg.JWT.IsDevelopmentDefault() {
+		log.Println("JWT_SECRET is not set, signing tokens with the development secret")
+	}
+	utils.ConfigureJWT(cfg.JWT.Secret, cfg.JWT.PreviousSecret, cfg.JWT.TTL)
+	utils.ConfigureURLSigning(cfg.URLSigningSecret)
+	if cfg.Env == config.EnvProduction {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
 	// Initialize database
-	db := config.InitDatabase()
-
-	// Auto-migrate models
-	/*err := db.AutoMigrate(
-		&models.User{},
-		&models.Company{},
-		&models.Branch{},
-		&models.Truck{},
-		&models.TruckLocation{},
-		&models.Visit{},
-		&models.Task{},
-		&models.Request{},
-		&models.Route{},
-		&models.RouteStop{},
-		&models.Cargo{},
-		&models.CargoEvent{},
-	)
+	db := config.InitDatabase(cfg)
+
+	// Initialize blob storage
+	blobStore := config.InitStorage(cfg.Storage)
+
+	// Initialize notification providers
+	emailSender, smsSender := config.InitNotifiers(cfg.Notify)
+
+	// Refuse to run against a database that lacks migrations
+	migrator, err := migrations.New(db)
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
-	}*/
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		return fmt.Errorf("refusing to start: %w (run \"migrate up\" first)", err)
+	}
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
@@ -68,23 +101,107 @@ func main() {
 	requestRepo := repositories.NewRequestRepository(db)
 	routeRepo := repositories.NewRouteRepository(db)
 	cargoRepo := repositories.NewCargoRepository(db)
+	trackingNumberRepo := repositories.NewTrackingNumberRepository(db)
+	podRepo := repositories.NewProofOfDeliveryRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db)
+	shipmentLegRepo := repositories.NewShipmentLegRepository(db)
+	cargoImportRepo := repositories.NewCargoImportRepository(db)
+	cargoPieceRepo := repositories.NewCargoPieceRepository(db)
+	temperatureRepo := repositories.NewTemperatureRepository(db)
+	pricingRepo := repositories.NewPricingRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	customerRepo := repositories.NewCustomerRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	scheduledJobRepo := repositories.NewScheduledJobRepository(db)
+	locationRetentionRepo := repositories.NewLocationRetentionRepository(db)
+	uow := repositories.NewUnitOfWork(db)
 
 	// Initialize services
+	webhookService := services.NewWebhookService(webhookRepo, cargoRepo, uow)
 	authService := services.NewAuthService(userRepo)
 	companyService := services.NewCompanyService(companyRepo)
 	branchService := services.NewBranchService(branchRepo)
-	userService := services.NewUserService(userRepo)
-	truckService := services.NewTruckService(truckRepo)
-	visitService := services.NewVisitService(visitRepo)
+	userService := services.NewUserService(userRepo, customerRepo)
+	truckService := services.NewTruckService(truckRepo, uow)
+	customerService := services.NewCustomerService(customerRepo)
+	visitService := services.NewVisitService(visitRepo, customerService)
 	taskService := services.NewTaskService(taskRepo)
-	requestService := services.NewRequestService(requestRepo)
-	routeService := services.NewRouteService(routeRepo, truckRepo)
-	cargoService := services.NewCargoService(cargoRepo, truckRepo)
+	requestService := services.NewRequestService(requestRepo, uow)
+	routeService := services.NewRouteService(routeRepo, truckRepo, cargoRepo, customerService, uow)
+	trackingNumberService := services.NewTrackingNumberService(trackingNumberRepo)
+	pricingService := services.NewPricingService(pricingRepo)
+	invoiceService := services.NewInvoiceService(invoiceRepo)
+	cargoService := services.NewCargoService(cargoRepo, truckRepo, podRepo, shipmentLegRepo, cargoPieceRepo, temperatureRepo, trackingNumberService, pricingService, customerService, uow)
+	shipmentLegService := services.NewShipmentLegService(shipmentLegRepo, cargoRepo, truckRepo, routeRepo, branchRepo)
+	cargoPieceService := services.NewCargoPieceService(cargoPieceRepo, cargoRepo, shipmentLegRepo)
+	scanService := services.NewScanService(cargoRepo, cargoPieceRepo, truckRepo, shipmentLegRepo, cargoService, shipmentLegService, cargoPieceService)
+	temperatureService := services.NewTemperatureService(temperatureRepo, cargoRepo, truckRepo)
+	labelService := services.NewLabelService(cargoRepo, routeRepo)
+	hazmatService := services.NewHazmatService(cargoRepo, routeRepo)
+	exportService := services.NewExportService(cargoRepo, truckRepo, routeRepo, visitRepo, invoiceRepo)
+	cargoImportService := services.NewCargoImportService(cargoImportRepo, cargoService, uow, blobStore)
+	podService := services.NewProofOfDeliveryService(podRepo, cargoRepo, cargoService, temperatureService, uow, blobStore)
+	portalService := services.NewPortalService(cargoRepo, customerRepo, cargoService, pricingService, invoiceService, podService)
+	notificationService := services.NewNotificationService(notificationRepo, cargoRepo, emailSender, smsSender)
+	maxAttachmentSize, attachmentQuota := cfg.Storage.AttachmentLimits()
+	attachmentService := services.NewAttachmentService(attachmentRepo, blobStore, maxAttachmentSize, attachmentQuota)
+	jobService := services.NewJobService(jobRepo)
+	scheduledJobService := services.NewScheduledJobService(scheduledJobRepo)
+	locationRetentionService := services.NewLocationRetentionService(locationRetentionRepo, blobStore)
 
 	// Initialize WebSocket hub
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
+	// Background workers run until shutdown, which waits for them
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
+	// Start background jobs: every domain event is published to webhooks, and
+	// recorded cargo events may notify the consignee
+	runner := jobs.NewRunner(jobRepo, outboxRepo, cfg.Jobs.Workers)
+	runner.Handle(models.JobTypeWebhookPublish, webhookService.HandleDomainEvent)
+	runner.Handle(models.JobTypeWebhookDeliver, webhookService.Deliver)
+	runner.Handle(models.JobTypeNotifyCargoEvent, notificationService.HandleDomainEvent)
+	runner.Handle(models.JobTypeCargoImport, cargoImportService.ProcessImport)
+	for _, eventType := range []string{
+		models.DomainEventCargoCreated,
+		models.DomainEventCargoEventRecorded,
+		models.DomainEventRouteApproved,
+		models.DomainEventTruckApproved,
+		models.DomainEventTruckLocation,
+		models.DomainEventRequestCreated,
+		models.DomainEventRequestAccepted,
+		models.DomainEventRequestTerminated,
+	} {
+		runner.Subscribe(eventType, models.JobTypeWebhookPublish)
+	}
+	runner.Subscribe(models.DomainEventCargoEventRecorded, models.JobTypeNotifyCargoEvent)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runner.Run(workerCtx)
+	}()
+
+	// Start the scheduler of recurring system jobs
+	if cfg.Jobs.SchedulerEnabled {
+		jobScheduler := scheduler.New(db, scheduledJobRepo)
+		jobScheduler.Register("truck_locations_retention", "0 2 * * *", "Downsample and archive truck locations by each company's retention policy", locationRetentionService.Apply)
+		jobScheduler.Register("scheduled_job_runs_prune", "30 3 * * *", "Delete scheduled job history older than 90 days", func(ctx context.Context) error {
+			_, err := scheduledJobRepo.DeleteRunsBefore(time.Now().AddDate(0, 0, -90))
+			return err
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			jobScheduler.Run(workerCtx)
+		}()
+	}
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	companyHandler := handlers.NewCompanyHandler(companyService)
@@ -96,21 +213,51 @@ func main() {
 	requestHandler := handlers.NewRequestHandler(requestService)
 	routeHandler := handlers.NewRouteHandler(routeService)
 	cargoHandler := handlers.NewCargoHandler(cargoService, wsHub)
+	shipmentLegHandler := handlers.NewShipmentLegHandler(shipmentLegService, wsHub)
+	cargoPieceHandler := handlers.NewCargoPieceHandler(cargoPieceService, wsHub)
+	scanHandler := handlers.NewScanHandler(scanService, wsHub)
+	temperatureHandler := handlers.NewTemperatureHandler(temperatureService, wsHub)
+	labelHandler := handlers.NewLabelHandler(labelService)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	hazmatHandler := handlers.NewHazmatHandler(hazmatService)
+	exportHandler := handlers.NewExportHandler(exportService, cfg.Server.WriteTimeout)
+	cargoImportHandler := handlers.NewCargoImportHandler(cargoImportService)
+	trackingNumberHandler := handlers.NewTrackingNumberHandler(trackingNumberService)
+	podHandler := handlers.NewProofOfDeliveryHandler(podService, wsHub)
+	portalHandler := handlers.NewPortalHandler(portalService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	jobHandler := handlers.NewJobHandler(jobService)
+	scheduledJobHandler := handlers.NewScheduledJobHandler(scheduledJobService)
+	locationRetentionHandler := handlers.NewLocationRetentionHandler(locationRetentionService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
 	wsHandler := handlers.NewWebSocketHandler(wsHub)
+	healthHandler := handlers.NewHealthHandler(db)
 
 	// Setup router
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
 
 	// Swagger docs
-	docs.SwaggerInfo.BasePath = "/api/v1"
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	if cfg.Features.Swagger {
+		docs.SwaggerInfo.BasePath = "/api/v1"
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+	}
 
 	// CORS middleware
-	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.CORSMiddleware(cfg.CORS.AllowedOrigins))
 
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
+		// Health checks for load balancers and orchestrators
+		v1.GET("/health/live", healthHandler.GetLiveness)
+		v1.GET("/health/ready", healthHandler.GetReadiness)
+
 		// Auth routes
 		auth := v1.Group("/auth")
 		{
@@ -120,7 +267,7 @@ func main() {
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.StaffMiddleware())
 		{
 			// Company routes (Admin only)
 			companies := protected.Group("/companies")
@@ -166,6 +313,7 @@ func main() {
 				// Admin can create trucks
 				trucks.POST("", middleware.AdminMiddleware(), truckHandler.CreateTruck)
 				trucks.GET("", truckHandler.GetTrucks) // Drivers see only their truck
+				trucks.GET("/export", middleware.ModeratorMiddleware(), exportHandler.ExportTrucks)
 				trucks.GET("/:id", truckHandler.GetTruck)
 				trucks.PUT("/:id", middleware.AdminMiddleware(), truckHandler.UpdateTruck)
 				trucks.DELETE("/:id", middleware.AdminMiddleware(), truckHandler.DeleteTruck)
@@ -181,12 +329,15 @@ func main() {
 			{
 				routes.POST("", middleware.ModeratorMiddleware(), routeHandler.CreateRoute)
 				routes.GET("", routeHandler.GetRoutes) // Drivers see only their routes
+				routes.GET("/export", middleware.ModeratorMiddleware(), exportHandler.ExportRoutes)
 				routes.GET("/:id", routeHandler.GetRoute)
 				routes.PUT("/:id", middleware.ModeratorMiddleware(), routeHandler.UpdateRoute)
 				routes.PUT("/:id/approve", middleware.ModeratorMiddleware(), routeHandler.ApproveRoute)
 				routes.DELETE("/:id", middleware.ModeratorMiddleware(), routeHandler.DeleteRoute)
 				routes.POST("/:id/stops", middleware.ModeratorMiddleware(), routeHandler.CreateRouteStop)
 				routes.GET("/:id/stops", routeHandler.GetRouteStops)
+				routes.GET("/:id/labels", labelHandler.GetRouteLabels)
+				routes.GET("/:id/dangerous-goods-manifest", hazmatHandler.GetRouteDangerousGoodsManifest)
 			}
 
 			// Route stop completion (Driver only)
@@ -198,6 +349,7 @@ func main() {
 			{
 				visits.POST("", middleware.ModeratorMiddleware(), visitHandler.CreateVisit)
 				visits.GET("", visitHandler.GetVisits)
+				visits.GET("/export", middleware.ModeratorMiddleware(), exportHandler.ExportVisits)
 				visits.GET("/:id", visitHandler.GetVisit)
 				visits.PUT("/:id", middleware.ModeratorMiddleware(), visitHandler.UpdateVisit)
 			}
@@ -229,16 +381,206 @@ func main() {
 			{
 				cargo.POST("", middleware.ModeratorMiddleware(), cargoHandler.CreateCargo)
 				cargo.GET("", cargoHandler.GetCargos)
+				cargo.GET("/export", middleware.ModeratorMiddleware(), exportHandler.ExportCargo)
 				cargo.GET("/unassigned", middleware.ModeratorMiddleware(), cargoHandler.GetUnassignedCargos)
+				cargo.POST("/scan", middleware.DriverMiddleware(), scanHandler.ScanCargo)
+				cargo.POST("/import", middleware.ModeratorMiddleware(), cargoImportHandler.ImportCargo)
+				cargo.GET("/imports", middleware.ModeratorMiddleware(), cargoImportHandler.GetCargoImports)
+				cargo.GET("/imports/:id", middleware.ModeratorMiddleware(), cargoImportHandler.GetCargoImport)
+				cargo.GET("/tracking-format", middleware.AdminMiddleware(), trackingNumberHandler.GetTrackingNumberFormat)
+				cargo.PUT("/tracking-format", middleware.AdminMiddleware(), trackingNumberHandler.UpdateTrackingNumberFormat)
 				cargo.GET("/:id", cargoHandler.GetCargo)
 				cargo.PUT("/:id", middleware.ModeratorMiddleware(), cargoHandler.UpdateCargo)
 				cargo.DELETE("/:id", middleware.ModeratorMiddleware(), cargoHandler.DeleteCargo)
 				cargo.POST("/:id/assign", middleware.ModeratorMiddleware(), cargoHandler.AssignCargoToTruck)
 				cargo.POST("/:id/unassign", middleware.ModeratorMiddleware(), cargoHandler.UnassignCargoFromTruck)
+				cargo.POST("/:id/approve", middleware.ModeratorMiddleware(), cargoHandler.ApproveCargoBooking)
+				cargo.POST("/:id/reject", middleware.ModeratorMiddleware(), cargoHandler.RejectCargoBooking)
+				cargo.POST("/:id/tracking-token", middleware.ModeratorMiddleware(), cargoHandler.RotateCargoTrackingToken)
 				cargo.POST("/:id/events", middleware.DriverMiddleware(), cargoHandler.CreateCargoEvent)
 				cargo.GET("/:id/events", cargoHandler.GetCargoEvents)
 				cargo.POST("/:id/location", middleware.DriverMiddleware(), cargoHandler.UpdateCargoLocation)
 				cargo.GET("/:id/route", cargoHandler.GetCargoRoute)
+				cargo.GET("/:id/legs", shipmentLegHandler.GetShipmentLegs)
+				cargo.POST("/:id/legs", middleware.ModeratorMiddleware(), shipmentLegHandler.CreateShipmentLeg)
+				cargo.PUT("/:id/legs/:leg_id", middleware.ModeratorMiddleware(), shipmentLegHandler.UpdateShipmentLeg)
+				cargo.DELETE("/:id/legs/:leg_id", middleware.ModeratorMiddleware(), shipmentLegHandler.DeleteShipmentLeg)
+				cargo.POST("/:id/legs/:leg_id/depart", middleware.DriverMiddleware(), shipmentLegHandler.DepartShipmentLeg)
+				cargo.POST("/:id/legs/:leg_id/arrive", middleware.DriverMiddleware(), shipmentLegHandler.ArriveShipmentLeg)
+				cargo.POST("/:id/legs/:leg_id/handover", middleware.DriverMiddleware(), shipmentLegHandler.CreateHandover)
+				cargo.GET("/:id/handovers", shipmentLegHandler.GetHandovers)
+				cargo.GET("/:id/label", labelHandler.GetCargoLabel)
+				cargo.GET("/:id/pieces", cargoPieceHandler.GetCargoPieces)
+				cargo.GET("/:id/pieces/labels", labelHandler.GetCargoPieceLabels)
+				cargo.POST("/:id/pieces", middleware.ModeratorMiddleware(), cargoPieceHandler.CreateCargoPieces)
+				cargo.DELETE("/:id/pieces/:piece_id", middleware.ModeratorMiddleware(), cargoPieceHandler.DeleteCargoPiece)
+				cargo.POST("/:id/split", middleware.ModeratorMiddleware(), cargoPieceHandler.SplitCargo)
+				cargo.POST("/:id/pod", middleware.DriverMiddleware(), podHandler.CaptureProofOfDelivery)
+				cargo.GET("/:id/pod", podHandler.GetProofOfDelivery)
+				cargo.GET("/:id/pod/signature", podHandler.GetProofOfDeliverySignature)
+				cargo.GET("/:id/pod/photos/:photo_id", podHandler.GetProofOfDeliveryPhoto)
+				cargo.GET("/:id/pod/temperature-log", podHandler.GetProofOfDeliveryTemperatureLog)
+				cargo.GET("/:id/temperature", temperatureHandler.GetCargoTemperature)
+				cargo.GET("/:id/temperature/log", temperatureHandler.GetCargoTemperatureLog)
+			}
+
+			// Piece scan routes
+			pieces := protected.Group("/pieces")
+			pieces.Use(middleware.TenantMiddleware())
+			{
+				pieces.POST("/scan", middleware.DriverMiddleware(), cargoPieceHandler.ScanPiece)
+			}
+
+			// Temperature telemetry routes
+			telemetry := protected.Group("/telemetry")
+			telemetry.Use(middleware.TenantMiddleware())
+			{
+				telemetry.POST("/temperature", temperatureHandler.RecordTemperatureReadings)
+			}
+
+			// Temperature excursion routes
+			temperature := protected.Group("/temperature")
+			temperature.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				temperature.GET("/excursions", temperatureHandler.GetTemperatureExcursions)
+				temperature.POST("/excursions/:id/acknowledge", temperatureHandler.AcknowledgeTemperatureExcursion)
+			}
+
+			// Customer routes
+			customers := protected.Group("/customers")
+			customers.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				customers.POST("", customerHandler.CreateCustomer)
+				customers.GET("", customerHandler.GetCustomers)
+				customers.GET("/addresses", customerHandler.SearchAddresses)
+				customers.GET("/:id", customerHandler.GetCustomer)
+				customers.PUT("/:id", customerHandler.UpdateCustomer)
+				customers.DELETE("/:id", middleware.AdminMiddleware(), customerHandler.DeleteCustomer)
+				customers.POST("/:id/contacts", customerHandler.AddContact)
+				customers.PUT("/:id/contacts/:contact_id", customerHandler.UpdateContact)
+				customers.DELETE("/:id/contacts/:contact_id", customerHandler.DeleteContact)
+				customers.POST("/:id/addresses", customerHandler.AddAddress)
+				customers.PUT("/:id/addresses/:address_id", customerHandler.UpdateAddress)
+				customers.DELETE("/:id/addresses/:address_id", customerHandler.DeleteAddress)
+			}
+
+			// Pricing routes
+			pricing := protected.Group("/pricing")
+			pricing.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				pricing.POST("/quote", pricingHandler.QuoteFreight)
+				pricing.GET("/settings", middleware.AdminMiddleware(), pricingHandler.GetPricingSettings)
+				pricing.PUT("/settings", middleware.AdminMiddleware(), pricingHandler.UpdatePricingSettings)
+				pricing.GET("/rates", pricingHandler.GetFreightRates)
+				pricing.POST("/rates", middleware.AdminMiddleware(), pricingHandler.CreateFreightRate)
+				pricing.PUT("/rates/:id", middleware.AdminMiddleware(), pricingHandler.UpdateFreightRate)
+				pricing.DELETE("/rates/:id", middleware.AdminMiddleware(), pricingHandler.DeleteFreightRate)
+			}
+
+			// Invoice routes
+			invoices := protected.Group("/invoices")
+			invoices.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				invoices.POST("/generate", invoiceHandler.GenerateInvoices)
+				invoices.GET("", invoiceHandler.GetInvoices)
+				invoices.GET("/export", exportHandler.ExportInvoices)
+				invoices.GET("/settings", middleware.AdminMiddleware(), invoiceHandler.GetInvoiceSettings)
+				invoices.PUT("/settings", middleware.AdminMiddleware(), invoiceHandler.UpdateInvoiceSettings)
+				invoices.GET("/:id", invoiceHandler.GetInvoice)
+				invoices.GET("/:id/pdf", invoiceHandler.GetInvoicePDF)
+				invoices.POST("/:id/issue", invoiceHandler.IssueInvoice)
+				invoices.POST("/:id/pay", invoiceHandler.MarkInvoicePaid)
+				invoices.POST("/:id/void", invoiceHandler.VoidInvoice)
+				invoices.POST("/:id/credit-notes", invoiceHandler.CreateCreditNote)
+			}
+
+			// Consignee notification routes
+			notifications := protected.Group("/notifications")
+			notifications.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				notifications.GET("/settings", middleware.AdminMiddleware(), notificationHandler.GetNotificationSettings)
+				notifications.PUT("/settings", middleware.AdminMiddleware(), notificationHandler.UpdateNotificationSettings)
+				notifications.GET("/templates", middleware.AdminMiddleware(), notificationHandler.GetNotificationTemplates)
+				notifications.PUT("/templates", middleware.AdminMiddleware(), notificationHandler.SaveNotificationTemplate)
+				notifications.DELETE("/templates/:id", middleware.AdminMiddleware(), notificationHandler.DeleteNotificationTemplate)
+				notifications.GET("/opt-outs", notificationHandler.GetNotificationOptOuts)
+				notifications.POST("/opt-outs", notificationHandler.CreateNotificationOptOut)
+				notifications.DELETE("/opt-outs/:id", notificationHandler.DeleteNotificationOptOut)
+				notifications.GET("/log", notificationHandler.GetNotificationLog)
+			}
+
+			// Webhook routes
+			webhooks := protected.Group("/webhooks")
+			webhooks.Use(middleware.TenantMiddleware(), middleware.AdminMiddleware())
+			{
+				webhooks.GET("/event-types", webhookHandler.GetWebhookEventTypes)
+				webhooks.POST("/endpoints", webhookHandler.CreateWebhookEndpoint)
+				webhooks.GET("/endpoints", webhookHandler.GetWebhookEndpoints)
+				webhooks.GET("/endpoints/:id", webhookHandler.GetWebhookEndpoint)
+				webhooks.PUT("/endpoints/:id", webhookHandler.UpdateWebhookEndpoint)
+				webhooks.DELETE("/endpoints/:id", webhookHandler.DeleteWebhookEndpoint)
+				webhooks.POST("/endpoints/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+				webhooks.GET("/deliveries", webhookHandler.GetWebhookDeliveries)
+				webhooks.GET("/deliveries/:id", webhookHandler.GetWebhookDelivery)
+				webhooks.POST("/deliveries/:id/replay", webhookHandler.ReplayWebhookDelivery)
+			}
+
+			// Background job routes
+			jobRoutes := protected.Group("/jobs")
+			jobRoutes.Use(middleware.TenantMiddleware(), middleware.AdminMiddleware())
+			{
+				jobRoutes.GET("", jobHandler.GetJobs)
+				jobRoutes.GET("/:id", jobHandler.GetJob)
+				jobRoutes.POST("/:id/retry", jobHandler.RetryJob)
+			}
+
+			// Scheduled jobs span every tenant, so they are for platform operators
+			scheduledJobs := protected.Group("/scheduled-jobs")
+			scheduledJobs.Use(middleware.OperatorMiddleware(cfg.OperatorUserIDs))
+			{
+				scheduledJobs.GET("", scheduledJobHandler.GetScheduledJobs)
+				scheduledJobs.GET("/:name", scheduledJobHandler.GetScheduledJob)
+				scheduledJobs.GET("/:name/runs", scheduledJobHandler.GetScheduledJobRuns)
+				scheduledJobs.POST("/:name/trigger", scheduledJobHandler.TriggerScheduledJob)
+				scheduledJobs.POST("/:name/pause", scheduledJobHandler.PauseScheduledJob)
+				scheduledJobs.POST("/:name/resume", scheduledJobHandler.ResumeScheduledJob)
+			}
+
+			// Location retention routes (Admin only)
+			locationRetention := protected.Group("/location-retention")
+			locationRetention.Use(middleware.AdminMiddleware())
+			{
+				locationRetention.GET("/policy", middleware.TenantMiddleware(), locationRetentionHandler.GetLocationRetentionPolicy)
+				locationRetention.PUT("/policy", middleware.TenantMiddleware(), locationRetentionHandler.UpdateLocationRetentionPolicy)
+				locationRetention.GET("/archives", middleware.TenantMiddleware(), locationRetentionHandler.GetLocationArchives)
+				locationRetention.GET("/archives/:id/download", middleware.TenantMiddleware(), locationRetentionHandler.DownloadLocationArchive)
+			}
+			// Storage spans every tenant, so it is for platform operators
+			protected.GET("/location-retention/storage", middleware.OperatorMiddleware(cfg.OperatorUserIDs), locationRetentionHandler.GetLocationStorage)
+
+			// Handling unit routes
+			handlingUnits := protected.Group("/handling-units")
+			handlingUnits.Use(middleware.TenantMiddleware(), middleware.ModeratorMiddleware())
+			{
+				handlingUnits.POST("", cargoPieceHandler.CreateHandlingUnit)
+				handlingUnits.GET("", cargoPieceHandler.GetHandlingUnits)
+				handlingUnits.GET("/:id", cargoPieceHandler.GetHandlingUnit)
+				handlingUnits.POST("/:id/pieces", cargoPieceHandler.AddHandlingUnitPieces)
+				handlingUnits.DELETE("/:id/pieces/:piece_id", cargoPieceHandler.RemoveHandlingUnitPiece)
+				handlingUnits.POST("/:id/close", cargoPieceHandler.CloseHandlingUnit)
+			}
+
+			// Attachment routes
+			attachments := protected.Group("/attachments")
+			attachments.Use(middleware.TenantMiddleware())
+			{
+				attachments.POST("", attachmentHandler.UploadAttachment)
+				attachments.GET("", attachmentHandler.GetAttachments)
+				attachments.GET("/usage", attachmentHandler.GetAttachmentUsage)
+				attachments.GET("/:id", attachmentHandler.GetAttachment)
+				attachments.GET("/:id/download", attachmentHandler.DownloadAttachment)
+				attachments.GET("/:id/url", attachmentHandler.GetAttachmentURL)
+				attachments.DELETE("/:id", middleware.ModeratorMiddleware(), attachmentHandler.DeleteAttachment)
 			}
 
 			// Truck cargo routes
@@ -246,14 +588,106 @@ func main() {
 			cargo.GET("/trucks/:truck_id/nearby-cargo", middleware.TenantMiddleware(), middleware.DriverMiddleware(), cargoHandler.GetNearbyCargoForTruck)
 		}
 
-		// Public cargo tracking
-		v1.GET("/cargo/track/:tracking_number", cargoHandler.GetCargoByTracking)
-		v1.GET("/cargo/track/:tracking_number/details", cargoHandler.GetCargoTracking)
+		// Customer booking portal
+		portal := v1.Group("/portal")
+		portal.Use(middleware.AuthMiddleware(), middleware.TenantMiddleware(), middleware.CustomerMiddleware())
+		{
+			portal.POST("/quote", portalHandler.QuoteBooking)
+			portal.POST("/bookings", portalHandler.CreateBooking)
+			portal.GET("/bookings", portalHandler.GetShipments)
+			portal.GET("/bookings/:id", portalHandler.GetShipment)
+			portal.GET("/bookings/:id/tracking", portalHandler.GetShipmentTracking)
+			portal.POST("/bookings/:id/cancel", portalHandler.CancelBooking)
+			portal.GET("/bookings/:id/pod", portalHandler.GetShipmentPOD)
+			portal.GET("/bookings/:id/pod/signature", portalHandler.GetShipmentPODSignature)
+			portal.GET("/bookings/:id/pod/photos/:photo_id", portalHandler.GetShipmentPODPhoto)
+			portal.GET("/invoices", portalHandler.GetInvoices)
+			portal.GET("/invoices/:id/pdf", portalHandler.GetInvoicePDF)
+			portal.GET("/addresses", portalHandler.GetAddresses)
+		}
+
+		// Public cargo tracking, rate limited per client IP
+		if cfg.Features.PublicTracking {
+			trackingLimit := middleware.RateLimitMiddleware(cfg.RateLimit.Public())
+			v1.GET("/cargo/track/:tracking_number", trackingLimit, cargoHandler.GetCargoByTracking)
+			v1.GET("/cargo/track/:tracking_number/details", trackingLimit, cargoHandler.GetCargoTracking)
+		}
+
+		// Signed file downloads
+		v1.GET("/files/:id", attachmentHandler.DownloadSignedFile)
 
 		// WebSocket endpoint
 		v1.GET("/ws", wsHandler.HandleWebSocket)
 	}
 
-	log.Println("Server starting on :8080")
-	router.Run(":8080")
+	server := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	log.Printf("Server starting on %s (%s)", cfg.Addr(), cfg.Env)
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+	// A second signal kills the process without waiting
+	stop()
+
+	log.Printf("Shutting down: failing readiness for %s, then draining for up to %s", cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout)
+	healthHandler.Drain()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	return shutdown(shutdownCtx, server, wsHub, stopWorkers, &workers, db)
+}
+
+// shutdown stops the server in order: it drains the HTTP requests in
+// flight, closes the WebSocket clients with a reconnect code, stops the
+// background workers and closes the database pool. Each step is cut short
+// when ctx is done, and the rest still run.
+func shutdown(ctx context.Context, server *http.Server, wsHub *websocket.Hub, stopWorkers context.CancelFunc, workers *sync.WaitGroup, db *gorm.DB) error {
+	var errs []error
+	if err := server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain HTTP requests: %w", err))
+	}
+	if err := wsHub.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("close WebSocket clients: %w", err))
+	}
+
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("stop background workers: %w", ctx.Err()))
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close database: %w", err))
+		}
+	}
+
+	if len(errs) == 0 {
+		log.Println("Server stopped")
+	}
+	return errors.Join(errs...)
 }